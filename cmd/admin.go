@@ -0,0 +1,690 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fhofherr/acmeproxy/pkg/acme"
+	"github.com/fhofherr/acmeproxy/pkg/api/grpcapi"
+	"github.com/fhofherr/acmeproxy/pkg/certutil"
+	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/fhofherr/acmeproxy/pkg/security"
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+)
+
+const (
+	flagAdminOutputName      = "output"
+	flagAdminPageSizeName    = "page-size"
+	flagAdminEmailName       = "email"
+	flagAdminLabelName       = "label"
+	flagAdminRemoveLabelName = "remove-label"
+	flagAdminPublicKeyName   = "public-key"
+	flagAdminDomainName      = "domain"
+	flagAdminUserName        = "user"
+	flagAdminSinceName       = "since"
+	flagAdminUntilName       = "until"
+	flagAdminSubjectName     = "subject"
+	flagAdminLimitName       = "limit"
+
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+func init() {
+	adminCmd.PersistentFlags().StringP(flagAdminOutputName, "o", outputTable,
+		"Output format. One of table or json.")
+
+	adminUserRegisterCmd.Flags().String(flagAdminEmailName, "",
+		"Contact address of the user. Used for the user's account with the ACME certificate authority.")
+
+	adminUserListCmd.Flags().Int(flagAdminPageSizeName, 100,
+		"Number of users requested from the server at once.")
+
+	adminUserUpdateCmd.Flags().String(flagAdminEmailName, "",
+		"Contact address of the user. Pass an empty string to remove it.")
+	adminUserUpdateCmd.Flags().StringToString(flagAdminLabelName, nil,
+		"Label attached to the user, e.g. 'team=web'. May be passed multiple times.")
+	adminUserUpdateCmd.Flags().StringSlice(flagAdminRemoveLabelName, nil,
+		"Key of a label to remove from the user. May be passed multiple times.")
+	adminUserUpdateCmd.Flags().String(flagAdminPublicKeyName, "",
+		"Path to the PEM encoded public key of the user. Pass an empty string to remove it.")
+
+	adminUserTransferDomainsCmd.Flags().StringSlice(flagAdminDomainName, nil,
+		"Name of a domain to transfer. May be passed multiple times. All domains are transferred if omitted.")
+
+	adminDomainListCmd.Flags().String(flagAdminUserName, "",
+		"List only the domains of the user with this ID.")
+
+	adminTokenRevokeCmd.Flags().String(flagTokenIDName, "",
+		"Unique ID (jti) of the token to revoke. Required if the token is not passed.")
+	adminTokenRevokeCmd.Flags().String(flagTokenExpiresAtName, "",
+		"Time the token expires at in RFC 3339 format. The revocation is kept forever if empty.")
+
+	adminAuditListCmd.Flags().String(flagAdminSinceName, "",
+		"Print only entries recorded at or after this time. Either in RFC 3339 format or a duration before now, e.g. '24h'.")
+	adminAuditListCmd.Flags().String(flagAdminUntilName, "",
+		"Print only entries recorded before this time. Either in RFC 3339 format or a duration before now, e.g. '1h'.")
+	adminAuditListCmd.Flags().String(flagAdminSubjectName, "",
+		"Print only entries of the caller with this subject, usually a user ID.")
+	adminAuditListCmd.Flags().String(flagAdminDomainName, "",
+		"Print only entries concerning the domain with this name.")
+	adminAuditListCmd.Flags().String(flagAdminUserName, "",
+		"Print only entries concerning the user with this ID. Must not be combined with --domain.")
+	adminAuditListCmd.Flags().Int(flagAdminLimitName, 0,
+		"Maximum number of entries printed. The server limits the number of entries if zero.")
+
+	for _, c := range []*cobra.Command{
+		adminUserRegisterCmd,
+		adminUserGetCmd,
+		adminUserListCmd,
+		adminUserUpdateCmd,
+		adminUserDeleteCmd,
+		adminUserTransferDomainsCmd,
+	} {
+		addClientFlags(c)
+		adminUserCmd.AddCommand(c)
+	}
+	addClientFlags(adminDomainListCmd)
+	adminDomainCmd.AddCommand(adminDomainListCmd)
+	addClientFlags(adminTokenRevokeCmd)
+	adminTokenCmd.AddCommand(adminTokenRevokeCmd)
+	addClientFlags(adminStatusCmd)
+	addClientFlags(adminAuditListCmd)
+	adminAuditCmd.AddCommand(adminAuditListCmd)
+
+	adminCmd.AddCommand(adminUserCmd)
+	adminCmd.AddCommand(adminDomainCmd)
+	adminCmd.AddCommand(adminTokenCmd)
+	adminCmd.AddCommand(adminStatusCmd)
+	adminCmd.AddCommand(adminAuditCmd)
+	rootCmd.AddCommand(adminCmd)
+}
+
+var adminCmd = &cobra.Command{
+	Use:   "admin",
+	Short: "Administer a running acmeproxy server",
+	Long: `
+Administer a running acmeproxy server using its gRPC API.
+
+Most commands require a token or client certificate granting the admin role.
+Listing domains and reading the server's status is permitted for operators,
+too. Only admins may read the audit log. The results are printed as table, or as JSON if --output=json is passed.
+
+Flags marked with [*] can also be set using environment variables. The name of
+the environment variable corresponds to the flag name prefixed with
+'ACMEPROXY_' and all hyphens replaced underscores.`,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		printErrorAndExit(checkAdminOutput(cmd))
+	},
+}
+
+var adminUserCmd = &cobra.Command{
+	Use:   "user",
+	Short: "Manage the users of acmeproxy",
+}
+
+var adminUserRegisterCmd = &cobra.Command{
+	Use:   "register",
+	Short: "Register a new user",
+	Long: `
+Register a new user and print it.
+
+acmeproxy creates an account with the ACME certificate authority for the new
+user and assigns it a random ID.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		email, err := cmd.Flags().GetString(flagAdminEmailName)
+		printErrorAndExit(err)
+		client, err := newClient(cmd)
+		printErrorAndExit(err)
+		defer client.Close()
+		userID, err := client.RegisterUser(context.Background(), email)
+		printErrorAndExit(err)
+		printErrorAndExit(printAdminOutput(cmd, adminUser{ID: userID, Email: email}))
+	},
+}
+
+var adminUserGetCmd = &cobra.Command{
+	Use:   "get <user-id>",
+	Short: "Print a user",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		userID, err := parseUserIDArg(args[0])
+		printErrorAndExit(err)
+		client, err := newClient(cmd)
+		printErrorAndExit(err)
+		defer client.Close()
+		user, err := client.GetUser(context.Background(), userID)
+		printErrorAndExit(err)
+		printErrorAndExit(printAdminOutput(cmd, newAdminUser(user)))
+	},
+}
+
+var adminUserListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Print all users",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		users, err := listUsers(cmd)
+		printErrorAndExit(err)
+		printErrorAndExit(printAdminOutput(cmd, users))
+	},
+}
+
+var adminUserUpdateCmd = &cobra.Command{
+	Use:   "update <user-id>",
+	Short: "Change the email, labels, or public key of a user",
+	Long: `
+Change the email, labels, or public key of a user and print the updated user.
+
+Only the attributes whose flags are passed are changed.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		userID, err := parseUserIDArg(args[0])
+		printErrorAndExit(err)
+		user, err := updateUser(cmd, userID)
+		printErrorAndExit(err)
+		printErrorAndExit(printAdminOutput(cmd, newAdminUser(user)))
+	},
+}
+
+var adminUserDeleteCmd = &cobra.Command{
+	Use:   "delete <user-id>",
+	Short: "Delete a user",
+	Long: `
+Delete a user.
+
+The server refuses to delete users which still own domains. Use
+transfer-domains to move the domains to another user first.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		userID, err := parseUserIDArg(args[0])
+		printErrorAndExit(err)
+		client, err := newClient(cmd)
+		printErrorAndExit(err)
+		defer client.Close()
+		printErrorAndExit(client.DeleteUser(context.Background(), userID))
+	},
+}
+
+var adminUserTransferDomainsCmd = &cobra.Command{
+	Use:   "transfer-domains <from-user-id> <to-user-id>",
+	Short: "Transfer domains from one user to another",
+	Long: `
+Transfer domains from one user to another and print the names of the
+transferred domains.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		from, err := parseUserIDArg(args[0])
+		printErrorAndExit(err)
+		to, err := parseUserIDArg(args[1])
+		printErrorAndExit(err)
+		domainNames, err := cmd.Flags().GetStringSlice(flagAdminDomainName)
+		printErrorAndExit(err)
+		client, err := newClient(cmd)
+		printErrorAndExit(err)
+		defer client.Close()
+		names, err := client.TransferDomains(context.Background(), from, to, domainNames)
+		printErrorAndExit(err)
+		printErrorAndExit(printAdminOutput(cmd, adminDomainNames(names)))
+	},
+}
+
+var adminDomainCmd = &cobra.Command{
+	Use:   "domain",
+	Short: "Inspect the domains managed by acmeproxy",
+}
+
+var adminDomainListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Print all domains",
+	Long: `
+Print all domains the caller may read, together with their owner and the
+expiry of their certificate.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		domains, err := listDomains(cmd)
+		printErrorAndExit(err)
+		printErrorAndExit(printAdminOutput(cmd, domains))
+	},
+}
+
+var adminTokenCmd = &cobra.Command{
+	Use:   "token",
+	Short: "Manage the bearer tokens accepted by acmeproxy",
+}
+
+var adminTokenRevokeCmd = &cobra.Command{
+	Use:   "revoke [token]",
+	Short: "Revoke a token before it expires",
+	Long: `
+Revoke a token before it expires.
+
+The ID and the expiry of the token are taken from the token if it is passed as
+argument. Otherwise they have to be passed using the --id and --expires-at
+flags. See 'acmeproxy token revoke'.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		printErrorAndExit(revokeToken(cmd, args))
+	},
+}
+
+var adminStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Print the status of the acmeproxy server",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		client, err := newClient(cmd)
+		printErrorAndExit(err)
+		defer client.Close()
+		status, err := client.GetStatus(context.Background())
+		printErrorAndExit(err)
+		printErrorAndExit(printAdminOutput(cmd, newAdminStatus(status)))
+	},
+}
+
+var adminAuditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Inspect the audit log of acmeproxy",
+}
+
+var adminAuditListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Print the entries of the audit log",
+	Long: `
+Print the entries of the audit log ordered by the time they were recorded.
+
+acmeproxy records every call of an authenticated client of its API. Each entry
+contains the subject, the roles, and the address of the caller, the operation,
+the resource the operation was performed on, and its outcome.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		entries, err := listAuditEntries(cmd, time.Now())
+		printErrorAndExit(err)
+		printErrorAndExit(printAdminOutput(cmd, entries))
+	},
+}
+
+// tableWriter is implemented by the results of the admin commands. writeTable
+// writes the result as tab separated rows. The first row contains the column
+// headers.
+type tableWriter interface {
+	writeTable(w io.Writer)
+}
+
+// checkAdminOutput checks the value of the output flag before the admin
+// commands contact the server.
+func checkAdminOutput(cmd *cobra.Command) error {
+	const op errors.Op = "cmd/checkAdminOutput"
+
+	output, err := cmd.Flags().GetString(flagAdminOutputName)
+	if err != nil {
+		return errors.New(op, err)
+	}
+	if output != outputTable && output != outputJSON {
+		return errors.New(op, errors.InvalidArgument, fmt.Sprintf("unknown output format: %s", output))
+	}
+	return nil
+}
+
+// printAdminOutput prints v in the format selected by the output flag.
+func printAdminOutput(cmd *cobra.Command, v tableWriter) error {
+	const op errors.Op = "cmd/printAdminOutput"
+
+	output, err := cmd.Flags().GetString(flagAdminOutputName)
+	if err != nil {
+		return errors.New(op, err)
+	}
+	if output == outputJSON {
+		return errors.Wrap(printJSON(cmd.OutOrStdout(), v), op)
+	}
+	tw := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	v.writeTable(tw)
+	return errors.Wrap(tw.Flush(), op, "write table")
+}
+
+// adminUser is the representation of a user printed by the admin commands.
+type adminUser struct {
+	ID         uuid.UUID         `json:"id"`
+	Email      string            `json:"email,omitempty"`
+	AccountURL string            `json:"accountURL,omitempty"`
+	Labels     map[string]string `json:"labels,omitempty"`
+	PublicKey  string            `json:"publicKey,omitempty"`
+}
+
+func newAdminUser(u acme.User) adminUser {
+	return adminUser{
+		ID:         u.ID,
+		Email:      u.Email,
+		AccountURL: u.AccountURL,
+		Labels:     u.Labels,
+		PublicKey:  string(u.PublicKey),
+	}
+}
+
+func (u adminUser) writeTable(w io.Writer) {
+	adminUsers{u}.writeTable(w)
+}
+
+type adminUsers []adminUser
+
+func (us adminUsers) writeTable(w io.Writer) {
+	fmt.Fprintln(w, "ID\tEMAIL\tACCOUNT URL\tLABELS\tPUBLIC KEY")
+	for _, u := range us {
+		labels := make([]string, 0, len(u.Labels))
+		for k, v := range u.Labels {
+			labels = append(labels, k+"="+v)
+		}
+		sort.Strings(labels)
+		fmt.Fprintf(w, "%v\t%s\t%s\t%s\t%t\n",
+			u.ID, u.Email, u.AccountURL, strings.Join(labels, ","), u.PublicKey != "")
+	}
+}
+
+// adminDomain is the representation of a domain printed by the admin
+// commands. NotAfter is nil if acmeproxy did not obtain a certificate for the
+// domain yet.
+type adminDomain struct {
+	Name     string     `json:"name"`
+	UserID   uuid.UUID  `json:"userID"`
+	NotAfter *time.Time `json:"notAfter,omitempty"`
+	Revoked  bool       `json:"revoked"`
+}
+
+type adminDomains []adminDomain
+
+func (ds adminDomains) writeTable(w io.Writer) {
+	fmt.Fprintln(w, "NAME\tUSER ID\tNOT AFTER\tREVOKED")
+	for _, d := range ds {
+		notAfter := "-"
+		if d.NotAfter != nil {
+			notAfter = d.NotAfter.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%s\t%v\t%s\t%t\n", d.Name, d.UserID, notAfter, d.Revoked)
+	}
+}
+
+type adminDomainNames []string
+
+func (ns adminDomainNames) writeTable(w io.Writer) {
+	fmt.Fprintln(w, "NAME")
+	for _, n := range ns {
+		fmt.Fprintln(w, n)
+	}
+}
+
+// adminStatus is the representation of the server's status printed by the
+// admin commands.
+type adminStatus struct {
+	Version   string    `json:"version,omitempty"`
+	GitHash   string    `json:"gitHash"`
+	BuildTime string    `json:"buildTime"`
+	StartedAt time.Time `json:"startedAt"`
+	Domains   int       `json:"domains"`
+}
+
+func newAdminStatus(s grpcapi.Status) adminStatus {
+	return adminStatus{
+		Version:   s.Version,
+		GitHash:   s.GitHash,
+		BuildTime: s.BuildTime,
+		StartedAt: s.StartedAt,
+		Domains:   s.Domains,
+	}
+}
+
+func (s adminStatus) writeTable(w io.Writer) {
+	fmt.Fprintln(w, "VERSION\tGIT HASH\tBUILD TIME\tSTARTED AT\tDOMAINS")
+	version := s.Version
+	if version == "" {
+		version = "-"
+	}
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\n",
+		version, s.GitHash, s.BuildTime, s.StartedAt.Format(time.RFC3339), s.Domains)
+}
+
+// adminAuditEntry is the representation of an audit log entry printed by the
+// admin commands.
+type adminAuditEntry struct {
+	Time      time.Time `json:"time"`
+	Subject   string    `json:"subject"`
+	Roles     []string  `json:"roles,omitempty"`
+	TokenID   string    `json:"tokenID,omitempty"`
+	PeerAddr  string    `json:"peerAddr,omitempty"`
+	Operation string    `json:"operation"`
+	Resource  string    `json:"resource,omitempty"`
+	Outcome   string    `json:"outcome"`
+}
+
+func newAdminAuditEntry(e security.AuditEntry) adminAuditEntry {
+	ae := adminAuditEntry{
+		Time:      e.Time,
+		Subject:   e.Subject,
+		TokenID:   e.TokenID,
+		PeerAddr:  e.PeerAddr,
+		Operation: e.Operation,
+		Resource:  e.Resource,
+		Outcome:   string(e.Outcome),
+	}
+	for _, r := range e.Roles {
+		ae.Roles = append(ae.Roles, string(r))
+	}
+	return ae
+}
+
+type adminAuditEntries []adminAuditEntry
+
+func (es adminAuditEntries) writeTable(w io.Writer) {
+	fmt.Fprintln(w, "TIME\tSUBJECT\tROLES\tPEER\tOPERATION\tRESOURCE\tOUTCOME")
+	for _, e := range es {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			e.Time.Format(time.RFC3339), e.Subject, strings.Join(e.Roles, ","), dashIfEmpty(e.PeerAddr),
+			e.Operation, dashIfEmpty(e.Resource), e.Outcome)
+	}
+}
+
+func dashIfEmpty(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func listUsers(cmd *cobra.Command) (adminUsers, error) {
+	const op errors.Op = "cmd/listUsers"
+
+	pageSize, err := cmd.Flags().GetInt(flagAdminPageSizeName)
+	if err != nil {
+		return nil, errors.New(op, err)
+	}
+	client, err := newClient(cmd)
+	if err != nil {
+		return nil, errors.New(op, err)
+	}
+	defer client.Close()
+
+	users := adminUsers{}
+	pageToken := ""
+	for {
+		page, next, err := client.ListUsers(context.Background(), pageSize, pageToken)
+		if err != nil {
+			return nil, errors.New(op, err)
+		}
+		for _, u := range page {
+			users = append(users, newAdminUser(u))
+		}
+		if next == "" {
+			return users, nil
+		}
+		pageToken = next
+	}
+}
+
+func updateUser(cmd *cobra.Command, userID uuid.UUID) (acme.User, error) {
+	const op errors.Op = "cmd/updateUser"
+
+	client, err := newClient(cmd)
+	if err != nil {
+		return acme.User{}, errors.New(op, err)
+	}
+	defer client.Close()
+
+	// UpdateUser replaces all editable attributes. Read the user first to
+	// keep the attributes whose flags were not passed.
+	user, err := client.GetUser(context.Background(), userID)
+	if err != nil {
+		return acme.User{}, errors.New(op, err)
+	}
+	flags := cmd.Flags()
+	if flags.Changed(flagAdminEmailName) {
+		user.Email = mustGetString(flags.GetString(flagAdminEmailName))
+	}
+	labels, err := flags.GetStringToString(flagAdminLabelName)
+	if err != nil {
+		return acme.User{}, errors.New(op, err)
+	}
+	removed, err := flags.GetStringSlice(flagAdminRemoveLabelName)
+	if err != nil {
+		return acme.User{}, errors.New(op, err)
+	}
+	if len(labels) > 0 && user.Labels == nil {
+		user.Labels = make(map[string]string, len(labels))
+	}
+	for k, v := range labels {
+		user.Labels[k] = v
+	}
+	for _, k := range removed {
+		delete(user.Labels, k)
+	}
+	if flags.Changed(flagAdminPublicKeyName) {
+		user.PublicKey = nil
+		if path := mustGetString(flags.GetString(flagAdminPublicKeyName)); path != "" {
+			bs, err := ioutil.ReadFile(path)
+			if err != nil {
+				return acme.User{}, errors.New(op, "read public key", err)
+			}
+			user.PublicKey = bs
+		}
+	}
+	user, err = client.UpdateUser(context.Background(), user)
+	return user, errors.Wrap(err, op)
+}
+
+func listDomains(cmd *cobra.Command) (adminDomains, error) {
+	const op errors.Op = "cmd/listDomains"
+
+	var userID uuid.UUID
+	if s := mustGetString(cmd.Flags().GetString(flagAdminUserName)); s != "" {
+		id, err := parseUserIDArg(s)
+		if err != nil {
+			return nil, errors.New(op, err)
+		}
+		userID = id
+	}
+	client, err := newClient(cmd)
+	if err != nil {
+		return nil, errors.New(op, err)
+	}
+	defer client.Close()
+
+	domains, err := client.ListDomains(context.Background(), userID)
+	if err != nil {
+		return nil, errors.New(op, err)
+	}
+	res := make(adminDomains, 0, len(domains))
+	for _, d := range domains {
+		ad := adminDomain{Name: d.Name, UserID: d.UserID, Revoked: d.Revoked}
+		if len(d.Certificate) > 0 {
+			cert, err := certutil.ParseCertificate(d.Certificate, true)
+			if err != nil {
+				return nil, errors.New(op, fmt.Sprintf("parse certificate: %s", d.Name), err)
+			}
+			ad.NotAfter = &cert.NotAfter
+		}
+		res = append(res, ad)
+	}
+	return res, nil
+}
+
+func listAuditEntries(cmd *cobra.Command, now time.Time) (adminAuditEntries, error) {
+	const op errors.Op = "cmd/listAuditEntries"
+
+	flags := cmd.Flags()
+	f := security.AuditFilter{
+		Subject: mustGetString(flags.GetString(flagAdminSubjectName)),
+	}
+	var err error
+	if f.Since, err = parseTimeFlag(mustGetString(flags.GetString(flagAdminSinceName)), now); err != nil {
+		return nil, errors.New(op, "since", err)
+	}
+	if f.Until, err = parseTimeFlag(mustGetString(flags.GetString(flagAdminUntilName)), now); err != nil {
+		return nil, errors.New(op, "until", err)
+	}
+	domain := mustGetString(flags.GetString(flagAdminDomainName))
+	user := mustGetString(flags.GetString(flagAdminUserName))
+	switch {
+	case domain != "" && user != "":
+		return nil, errors.New(op, errors.InvalidArgument, "--domain and --user must not be combined")
+	case domain != "":
+		f.Resource = security.AuditDomain(domain)
+	case user != "":
+		userID, err := parseUserIDArg(user)
+		if err != nil {
+			return nil, errors.New(op, err)
+		}
+		f.Resource = security.AuditUser(userID)
+	}
+	if f.Limit, err = flags.GetInt(flagAdminLimitName); err != nil {
+		return nil, errors.New(op, err)
+	}
+	client, err := newClient(cmd)
+	if err != nil {
+		return nil, errors.New(op, err)
+	}
+	defer client.Close()
+
+	entries, err := client.ListAuditEntries(context.Background(), f)
+	if err != nil {
+		return nil, errors.New(op, err)
+	}
+	res := make(adminAuditEntries, 0, len(entries))
+	for _, e := range entries {
+		res = append(res, newAdminAuditEntry(e))
+	}
+	return res, nil
+}
+
+// parseTimeFlag parses s either as time in RFC 3339 format, or as duration
+// before now. It returns the zero time if s is empty.
+func parseTimeFlag(s string, now time.Time) (time.Time, error) {
+	const op errors.Op = "cmd/parseTimeFlag"
+
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, errors.New(op, errors.InvalidArgument, fmt.Sprintf("invalid time: %s", s), err)
+	}
+	return t, nil
+}
+
+func parseUserIDArg(s string) (uuid.UUID, error) {
+	const op errors.Op = "cmd/parseUserIDArg"
+
+	id, err := uuid.Parse(s)
+	if err != nil {
+		return uuid.UUID{}, errors.New(op, errors.InvalidArgument, "invalid user id", err)
+	}
+	return id, nil
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/fhofherr/acmeproxy/pkg/api/grpcapi"
+	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	flagServerAddrName = "server-addr"
+	flagCABundleName   = "ca-bundle"
+	flagTokenName      = "token"
+	flagClientCertName = "client-cert"
+	flagClientKeyName  = "client-key"
+)
+
+// addClientFlags adds the flags required to connect to acmeproxy's gRPC API
+// to cmd.
+func addClientFlags(cmd *cobra.Command) {
+	cmd.Flags().String(flagServerAddrName, "",
+		"Address of acmeproxy's gRPC API. [*]")
+	cmd.Flags().String(flagCABundleName, "",
+		"Path to a PEM encoded bundle of CA certificates used to verify the server's certificate. Uses the system's CA certificates if empty. [*]")
+	cmd.Flags().String(flagTokenName, "",
+		"Bearer token used to authenticate with the server. Not required if a client certificate is provided. [*]")
+	cmd.Flags().String(flagClientCertName, "",
+		"Path to a PEM encoded client certificate used to authenticate with the server. [*]")
+	cmd.Flags().String(flagClientKeyName, "",
+		"Path to the PEM encoded private key of the client certificate. [*]")
+}
+
+// newClient creates a client for acmeproxy's gRPC API using the flags added
+// by addClientFlags.
+//
+// The flags are bound to their environment variables only when newClient is
+// called. This allows several commands to share the same flag names.
+func newClient(cmd *cobra.Command) (*grpcapi.Client, error) {
+	const op errors.Op = "cmd/newClient"
+
+	names := []string{flagServerAddrName, flagCABundleName, flagTokenName, flagClientCertName, flagClientKeyName}
+	for _, name := range names {
+		if err := viper.BindPFlag(name, cmd.Flags().Lookup(name)); err != nil {
+			return nil, errors.New(op, err)
+		}
+	}
+	addr := viper.GetString(flagServerAddrName)
+	if addr == "" {
+		return nil, errors.New(op, errors.InvalidArgument, "no server address provided")
+	}
+	tlsConfig := &tls.Config{}
+	if certFile := viper.GetString(flagClientCertName); certFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, viper.GetString(flagClientKeyName))
+		if err != nil {
+			return nil, errors.New(op, "load client certificate", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	var token *grpcapi.AuthToken
+	if t := viper.GetString(flagTokenName); t != "" {
+		token = &grpcapi.AuthToken{Token: t}
+	}
+	if token == nil && len(tlsConfig.Certificates) == 0 {
+		return nil, errors.New(op, errors.InvalidArgument, "no token or client certificate provided")
+	}
+	if path := viper.GetString(flagCABundleName); path != "" {
+		bs, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, errors.New(op, "read ca bundle", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(bs) {
+			return nil, errors.New(op, errors.InvalidArgument, fmt.Sprintf("no certificates found: %s", path))
+		}
+		tlsConfig.RootCAs = pool
+	}
+	client, err := grpcapi.NewClient(addr, token, tlsConfig)
+	return client, errors.Wrap(err, op)
+}
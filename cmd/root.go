@@ -5,6 +5,7 @@ import (
 	"os"
 	"strings"
 
+	"github.com/fhofherr/acmeproxy/pkg/errors"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -32,6 +33,9 @@ func Execute() {
 func printErrorAndExit(err error) {
 	if err != nil {
 		fmt.Printf("%+v", err)
+		if id := errors.GetRequestID(err); id != "" {
+			fmt.Printf("\nrequest id: %s", id)
+		}
 		os.Exit(1)
 	}
 }
@@ -2,33 +2,213 @@ package cmd
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/fhofherr/acmeproxy/pkg/acme"
 	"github.com/fhofherr/acmeproxy/pkg/api"
+	"github.com/fhofherr/acmeproxy/pkg/api/auth"
+	"github.com/fhofherr/acmeproxy/pkg/api/grpcapi"
 	"github.com/fhofherr/acmeproxy/pkg/errors"
-	"github.com/fhofherr/golf-zap/golfzap"
+	"github.com/fhofherr/acmeproxy/pkg/logging"
+	"github.com/fhofherr/acmeproxy/pkg/policy"
+	"github.com/fhofherr/acmeproxy/pkg/tracing"
+	"github.com/fhofherr/golf/log"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
-	"go.uber.org/zap"
 )
 
 const (
-	flagACMEDirectoryURLName = "acme-directory-url"
-	flagHTTPAPIAddrName      = "http-api-addr"
+	flagACMEDirectoryURLName   = "acme-directory-url"
+	flagACMEResolverAddrName   = "acme-resolver-addr"
+	flagACMEHTTP01PortName     = "acme-http01-port"
+	flagHTTPAPIAddrName        = "http-api-addr"
+	flagMetricsAddrName        = "metrics-addr"
+	flagAdminAddrName          = "admin-addr"
+	flagDomainPolicyName       = "domain-policy"
+	flagGRPCAPIAddrName        = "grpc-api-addr"
+	flagRESTAPIAddrName        = "rest-api-addr"
+	flagGRPCAPICallTimeoutName = "grpc-api-call-timeout"
+	flagGRPCAPIReflectionName  = "grpc-api-reflection"
+	flagGRPCAPIRateLimitsName  = "grpc-api-rate-limits"
+	flagGRPCAPITLSCertName     = "grpc-api-tls-cert"
+	flagGRPCAPITLSKeyName      = "grpc-api-tls-key"
+	flagAPITokenKeysName       = "token-keys"
+	flagAPITokenKeysGraceName  = "token-keys-grace-period"
+	flagGRPCAPIClientCAName    = "grpc-api-client-ca"
+	flagGRPCAPIClientIDsName   = "grpc-api-client-identities"
+	flagOIDCIssuerName         = "oidc-issuer"
+	flagOIDCClientIDName       = "oidc-client-id"
+	flagOIDCUserIDClaimName    = "oidc-user-id-claim"
+	flagOIDCGroupsClaimName    = "oidc-groups-claim"
+	flagOIDCRoleMappingName    = "oidc-role-mapping"
+
+	flagQuotaWindowName              = "quota-window"
+	flagQuotaPerUserName             = "quota-per-user"
+	flagQuotaPerRegisteredDomainName = "quota-per-registered-domain"
+	flagQuotaPerNameSetName          = "quota-per-name-set"
+
+	flagAuditRetentionName  = "audit-retention"
+	flagAuditMaxEntriesName = "audit-max-entries"
+
+	flagTracingExporterName     = "tracing-exporter"
+	flagTracingOTLPEndpointName = "tracing-otlp-endpoint"
+	flagTracingOTLPInsecureName = "tracing-otlp-insecure"
+
+	flagLogLevelName           = "log-level"
+	flagLogFormatName          = "log-format"
+	flagLogComponentLevelsName = "log-component-level"
 )
 
 func init() {
 	serveCmd.Flags().String(flagACMEDirectoryURLName, acme.DefaultDirectoryURL,
 		"Directory URL of the ACME server. [*]")
+	serveCmd.Flags().String(flagACMEResolverAddrName, "",
+		"Address of the DNS server used to check domains and their CAA records before ordering certificates. Uses the system resolver if empty. [*]")
+	serveCmd.Flags().Int(flagACMEHTTP01PortName, 80,
+		"Port the ACME server validates HTTP01 challenges on. [*]")
 	serveCmd.Flags().String(flagHTTPAPIAddrName, ":http",
 		"TCP address the HTTP API listens on. [*]")
+	serveCmd.Flags().String(flagMetricsAddrName, "",
+		"TCP address the Prometheus metrics endpoint /metrics listens on. Should not be reachable publicly. The metrics endpoint is disabled if empty. [*]")
+	serveCmd.Flags().String(flagAdminAddrName, "",
+		"TCP address the admin endpoints /livez, /readyz, and /status listen on. Should not be reachable publicly. The admin endpoints are disabled if empty. [*]")
+	serveCmd.Flags().String(flagDomainPolicyName, "",
+		"Path to a JSON file restricting the domains users may register. Any domain may be registered if empty. [*]")
+	serveCmd.Flags().String(flagGRPCAPIAddrName, "",
+		"TCP address the gRPC API listens on. The gRPC API is disabled if empty. [*]")
+	serveCmd.Flags().String(flagRESTAPIAddrName, "",
+		"TCP address the REST gateway of the gRPC API listens on. Uses the TLS and authentication settings of the gRPC API. The REST gateway is disabled if empty. [*]")
+	serveCmd.Flags().Duration(flagGRPCAPICallTimeoutName, time.Minute,
+		"Maximum duration of unary gRPC API calls. Unlimited if zero. Streaming calls are not limited. [*]")
+	serveCmd.Flags().Bool(flagGRPCAPIReflectionName, false,
+		"Register the gRPC server reflection service. Callers do not need to authenticate to use it. [*]")
+	serveCmd.Flags().String(flagGRPCAPIRateLimitsName, "",
+		"Path to a JSON file limiting the calls per client, RPC, and role to the gRPC API. Calls are not limited if empty. [*]")
+	serveCmd.Flags().String(flagGRPCAPITLSCertName, "",
+		"Path to the PEM encoded TLS certificate of the gRPC API. [*]")
+	serveCmd.Flags().String(flagGRPCAPITLSKeyName, "",
+		"Path to the PEM encoded private key of the TLS certificate of the gRPC API. [*]")
+	serveCmd.Flags().String(flagGRPCAPIClientCAName, "",
+		"Path to a PEM encoded bundle of CA certificates used to verify client certificates. Clients may authenticate using certificates if set. [*]")
+	serveCmd.Flags().String(flagGRPCAPIClientIDsName, "",
+		"Path to a JSON file mapping client certificates to acmeproxy users and roles. Required if --grpc-api-client-ca is set. [*]")
+	serveCmd.Flags().String(flagAPITokenKeysName, "",
+		"Path to a JWKS document or a directory of PEM encoded public keys used to verify the bearer tokens presented to the gRPC API. The keys are reloaded on SIGHUP. Ignored if --oidc-issuer is set. [*]")
+	serveCmd.Flags().Duration(flagAPITokenKeysGraceName, 24*time.Hour,
+		"Duration keys removed from the token keys remain valid. [*]")
+	serveCmd.Flags().String(flagOIDCIssuerName, "",
+		"URL of an OpenID Connect issuer. If set the gRPC API accepts the tokens of the issuer instead of the token keys. [*]")
+	serveCmd.Flags().String(flagOIDCClientIDName, "",
+		"Client ID the tokens of the OpenID Connect issuer have to contain as audience. The audience is not checked if empty. [*]")
+	serveCmd.Flags().String(flagOIDCUserIDClaimName, "sub",
+		"Claim of the OpenID Connect tokens containing the acmeproxy user ID. [*]")
+	serveCmd.Flags().String(flagOIDCGroupsClaimName, "groups",
+		"Claim of the OpenID Connect tokens containing the groups of the bearer. [*]")
+	serveCmd.Flags().StringSlice(flagOIDCRoleMappingName, nil,
+		"Mapping of an OpenID Connect group to an acmeproxy role, e.g. 'acmeproxy-admins=admin'. May be passed multiple times. Tokens without a mapped group are rejected. [*]")
+	serveCmd.Flags().Duration(flagQuotaWindowName, acme.DefaultQuotas.Window,
+		"Length of the sliding window the quotas apply to. [*]")
+	serveCmd.Flags().Int(flagQuotaPerUserName, acme.DefaultQuotas.PerUser,
+		"Maximum number of certificates obtained per user within the quota window. Unlimited if zero. [*]")
+	serveCmd.Flags().Int(flagQuotaPerRegisteredDomainName, acme.DefaultQuotas.PerRegisteredDomain,
+		"Maximum number of certificates obtained per registered domain within the quota window. Unlimited if zero. [*]")
+	serveCmd.Flags().Int(flagQuotaPerNameSetName, acme.DefaultQuotas.PerNameSet,
+		"Maximum number of certificates obtained for the same set of names within the quota window. Unlimited if zero. [*]")
+	serveCmd.Flags().Duration(flagAuditRetentionName, 90*24*time.Hour,
+		"Duration entries of the audit log are kept. Forever if zero. [*]")
+	serveCmd.Flags().Int(flagAuditMaxEntriesName, 0,
+		"Maximum number of entries kept in the audit log. The oldest entries are removed first. Unlimited if zero. [*]")
+	serveCmd.Flags().String(flagTracingExporterName, tracing.ExporterNone,
+		"Exporter of OpenTelemetry spans: none, stdout, or otlp. [*]")
+	serveCmd.Flags().String(flagTracingOTLPEndpointName, "",
+		"host:port of the OpenTelemetry collector receiving spans via OTLP over HTTP. Uses localhost:4318 if empty. [*]")
+	serveCmd.Flags().Bool(flagTracingOTLPInsecureName, false,
+		"Connect to the OpenTelemetry collector without TLS. [*]")
+	serveCmd.Flags().String(flagLogLevelName, "info",
+		"Minimum level of log entries: debug, info, warn, or error. [*]")
+	serveCmd.Flags().String(flagLogFormatName, logging.FormatJSON,
+		"Format of log entries: json or console. [*]")
+	serveCmd.Flags().StringSlice(flagLogComponentLevelsName, nil,
+		"Level of an individual component overriding --log-level, e.g. 'lego=debug'. Components are lego, grpc, and agent. May be passed multiple times. [*]")
 
 	printErrorAndExit(
 		viper.BindPFlag(flagACMEDirectoryURLName, serveCmd.Flags().Lookup(flagACMEDirectoryURLName)))
+	printErrorAndExit(
+		viper.BindPFlag(flagACMEResolverAddrName, serveCmd.Flags().Lookup(flagACMEResolverAddrName)))
+	printErrorAndExit(
+		viper.BindPFlag(flagACMEHTTP01PortName, serveCmd.Flags().Lookup(flagACMEHTTP01PortName)))
 	printErrorAndExit(
 		viper.BindPFlag(flagHTTPAPIAddrName, serveCmd.Flags().Lookup(flagHTTPAPIAddrName)))
+	printErrorAndExit(
+		viper.BindPFlag(flagMetricsAddrName, serveCmd.Flags().Lookup(flagMetricsAddrName)))
+	printErrorAndExit(
+		viper.BindPFlag(flagAdminAddrName, serveCmd.Flags().Lookup(flagAdminAddrName)))
+	printErrorAndExit(
+		viper.BindPFlag(flagDomainPolicyName, serveCmd.Flags().Lookup(flagDomainPolicyName)))
+	printErrorAndExit(
+		viper.BindPFlag(flagGRPCAPIAddrName, serveCmd.Flags().Lookup(flagGRPCAPIAddrName)))
+	printErrorAndExit(
+		viper.BindPFlag(flagRESTAPIAddrName, serveCmd.Flags().Lookup(flagRESTAPIAddrName)))
+	printErrorAndExit(
+		viper.BindPFlag(flagGRPCAPICallTimeoutName, serveCmd.Flags().Lookup(flagGRPCAPICallTimeoutName)))
+	printErrorAndExit(
+		viper.BindPFlag(flagGRPCAPIReflectionName, serveCmd.Flags().Lookup(flagGRPCAPIReflectionName)))
+	printErrorAndExit(
+		viper.BindPFlag(flagGRPCAPIRateLimitsName, serveCmd.Flags().Lookup(flagGRPCAPIRateLimitsName)))
+	printErrorAndExit(
+		viper.BindPFlag(flagGRPCAPITLSCertName, serveCmd.Flags().Lookup(flagGRPCAPITLSCertName)))
+	printErrorAndExit(
+		viper.BindPFlag(flagGRPCAPITLSKeyName, serveCmd.Flags().Lookup(flagGRPCAPITLSKeyName)))
+	printErrorAndExit(
+		viper.BindPFlag(flagGRPCAPIClientCAName, serveCmd.Flags().Lookup(flagGRPCAPIClientCAName)))
+	printErrorAndExit(
+		viper.BindPFlag(flagGRPCAPIClientIDsName, serveCmd.Flags().Lookup(flagGRPCAPIClientIDsName)))
+	printErrorAndExit(
+		viper.BindPFlag(flagAPITokenKeysName, serveCmd.Flags().Lookup(flagAPITokenKeysName)))
+	printErrorAndExit(
+		viper.BindPFlag(flagAPITokenKeysGraceName, serveCmd.Flags().Lookup(flagAPITokenKeysGraceName)))
+	printErrorAndExit(
+		viper.BindPFlag(flagOIDCIssuerName, serveCmd.Flags().Lookup(flagOIDCIssuerName)))
+	printErrorAndExit(
+		viper.BindPFlag(flagOIDCClientIDName, serveCmd.Flags().Lookup(flagOIDCClientIDName)))
+	printErrorAndExit(
+		viper.BindPFlag(flagOIDCUserIDClaimName, serveCmd.Flags().Lookup(flagOIDCUserIDClaimName)))
+	printErrorAndExit(
+		viper.BindPFlag(flagOIDCGroupsClaimName, serveCmd.Flags().Lookup(flagOIDCGroupsClaimName)))
+	printErrorAndExit(
+		viper.BindPFlag(flagOIDCRoleMappingName, serveCmd.Flags().Lookup(flagOIDCRoleMappingName)))
+	printErrorAndExit(
+		viper.BindPFlag(flagQuotaWindowName, serveCmd.Flags().Lookup(flagQuotaWindowName)))
+	printErrorAndExit(
+		viper.BindPFlag(flagQuotaPerUserName, serveCmd.Flags().Lookup(flagQuotaPerUserName)))
+	printErrorAndExit(
+		viper.BindPFlag(flagQuotaPerRegisteredDomainName, serveCmd.Flags().Lookup(flagQuotaPerRegisteredDomainName)))
+	printErrorAndExit(
+		viper.BindPFlag(flagQuotaPerNameSetName, serveCmd.Flags().Lookup(flagQuotaPerNameSetName)))
+	printErrorAndExit(
+		viper.BindPFlag(flagAuditRetentionName, serveCmd.Flags().Lookup(flagAuditRetentionName)))
+	printErrorAndExit(
+		viper.BindPFlag(flagAuditMaxEntriesName, serveCmd.Flags().Lookup(flagAuditMaxEntriesName)))
+	printErrorAndExit(
+		viper.BindPFlag(flagTracingExporterName, serveCmd.Flags().Lookup(flagTracingExporterName)))
+	printErrorAndExit(
+		viper.BindPFlag(flagTracingOTLPEndpointName, serveCmd.Flags().Lookup(flagTracingOTLPEndpointName)))
+	printErrorAndExit(
+		viper.BindPFlag(flagTracingOTLPInsecureName, serveCmd.Flags().Lookup(flagTracingOTLPInsecureName)))
+	printErrorAndExit(
+		viper.BindPFlag(flagLogLevelName, serveCmd.Flags().Lookup(flagLogLevelName)))
+	printErrorAndExit(
+		viper.BindPFlag(flagLogFormatName, serveCmd.Flags().Lookup(flagLogFormatName)))
+	printErrorAndExit(
+		viper.BindPFlag(flagLogComponentLevelsName, serveCmd.Flags().Lookup(flagLogComponentLevelsName)))
 	rootCmd.AddCommand(serveCmd)
 }
 
@@ -51,18 +231,59 @@ environment variable corresponds to the flag name prefixed with 'ACMEPROXY_' and
 all hyphens replaced underscores. For example the name of the environment
 variable matching the flag '--http-api-addr' would be 'ACMEPROXY_HTTP_API_ADDR'.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		zapLogger, err := zap.NewProduction()
+		loggers, err := newLoggers()
 		if err != nil {
 			printErrorAndExit(err)
 		}
-		defer zapLogger.Sync() //nolint: errcheck
+		defer loggers.Sync() //nolint: errcheck
 
-		logger := golfzap.New(zapLogger)
+		logger := loggers.Logger()
+
+		domainPolicy, err := loadDomainPolicy(viper.GetString(flagDomainPolicyName))
+		if err != nil {
+			printErrorAndExit(err)
+		}
+		tracerProvider, err := tracing.NewProvider(tracing.Config{
+			Exporter:     viper.GetString(flagTracingExporterName),
+			OTLPEndpoint: viper.GetString(flagTracingOTLPEndpointName),
+			OTLPInsecure: viper.GetBool(flagTracingOTLPInsecureName),
+		})
+		if err != nil {
+			printErrorAndExit(err)
+		}
 
 		s := &api.Server{
 			ACMEDirectoryURL: viper.GetString(flagACMEDirectoryURLName),
+			ACMEResolverAddr: viper.GetString(flagACMEResolverAddrName),
+			ACMEHTTP01Port:   viper.GetInt(flagACMEHTTP01PortName),
 			HTTPAPIAddr:      viper.GetString(flagHTTPAPIAddrName),
-			Logger:           logger,
+			MetricsAddr:      viper.GetString(flagMetricsAddrName),
+			AdminAddr:        viper.GetString(flagAdminAddrName),
+			Quotas: acme.Quotas{
+				Window:              viper.GetDuration(flagQuotaWindowName),
+				PerUser:             viper.GetInt(flagQuotaPerUserName),
+				PerRegisteredDomain: viper.GetInt(flagQuotaPerRegisteredDomainName),
+				PerNameSet:          viper.GetInt(flagQuotaPerNameSetName),
+			},
+			AuditRetention:  viper.GetDuration(flagAuditRetentionName),
+			AuditMaxEntries: viper.GetInt(flagAuditMaxEntriesName),
+			TracerProvider:  tracerProvider,
+			Logger:          logger,
+			ComponentLoggers: map[string]log.Logger{
+				logging.ComponentLego:  loggers.Component(logging.ComponentLego),
+				logging.ComponentGRPC:  loggers.Component(logging.ComponentGRPC),
+				logging.ComponentAgent: loggers.Component(logging.ComponentAgent),
+			},
+		}
+		if domainPolicy != nil {
+			// Don't store a typed nil in the interface.
+			s.DomainPolicy = domainPolicy
+		}
+		if err := configureGRPCAPI(s); err != nil {
+			printErrorAndExit(err)
+		}
+		if s.TokenKeys != nil {
+			go reloadTokenKeysOnSIGHUP(logger, s)
 		}
 		err = s.Start()
 		if err != nil {
@@ -76,3 +297,157 @@ variable matching the flag '--http-api-addr' would be 'ACMEPROXY_HTTP_API_ADDR'.
 		select {}
 	},
 }
+
+func newLoggers() (*logging.Loggers, error) {
+	const op errors.Op = "cmd/newLoggers"
+
+	levels, err := logging.ParseComponentLevels(viper.GetStringSlice(flagLogComponentLevelsName))
+	if err != nil {
+		return nil, errors.New(op, err)
+	}
+	loggers, err := logging.New(logging.Config{
+		Level:           viper.GetString(flagLogLevelName),
+		Format:          viper.GetString(flagLogFormatName),
+		ComponentLevels: levels,
+	})
+	return loggers, errors.Wrap(err, op)
+}
+
+func loadDomainPolicy(path string) (*policy.Policy, error) {
+	const op errors.Op = "cmd/loadDomainPolicy"
+
+	if path == "" {
+		return nil, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.New(op, "open domain policy", err)
+	}
+	defer f.Close()
+	p, err := policy.Load(f)
+	if err != nil {
+		return nil, errors.New(op, fmt.Sprintf("load domain policy: %s", path), err)
+	}
+	return p, nil
+}
+
+func configureGRPCAPI(s *api.Server) error {
+	const op errors.Op = "cmd/configureGRPCAPI"
+
+	s.GRPCAPIAddr = viper.GetString(flagGRPCAPIAddrName)
+	s.RESTAPIAddr = viper.GetString(flagRESTAPIAddrName)
+	if s.GRPCAPIAddr == "" && s.RESTAPIAddr == "" {
+		return nil
+	}
+	s.GRPCAPICallTimeout = viper.GetDuration(flagGRPCAPICallTimeoutName)
+	s.GRPCAPIReflection = viper.GetBool(flagGRPCAPIReflectionName)
+	if err := configureRateLimits(s); err != nil {
+		return errors.New(op, err)
+	}
+	cert, err := tls.LoadX509KeyPair(viper.GetString(flagGRPCAPITLSCertName), viper.GetString(flagGRPCAPITLSKeyName))
+	if err != nil {
+		return errors.New(op, "load grpc api tls certificate", err)
+	}
+	s.GRPCAPITLSConfig = &tls.Config{
+		Certificates: []tls.Certificate{cert},
+	}
+	if err := configureClientCertificates(s); err != nil {
+		return errors.New(op, err)
+	}
+	if viper.GetString(flagOIDCIssuerName) != "" {
+		s.OIDCProvider, err = newOIDCProvider()
+		return errors.Wrap(err, op)
+	}
+	s.TokenKeys = &auth.KeySet{
+		Path:        viper.GetString(flagAPITokenKeysName),
+		GracePeriod: viper.GetDuration(flagAPITokenKeysGraceName),
+	}
+	if err := s.TokenKeys.Reload(); err != nil {
+		return errors.New(op, "load token keys", err)
+	}
+	return nil
+}
+
+func configureRateLimits(s *api.Server) error {
+	const op errors.Op = "cmd/configureRateLimits"
+
+	path := viper.GetString(flagGRPCAPIRateLimitsName)
+	if path == "" {
+		return nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return errors.New(op, err)
+	}
+	defer f.Close()
+	s.GRPCAPIRateLimits, err = grpcapi.LoadRateLimits(f)
+	return errors.Wrap(err, op, fmt.Sprintf("load rate limits: %s", path))
+}
+
+func configureClientCertificates(s *api.Server) error {
+	const op errors.Op = "cmd/configureClientCertificates"
+
+	caFile := viper.GetString(flagGRPCAPIClientCAName)
+	if caFile == "" {
+		return nil
+	}
+	bs, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		return errors.New(op, "read client ca bundle", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(bs) {
+		return errors.New(op, errors.InvalidArgument, fmt.Sprintf("no certificates found: %s", caFile))
+	}
+	s.GRPCAPITLSConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	s.GRPCAPITLSConfig.ClientCAs = pool
+
+	idsFile := viper.GetString(flagGRPCAPIClientIDsName)
+	if idsFile == "" {
+		return errors.New(op, errors.InvalidArgument, "no client identities provided")
+	}
+	f, err := os.Open(idsFile)
+	if err != nil {
+		return errors.New(op, err)
+	}
+	defer f.Close()
+	s.ClientCertificates, err = auth.LoadCertificateMapper(f)
+	return errors.Wrap(err, op, fmt.Sprintf("load client identities: %s", idsFile))
+}
+
+func newOIDCProvider() (*auth.OIDCProvider, error) {
+	const op errors.Op = "cmd/newOIDCProvider"
+
+	p := &auth.OIDCProvider{
+		Issuer:       viper.GetString(flagOIDCIssuerName),
+		ClientID:     viper.GetString(flagOIDCClientIDName),
+		UserIDClaim:  viper.GetString(flagOIDCUserIDClaimName),
+		GroupsClaim:  viper.GetString(flagOIDCGroupsClaimName),
+		RoleMappings: make(map[string]auth.Role),
+	}
+	for _, m := range viper.GetStringSlice(flagOIDCRoleMappingName) {
+		parts := strings.SplitN(m, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, errors.New(op, errors.InvalidArgument, fmt.Sprintf("invalid role mapping: %s", m))
+		}
+		role, err := auth.ParseRole(parts[1])
+		if err != nil {
+			return nil, errors.New(op, fmt.Sprintf("invalid role mapping: %s", m), err)
+		}
+		p.RoleMappings[parts[0]] = role
+	}
+	if err := p.Refresh(context.Background()); err != nil {
+		return nil, errors.New(op, "load oidc issuer keys", err)
+	}
+	return p, nil
+}
+
+// reloadTokenKeysOnSIGHUP reloads the token keys of s whenever the process
+// receives SIGHUP.
+func reloadTokenKeysOnSIGHUP(logger log.Logger, s *api.Server) {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGHUP)
+	for range sigs {
+		errors.LogFunc(logger, s.TokenKeys.Reload)
+	}
+}
@@ -0,0 +1,350 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/fhofherr/acmeproxy/pkg/api/auth"
+	"github.com/fhofherr/acmeproxy/pkg/certutil"
+	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	flagTokenSigningKeyName  = "signing-key"
+	flagTokenPublicKeyName   = "public-key"
+	flagTokenAlgorithmName   = "algorithm"
+	flagTokenSubjectName     = "subject"
+	flagTokenRoleName        = "role"
+	flagTokenExpiresInName   = "expires-in"
+	flagTokenAudienceName    = "audience"
+	flagTokenIDName          = "id"
+	flagTokenScopeUserName   = "scope-user"
+	flagTokenScopeDomainName = "scope-domain"
+	flagTokenExpiresAtName   = "expires-at"
+	flagTokenKeyIDName       = "key-id"
+)
+
+func init() {
+	tokenCreateCmd.Flags().String(flagTokenSigningKeyName, "",
+		"Path to the PEM encoded private key used to sign the token. [*]")
+	tokenCreateCmd.Flags().String(flagTokenAlgorithmName, auth.ES256.String(),
+		"Algorithm used to sign the token. One of ES256, ES384, ES512, EdDSA, RS256, or PS256.")
+	tokenCreateCmd.Flags().String(flagTokenSubjectName, "",
+		"Subject of the token, i.e. the ID of the acmeproxy user.")
+	tokenCreateCmd.Flags().StringSlice(flagTokenRoleName, nil,
+		"Role granted to the bearer of the token. May be passed multiple times.")
+	tokenCreateCmd.Flags().Duration(flagTokenExpiresInName, 24*time.Hour,
+		"Duration after which the token expires. The token does not expire if zero.")
+	tokenCreateCmd.Flags().String(flagTokenAudienceName, "",
+		"Audience of the token.")
+	tokenCreateCmd.Flags().String(flagTokenIDName, "",
+		"Unique ID (jti) of the token. A random ID is generated if empty.")
+	tokenCreateCmd.Flags().StringSlice(flagTokenScopeUserName, nil,
+		"Limit the token to resources of the user with this ID. May be passed multiple times.")
+	tokenCreateCmd.Flags().StringSlice(flagTokenScopeDomainName, nil,
+		"Limit the token to domains matching this pattern, e.g. '*.example.com'. May be passed multiple times.")
+	tokenCreateCmd.Flags().String(flagTokenKeyIDName, "",
+		"ID (kid) of the signing key. Required if acmeproxy verifies tokens using a key set.")
+	printErrorAndExit(
+		viper.BindPFlag(flagTokenSigningKeyName, tokenCreateCmd.Flags().Lookup(flagTokenSigningKeyName)))
+
+	tokenVerifyCmd.Flags().String(flagTokenPublicKeyName, "",
+		"Path to the PEM encoded public key used to verify the token's signature. [*]")
+	tokenVerifyCmd.Flags().String(flagTokenAudienceName, "",
+		"Audience the token is expected to contain. The audience is not checked if empty.")
+	printErrorAndExit(
+		viper.BindPFlag(flagTokenPublicKeyName, tokenVerifyCmd.Flags().Lookup(flagTokenPublicKeyName)))
+
+	tokenRevokeCmd.Flags().String(flagTokenIDName, "",
+		"Unique ID (jti) of the token to revoke. Required if the token is not passed.")
+	tokenRevokeCmd.Flags().String(flagTokenExpiresAtName, "",
+		"Time the token expires at in RFC 3339 format. The revocation is kept forever if empty.")
+	addClientFlags(tokenRevokeCmd)
+
+	tokenCmd.AddCommand(tokenCreateCmd)
+	tokenCmd.AddCommand(tokenInspectCmd)
+	tokenCmd.AddCommand(tokenVerifyCmd)
+	tokenCmd.AddCommand(tokenRevokeCmd)
+	rootCmd.AddCommand(tokenCmd)
+}
+
+var tokenCmd = &cobra.Command{
+	Use:   "token",
+	Short: "Create and inspect bearer tokens for acmeproxy's API",
+	Long: `
+Create and inspect bearer tokens for acmeproxy's API.
+
+Clients of acmeproxy's API authenticate themselves using JSON Web Tokens
+(JWT). The tokens are signed using an EC private key. The acmeproxy server
+verifies the tokens using the matching public key.
+
+Flags marked with [*] can also be set using environment variables. The name of
+the environment variable corresponds to the flag name prefixed with
+'ACMEPROXY_' and all hyphens replaced underscores.`,
+}
+
+var tokenCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create and sign a new token",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		token, err := createToken(cmd)
+		printErrorAndExit(err)
+		fmt.Fprintln(cmd.OutOrStdout(), token)
+	},
+}
+
+var tokenInspectCmd = &cobra.Command{
+	Use:   "inspect [token]",
+	Short: "Decode a token without verifying it and print it as JSON",
+	Long: `
+Decode a token without verifying it and print its header and claims as JSON.
+
+The token is read from standard input if it is not passed as argument.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		token, err := readToken(cmd.InOrStdin(), args)
+		printErrorAndExit(err)
+		header, claims, err := auth.DecodeToken(token)
+		printErrorAndExit(err)
+		printErrorAndExit(printJSON(cmd.OutOrStdout(), struct {
+			Header map[string]interface{} `json:"header"`
+			Claims *auth.Claims           `json:"claims"`
+		}{header, claims}))
+	},
+}
+
+var tokenVerifyCmd = &cobra.Command{
+	Use:   "verify [token]",
+	Short: "Verify a token and print its claims as JSON",
+	Long: `
+Verify the signature and the expiry of a token and print its claims as JSON.
+
+The token is read from standard input if it is not passed as argument.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		token, err := readToken(cmd.InOrStdin(), args)
+		printErrorAndExit(err)
+		claims, err := verifyToken(cmd, token)
+		printErrorAndExit(err)
+		printErrorAndExit(printJSON(cmd.OutOrStdout(), claims))
+	},
+}
+
+var tokenRevokeCmd = &cobra.Command{
+	Use:   "revoke [token]",
+	Short: "Revoke a token before it expires",
+	Long: `
+Revoke a token before it expires.
+
+The acmeproxy server rejects revoked tokens. Tokens are identified by their
+unique ID (jti). Tokens without an ID cannot be revoked. The ID and the expiry
+of the token are taken from the token if it is passed as argument. Otherwise
+they have to be passed using the --id and --expires-at flags.
+
+Revoking tokens requires a token with the admin role.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		printErrorAndExit(revokeToken(cmd, args))
+	},
+}
+
+func createToken(cmd *cobra.Command) (string, error) {
+	const op errors.Op = "cmd/createToken"
+
+	flags := cmd.Flags()
+	alg, err := auth.ParseAlgorithm(mustGetString(flags.GetString(flagTokenAlgorithmName)))
+	if err != nil {
+		return "", errors.New(op, err)
+	}
+	keyPath := viper.GetString(flagTokenSigningKeyName)
+	if keyPath == "" {
+		return "", errors.New(op, errors.InvalidArgument, "no signing key provided")
+	}
+	key, err := certutil.ReadPrivateKeyFromFile(keyTypeForAlgorithm(alg), keyPath, true)
+	if err != nil {
+		return "", errors.New(op, "read signing key", err)
+	}
+	subject := mustGetString(flags.GetString(flagTokenSubjectName))
+	if subject == "" {
+		return "", errors.New(op, errors.InvalidArgument, "no subject provided")
+	}
+	roles, err := flags.GetStringSlice(flagTokenRoleName)
+	if err != nil {
+		return "", errors.New(op, err)
+	}
+	expiresIn, err := flags.GetDuration(flagTokenExpiresInName)
+	if err != nil {
+		return "", errors.New(op, err)
+	}
+	id := mustGetString(flags.GetString(flagTokenIDName))
+	if id == "" {
+		id = uuid.Must(uuid.NewRandom()).String()
+	}
+
+	now := time.Now()
+	claims := &auth.Claims{
+		StandardClaims: jwt.StandardClaims{
+			Subject:  subject,
+			Audience: mustGetString(flags.GetString(flagTokenAudienceName)),
+			Id:       id,
+			IssuedAt: now.Unix(),
+		},
+	}
+	if expiresIn > 0 {
+		claims.ExpiresAt = now.Add(expiresIn).Unix()
+	}
+	for _, r := range roles {
+		claims.Roles = append(claims.Roles, auth.Role(r))
+	}
+	claims.Scope, err = tokenScope(cmd)
+	if err != nil {
+		return "", errors.New(op, err)
+	}
+	kid := mustGetString(flags.GetString(flagTokenKeyIDName))
+	token, err := auth.NewTokenWithKeyID(claims, alg, key, kid)
+	return token, errors.Wrap(err, op)
+}
+
+func tokenScope(cmd *cobra.Command) (*auth.Scope, error) {
+	const op errors.Op = "cmd/tokenScope"
+
+	flags := cmd.Flags()
+	users, err := flags.GetStringSlice(flagTokenScopeUserName)
+	if err != nil {
+		return nil, errors.New(op, err)
+	}
+	domains, err := flags.GetStringSlice(flagTokenScopeDomainName)
+	if err != nil {
+		return nil, errors.New(op, err)
+	}
+	if len(users) == 0 && len(domains) == 0 {
+		return nil, nil
+	}
+	scope := &auth.Scope{Domains: domains}
+	for _, u := range users {
+		userID, err := uuid.Parse(u)
+		if err != nil {
+			return nil, errors.New(op, errors.InvalidArgument, fmt.Sprintf("invalid user id: %s", u), err)
+		}
+		scope.Users = append(scope.Users, userID)
+	}
+	return scope, nil
+}
+
+func verifyToken(cmd *cobra.Command, token string) (*auth.Claims, error) {
+	const op errors.Op = "cmd/verifyToken"
+
+	flags := cmd.Flags()
+	keyPath := viper.GetString(flagTokenPublicKeyName)
+	if keyPath == "" {
+		return nil, errors.New(op, errors.InvalidArgument, "no public key provided")
+	}
+	key, err := certutil.ReadPublicKeyFromFile(keyPath, true)
+	if err != nil {
+		return nil, errors.New(op, "read public key", err)
+	}
+	claims, err := auth.ParseToken(token, key)
+	if err != nil {
+		return nil, errors.New(op, err)
+	}
+	aud := mustGetString(flags.GetString(flagTokenAudienceName))
+	if aud != "" && !claims.VerifyAudience(aud, true) {
+		return nil, errors.New(op, errors.Unauthorized, fmt.Sprintf("audience mismatch: %s", claims.Audience))
+	}
+	return claims, nil
+}
+
+func revokeToken(cmd *cobra.Command, args []string) error {
+	const op errors.Op = "cmd/revokeToken"
+
+	var (
+		id        string
+		expiresAt time.Time
+	)
+	if len(args) > 0 {
+		_, claims, err := auth.DecodeToken(args[0])
+		if err != nil {
+			return errors.New(op, err)
+		}
+		id = claims.Id
+		if claims.ExpiresAt > 0 {
+			expiresAt = time.Unix(claims.ExpiresAt, 0)
+		}
+	} else {
+		flags := cmd.Flags()
+		id = mustGetString(flags.GetString(flagTokenIDName))
+		if s := mustGetString(flags.GetString(flagTokenExpiresAtName)); s != "" {
+			t, err := time.Parse(time.RFC3339, s)
+			if err != nil {
+				return errors.New(op, errors.InvalidArgument, "parse expiry", err)
+			}
+			expiresAt = t
+		}
+	}
+	if id == "" {
+		return errors.New(op, errors.InvalidArgument, "no token id")
+	}
+
+	client, err := newClient(cmd)
+	if err != nil {
+		return errors.New(op, err)
+	}
+	defer client.Close()
+	err = client.RevokeToken(context.Background(), id, expiresAt)
+	return errors.Wrap(err, op)
+}
+
+func readToken(r io.Reader, args []string) (string, error) {
+	const op errors.Op = "cmd/readToken"
+
+	if len(args) > 0 {
+		return args[0], nil
+	}
+	bs, err := ioutil.ReadAll(r)
+	if err != nil {
+		return "", errors.New(op, "read token", err)
+	}
+	token := strings.TrimSpace(string(bs))
+	if token == "" {
+		return "", errors.New(op, errors.InvalidArgument, "no token provided")
+	}
+	return token, nil
+}
+
+func keyTypeForAlgorithm(alg auth.Algorithm) certutil.KeyType {
+	switch alg {
+	case auth.ES384:
+		return certutil.EC384
+	case auth.ES512:
+		return certutil.EC521
+	case auth.EdDSA:
+		return certutil.Ed25519
+	case auth.RS256, auth.PS256:
+		return certutil.RSA2048
+	default:
+		return certutil.EC256
+	}
+}
+
+func printJSON(w io.Writer, v interface{}) error {
+	const op errors.Op = "cmd/printJSON"
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return errors.Wrap(enc.Encode(v), op, "encode json")
+}
+
+func mustGetString(s string, err error) string {
+	printErrorAndExit(err)
+	return s
+}
@@ -9,15 +9,23 @@ require (
 	github.com/fhofherr/golf-zap v0.2.0
 	github.com/go-acme/lego v2.7.0+incompatible
 	github.com/go-chi/chi v4.0.2+incompatible
-	github.com/golang/protobuf v1.3.2
-	github.com/google/uuid v1.1.1
-	github.com/miekg/dns v1.1.15 // indirect
+	github.com/golang/protobuf v1.5.2
+	github.com/google/uuid v1.1.2
+	github.com/miekg/dns v1.1.15
+	github.com/prometheus/client_golang v1.12.2
 	github.com/spf13/cobra v0.0.5
 	github.com/spf13/viper v1.3.2
-	github.com/stretchr/testify v1.4.0
+	github.com/stretchr/testify v1.7.1
 	go.etcd.io/bbolt v1.3.3
+	go.opentelemetry.io/otel v1.7.0
+	go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp v1.7.0
+	go.opentelemetry.io/otel/exporters/stdout/stdouttrace v1.7.0
+	go.opentelemetry.io/otel/sdk v1.7.0
+	go.opentelemetry.io/otel/trace v1.7.0
 	go.uber.org/zap v1.9.1
-	golang.org/x/crypto v0.0.0-20190701094942-4def268fd1a4 // indirect
-	google.golang.org/grpc v1.24.0
-	gopkg.in/square/go-jose.v2 v2.3.1 // indirect
+	golang.org/x/crypto v0.0.0-20200622213623-75b288015ac9
+	golang.org/x/net v0.0.0-20210525063256-abc453219eb5
+	google.golang.org/genproto v0.0.0-20211118181313-81c1377c94b1
+	google.golang.org/grpc v1.46.0
+	gopkg.in/square/go-jose.v2 v2.3.1
 )
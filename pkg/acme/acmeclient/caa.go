@@ -0,0 +1,336 @@
+package acmeclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fhofherr/acmeproxy/pkg/acme"
+	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/fhofherr/acmeproxy/pkg/tracing"
+	legoacme "github.com/go-acme/lego/acme"
+	"github.com/miekg/dns"
+)
+
+const (
+	// caaTimeout limits the time a single CAA check may take.
+	caaTimeout = 10 * time.Second
+
+	// maxDirectorySize limits the number of bytes read from the ACME
+	// directory.
+	maxDirectorySize = 64 * 1024
+
+	// caaIdentitiesTTL is the time Client re-uses the caaIdentities it
+	// fetched from the ACME directory.
+	caaIdentitiesTTL = time.Hour
+
+	// caaFlagCritical is the issuer critical flag of a CAA record.
+	caaFlagCritical = 128
+
+	// resolvConf is used to find a DNS server if DNSCAAResolver has no
+	// address configured.
+	resolvConf = "/etc/resolv.conf"
+)
+
+// CAAResolver wraps the LookupCAA method.
+//
+// LookupCAA returns the CAA records published at exactly name. It does not
+// climb the DNS tree. If name has no CAA records, or does not exist, LookupCAA
+// returns an empty slice and no error.
+type CAAResolver interface {
+	LookupCAA(ctx context.Context, name string) ([]acme.CAARecord, error)
+}
+
+// DNSCAAResolver looks up CAA records by querying the DNS server listening on
+// Addr. If Addr is empty DNSCAAResolver uses the first name server listed in
+// /etc/resolv.conf.
+type DNSCAAResolver struct {
+	Addr string
+}
+
+// LookupCAA looks up the CAA records of name.
+func (r *DNSCAAResolver) LookupCAA(ctx context.Context, name string) ([]acme.CAARecord, error) {
+	const op errors.Op = "acmeclient/dnsCAAResolver.LookupCAA"
+
+	addr, err := r.serverAddr()
+	if err != nil {
+		return nil, errors.New(op, err)
+	}
+	msg := &dns.Msg{}
+	msg.SetQuestion(dns.Fqdn(name), dns.TypeCAA)
+	msg.RecursionDesired = true
+
+	client := &dns.Client{}
+	res, _, err := client.ExchangeContext(ctx, msg, addr)
+	if err != nil {
+		return nil, errors.New(op, fmt.Sprintf("query caa records: %s", name), err)
+	}
+	switch res.Rcode {
+	case dns.RcodeSuccess:
+	case dns.RcodeNameError:
+		return nil, nil
+	default:
+		msg := fmt.Sprintf("query caa records: %s: %s", name, dns.RcodeToString[res.Rcode])
+		return nil, errors.New(op, msg)
+	}
+	var records []acme.CAARecord
+	for _, rr := range res.Answer {
+		caa, ok := rr.(*dns.CAA)
+		if !ok {
+			continue
+		}
+		records = append(records, acme.CAARecord{
+			Name:  name,
+			Flag:  caa.Flag,
+			Tag:   caa.Tag,
+			Value: caa.Value,
+		})
+	}
+	return records, nil
+}
+
+func (r *DNSCAAResolver) serverAddr() (string, error) {
+	const op errors.Op = "acmeclient/dnsCAAResolver.serverAddr"
+
+	if r.Addr != "" {
+		return r.Addr, nil
+	}
+	cfg, err := dns.ClientConfigFromFile(resolvConf)
+	if err != nil {
+		return "", errors.New(op, "read resolver configuration", err)
+	}
+	if len(cfg.Servers) == 0 {
+		return "", errors.New(op, fmt.Sprintf("no name servers in %s", resolvConf))
+	}
+	return net.JoinHostPort(cfg.Servers[0], cfg.Port), nil
+}
+
+// RecommendCAARecords returns the CAA records domainName has to publish in
+// order to allow only the ACME account identified by accountURL to obtain
+// certificates for it.
+//
+// The records restrict issuance to the issuer domains the ACME CA lists in
+// the caaIdentities of its directory, the account identified by accountURL,
+// and the http-01 validation method.
+func (c *Client) RecommendCAARecords(ctx context.Context, domainName, accountURL string) ([]acme.CAARecord, error) {
+	const op errors.Op = "acmeclient/client.RecommendCAARecords"
+
+	if accountURL == "" {
+		return nil, errors.New(op, errors.InvalidArgument, "no account url")
+	}
+	ctx, cancel := context.WithTimeout(ctx, caaTimeout)
+	defer cancel()
+	identities, err := c.caaIdentities(ctx)
+	if err != nil {
+		return nil, errors.New(op, err)
+	}
+	if len(identities) == 0 {
+		return nil, errors.New(op, errors.NotFound, "ca does not publish caa identities")
+	}
+	records := make([]acme.CAARecord, 0, len(identities))
+	for _, id := range identities {
+		records = append(records, acme.CAARecord{
+			Name:  domainName,
+			Tag:   "issue",
+			Value: fmt.Sprintf("%s; accounturi=%s; validationmethods=http-01", id, accountURL),
+		})
+	}
+	return records, nil
+}
+
+// checkCAA checks if the CAA records of domain permit the ACME CA to issue a
+// certificate for domain to the account identified by accountURL.
+//
+// checkCAA climbs the DNS tree as described in RFC 8659 until it finds the
+// relevant CAA record set. It honors the accounturi and validationmethods
+// parameters defined in RFC 8657. If the CA does not list any caaIdentities in
+// its directory checkCAA assumes issuance is permitted.
+//
+// If issuance is not permitted checkCAA returns an error of kind
+// InvalidArgument.
+func (c *Client) checkCAA(ctx context.Context, domain, accountURL string) (err error) {
+	const op errors.Op = "acmeclient/client.checkCAA"
+
+	ctx, span := tracing.Start(ctx, string(op), tracing.DomainKey.String(domain))
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := context.WithTimeout(ctx, caaTimeout)
+	defer cancel()
+	identities, err := c.caaIdentities(ctx)
+	if err != nil {
+		return errors.New(op, err)
+	}
+	if len(identities) == 0 {
+		return nil
+	}
+	records, err := c.relevantCAARecords(ctx, domain)
+	if err != nil {
+		return errors.New(op, err)
+	}
+	wildcard := strings.HasPrefix(domain, "*.")
+	if !caaPermits(records, identities, accountURL, wildcard) {
+		msg := fmt.Sprintf(
+			"caa records of %s do not permit %s to issue certificates for account %s",
+			domain, strings.Join(identities, ", "), accountURL)
+		return errors.New(op, errors.InvalidArgument, msg)
+	}
+	return nil
+}
+
+// relevantCAARecords returns the first non-empty CAA record set found while
+// climbing the DNS tree from domain towards the root.
+func (c *Client) relevantCAARecords(ctx context.Context, domain string) ([]acme.CAARecord, error) {
+	const op errors.Op = "acmeclient/client.relevantCAARecords"
+
+	var resolver CAAResolver = &DNSCAAResolver{}
+	if c.CAAResolver != nil {
+		resolver = c.CAAResolver
+	}
+	name := strings.TrimSuffix(strings.TrimPrefix(domain, "*."), ".")
+	for name != "" {
+		records, err := resolver.LookupCAA(ctx, name)
+		if err != nil {
+			return nil, errors.New(op, fmt.Sprintf("lookup caa records: %s", name), err)
+		}
+		if len(records) > 0 {
+			return records, nil
+		}
+		idx := strings.Index(name, ".")
+		if idx < 0 {
+			break
+		}
+		name = name[idx+1:]
+	}
+	return nil, nil
+}
+
+// caaIdentities returns the issuer domain names listed in the directory of
+// the ACME CA.
+//
+// The directory is fetched using the HTTP client of c. caaIdentities caches
+// the issuer domain names for caaIdentitiesTTL. Failed fetches are not
+// cached.
+func (c *Client) caaIdentities(ctx context.Context) ([]string, error) {
+	const op errors.Op = "acmeclient/client.caaIdentities"
+
+	if identities, ok := c.caaCache.get(time.Now()); ok {
+		return identities, nil
+	}
+	req, err := http.NewRequest(http.MethodGet, c.DirectoryURL, nil)
+	if err != nil {
+		return nil, errors.New(op, "create request", err)
+	}
+	res, err := c.httpClient().Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, errors.New(op, fmt.Sprintf("get directory: %s", c.DirectoryURL), err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.New(op, fmt.Sprintf("get directory %s: unexpected status: %d", c.DirectoryURL, res.StatusCode))
+	}
+	var dir legoacme.Directory
+	if err := json.NewDecoder(io.LimitReader(res.Body, maxDirectorySize)).Decode(&dir); err != nil {
+		return nil, errors.New(op, "decode directory", err)
+	}
+	c.caaCache.set(dir.Meta.CaaIdentities, time.Now().Add(caaIdentitiesTTL))
+	return dir.Meta.CaaIdentities, nil
+}
+
+// caaIdentityCache holds the caaIdentities of an ACME directory until they
+// expire. The zero value is an empty cache.
+type caaIdentityCache struct {
+	mu         sync.Mutex
+	identities []string
+	expires    time.Time
+}
+
+func (c *caaIdentityCache) get(now time.Time) ([]string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !now.Before(c.expires) {
+		return nil, false
+	}
+	return c.identities, true
+}
+
+func (c *caaIdentityCache) set(identities []string, expires time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.identities = identities
+	c.expires = expires
+}
+
+// caaPermits evaluates the relevant CAA record set as described in RFC 8659
+// section 4.
+func caaPermits(records []acme.CAARecord, identities []string, accountURL string, wildcard bool) bool {
+	if len(records) == 0 {
+		return true
+	}
+	tag := "issue"
+	for _, r := range records {
+		t := strings.ToLower(r.Tag)
+		if r.Flag&caaFlagCritical != 0 && t != "issue" && t != "issuewild" && t != "iodef" {
+			// We don't understand a critical property. RFC 8659 forbids
+			// issuance in this case.
+			return false
+		}
+		if wildcard && t == "issuewild" {
+			tag = "issuewild"
+		}
+	}
+	found := false
+	for _, r := range records {
+		if !strings.EqualFold(r.Tag, tag) {
+			continue
+		}
+		found = true
+		issuer, params := parseCAAIssueValue(r.Value)
+		if !containsFold(identities, issuer) {
+			continue
+		}
+		if uri, ok := params["accounturi"]; ok && uri != accountURL {
+			continue
+		}
+		if methods, ok := params["validationmethods"]; ok && !containsFold(strings.Split(methods, ","), "http-01") {
+			continue
+		}
+		return true
+	}
+	// A relevant record set without any issue properties does not restrict
+	// issuance.
+	return !found
+}
+
+// parseCAAIssueValue parses the value of an issue or issuewild property into
+// the issuer domain name and its parameters.
+func parseCAAIssueValue(value string) (string, map[string]string) {
+	parts := strings.Split(value, ";")
+	issuer := strings.TrimSpace(parts[0])
+	params := make(map[string]string, len(parts)-1)
+	for _, p := range parts[1:] {
+		kv := strings.SplitN(strings.TrimSpace(p), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		params[strings.ToLower(strings.TrimSpace(kv[0]))] = strings.TrimSpace(kv[1])
+	}
+	return issuer, params
+}
+
+func containsFold(ss []string, s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, v := range ss {
+		if strings.EqualFold(strings.TrimSpace(v), s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,270 @@
+package acmeclient
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fhofherr/acmeproxy/pkg/acme"
+	"github.com/fhofherr/acmeproxy/pkg/errors"
+	legoacme "github.com/go-acme/lego/acme"
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+)
+
+const testAccountURL = "https://ca.example.com/acme/acct/1"
+
+type mapCAAResolver map[string][]acme.CAARecord
+
+func (r mapCAAResolver) LookupCAA(_ context.Context, name string) ([]acme.CAARecord, error) {
+	return r[name], nil
+}
+
+func issue(name, value string) acme.CAARecord {
+	return acme.CAARecord{Name: name, Tag: "issue", Value: value}
+}
+
+func TestCheckCAA(t *testing.T) {
+	tests := []struct {
+		name       string
+		domain     string
+		identities []string
+		records    mapCAAResolver
+		err        error
+	}{
+		{
+			name:       "no caa records",
+			domain:     "www.example.com",
+			identities: []string{"ca.example.com"},
+		},
+		{
+			name:    "ca publishes no caa identities",
+			domain:  "www.example.com",
+			records: mapCAAResolver{"www.example.com": {issue("www.example.com", "other.example.org")}},
+		},
+		{
+			name:       "issuer permitted",
+			domain:     "www.example.com",
+			identities: []string{"ca.example.com"},
+			records:    mapCAAResolver{"www.example.com": {issue("www.example.com", "ca.example.com")}},
+		},
+		{
+			name:       "issuer permitted on parent domain",
+			domain:     "www.example.com",
+			identities: []string{"ca.example.com"},
+			records:    mapCAAResolver{"example.com": {issue("example.com", "ca.example.com")}},
+		},
+		{
+			name:       "other issuer on parent domain",
+			domain:     "www.example.com",
+			identities: []string{"ca.example.com"},
+			records:    mapCAAResolver{"example.com": {issue("example.com", "other.example.org")}},
+			err:        errors.New(errors.InvalidArgument),
+		},
+		{
+			name:       "closest record set wins",
+			domain:     "www.example.com",
+			identities: []string{"ca.example.com"},
+			records: mapCAAResolver{
+				"www.example.com": {issue("www.example.com", "ca.example.com")},
+				"example.com":     {issue("example.com", "other.example.org")},
+			},
+		},
+		{
+			name:       "issuance forbidden",
+			domain:     "www.example.com",
+			identities: []string{"ca.example.com"},
+			records:    mapCAAResolver{"www.example.com": {issue("www.example.com", ";")}},
+			err:        errors.New(errors.InvalidArgument),
+		},
+		{
+			name:       "record set without issue property",
+			domain:     "www.example.com",
+			identities: []string{"ca.example.com"},
+			records: mapCAAResolver{"www.example.com": {
+				{Name: "www.example.com", Tag: "iodef", Value: "mailto:security@example.com"},
+			}},
+		},
+		{
+			name:       "unknown critical property",
+			domain:     "www.example.com",
+			identities: []string{"ca.example.com"},
+			records: mapCAAResolver{"www.example.com": {
+				issue("www.example.com", "ca.example.com"),
+				{Name: "www.example.com", Flag: 128, Tag: "tbs", Value: "unknown"},
+			}},
+			err: errors.New(errors.InvalidArgument),
+		},
+		{
+			name:       "matching account uri",
+			domain:     "www.example.com",
+			identities: []string{"ca.example.com"},
+			records: mapCAAResolver{"www.example.com": {
+				issue("www.example.com", "ca.example.com; accounturi="+testAccountURL),
+			}},
+		},
+		{
+			name:       "different account uri",
+			domain:     "www.example.com",
+			identities: []string{"ca.example.com"},
+			records: mapCAAResolver{"www.example.com": {
+				issue("www.example.com", "ca.example.com; accounturi=https://ca.example.com/acme/acct/2"),
+			}},
+			err: errors.New(errors.InvalidArgument),
+		},
+		{
+			name:       "http-01 permitted",
+			domain:     "www.example.com",
+			identities: []string{"ca.example.com"},
+			records: mapCAAResolver{"www.example.com": {
+				issue("www.example.com", "ca.example.com; validationmethods=dns-01,http-01"),
+			}},
+		},
+		{
+			name:       "http-01 not permitted",
+			domain:     "www.example.com",
+			identities: []string{"ca.example.com"},
+			records: mapCAAResolver{"www.example.com": {
+				issue("www.example.com", "ca.example.com; validationmethods=dns-01"),
+			}},
+			err: errors.New(errors.InvalidArgument),
+		},
+		{
+			name:       "issuewild applies to wildcard domains",
+			domain:     "*.example.com",
+			identities: []string{"ca.example.com"},
+			records: mapCAAResolver{"example.com": {
+				issue("example.com", "ca.example.com"),
+				{Name: "example.com", Tag: "issuewild", Value: ";"},
+			}},
+			err: errors.New(errors.InvalidArgument),
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			dir := newDirectoryServer(t, tt.identities)
+			defer dir.Close()
+
+			client := &Client{
+				DirectoryURL: dir.URL,
+				CAAResolver:  tt.records,
+			}
+			err := client.checkCAA(context.Background(), tt.domain, testAccountURL)
+			if tt.err == nil {
+				assert.NoError(t, err)
+				return
+			}
+			errors.AssertMatches(t, tt.err, err)
+		})
+	}
+}
+
+func TestRecommendCAARecords(t *testing.T) {
+	dir := newDirectoryServer(t, []string{"ca.example.com"})
+	defer dir.Close()
+
+	client := &Client{DirectoryURL: dir.URL}
+	records, err := client.RecommendCAARecords(context.Background(), "www.example.com", testAccountURL)
+	assert.NoError(t, err)
+	expected := []acme.CAARecord{
+		issue("www.example.com", "ca.example.com; accounturi="+testAccountURL+"; validationmethods=http-01"),
+	}
+	assert.Equal(t, expected, records)
+
+	// The recommended records must pass our own check.
+	client.CAAResolver = mapCAAResolver{"www.example.com": records}
+	assert.NoError(t, client.checkCAA(context.Background(), "www.example.com", testAccountURL))
+}
+
+func TestCAAIdentitiesAreCached(t *testing.T) {
+	var requests int
+	dir := newDirectoryServer(t, []string{"ca.example.com"})
+	handler := dir.Config.Handler
+	dir.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests++
+		handler.ServeHTTP(w, req)
+	})
+	defer dir.Close()
+
+	client := &Client{DirectoryURL: dir.URL, HTTPClient: dir.Client()}
+	for i := 0; i < 2; i++ {
+		identities, err := client.caaIdentities(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"ca.example.com"}, identities)
+	}
+	assert.Equal(t, 1, requests)
+
+	client.caaCache.set([]string{"ca.example.com"}, time.Now())
+	_, err := client.caaIdentities(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, requests)
+}
+
+func TestCAAIdentitiesHonorContext(t *testing.T) {
+	dir := newDirectoryServer(t, []string{"ca.example.com"})
+	defer dir.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	client := &Client{DirectoryURL: dir.URL}
+	_, err := client.caaIdentities(ctx)
+	assert.Error(t, err)
+
+	// Failed fetches are not cached.
+	identities, err := client.caaIdentities(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ca.example.com"}, identities)
+}
+
+func TestDNSCAAResolver(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &dns.Server{
+		PacketConn: pc,
+		Handler: dns.HandlerFunc(func(w dns.ResponseWriter, req *dns.Msg) {
+			res := &dns.Msg{}
+			res.SetReply(req)
+			if req.Question[0].Name != "example.com." {
+				res.Rcode = dns.RcodeNameError
+				w.WriteMsg(res) // nolint: errcheck
+				return
+			}
+			rr, err := dns.NewRR(`example.com. 300 IN CAA 0 issue "ca.example.com"`)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res.Answer = append(res.Answer, rr)
+			w.WriteMsg(res) // nolint: errcheck
+		}),
+	}
+	go server.ActivateAndServe() // nolint: errcheck
+	defer server.Shutdown()      // nolint: errcheck
+
+	resolver := &DNSCAAResolver{Addr: pc.LocalAddr().String()}
+	records, err := resolver.LookupCAA(context.Background(), "example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, []acme.CAARecord{issue("example.com", "ca.example.com")}, records)
+
+	records, err = resolver.LookupCAA(context.Background(), "www.example.com")
+	assert.NoError(t, err)
+	assert.Empty(t, records)
+}
+
+func newDirectoryServer(t *testing.T, identities []string) *httptest.Server {
+	var dir legoacme.Directory
+	dir.Meta.CaaIdentities = identities
+	bs, err := json.Marshal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write(bs) // nolint: errcheck
+	}))
+}
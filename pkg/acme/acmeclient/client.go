@@ -1,11 +1,17 @@
 package acmeclient
 
 import (
+	"context"
 	"crypto"
 	"fmt"
+	"net/http"
+	"time"
 
 	"github.com/fhofherr/acmeproxy/pkg/acme"
 	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/fhofherr/acmeproxy/pkg/requestid"
+	"github.com/fhofherr/acmeproxy/pkg/tracing"
+	"github.com/fhofherr/golf/log"
 	"github.com/go-acme/lego/certificate"
 	"github.com/go-acme/lego/lego"
 	"github.com/go-acme/lego/registration"
@@ -13,17 +19,43 @@ import (
 
 // Client is an ACME protocol client capable of obtaining and renewing
 // certificates.
+//
+// Before placing an order Client checks if the ACME CA will be able to
+// validate the HTTP01 challenges for the requested domains. To this end it
+// resolves each domain using Resolver and fetches a self-test token from
+// HTTP01Port of every resolved address. Additionally it uses CAAResolver to
+// check that the CAA records of each domain permit the ACME CA to issue
+// certificates. The caaIdentities listed in the directory of the ACME CA are
+// fetched using HTTPClient and cached for an hour.
+//
+// Client logs the certificates it obtains to Logger. The log entries, as
+// well as those of lego, carry the request ID found in the context passed to
+// ObtainCertificate. Client records spans for obtaining a certificate, for
+// the pre-flight and CAA checks, and for lego's order. They are children of
+// the span carried by the context. If Metrics is not nil Client records
+// every certificate order in it.
 type Client struct {
 	DirectoryURL string
 	HTTP01Solver HTTP01Solver
+	HTTP01Port   int          // Port the ACME CA validates HTTP01 challenges on; 80 if zero.
+	Resolver     Resolver     // Resolves domains during pre-flight checks; net.DefaultResolver if nil.
+	CAAResolver  CAAResolver  // Looks up CAA records during pre-flight checks; a DNSCAAResolver if nil.
+	HTTPClient   *http.Client // Used for OCSP requests, pre-flight checks, and the directory; http.DefaultClient if nil.
+	Metrics      *OrderMetrics
+	Logger       log.Logger
+
+	caaCache caaIdentityCache
 }
 
 // CreateAccount creates a new ACME account for the accountKey.
 //
 // If email is not empty it is used as the contact address for the new account.
-func (c *Client) CreateAccount(accountKey crypto.PrivateKey, email string) (string, error) {
+func (c *Client) CreateAccount(ctx context.Context, accountKey crypto.PrivateKey, email string) (_ string, err error) {
 	const op errors.Op = "acmeclient/client.CreateAccount"
 
+	ctx, span := tracing.Start(ctx, string(op))
+	defer func() { tracing.End(span, err) }()
+
 	user := &User{
 		Email:      email,
 		PrivateKey: accountKey,
@@ -38,27 +70,54 @@ func (c *Client) CreateAccount(accountKey crypto.PrivateKey, email string) (stri
 	if err != nil {
 		return "", errors.New(op, "register new ACME account", err)
 	}
+	log.Log(requestid.Logger(ctx, c.Logger),
+		"level", "info",
+		"message", "created ACME account",
+		"account", user.Registration.URI)
 	return user.Registration.URI, nil
 }
 
 // ObtainCertificate obtains a new certificate from the remote ACME server.
-func (c *Client) ObtainCertificate(req acme.CertificateRequest) (*acme.CertificateInfo, error) {
+//
+// ObtainCertificate refuses to place an order with an error of kind
+// InvalidArgument if a requested domain fails the pre-flight check, or if its
+// CAA records do not permit the ACME CA to issue certificates for the account.
+func (c *Client) ObtainCertificate(
+	ctx context.Context, req acme.CertificateRequest,
+) (_ *acme.CertificateInfo, err error) {
 	const op errors.Op = "acmeclient/client.ObtainCertificate"
 
+	ctx, span := tracing.Start(ctx, string(op), tracing.DomainsKey.StringSlice(req.Domains))
+	defer func() { tracing.End(span, err) }()
+	start := time.Now()
+	defer func() { c.Metrics.observe(c.DirectoryURL, start, err) }()
+
 	if len(req.Domains) < 1 {
 		return nil, errors.New(op, errors.InvalidArgument, "no domains")
 	}
+	logger := requestid.Logger(ctx, c.Logger)
+	log.Log(logger, "level", "info", "message", "obtaining certificate", "domains", req.Domains)
+	for _, domain := range req.Domains {
+		if err := c.preflight(ctx, domain); err != nil {
+			return nil, errors.New(op, fmt.Sprintf("pre-flight check: %s", domain), err)
+		}
+	}
 	keyType, err := legoKeyType(req.KeyType)
 	if err != nil {
 		return nil, errors.New(op, "determine lego key type", err)
 	}
 	if req.AccountURL == "" {
 		var err error
-		req.AccountURL, err = c.CreateAccount(req.AccountKey, req.Email)
+		req.AccountURL, err = c.CreateAccount(ctx, req.AccountKey, req.Email)
 		if err != nil {
 			return nil, errors.New(op, "create ad-hoc account", err)
 		}
 	}
+	for _, domain := range req.Domains {
+		if err := c.checkCAA(ctx, domain, req.AccountURL); err != nil {
+			return nil, errors.New(op, fmt.Sprintf("caa check: %s", domain), err)
+		}
+	}
 	user := &User{
 		Email:        req.Email,
 		Registration: &registration.Resource{URI: req.AccountURL},
@@ -79,10 +138,11 @@ func (c *Client) ObtainCertificate(req acme.CertificateRequest) (*acme.Certifica
 		Domains: req.Domains,
 		Bundle:  req.Bundle,
 	}
-	certs, err := legoClient.Certificate.Obtain(obtReq)
+	certs, err := obtainWithLego(ctx, legoClient, obtReq)
 	if err != nil {
 		return nil, errors.New(op, fmt.Sprintf("obtain certificates %s", req.Domains[0]), err)
 	}
+	log.Log(logger, "level", "info", "message", "obtained certificate", "domains", req.Domains)
 	return &acme.CertificateInfo{
 		URL:               certs.CertURL,
 		AccountURL:        user.Registration.URI,
@@ -91,3 +151,45 @@ func (c *Client) ObtainCertificate(req acme.CertificateRequest) (*acme.Certifica
 		PrivateKey:        certs.PrivateKey,
 	}, nil
 }
+
+// obtainWithLego places the order for obtReq using legoClient. It registers
+// the span of the order with legoRequests. This allows lego's log entries and
+// the challenges to be attributed to the order.
+func obtainWithLego(
+	ctx context.Context, legoClient *lego.Client, obtReq certificate.ObtainRequest,
+) (_ *certificate.Resource, err error) {
+	const op errors.Op = "acmeclient/lego.Obtain"
+
+	ctx, span := tracing.Start(ctx, string(op), tracing.DomainsKey.StringSlice(obtReq.Domains))
+	defer func() { tracing.End(span, err) }()
+
+	unregister := legoRequests.register(ctx, obtReq.Domains)
+	defer unregister()
+	return legoClient.Certificate.Obtain(obtReq)
+}
+
+// CheckDirectory checks that the directory of the ACME CA is reachable.
+func (c *Client) CheckDirectory(ctx context.Context) error {
+	const op errors.Op = "acmeclient/client.CheckDirectory"
+
+	req, err := http.NewRequest(http.MethodGet, c.DirectoryURL, nil)
+	if err != nil {
+		return errors.New(op, "create request", err)
+	}
+	res, err := c.httpClient().Do(req.WithContext(ctx))
+	if err != nil {
+		return errors.New(op, fmt.Sprintf("get directory: %s", c.DirectoryURL), err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return errors.New(op, fmt.Sprintf("get directory: %s: status %d", c.DirectoryURL, res.StatusCode))
+	}
+	return nil
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient == nil {
+		return http.DefaultClient
+	}
+	return c.HTTPClient
+}
@@ -1,6 +1,9 @@
 package acmeclient_test
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -33,7 +36,7 @@ func TestCreateAccount(t *testing.T) {
 				certutil.WritePrivateKeyForTesting(t, keyFile, certutil.EC256, true)
 			}
 			accountKey := certutil.KeyMust(certutil.ReadPrivateKeyFromFile(certutil.EC256, keyFile, true))
-			accountURL, err := fx.Client.CreateAccount(accountKey, tt.email)
+			accountURL, err := fx.Client.CreateAccount(context.Background(), accountKey, tt.email)
 			assert.NoError(t, err)
 			assert.NotEmpty(t, accountURL)
 			assert.Truef(
@@ -120,7 +123,7 @@ func TestObtainCertificate(t *testing.T) {
 	for _, tt := range tests {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
-			certInfo, err := fx.Client.ObtainCertificate(tt.CertificateRequest)
+			certInfo, err := fx.Client.ObtainCertificate(context.Background(), tt.CertificateRequest)
 			if err != nil {
 				if tt.errTmpl == nil {
 					t.Fatalf("Unexpected error: %v", err)
@@ -149,7 +152,7 @@ func TestObtainCertificateWithPreExistingAccount(t *testing.T) {
 
 	domain := "www.example.com"
 	accountKey := certutil.KeyMust(certutil.NewPrivateKey(certutil.EC256))
-	accountURL, err := fx.Client.CreateAccount(accountKey, "jane.doe@example.com")
+	accountURL, err := fx.Client.CreateAccount(context.Background(), accountKey, "jane.doe@example.com")
 	assert.NoError(t, err)
 
 	req := acme.CertificateRequest{
@@ -160,7 +163,22 @@ func TestObtainCertificateWithPreExistingAccount(t *testing.T) {
 		AccountKey: accountKey,
 		KeyType:    certutil.RSA2048,
 	}
-	ci, err := fx.Client.ObtainCertificate(req)
+	ci, err := fx.Client.ObtainCertificate(context.Background(), req)
 	assert.NoError(t, err)
 	fx.Pebble.AssertIssuedByPebble(t, domain, ci.Certificate)
 }
+
+func TestCheckDirectory(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/directory" {
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := &acmeclient.Client{DirectoryURL: server.URL + "/directory"}
+	assert.NoError(t, client.CheckDirectory(context.Background()))
+
+	client = &acmeclient.Client{DirectoryURL: server.URL + "/missing"}
+	assert.Error(t, client.CheckDirectory(context.Background()))
+}
@@ -1,10 +1,14 @@
 package acmeclient
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"sync"
 
+	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/fhofherr/acmeproxy/pkg/requestid"
+	"github.com/fhofherr/acmeproxy/pkg/tracing"
 	"github.com/fhofherr/golf/log"
 )
 
@@ -19,6 +23,11 @@ import (
 // The methods Present and CleanUp are intended for use by lego and should not
 // be called directly.
 //
+// HTTP01Solver records a span for presenting, serving, and cleaning up each
+// challenge. The spans are children of the span of the request obtaining the
+// certificate for the challenge's domain. HTTP01Solver implements
+// prometheus.Collector reporting the number of pending challenges.
+//
 // The zero value of HTTP01Solver is fully functional.
 type HTTP01Solver struct {
 	Logger     log.Logger
@@ -30,6 +39,16 @@ type HTTP01Solver struct {
 //
 // This method is intended to be used by lego and should not be called directly.
 func (p *HTTP01Solver) Present(domain, token, keyAuth string) error {
+	ctx, _ := legoRequests.context(domain)
+	return p.present(ctx, domain, token, keyAuth)
+}
+
+func (p *HTTP01Solver) present(ctx context.Context, domain, token, keyAuth string) (err error) {
+	const op errors.Op = "acmeclient/http01Solver.Present"
+
+	_, span := tracing.Start(ctx, string(op), tracing.DomainKey.String(domain))
+	defer func() { tracing.End(span, err) }()
+
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
@@ -46,6 +65,16 @@ func (p *HTTP01Solver) Present(domain, token, keyAuth string) error {
 //
 // This method is intended to be used by lego and should not be called directly.
 func (p *HTTP01Solver) CleanUp(domain, token, keyAuth string) error {
+	ctx, _ := legoRequests.context(domain)
+	return p.cleanUp(ctx, domain, token)
+}
+
+func (p *HTTP01Solver) cleanUp(ctx context.Context, domain, token string) (err error) {
+	const op errors.Op = "acmeclient/http01Solver.CleanUp"
+
+	_, span := tracing.Start(ctx, string(op), tracing.DomainKey.String(domain))
+	defer func() { tracing.End(span, err) }()
+
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
@@ -54,12 +83,23 @@ func (p *HTTP01Solver) CleanUp(domain, token, keyAuth string) error {
 	return nil
 }
 
+// Pending returns the number of challenges presented but not yet cleaned
+// up.
+func (p *HTTP01Solver) Pending() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return len(p.challenges)
+}
+
 // Handler creates an http.Handler serving the actual HTTP01 challenge.
 //
 // The extractParams function is used to extract the required parameters from
 // the request. It must return a map containing the keys domain and token, or nil if the map cannot be constructed.
 func (p *HTTP01Solver) Handler(extractParams func(*http.Request) map[string]string) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		const op errors.Op = "acmeclient/http01Solver.Handler"
+
 		p.mu.Lock()
 		defer p.mu.Unlock()
 
@@ -67,20 +107,35 @@ func (p *HTTP01Solver) Handler(extractParams func(*http.Request) map[string]stri
 		domain := params["domain"]
 		token := params["token"]
 
+		_, span := tracing.StartServer(challengeCtx(req.Context(), domain), string(op), tracing.DomainKey.String(domain))
 		key := challengeKey(domain, token)
 		keyAuth, ok := p.challenges[key]
+		logger := requestid.Logger(req.Context(), p.Logger)
 		if !ok {
+			tracing.End(span, errors.New(op, errors.NotFound, fmt.Sprintf("no challenge for domain: %s", domain)))
 			w.WriteHeader(http.StatusNotFound)
-			p.writeBody(w, []byte("Not found"))
+			writeBody(logger, w, []byte("Not found"))
 			return
 		}
-		p.writeBody(w, []byte(keyAuth))
+		tracing.End(span, nil)
+		writeBody(logger, w, []byte(keyAuth))
 	})
 }
 
-func (p *HTTP01Solver) writeBody(w http.ResponseWriter, body []byte) {
+// challengeCtx returns the context of the request obtaining a certificate for
+// domain. The ACME CA does not pass a trace context when validating the
+// challenge. challengeCtx returns ctx if no certificate is being obtained for
+// domain.
+func challengeCtx(ctx context.Context, domain string) context.Context {
+	if lctx, ok := legoRequests.context(domain); ok {
+		return lctx
+	}
+	return ctx
+}
+
+func writeBody(logger log.Logger, w http.ResponseWriter, body []byte) {
 	if _, err := w.Write(body); err != nil {
-		log.Log(p.Logger, "level", "warn", "error", err)
+		log.Log(logger, "level", "warn", "error", err)
 	}
 }
 
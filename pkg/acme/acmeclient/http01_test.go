@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/fhofherr/acmeproxy/pkg/acme/acmeclient"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -32,6 +33,19 @@ func TestHTTP01Solver_GetKeyAuthForTokenAndDomain(t *testing.T) {
 	assert.Equal(t, keyAuth, actualKeyAuth)
 }
 
+func TestHTTP01Solver_PendingChallenges(t *testing.T) {
+	solver := &acmeclient.HTTP01Solver{}
+	assert.Equal(t, float64(0), testutil.ToFloat64(solver))
+
+	assert.NoError(t, solver.Present("www.example.com", "token", "keyAuth"))
+	assert.NoError(t, solver.Present("www.example.org", "token", "keyAuth"))
+	assert.Equal(t, 2, solver.Pending())
+	assert.Equal(t, float64(2), testutil.ToFloat64(solver))
+
+	assert.NoError(t, solver.CleanUp("www.example.com", "token", "keyAuth"))
+	assert.Equal(t, float64(1), testutil.ToFloat64(solver))
+}
+
 func TestHTTP01Solver_ReturnNotFoundOnMissingKeyAuth(t *testing.T) {
 	domain := "www.example.com"
 	token := "token"
@@ -1,11 +1,14 @@
 package acmeclient
 
 import (
+	"context"
 	"fmt"
+	"strings"
 	"sync"
 
 	"github.com/fhofherr/acmeproxy/pkg/certutil"
 	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/fhofherr/acmeproxy/pkg/requestid"
 	"github.com/fhofherr/golf/log"
 	"github.com/go-acme/lego/certcrypto"
 	legolog "github.com/go-acme/lego/log"
@@ -13,6 +16,10 @@ import (
 
 var legoOnce sync.Once
 
+// legoRequests maps the domains of the certificates lego currently obtains to
+// the contexts of the requests asking for them.
+var legoRequests = &requestRegistry{}
+
 // InitializeLego initializes the lego library.
 //
 // Lego uses a global variable to store a logger. In order to use a different
@@ -22,37 +29,119 @@ var legoOnce sync.Once
 func InitializeLego(logger log.Logger) {
 	legoOnce.Do(func() {
 		legolog.Logger = &loggerAdapter{
-			Logger: logger,
+			Logger:   logger,
+			Requests: legoRequests,
 		}
 	})
 }
 
+// loggerAdapter passes the log messages of lego on to Logger.
+//
+// Most of lego's messages start with the domains they concern in square
+// brackets. If Requests is not nil loggerAdapter uses it to add the ID of the
+// request the message belongs to.
 type loggerAdapter struct {
-	Logger log.Logger
+	Logger   log.Logger
+	Requests *requestRegistry
 }
 
 func (l *loggerAdapter) Fatal(args ...interface{}) {
-	log.Log(l.Logger, "level", "error", "message", fmt.Sprint(args...))
+	l.log("error", fmt.Sprint(args...))
 }
 
 func (l *loggerAdapter) Fatalln(args ...interface{}) {
-	log.Log(l.Logger, "level", "error", "message", fmt.Sprint(args...))
+	l.log("error", fmt.Sprint(args...))
 }
 
 func (l *loggerAdapter) Fatalf(format string, args ...interface{}) {
-	log.Log(l.Logger, "level", "error", "message", fmt.Sprintf(format, args...))
+	l.log("error", fmt.Sprintf(format, args...))
 }
 
 func (l *loggerAdapter) Print(args ...interface{}) {
-	log.Log(l.Logger, "level", "info", "message", fmt.Sprint(args...))
+	l.log("info", fmt.Sprint(args...))
 }
 
 func (l *loggerAdapter) Println(args ...interface{}) {
-	log.Log(l.Logger, "level", "info", "message", fmt.Sprint(args...))
+	l.log("info", fmt.Sprint(args...))
 }
 
 func (l *loggerAdapter) Printf(format string, args ...interface{}) {
-	log.Log(l.Logger, "level", "info", "message", fmt.Sprintf(format, args...))
+	l.log("info", fmt.Sprintf(format, args...))
+}
+
+func (l *loggerAdapter) log(level, msg string) {
+	kvs := []interface{}{"level", level, "message", msg}
+	if id := l.Requests.lookup(msg); id != "" {
+		kvs = append(kvs, requestid.LogKey, id)
+	}
+	log.Log(l.Logger, kvs...)
+}
+
+// requestRegistry maps domain names to the contexts of requests.
+//
+// Lego calls the challenge providers and the logger without a context. The
+// registry allows them to find the request ID and the span of the request
+// they act on behalf of.
+type requestRegistry struct {
+	mu   sync.RWMutex
+	ctxs map[string]context.Context
+}
+
+// register maps each of domains, as well as all domains together, to ctx.
+// It returns a function removing the mappings again.
+func (r *requestRegistry) register(ctx context.Context, domains []string) func() {
+	if len(domains) == 0 {
+		return func() {}
+	}
+	keys := append([]string{strings.Join(domains, ", ")}, domains...)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.ctxs == nil {
+		r.ctxs = make(map[string]context.Context)
+	}
+	for _, k := range keys {
+		r.ctxs[k] = ctx
+	}
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		for _, k := range keys {
+			if r.ctxs[k] == ctx {
+				delete(r.ctxs, k)
+			}
+		}
+	}
+}
+
+// context returns the context registered for domains. It returns
+// context.Background() and false if there is none.
+func (r *requestRegistry) context(domains string) (context.Context, bool) {
+	if r == nil {
+		return context.Background(), false
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if ctx, ok := r.ctxs[domains]; ok {
+		return ctx, true
+	}
+	return context.Background(), false
+}
+
+// lookup returns the request ID of the domains msg starts with. It returns
+// the empty string if msg does not start with domains in square brackets, or
+// if no request ID is registered for them.
+func (r *requestRegistry) lookup(msg string) string {
+	if !strings.HasPrefix(msg, "[") {
+		return ""
+	}
+	end := strings.Index(msg, "]")
+	if end < 0 {
+		return ""
+	}
+	ctx, _ := r.context(msg[1:end])
+	return requestid.FromContext(ctx)
 }
 
 func legoKeyType(kt certutil.KeyType) (certcrypto.KeyType, error) {
@@ -1,10 +1,15 @@
 package acmeclient
 
 import (
+	"context"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/fhofherr/acmeproxy/pkg/certutil"
+	"github.com/fhofherr/acmeproxy/pkg/requestid"
+	"github.com/fhofherr/acmeproxy/pkg/tracing"
 	"github.com/fhofherr/golf/log"
 	"github.com/go-acme/lego/certcrypto"
 	"github.com/stretchr/testify/assert"
@@ -86,6 +91,62 @@ func TestLoggerAdapter(t *testing.T) {
 	}
 }
 
+func TestLoggerAdapter_RequestID(t *testing.T) {
+	testLogger := &log.TestLogger{}
+	requests := &requestRegistry{}
+	adapter := &loggerAdapter{
+		Logger:   testLogger,
+		Requests: requests,
+	}
+	ctx := requestid.NewContext(context.Background(), "some-request")
+	unregister := requests.register(ctx, []string{"www.example.com", "example.com"})
+
+	adapter.Printf("[%s] acme: Obtaining bundled SAN certificate", "www.example.com, example.com")
+	adapter.Printf("[%s] acme: Trying to solve HTTP-01", "example.com")
+	adapter.Printf("[%s] acme: Trying to solve HTTP-01", "www.example.org")
+	unregister()
+	adapter.Printf("[%s] acme: Trying to solve HTTP-01", "example.com")
+
+	testLogger.AssertHasMatchingLogEntries(t, 2, func(e log.TestLogEntry) bool {
+		return e[requestid.LogKey] == "some-request"
+	})
+	testLogger.AssertHasMatchingLogEntries(t, 2, func(e log.TestLogEntry) bool {
+		_, ok := e[requestid.LogKey]
+		return !ok
+	})
+}
+
+func TestHTTP01Solver_Tracing(t *testing.T) {
+	rec := tracing.Record(t)
+	solver := &HTTP01Solver{}
+	ctx, span := tracing.Start(context.Background(), "test/obtain")
+	unregister := legoRequests.register(ctx, []string{"www.example.com"})
+
+	assert.NoError(t, solver.Present("www.example.com", "token", "keyAuth"))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	handler := solver.Handler(func(*http.Request) map[string]string {
+		return map[string]string{"domain": "www.example.com", "token": "token"}
+	})
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	assert.NoError(t, solver.CleanUp("www.example.com", "token", "keyAuth"))
+	unregister()
+	tracing.End(span, nil)
+
+	spans := rec.Ended()
+	if !assert.Len(t, spans, 4) {
+		return
+	}
+	assert.Equal(t, []string{
+		"acmeclient/http01Solver.Present",
+		"acmeclient/http01Solver.Handler",
+		"acmeclient/http01Solver.CleanUp",
+		"test/obtain",
+	}, tracing.SpanNames(rec))
+	for _, s := range spans[:3] {
+		assert.Equal(t, spans[3].SpanContext().SpanID(), s.Parent().SpanID(), s.Name())
+	}
+}
+
 func TestKeyTypeToLegoKeyType(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -0,0 +1,118 @@
+package acmeclient
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// OrderMetrics counts the certificate orders of a Client and observes their
+// latency. All metrics are labeled with the host name of the ACME CA. Failed
+// orders are additionally labeled with the errors.Kind of the failure.
+//
+// OrderMetrics implements prometheus.Collector. A nil *OrderMetrics
+// discards all observations.
+type OrderMetrics struct {
+	orders    *prometheus.CounterVec
+	succeeded *prometheus.CounterVec
+	failed    *prometheus.CounterVec
+	duration  *prometheus.HistogramVec
+}
+
+// NewOrderMetrics creates new OrderMetrics.
+func NewOrderMetrics() *OrderMetrics {
+	return &OrderMetrics{
+		orders: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "acmeproxy",
+			Subsystem: "acme",
+			Name:      "orders_total",
+			Help:      "Number of certificates ordered from the ACME CA.",
+		}, []string{"ca"}),
+		succeeded: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "acmeproxy",
+			Subsystem: "acme",
+			Name:      "orders_succeeded_total",
+			Help:      "Number of certificates obtained from the ACME CA.",
+		}, []string{"ca"}),
+		failed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "acmeproxy",
+			Subsystem: "acme",
+			Name:      "orders_failed_total",
+			Help:      "Number of certificate orders that failed, by kind of error.",
+		}, []string{"ca", "kind"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "acmeproxy",
+			Subsystem: "acme",
+			Name:      "order_duration_seconds",
+			Help:      "Time it took to obtain a certificate, including pre-flight and CAA checks.",
+			Buckets:   []float64{0.5, 1, 2.5, 5, 10, 20, 30, 60, 120, 300},
+		}, []string{"ca"}),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (m *OrderMetrics) Describe(ch chan<- *prometheus.Desc) {
+	m.orders.Describe(ch)
+	m.succeeded.Describe(ch)
+	m.failed.Describe(ch)
+	m.duration.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (m *OrderMetrics) Collect(ch chan<- prometheus.Metric) {
+	m.orders.Collect(ch)
+	m.succeeded.Collect(ch)
+	m.failed.Collect(ch)
+	m.duration.Collect(ch)
+}
+
+// observe records an order placed with the CA at directoryURL. The order
+// started at start and failed with err unless err is nil.
+func (m *OrderMetrics) observe(directoryURL string, start time.Time, err error) {
+	if m == nil {
+		return
+	}
+	ca := caLabel(directoryURL)
+	m.orders.WithLabelValues(ca).Inc()
+	m.duration.WithLabelValues(ca).Observe(time.Since(start).Seconds())
+	if err != nil {
+		m.failed.WithLabelValues(ca, kindLabel(err)).Inc()
+		return
+	}
+	m.succeeded.WithLabelValues(ca).Inc()
+}
+
+func caLabel(directoryURL string) string {
+	if u, err := url.Parse(directoryURL); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return directoryURL
+}
+
+func kindLabel(err error) string {
+	kind := errors.GetKind(err)
+	if kind == errors.Unspecified {
+		return "unspecified"
+	}
+	return strings.ReplaceAll(kind.String(), " ", "_")
+}
+
+var pendingChallengesDesc = prometheus.NewDesc(
+	"acmeproxy_http01_pending_challenges",
+	"Number of HTTP01 challenges currently presented to the ACME CA.",
+	nil, nil,
+)
+
+// Describe implements prometheus.Collector.
+func (p *HTTP01Solver) Describe(ch chan<- *prometheus.Desc) {
+	ch <- pendingChallengesDesc
+}
+
+// Collect implements prometheus.Collector. It reports the number of pending
+// challenges.
+func (p *HTTP01Solver) Collect(ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(pendingChallengesDesc, prometheus.GaugeValue, float64(p.Pending()))
+}
@@ -0,0 +1,31 @@
+package acmeclient
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderMetrics_Observe(t *testing.T) {
+	const directoryURL = "https://acme-staging-v02.api.letsencrypt.org/directory"
+	const ca = "acme-staging-v02.api.letsencrypt.org"
+	m := NewOrderMetrics()
+	start := time.Now()
+
+	m.observe(directoryURL, start, nil)
+	m.observe(directoryURL, start, errors.New(errors.InvalidArgument, "pre-flight check failed"))
+	m.observe(directoryURL, start, errors.New("order failed"))
+
+	assert.Equal(t, float64(3), testutil.ToFloat64(m.orders.WithLabelValues(ca)))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.succeeded.WithLabelValues(ca)))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.failed.WithLabelValues(ca, "invalid_argument")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.failed.WithLabelValues(ca, "unspecified")))
+	assert.Equal(t, 1, testutil.CollectAndCount(m.duration))
+
+	// A nil *OrderMetrics discards all observations.
+	var nilMetrics *OrderMetrics
+	nilMetrics.observe(directoryURL, start, nil)
+}
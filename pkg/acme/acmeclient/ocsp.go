@@ -0,0 +1,94 @@
+package acmeclient
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/fhofherr/acmeproxy/pkg/acme"
+	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/go-acme/lego/certcrypto"
+	"golang.org/x/crypto/ocsp"
+)
+
+// maxOCSPResponseSize limits the number of bytes read from an OCSP responder.
+// Real world OCSP responses are just a few kilobytes in size.
+const maxOCSPResponseSize = 1024 * 1024
+
+// FetchOCSPResponse obtains an OCSP response for the PEM encoded certificate
+// from the first OCSP responder listed in the certificate.
+//
+// The certificate has to be bundled with the certificate of its issuer. The
+// issuer certificate is used to verify the signature of the OCSP response.
+func (c *Client) FetchOCSPResponse(bundle []byte) (*acme.OCSPResponse, error) {
+	const op errors.Op = "acmeclient/client.FetchOCSPResponse"
+
+	certs, err := certcrypto.ParsePEMBundle(bundle)
+	if err != nil {
+		return nil, errors.New(op, errors.InvalidArgument, "parse certificate bundle", err)
+	}
+	if len(certs) < 2 {
+		return nil, errors.New(op, errors.InvalidArgument, "certificate not bundled with issuer")
+	}
+	cert, issuer := certs[0], certs[1]
+	if len(cert.OCSPServer) == 0 {
+		return nil, errors.New(op, errors.InvalidArgument, "no ocsp server in certificate")
+	}
+	ocspReq, err := ocsp.CreateRequest(cert, issuer, nil)
+	if err != nil {
+		return nil, errors.New(op, "create ocsp request", err)
+	}
+	raw, err := c.postOCSPRequest(cert.OCSPServer[0], ocspReq)
+	if err != nil {
+		return nil, errors.New(op, err)
+	}
+	ocspRes, err := ocsp.ParseResponseForCert(raw, cert, issuer)
+	if err != nil {
+		return nil, errors.New(op, "parse ocsp response", err)
+	}
+	status, err := ocspStatus(ocspRes.Status)
+	if err != nil {
+		return nil, errors.New(op, err)
+	}
+	return &acme.OCSPResponse{
+		Raw:        raw,
+		Status:     status,
+		ThisUpdate: ocspRes.ThisUpdate,
+		NextUpdate: ocspRes.NextUpdate,
+	}, nil
+}
+
+func (c *Client) postOCSPRequest(url string, ocspReq []byte) ([]byte, error) {
+	const op errors.Op = "acmeclient/client.postOCSPRequest"
+
+	res, err := c.httpClient().Post(url, "application/ocsp-request", bytes.NewReader(ocspReq))
+	if err != nil {
+		return nil, errors.New(op, fmt.Sprintf("post ocsp request: %s", url), err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.New(op, fmt.Sprintf("unexpected status from ocsp server %s: %d", url, res.StatusCode))
+	}
+	raw, err := ioutil.ReadAll(io.LimitReader(res.Body, maxOCSPResponseSize))
+	if err != nil {
+		return nil, errors.New(op, "read ocsp response", err)
+	}
+	return raw, nil
+}
+
+func ocspStatus(status int) (acme.OCSPStatus, error) {
+	const op errors.Op = "acmeclient/ocspStatus"
+
+	switch status {
+	case ocsp.Good:
+		return acme.OCSPGood, nil
+	case ocsp.Revoked:
+		return acme.OCSPRevoked, nil
+	case ocsp.Unknown:
+		return acme.OCSPUnknown, nil
+	default:
+		return acme.OCSPUnknown, errors.New(op, fmt.Sprintf("unsupported ocsp status: %d", status))
+	}
+}
@@ -0,0 +1,191 @@
+package acmeclient_test
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fhofherr/acmeproxy/pkg/acme"
+	"github.com/fhofherr/acmeproxy/pkg/acme/acmeclient"
+	"github.com/fhofherr/acmeproxy/pkg/certutil"
+	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ocsp"
+)
+
+func TestFetchOCSPResponse(t *testing.T) {
+	tests := []struct {
+		name       string
+		ocspStatus int
+		httpStatus int
+		noIssuer   bool
+		status     acme.OCSPStatus
+		err        error
+	}{
+		{
+			name:       "good certificate",
+			ocspStatus: ocsp.Good,
+			status:     acme.OCSPGood,
+		},
+		{
+			name:       "revoked certificate",
+			ocspStatus: ocsp.Revoked,
+			status:     acme.OCSPRevoked,
+		},
+		{
+			name:       "unknown certificate",
+			ocspStatus: ocsp.Unknown,
+			status:     acme.OCSPUnknown,
+		},
+		{
+			name:     "certificate without issuer",
+			noIssuer: true,
+			err:      errors.New(errors.InvalidArgument),
+		},
+		{
+			name:       "ocsp responder fails",
+			httpStatus: http.StatusInternalServerError,
+			err:        errors.New(errors.Op("acmeclient/client.postOCSPRequest")),
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			fx := newOCSPFixture(t, tt.ocspStatus, tt.httpStatus)
+			defer fx.Close()
+
+			bundle := fx.Bundle(!tt.noIssuer)
+			client := &acmeclient.Client{}
+			res, err := client.FetchOCSPResponse(bundle)
+			if tt.err != nil {
+				errors.AssertMatches(t, tt.err, err)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, tt.status, res.Status)
+			assert.NotEmpty(t, res.Raw)
+			assert.True(t, res.NextUpdate.After(res.ThisUpdate))
+		})
+	}
+}
+
+type ocspFixture struct {
+	t          *testing.T
+	server     *httptest.Server
+	issuerKey  crypto.Signer
+	issuer     *x509.Certificate
+	cert       *x509.Certificate
+	ocspStatus int
+	httpStatus int
+}
+
+func newOCSPFixture(t *testing.T, ocspStatus, httpStatus int) *ocspFixture {
+	fx := &ocspFixture{
+		t:          t,
+		ocspStatus: ocspStatus,
+		httpStatus: httpStatus,
+	}
+	fx.server = httptest.NewServer(http.HandlerFunc(fx.respond))
+	fx.issuerKey = certutil.KeyMust(certutil.NewPrivateKey(certutil.EC256)).(crypto.Signer)
+	fx.issuer = fx.createCertificate(&x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}, nil, fx.issuerKey.Public())
+	certKey := certutil.KeyMust(certutil.NewPrivateKey(certutil.EC256)).(crypto.Signer)
+	fx.cert = fx.createCertificate(&x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "www.example.com"},
+		DNSNames:     []string{"www.example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		OCSPServer:   []string{fx.server.URL},
+	}, fx.issuer, certKey.Public())
+	return fx
+}
+
+func (fx *ocspFixture) createCertificate(
+	template, parent *x509.Certificate, pub crypto.PublicKey,
+) *x509.Certificate {
+	if parent == nil {
+		parent = template
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, pub, fx.issuerKey)
+	if err != nil {
+		fx.t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		fx.t.Fatal(err)
+	}
+	return cert
+}
+
+func (fx *ocspFixture) respond(w http.ResponseWriter, req *http.Request) {
+	if fx.httpStatus != 0 {
+		w.WriteHeader(fx.httpStatus)
+		return
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		fx.t.Error(err)
+		return
+	}
+	ocspReq, err := ocsp.ParseRequest(body)
+	if err != nil {
+		fx.t.Error(err)
+		return
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	template := ocsp.Response{
+		Status:       fx.ocspStatus,
+		SerialNumber: ocspReq.SerialNumber,
+		ThisUpdate:   now,
+		NextUpdate:   now.Add(72 * time.Hour),
+	}
+	if fx.ocspStatus == ocsp.Revoked {
+		template.RevokedAt = now
+	}
+	res, err := ocsp.CreateResponse(fx.issuer, fx.issuer, template, fx.issuerKey)
+	if err != nil {
+		fx.t.Error(err)
+		return
+	}
+	if _, err := w.Write(res); err != nil {
+		fx.t.Error(err)
+	}
+}
+
+func (fx *ocspFixture) Bundle(withIssuer bool) []byte {
+	var buf bytes.Buffer
+	if err := certutil.WriteCertificate(fx.cert, &buf, true); err != nil {
+		fx.t.Fatal(err)
+	}
+	if withIssuer {
+		if err := certutil.WriteCertificate(fx.issuer, &buf, true); err != nil {
+			fx.t.Fatal(err)
+		}
+	}
+	return buf.Bytes()
+}
+
+func (fx *ocspFixture) Close() {
+	fx.server.Close()
+}
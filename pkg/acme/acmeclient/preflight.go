@@ -0,0 +1,149 @@
+package acmeclient
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/fhofherr/acmeproxy/pkg/tracing"
+)
+
+const (
+	// preflightTimeout limits the time a single pre-flight check may take.
+	preflightTimeout = 10 * time.Second
+
+	// defaultHTTP01Port is the port ACME CAs use to validate HTTP01
+	// challenges.
+	defaultHTTP01Port = 80
+
+	// maxPreflightResponseSize limits the number of bytes read while
+	// fetching the self-test token. The token is just a few bytes long.
+	maxPreflightResponseSize = 1024
+)
+
+// Resolver wraps the LookupHost method.
+//
+// LookupHost looks up the given host and returns a slice of its addresses.
+// *net.Resolver satisfies Resolver.
+type Resolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// NewResolver creates a Resolver which sends all its DNS queries to the DNS
+// server listening on addr. If addr is empty NewResolver returns
+// net.DefaultResolver.
+func NewResolver(addr string) *net.Resolver {
+	if addr == "" {
+		return net.DefaultResolver
+	}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, addr)
+		},
+	}
+}
+
+// preflight checks if the ACME CA will be able to validate a HTTP01
+// challenge for domain.
+//
+// It presents a random self-test token using the HTTP01Solver of the client
+// and fetches it from every address domain resolves to. If any address does
+// not serve the expected token, preflight returns an error of kind
+// InvalidArgument. Placing an ACME order for domain would fail in this case
+// and count towards the CA's failed validation limit.
+func (c *Client) preflight(ctx context.Context, domain string) (err error) {
+	const op errors.Op = "acmeclient/client.preflight"
+
+	ctx, span := tracing.Start(ctx, string(op), tracing.DomainKey.String(domain))
+	defer func() { tracing.End(span, err) }()
+
+	token, err := randomToken()
+	if err != nil {
+		return errors.New(op, "create self-test token", err)
+	}
+	keyAuth, err := randomToken()
+	if err != nil {
+		return errors.New(op, "create self-test key authorization", err)
+	}
+	if err := c.HTTP01Solver.present(ctx, domain, token, keyAuth); err != nil {
+		return errors.New(op, "present self-test token", err)
+	}
+	defer c.HTTP01Solver.cleanUp(ctx, domain, token) // nolint: errcheck
+
+	ctx, cancel := context.WithTimeout(ctx, preflightTimeout)
+	defer cancel()
+
+	resolver := c.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	addrs, err := resolver.LookupHost(ctx, domain)
+	if err != nil {
+		return errors.New(op, errors.InvalidArgument, fmt.Sprintf("resolve domain: %s", domain), err)
+	}
+	if len(addrs) == 0 {
+		return errors.New(op, errors.InvalidArgument, fmt.Sprintf("domain does not resolve: %s", domain))
+	}
+	for _, addr := range addrs {
+		body, err := c.fetchSelfTestToken(ctx, domain, addr, token)
+		if err != nil {
+			return errors.New(op, errors.InvalidArgument, err)
+		}
+		if !bytes.Equal(body, []byte(keyAuth)) {
+			msg := fmt.Sprintf("%s at %s does not serve acmeproxy's self-test token; does it point to acmeproxy?", domain, addr)
+			return errors.New(op, errors.InvalidArgument, msg)
+		}
+	}
+	return nil
+}
+
+func (c *Client) fetchSelfTestToken(ctx context.Context, domain, addr, token string) ([]byte, error) {
+	const op errors.Op = "acmeclient/client.fetchSelfTestToken"
+
+	port := c.HTTP01Port
+	if port == 0 {
+		port = defaultHTTP01Port
+	}
+	hostPort := net.JoinHostPort(addr, strconv.Itoa(port))
+	url := fmt.Sprintf("http://%s/.well-known/acme-challenge/%s", hostPort, token)
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, errors.New(op, "create request", err)
+	}
+	req = req.WithContext(ctx)
+	req.Host = domain
+
+	res, err := c.httpClient().Do(req)
+	if err != nil {
+		return nil, errors.New(op, fmt.Sprintf("fetch self-test token for %s from %s", domain, hostPort), err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		msg := fmt.Sprintf("fetch self-test token for %s from %s: unexpected status: %d", domain, hostPort, res.StatusCode)
+		return nil, errors.New(op, msg)
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(res.Body, maxPreflightResponseSize))
+	if err != nil {
+		return nil, errors.New(op, "read self-test token", err)
+	}
+	return body, nil
+}
+
+func randomToken() (string, error) {
+	bs := make([]byte, 32)
+	if _, err := rand.Read(bs); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(bs), nil
+}
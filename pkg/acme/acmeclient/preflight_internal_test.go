@@ -0,0 +1,104 @@
+package acmeclient
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/fhofherr/acmeproxy/pkg/errors"
+)
+
+type staticResolver struct {
+	addrs []string
+	err   error
+}
+
+func (r staticResolver) LookupHost(_ context.Context, _ string) ([]string, error) {
+	return r.addrs, r.err
+}
+
+func TestPreflight(t *testing.T) {
+	domain := "www.example.com"
+	tests := []struct {
+		name     string
+		resolver Resolver
+		handler  func(*Client) http.Handler
+		err      error
+	}{
+		{
+			name:     "domain serves self-test token",
+			resolver: staticResolver{addrs: []string{"127.0.0.1"}},
+			handler:  solverHandler,
+		},
+		{
+			name:     "domain cannot be resolved",
+			resolver: staticResolver{err: errors.New("no such host")},
+			handler:  solverHandler,
+			err:      errors.New(errors.InvalidArgument),
+		},
+		{
+			name:     "domain resolves to no address",
+			resolver: staticResolver{},
+			handler:  solverHandler,
+			err:      errors.New(errors.InvalidArgument),
+		},
+		{
+			name:     "domain does not serve self-test token",
+			resolver: staticResolver{addrs: []string{"127.0.0.1"}},
+			handler: func(*Client) http.Handler {
+				return http.NotFoundHandler()
+			},
+			err: errors.New(errors.InvalidArgument),
+		},
+		{
+			name:     "domain serves wrong self-test token",
+			resolver: staticResolver{addrs: []string{"127.0.0.1"}},
+			handler: func(*Client) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+					w.Write([]byte("some other server")) // nolint: errcheck
+				})
+			},
+			err: errors.New(errors.InvalidArgument),
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			client := &Client{
+				Resolver: tt.resolver,
+			}
+			server := httptest.NewServer(tt.handler(client))
+			defer server.Close()
+			_, port, err := net.SplitHostPort(server.Listener.Addr().String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			client.HTTP01Port, err = strconv.Atoi(port)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = client.preflight(context.Background(), domain)
+			if tt.err == nil && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.err != nil {
+				errors.AssertMatches(t, tt.err, err)
+			}
+		})
+	}
+}
+
+func solverHandler(c *Client) http.Handler {
+	return c.HTTP01Solver.Handler(func(req *http.Request) map[string]string {
+		pathParts := strings.Split(req.URL.Path, "/")
+		return map[string]string{
+			"domain": req.Host,
+			"token":  pathParts[len(pathParts)-1],
+		}
+	})
+}
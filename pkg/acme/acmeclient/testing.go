@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/fhofherr/acmeproxy/pkg/internal/testsupport"
+	"github.com/go-acme/lego/lego"
 )
 
 const (
@@ -32,6 +33,10 @@ func NewTestFixture(t *testing.T) (TestFixture, func()) {
 	resetCACerts := testsupport.SetLegoCACertificates(t, pebble.TestCert)
 	client := &Client{
 		DirectoryURL: pebble.DirectoryURL(),
+		HTTP01Port:   pebble.HTTPPort(),
+		Resolver:     NewResolver("127.0.0.1:" + DNSPort),
+		CAAResolver:  &DNSCAAResolver{Addr: "127.0.0.1:" + DNSPort},
+		HTTPClient:   lego.NewConfig(nil).HTTPClient,
 	}
 	server := NewChallengeServer(t, &client.HTTP01Solver, pebble.HTTPPort())
 	fixture := TestFixture{
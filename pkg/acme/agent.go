@@ -1,12 +1,18 @@
 package acme
 
 import (
+	"bytes"
+	"context"
 	"crypto"
 	"fmt"
 	"io"
+	"sync"
+	"time"
 
 	"github.com/fhofherr/acmeproxy/pkg/certutil"
 	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/fhofherr/acmeproxy/pkg/requestid"
+	"github.com/fhofherr/acmeproxy/pkg/tracing"
 	"github.com/go-acme/lego/lego"
 	"github.com/google/uuid"
 )
@@ -42,14 +48,36 @@ type CertificateInfo struct {
 
 // CertificateObtainer wraps the ObtainCertificate method which obtains
 // a certificate for a specific domain from an ACME certificate authority.
+// ctx carries the ID of the request the certificate is obtained for.
 type CertificateObtainer interface {
-	ObtainCertificate(CertificateRequest) (*CertificateInfo, error)
+	ObtainCertificate(context.Context, CertificateRequest) (*CertificateInfo, error)
 }
 
 // AccountCreator wraps the CreateAccount method which creates an new
-// account at the ACME certificate authority.
+// account at the ACME certificate authority. ctx carries the ID of the
+// request the account is created for.
 type AccountCreator interface {
-	CreateAccount(key crypto.PrivateKey, email string) (string, error)
+	CreateAccount(ctx context.Context, key crypto.PrivateKey, email string) (string, error)
+}
+
+// DomainPolicy wraps the CheckDomains and CheckTransfer methods.
+//
+// CheckDomains decides if the user identified by userID may obtain a single
+// certificate for all of domainNames. It returns the normalized form of
+// domainNames if this is the case. Implementations return an error of kind
+// InvalidArgument if a domain name is malformed, and an error of kind
+// Unauthorized if the user must not obtain a certificate for it. ctx may carry
+// further information about the user, e.g. the claims of its token. If it
+// does not, implementations decide based on userID alone.
+//
+// CheckTransfer decides if domains which have already been registered may be
+// transferred to the user identified by userID. The user does not request
+// the transfer. Implementations thus cannot take the claims of the user into
+// account. CheckTransfer returns the normalized form of domainNames and the
+// same errors as CheckDomains.
+type DomainPolicy interface {
+	CheckDomains(ctx context.Context, userID uuid.UUID, domainNames []string) ([]string, error)
+	CheckTransfer(userID uuid.UUID, domainNames []string) ([]string, error)
 }
 
 // Agent takes care of obtaining and renewing ACME certificates for the domains
@@ -63,11 +91,62 @@ type AccountCreator interface {
 // certificates  for the wrong domain from within acmeproxy. The Agent does not
 // implement any further mechanisms to ensure the user is actually allowed to
 // retrieve certificates belonging to a domain.
+//
+// Additionally the Agent keeps an OCSP response for the certificate of each
+// domain, which users may staple to their TLS handshakes.
+//
+// Every domain registration and transfer passes through Policy. Certificates
+// the Agent re-issues for registered domains do not. If Policy is nil the
+// Agent accepts any domain name.
+//
+// If Issuances is not nil the Agent records every certificate it obtains and
+// enforces Quotas before obtaining a new one. A request exceeding a quota is
+// refused with an error of kind RateLimited. Certificates which are still
+// being obtained count against the Quotas as well. Certificates the Agent
+// re-issues are recorded, but are not subject to the Quotas. PruneIssuances
+// removes the issuances the Quotas no longer need.
+//
+// If Audit is not nil the Agent records every domain registration, every
+// certificate it obtains, including those it re-issues, and every private key
+// it hands out. An operation which cannot be recorded fails.
+//
+// Users may ask the Agent which CAA records they should publish for their
+// domains. Those records restrict certificate issuance to the user's ACME
+// account.
+//
+// Users may watch the certificates of their domains to learn about renewals
+// without polling the Agent.
+//
+// The Agent passes the context of each request on to its CertificateObtainer
+// and AccountCreator. This allows them to attach the request ID carried by
+// the context to their log entries. Certificates the Agent re-issues on its
+// own get a new request ID.
+//
+// The Agent records a span for each of its operations that obtains or
+// renews certificates, and for the repository transactions those operations
+// perform. The spans are children of the span carried by the context of the
+// request.
+//
+// The Agent implements prometheus.Collector reporting the days until the
+// certificate of each domain expires.
 type Agent struct {
 	Domains      DomainRepository
 	Users        UserRepository
 	Certificates CertificateObtainer
 	ACMEAccounts AccountCreator
+	OCSP         OCSPFetcher
+	CAA          CAARecommender
+	Policy       DomainPolicy
+	Issuances    IssuanceRepository
+	Quotas       Quotas
+	Audit        AuditTrail
+	watchers     certificateWatchers
+	reservations quotaReservations
+
+	// owners serializes changes of the owner of a domain with the removal
+	// of users. Otherwise a domain could be registered to, or transferred
+	// to, a user while the user is removed.
+	owners sync.Mutex
 }
 
 // RegisterUser registers a new user of acme.Agent.
@@ -79,10 +158,13 @@ type Agent struct {
 //
 // RegisterUser does nothing if the user has already been registered with
 // the Agent.
-func (a *Agent) RegisterUser(userID uuid.UUID, email string) error {
+func (a *Agent) RegisterUser(ctx context.Context, userID uuid.UUID, email string) (err error) {
 	const op errors.Op = "acme/agent.RegisterUser"
 
-	_, err := a.Users.UpdateUser(userID, func(c *User) error {
+	ctx, span := tracing.Start(ctx, string(op), tracing.UserIDKey.String(userID.String()))
+	defer func() { tracing.End(span, err) }()
+
+	_, err = a.updateUser(ctx, userID, func(ctx context.Context, c *User) error {
 		if !c.IsZero() {
 			return nil
 		}
@@ -91,13 +173,14 @@ func (a *Agent) RegisterUser(userID uuid.UUID, email string) error {
 			return errors.New(op, fmt.Sprintf("new private key for user: %v", userID), err)
 		}
 
-		url, err := a.ACMEAccounts.CreateAccount(key, email)
+		url, err := a.ACMEAccounts.CreateAccount(ctx, key, email)
 		if err != nil {
 			return errors.New(op, fmt.Sprintf("register account for user: %v", userID), err)
 		}
 		c.ID = userID
 		c.Key = key
 		c.AccountURL = url
+		c.Email = email
 
 		return nil
 	})
@@ -113,39 +196,257 @@ func (a *Agent) RegisterUser(userID uuid.UUID, email string) error {
 // RegisterDomain will silently ignore the domain if it was already registered
 // with the same userID. It will return an error if the domain is already
 // registered with a different userID.
-func (a *Agent) RegisterDomain(userID uuid.UUID, domainName string) error {
+//
+// The domain name is checked against the Agent's Policy and registered in its
+// normalized form. ctx is passed on to the Policy.
+func (a *Agent) RegisterDomain(ctx context.Context, userID uuid.UUID, domainName string) (err error) {
 	const op errors.Op = "acme/agent.RegisterDomain"
 
+	ctx, span := tracing.Start(ctx, string(op),
+		tracing.UserIDKey.String(userID.String()), tracing.DomainKey.String(domainName))
+	defer func() { tracing.End(span, err) }()
+	defer func() { err = a.audit(userID, AuditRegisterDomain, domainName, err) }()
+
+	names, err := a.checkPolicy(ctx, userID, []string{domainName})
+	if err != nil {
+		return errors.New(op, err)
+	}
+	domainName = names[0]
+
 	user, err := a.Users.GetUser(userID)
 	if err != nil {
 		return errors.New(op, fmt.Sprintf("get user: %v", userID), err)
 	}
-	_, err = a.Domains.UpdateDomain(domainName, func(d *Domain) error {
+	domain, err := a.Domains.GetDomain(domainName)
+	if err != nil && !errors.IsKind(err, errors.NotFound) {
+		return errors.New(op, fmt.Sprintf("get domain: %s", domainName), err)
+	}
+	if !domain.IsZero() {
+		if userID != domain.UserID {
+			return errors.New(op, fmt.Sprintf("already registered: %s", domainName))
+		}
+		return nil
+	}
+
+	// Obtain the certificate before updating the domain. Repositories may
+	// block all other writers until UpdateDomain returns.
+	ci, err := a.obtainCertificate(ctx, user, domainName, false)
+	if err != nil {
+		return errors.New(op, err)
+	}
+	a.owners.Lock()
+	defer a.owners.Unlock()
+	// The user may have been removed while the certificate was obtained.
+	if _, err := a.GetUser(userID); err != nil {
+		return errors.New(op, err)
+	}
+	obtained := false
+	domain, err = a.updateDomain(ctx, domainName, func(ctx context.Context, d *Domain) error {
 		if !d.IsZero() {
+			// The domain was registered while the certificate was obtained.
 			if userID != d.UserID {
 				return errors.New(op, fmt.Sprintf("already registered: %s", domainName))
 			}
 			return nil
 		}
-
-		req := CertificateRequest{
-			AccountURL: user.AccountURL,
-			AccountKey: user.Key,
-			KeyType:    DefaultKeyType,
-			Domains:    []string{domainName},
-			Bundle:     true,
-		}
-		ci, err := a.Certificates.ObtainCertificate(req)
-		if err != nil {
-			return errors.New(op, fmt.Sprintf("obtain certificate for domain: %s", domainName), err)
-		}
 		d.Name = domainName
 		d.UserID = userID
 		d.Certificate = ci.Certificate
 		d.PrivateKey = ci.PrivateKey
+		obtained = true
 		return nil
 	})
-	return errors.Wrap(err, op, fmt.Sprintf("update domain: %s", domainName))
+	if err != nil {
+		return errors.New(op, fmt.Sprintf("update domain: %s", domainName), err)
+	}
+	if obtained {
+		a.watchers.notify(domain)
+	}
+	return nil
+}
+
+// obtainCertificate obtains a certificate for domainName on behalf of user.
+// Renewals of already issued certificates are recorded, but are not subject
+// to the Quotas of the Agent.
+func (a *Agent) obtainCertificate(
+	ctx context.Context, user User, domainName string, renewal bool,
+) (_ *CertificateInfo, err error) {
+	const op errors.Op = "acme/agent.obtainCertificate"
+
+	ctx, span := tracing.Start(ctx, string(op),
+		tracing.UserIDKey.String(user.ID.String()), tracing.DomainKey.String(domainName))
+	defer func() { tracing.End(span, err) }()
+	defer func() { err = a.audit(user.ID, AuditObtainCertificate, domainName, err) }()
+
+	// The Policy is checked once when the domain is registered. The claims
+	// which admitted the domain are not available when its certificate is
+	// re-issued.
+	domains := []string{domainName}
+	iss, err := a.reserveQuota(user.ID, domains, !renewal)
+	if err != nil {
+		return nil, errors.New(op, err)
+	}
+	req := CertificateRequest{
+		AccountURL: user.AccountURL,
+		AccountKey: user.Key,
+		KeyType:    DefaultKeyType,
+		Domains:    domains,
+		Bundle:     true,
+	}
+	ci, err := a.Certificates.ObtainCertificate(ctx, req)
+	if err != nil {
+		a.releaseQuota(iss)
+		return nil, errors.New(op, fmt.Sprintf("obtain certificate for domain: %s", domainName), err)
+	}
+	if err := a.recordIssuance(iss); err != nil {
+		return nil, errors.New(op, err)
+	}
+	return ci, nil
+}
+
+// reserveQuota reserves an issuance of a certificate for domains on behalf
+// of the user identified by userID. If enforce is true it checks the Quotas
+// first. Checking the Quotas and reserving the issuance is atomic. The
+// reservation counts against the Quotas until it is either recorded using
+// recordIssuance or released using releaseQuota.
+//
+// reserveQuota returns nil if the Agent does not record issuances.
+func (a *Agent) reserveQuota(userID uuid.UUID, domains []string, enforce bool) (*Issuance, error) {
+	const op errors.Op = "acme/agent.reserveQuota"
+
+	if a.Issuances == nil {
+		return nil, nil
+	}
+	a.reservations.mu.Lock()
+	defer a.reservations.mu.Unlock()
+
+	now := time.Now()
+	if enforce && a.Quotas.Window > 0 {
+		issuances, err := a.Issuances.ListIssuances(now.Add(-a.Quotas.Window))
+		if err != nil {
+			return nil, errors.New(op, "list issuances", err)
+		}
+		issuances = append(issuances, a.reservations.list()...)
+		resetAt, reason := a.Quotas.exceeded(issuances, userID, domains)
+		if !resetAt.IsZero() {
+			return nil, errors.New(op, errors.RateLimited, resetAt, fmt.Sprintf("quota exceeded: %s", reason))
+		}
+	}
+	iss := &Issuance{
+		UserID:   userID,
+		Domains:  domains,
+		IssuedAt: now,
+	}
+	a.reservations.add(iss)
+	return iss, nil
+}
+
+// recordIssuance records the reserved issuance iss once the certificate has
+// been obtained.
+func (a *Agent) recordIssuance(iss *Issuance) error {
+	const op errors.Op = "acme/agent.recordIssuance"
+
+	if iss == nil {
+		return nil
+	}
+	a.reservations.mu.Lock()
+	defer a.reservations.mu.Unlock()
+
+	a.reservations.remove(iss)
+	iss.IssuedAt = time.Now()
+	return errors.Wrap(a.Issuances.RecordIssuance(*iss), op, "record issuance")
+}
+
+// releaseQuota releases the reserved issuance iss without recording it,
+// e.g. because the certificate could not be obtained.
+func (a *Agent) releaseQuota(iss *Issuance) {
+	if iss == nil {
+		return
+	}
+	a.reservations.mu.Lock()
+	defer a.reservations.mu.Unlock()
+
+	a.reservations.remove(iss)
+}
+
+// PruneIssuances removes the issuances which left the window of the Agent's
+// Quotas at now. If the Quotas do not define a window, all issuances recorded
+// before now are removed.
+func (a *Agent) PruneIssuances(now time.Time) error {
+	const op errors.Op = "acme/agent.PruneIssuances"
+
+	if a.Issuances == nil {
+		return nil
+	}
+	before := now
+	if a.Quotas.Window > 0 {
+		before = now.Add(-a.Quotas.Window)
+	}
+	return errors.Wrap(a.Issuances.PruneIssuances(before), op, "prune issuances")
+}
+
+// RecommendCAARecords returns the CAA records the user identified by userID
+// should publish for domainName.
+//
+// The records allow the ACME certificate authority to issue certificates for
+// domainName only to the ACME account of the user. The domain does not need
+// to be registered with the Agent yet. RecommendCAARecords returns an error of
+// kind NotFound if the user does not exist.
+func (a *Agent) RecommendCAARecords(ctx context.Context, userID uuid.UUID, domainName string) ([]CAARecord, error) {
+	const op errors.Op = "acme/agent.RecommendCAARecords"
+
+	if a.CAA == nil {
+		return nil, errors.New(op, "no caa recommender configured")
+	}
+	user, err := a.Users.GetUser(userID)
+	if err != nil {
+		return nil, errors.New(op, fmt.Sprintf("get user: %v", userID), err)
+	}
+	if user.IsZero() {
+		return nil, errors.New(op, errors.NotFound, fmt.Sprintf("user: %v", userID))
+	}
+	records, err := a.CAA.RecommendCAARecords(ctx, domainName, user.AccountURL)
+	if err != nil {
+		return nil, errors.New(op, fmt.Sprintf("recommend caa records for domain: %s", domainName), err)
+	}
+	return records, nil
+}
+
+func (a *Agent) checkPolicy(ctx context.Context, userID uuid.UUID, domainNames []string) ([]string, error) {
+	const op errors.Op = "acme/agent.checkPolicy"
+
+	if a.Policy == nil {
+		return domainNames, nil
+	}
+	names, err := a.Policy.CheckDomains(ctx, userID, domainNames)
+	if err != nil {
+		return nil, errors.New(op, fmt.Sprintf("check domain policy for user: %v", userID), err)
+	}
+	return names, nil
+}
+
+// ListDomains returns all domains managed by the Agent ordered by their name.
+func (a *Agent) ListDomains() ([]Domain, error) {
+	const op errors.Op = "acme/agent.ListDomains"
+
+	domains, err := a.Domains.ListDomains()
+	return domains, errors.Wrap(err, op)
+}
+
+// DomainOwner returns the ID of the user the domain with the passed name is
+// registered to. It returns an error of kind NotFound if the domain is not
+// registered.
+func (a *Agent) DomainOwner(domainName string) (uuid.UUID, error) {
+	const op errors.Op = "acme/agent.DomainOwner"
+
+	domain, err := a.Domains.GetDomain(domainName)
+	if err != nil {
+		return uuid.UUID{}, errors.New(op, fmt.Sprintf("get domain: %s", domainName), err)
+	}
+	if domain.Name == "" {
+		return uuid.UUID{}, errors.New(op, errors.NotFound, fmt.Sprintf("domain not registered: %s", domainName))
+	}
+	return domain.UserID, nil
 }
 
 // WriteCertificate writes the PEM encoded certificate for the domain to w.
@@ -169,13 +470,187 @@ func (a *Agent) WriteCertificate(userID uuid.UUID, domainName string, w io.Write
 // WritePrivateKey returns an error if the domain was not registered, or was
 // registered to a different userID. If the Agent has not yet obtained a
 // certificate WritePrivateKey returns an instance of RetryLater as error.
+// The key is only written once its retrieval has been recorded.
 func (a *Agent) WritePrivateKey(userID uuid.UUID, domainName string, w io.Writer) error {
 	const op errors.Op = "acme/agent.WritePrivateKey"
 
 	domain, err := a.Domains.GetDomain(domainName)
 	if err != nil {
-		return errors.New(op, fmt.Sprintf("get domain: %s", domainName), err)
+		err = errors.New(op, fmt.Sprintf("get domain: %s", domainName), err)
+	}
+	if err := a.audit(userID, AuditWritePrivateKey, domainName, err); err != nil {
+		return err
 	}
 	_, err = w.Write(domain.PrivateKey)
 	return errors.Wrap(err, op, fmt.Sprintf("write private key for domain: %s", domainName))
 }
+
+// WriteOCSPResponse writes the DER encoded OCSP response for the certificate of
+// the domain to w.
+//
+// WriteOCSPResponse returns an error if the domain was not registered, or was
+// registered to a different userID. If the Agent has not yet obtained an OCSP
+// response for the domain's certificate it returns an error of kind NotFound.
+func (a *Agent) WriteOCSPResponse(userID uuid.UUID, domainName string, w io.Writer) error {
+	const op errors.Op = "acme/agent.WriteOCSPResponse"
+
+	domain, err := a.Domains.GetDomain(domainName)
+	if err != nil {
+		return errors.New(op, fmt.Sprintf("get domain: %s", domainName), err)
+	}
+	if domain.IsZero() {
+		return errors.New(op, errors.NotFound, fmt.Sprintf("domain: %s", domainName))
+	}
+	if domain.UserID != userID {
+		return errors.New(op, errors.Unauthorized, fmt.Sprintf("domain %s not registered to user: %v", domainName, userID))
+	}
+	if domain.OCSPResponse.IsZero() {
+		return errors.New(op, errors.NotFound, fmt.Sprintf("no ocsp response for domain: %s", domainName))
+	}
+	_, err = w.Write(domain.OCSPResponse.Raw)
+	return errors.Wrap(err, op, fmt.Sprintf("write ocsp response for domain: %s", domainName))
+}
+
+// UpdateOCSPResponses obtains fresh OCSP responses for the certificates of all
+// domains managed by the Agent.
+//
+// UpdateOCSPResponses only contacts the OCSP responder if the Agent has no
+// response for a certificate yet, or if the existing response is due for a
+// refresh. Callers should call UpdateOCSPResponses regularly to make sure the
+// Agent always has an OCSP response which did not yet reach its NextUpdate.
+//
+// If the OCSP responder reports a certificate as revoked, the Agent flags the
+// domain as revoked and re-issues the certificate. Should the re-issuance
+// fail, the next call to UpdateOCSPResponses attempts it again.
+//
+// Failing to update one domain does not stop UpdateOCSPResponses from updating
+// the remaining domains. All errors that occurred are returned together.
+func (a *Agent) UpdateOCSPResponses() (err error) {
+	const op errors.Op = "acme/agent.UpdateOCSPResponses"
+
+	ctx, span := tracing.Start(context.Background(), string(op))
+	defer func() { tracing.End(span, err) }()
+
+	if a.OCSP == nil {
+		return errors.New(op, "no ocsp fetcher")
+	}
+	domains, err := a.Domains.ListDomains()
+	if err != nil {
+		return errors.New(op, "list domains", err)
+	}
+	var errcol errors.Collection
+	now := time.Now()
+	for _, d := range domains {
+		if !d.Revoked && !d.OCSPResponse.dueForRefresh(now) {
+			continue
+		}
+		errcol = errors.Append(errcol, a.updateOCSPResponse(ctx, d.Name), op)
+	}
+	return errcol.ErrorOrNil()
+}
+
+func (a *Agent) updateOCSPResponse(ctx context.Context, domainName string) (err error) {
+	const op errors.Op = "acme/agent.updateOCSPResponse"
+
+	ctx, span := tracing.Start(ctx, string(op), tracing.DomainKey.String(domainName))
+	defer func() { tracing.End(span, err) }()
+
+	domain, err := a.Domains.GetDomain(domainName)
+	if err != nil {
+		return errors.New(op, fmt.Sprintf("get domain: %s", domainName), err)
+	}
+	if !domain.Revoked {
+		// Fetch the response before updating the domain. This keeps the
+		// round-trip to the OCSP responder out of the write transaction.
+		res, err := a.OCSP.FetchOCSPResponse(domain.Certificate)
+		if err != nil {
+			return errors.New(op, fmt.Sprintf("fetch ocsp response for domain: %s", domainName), err)
+		}
+		domain, err = a.updateDomain(ctx, domainName, func(_ context.Context, d *Domain) error {
+			// The certificate may have been re-issued in the meantime. The
+			// response is useless then.
+			if d.Revoked || !bytes.Equal(d.Certificate, domain.Certificate) {
+				return nil
+			}
+			d.OCSPResponse = *res
+			d.Revoked = res.Status == OCSPRevoked
+			return nil
+		})
+	}
+	if err != nil {
+		return errors.New(op, fmt.Sprintf("update domain: %s", domainName), err)
+	}
+	if !domain.Revoked {
+		return nil
+	}
+	return errors.Wrap(a.reissueCertificate(ctx, domainName), op)
+}
+
+func (a *Agent) reissueCertificate(ctx context.Context, domainName string) (err error) {
+	const op errors.Op = "acme/agent.reissueCertificate"
+
+	// There is no user waiting for the re-issued certificate. It gets a
+	// request ID of its own.
+	ctx = requestid.NewContext(ctx, requestid.New())
+	ctx, span := tracing.Start(ctx, string(op), tracing.DomainKey.String(domainName))
+	defer func() { tracing.End(span, err) }()
+
+	domain, err := a.Domains.GetDomain(domainName)
+	if err != nil {
+		return requestid.Error(ctx, errors.New(op, fmt.Sprintf("get domain: %s", domainName), err))
+	}
+	user, err := a.Users.GetUser(domain.UserID)
+	if err != nil {
+		return requestid.Error(ctx, errors.New(op, fmt.Sprintf("get user: %v", domain.UserID), err))
+	}
+	// Obtain the certificate before updating the domain. Repositories may
+	// block all other writers until UpdateDomain returns.
+	ci, err := a.obtainCertificate(ctx, user, domainName, true)
+	if err != nil {
+		return requestid.Error(ctx, errors.New(op, fmt.Sprintf("re-issue certificate for domain: %s", domainName), err))
+	}
+	domain, err = a.updateDomain(ctx, domainName, func(ctx context.Context, d *Domain) error {
+		if d.IsZero() {
+			return errors.New(op, errors.NotFound, fmt.Sprintf("domain: %s", domainName))
+		}
+		d.Certificate = ci.Certificate
+		d.PrivateKey = ci.PrivateKey
+		d.OCSPResponse = OCSPResponse{}
+		d.Revoked = false
+		return nil
+	})
+	if err != nil {
+		return requestid.Error(ctx, errors.New(op, fmt.Sprintf("update domain: %s", domainName), err))
+	}
+	a.watchers.notify(domain)
+	return nil
+}
+
+// updateDomain calls UpdateDomain of a.Domains and records a span for the
+// transaction. f is passed the context carrying this span.
+func (a *Agent) updateDomain(
+	ctx context.Context, domainName string, f func(context.Context, *Domain) error,
+) (_ Domain, err error) {
+	const op errors.Op = "acme/domainRepository.UpdateDomain"
+
+	ctx, span := tracing.Start(ctx, string(op), tracing.DomainKey.String(domainName))
+	defer func() { tracing.End(span, err) }()
+
+	return a.Domains.UpdateDomain(domainName, func(d *Domain) error {
+		return f(ctx, d)
+	})
+}
+
+// updateUser is the equivalent of updateDomain for UpdateUser of a.Users.
+func (a *Agent) updateUser(
+	ctx context.Context, userID uuid.UUID, f func(context.Context, *User) error,
+) (_ User, err error) {
+	const op errors.Op = "acme/userRepository.UpdateUser"
+
+	ctx, span := tracing.Start(ctx, string(op), tracing.UserIDKey.String(userID.String()))
+	defer func() { tracing.End(span, err) }()
+
+	return a.Users.UpdateUser(userID, func(c *User) error {
+		return f(ctx, c)
+	})
+}
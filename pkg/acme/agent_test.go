@@ -2,13 +2,22 @@ package acme_test
 
 import (
 	"bytes"
+	"context"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/fhofherr/acmeproxy/pkg/acme"
+	"github.com/fhofherr/acmeproxy/pkg/api/auth"
 	"github.com/fhofherr/acmeproxy/pkg/certutil"
+	"github.com/fhofherr/acmeproxy/pkg/db"
+	"github.com/fhofherr/acmeproxy/pkg/errors"
 	"github.com/fhofherr/acmeproxy/pkg/internal/testsupport"
+	"github.com/fhofherr/acmeproxy/pkg/policy"
+	"github.com/fhofherr/acmeproxy/pkg/requestid"
+	"github.com/fhofherr/acmeproxy/pkg/tracing"
 	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -27,7 +36,7 @@ func TestRegisterNewUser(t *testing.T) {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
 			fx := newAgentFixture(t, "www.example.com")
-			err := fx.Agent.RegisterUser(tt.userID, tt.email)
+			err := fx.Agent.RegisterUser(context.Background(), tt.userID, tt.email)
 			assert.NoError(t, err)
 			user, err := fx.UserRepository.GetUser(tt.userID)
 			assert.NoError(t, err)
@@ -39,13 +48,25 @@ func TestRegisterNewUser(t *testing.T) {
 func TestRegisterNewDomain(t *testing.T) {
 	domainName := "www.example.com"
 	fx := newAgentFixture(t, domainName)
+	rec := tracing.Record(t)
+	assert.Equal(t, 0, testutil.CollectAndCount(fx.Agent))
 
 	userID := uuid.Must(uuid.NewRandom())
-	err := fx.Agent.RegisterUser(userID, "")
+	err := fx.Agent.RegisterUser(context.Background(), userID, "")
 	assert.NoError(t, err)
 
-	err = fx.Agent.RegisterDomain(userID, domainName)
+	ctx := requestid.NewContext(context.Background(), "some-request")
+	err = fx.Agent.RegisterDomain(ctx, userID, domainName)
 	assert.NoError(t, err)
+	fx.FakeCA.AssertRequestIDs("some-request")
+	assert.Equal(t, []string{
+		"acme/userRepository.UpdateUser",
+		"acme/agent.RegisterUser",
+		"acme/agent.obtainCertificate",
+		"acme/domainRepository.UpdateDomain",
+		"acme/agent.RegisterDomain",
+	}, tracing.SpanNames(rec))
+	assert.Equal(t, 1, testutil.CollectAndCount(fx.Agent, "acmeproxy_certificate_expiry_days"))
 
 	domain, err := fx.DomainRepository.GetDomain(domainName)
 	assert.NoError(t, err)
@@ -54,7 +75,7 @@ func TestRegisterNewDomain(t *testing.T) {
 
 	// Re-registering the domain again with the same userID must not lead
 	// to an error.
-	err = fx.Agent.RegisterDomain(userID, domainName)
+	err = fx.Agent.RegisterDomain(context.Background(), userID, domainName)
 	assert.NoError(t, err)
 
 	certBuf := bytes.Buffer{}
@@ -73,7 +94,7 @@ func TestRegisterDomainForUnknownUser(t *testing.T) {
 	fx := newAgentFixture(t, domainName)
 
 	userID := uuid.Must(uuid.NewRandom())
-	err := fx.Agent.RegisterDomain(userID, domainName)
+	err := fx.Agent.RegisterDomain(context.Background(), userID, domainName)
 	assert.Error(t, err)
 }
 
@@ -82,26 +103,394 @@ func TestRegisterSameDomainForDifferentUsers(t *testing.T) {
 	fx := newAgentFixture(t, domain)
 
 	userID1 := uuid.Must(uuid.NewRandom())
-	err := fx.Agent.RegisterUser(userID1, "")
+	err := fx.Agent.RegisterUser(context.Background(), userID1, "")
 	assert.NoError(t, err)
 
 	userID2 := uuid.Must(uuid.NewRandom())
-	err = fx.Agent.RegisterUser(userID2, "")
+	err = fx.Agent.RegisterUser(context.Background(), userID2, "")
 	assert.NoError(t, err)
 
-	err = fx.Agent.RegisterDomain(userID1, domain)
+	err = fx.Agent.RegisterDomain(context.Background(), userID1, domain)
 	assert.NoError(t, err)
 
-	err = fx.Agent.RegisterDomain(userID2, domain)
+	err = fx.Agent.RegisterDomain(context.Background(), userID2, domain)
 	assert.Error(t, err)
 }
 
+func TestRegisterDomainNormalizesName(t *testing.T) {
+	domainName := "www.example.com"
+	fx := newAgentFixture(t, domainName)
+	fx.Agent.Policy = &policy.Policy{}
+
+	userID := uuid.Must(uuid.NewRandom())
+	err := fx.Agent.RegisterUser(context.Background(), userID, "")
+	assert.NoError(t, err)
+
+	err = fx.Agent.RegisterDomain(context.Background(), userID, "WWW.Example.com.")
+	assert.NoError(t, err)
+
+	domain, err := fx.DomainRepository.GetDomain(domainName)
+	assert.NoError(t, err)
+	assert.Equal(t, domainName, domain.Name)
+}
+
+func TestRegisterDomainRejectedByPolicy(t *testing.T) {
+	tests := []struct {
+		name       string
+		domainName string
+		err        error
+	}{
+		{
+			name:       "malformed domain name",
+			domainName: "www.exa_mple.com",
+			err:        errors.New(errors.InvalidArgument),
+		},
+		{
+			name:       "suffix not allowed",
+			domainName: "www.example.org",
+			err:        errors.New(errors.Unauthorized),
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			fx := newAgentFixture(t, tt.domainName)
+			fx.Agent.Policy = &policy.Policy{
+				Default: policy.Rules{AllowedSuffixes: []string{"example.com"}},
+			}
+			userID := uuid.Must(uuid.NewRandom())
+			err := fx.Agent.RegisterUser(context.Background(), userID, "")
+			assert.NoError(t, err)
+
+			ctx := auth.AddClaimsToContext(context.Background(), &auth.Claims{})
+			err = fx.Agent.RegisterDomain(ctx, userID, tt.domainName)
+			errors.AssertMatches(t, tt.err, err)
+			fx.FakeCA.AssertObtained(0)
+		})
+	}
+}
+
+func TestRegisterDomainExceedingQuota(t *testing.T) {
+	domainName := "www.example.com"
+	fx := newAgentFixture(t, domainName)
+	fx.Agent.Quotas = acme.Quotas{Window: time.Hour, PerNameSet: 1}
+
+	userID := uuid.Must(uuid.NewRandom())
+	err := fx.Agent.RegisterUser(context.Background(), userID, "")
+	assert.NoError(t, err)
+
+	issuedAt := time.Now().Add(-time.Minute).UTC().Truncate(time.Second)
+	err = fx.IssuanceRepository.RecordIssuance(acme.Issuance{
+		UserID:   uuid.Must(uuid.NewRandom()),
+		Domains:  []string{domainName},
+		IssuedAt: issuedAt,
+	})
+	assert.NoError(t, err)
+
+	err = fx.Agent.RegisterDomain(context.Background(), userID, domainName)
+	errors.AssertMatches(t, errors.New(errors.RateLimited), err)
+	assert.Equal(t, issuedAt.Add(time.Hour), errors.GetRetryAt(err))
+	fx.FakeCA.AssertObtained(0)
+}
+
+func TestRegisterDomainCountsPendingIssuances(t *testing.T) {
+	domainName := "www.example.com"
+	fx := newAgentFixture(t, domainName)
+	fx.Agent.Quotas = acme.Quotas{Window: time.Hour, PerUser: 1}
+
+	userID := uuid.Must(uuid.NewRandom())
+	err := fx.Agent.RegisterUser(context.Background(), userID, "")
+	assert.NoError(t, err)
+
+	var concurrentErr error
+	fx.Agent.Certificates = obtainerFunc(func(
+		ctx context.Context, req acme.CertificateRequest,
+	) (*acme.CertificateInfo, error) {
+		// Request another certificate while the first one is obtained.
+		concurrentErr = fx.Agent.RegisterDomain(ctx, userID, "mail.example.com")
+		return fx.FakeCA.ObtainCertificate(ctx, req)
+	})
+	err = fx.Agent.RegisterDomain(context.Background(), userID, domainName)
+	assert.NoError(t, err)
+	errors.AssertMatches(t, errors.New(errors.RateLimited), concurrentErr)
+	fx.FakeCA.AssertObtained(1)
+
+	issuances, err := fx.IssuanceRepository.ListIssuances(time.Time{})
+	assert.NoError(t, err)
+	assert.Len(t, issuances, 1)
+}
+
+func TestRegisterDomainReleasesQuotaOfFailedIssuance(t *testing.T) {
+	domainName := "www.example.com"
+	fx := newAgentFixture(t, domainName)
+	fx.Agent.Quotas = acme.Quotas{Window: time.Hour, PerUser: 1}
+
+	userID := uuid.Must(uuid.NewRandom())
+	err := fx.Agent.RegisterUser(context.Background(), userID, "")
+	assert.NoError(t, err)
+
+	fx.Agent.Certificates = obtainerFunc(func(context.Context, acme.CertificateRequest) (*acme.CertificateInfo, error) {
+		return nil, errors.New("ca unavailable")
+	})
+	err = fx.Agent.RegisterDomain(context.Background(), userID, domainName)
+	assert.Error(t, err)
+
+	fx.Agent.Certificates = fx.FakeCA
+	err = fx.Agent.RegisterDomain(context.Background(), userID, domainName)
+	assert.NoError(t, err)
+	fx.FakeCA.AssertObtained(1)
+}
+
+func TestPruneIssuances(t *testing.T) {
+	fx := newAgentFixture(t, "www.example.com")
+	fx.Agent.Quotas = acme.Quotas{Window: time.Hour, PerUser: 1}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	userID := uuid.Must(uuid.NewRandom())
+	recorded := []acme.Issuance{
+		{UserID: userID, Domains: []string{"www.example.com"}, IssuedAt: now.Add(-2 * time.Hour)},
+		{UserID: userID, Domains: []string{"www.example.com"}, IssuedAt: now.Add(-time.Minute)},
+	}
+	for _, iss := range recorded {
+		assert.NoError(t, fx.IssuanceRepository.RecordIssuance(iss))
+	}
+
+	err := fx.Agent.PruneIssuances(now)
+	assert.NoError(t, err)
+
+	issuances, err := fx.IssuanceRepository.ListIssuances(time.Time{})
+	assert.NoError(t, err)
+	assert.Equal(t, recorded[1:], issuances)
+}
+
+func TestUpdateOCSPResponses(t *testing.T) {
+	domainName := "www.example.com"
+	fx := newAgentFixture(t, domainName)
+	fx.OCSPFetcher.Validity = 72 * time.Hour
+	userID := fx.MustRegisterDomain(domainName)
+
+	err := fx.Agent.UpdateOCSPResponses()
+	assert.NoError(t, err)
+	fx.OCSPFetcher.AssertFetched(t, 1)
+
+	buf := bytes.Buffer{}
+	err = fx.Agent.WriteOCSPResponse(userID, domainName, &buf)
+	assert.NoError(t, err)
+	assert.Equal(t, "ocsp response 1", buf.String())
+
+	// The response is still fresh. Updating it again must not lead to a
+	// new response being fetched.
+	err = fx.Agent.UpdateOCSPResponses()
+	assert.NoError(t, err)
+	fx.OCSPFetcher.AssertFetched(t, 1)
+}
+
+func TestUpdateOCSPResponsesRefreshesStaleResponses(t *testing.T) {
+	domainName := "www.example.com"
+	fx := newAgentFixture(t, domainName)
+	userID := fx.MustRegisterDomain(domainName)
+
+	// A validity of zero makes each response due for a refresh immediately.
+	for i := 0; i < 2; i++ {
+		err := fx.Agent.UpdateOCSPResponses()
+		assert.NoError(t, err)
+	}
+	fx.OCSPFetcher.AssertFetched(t, 2)
+
+	buf := bytes.Buffer{}
+	err := fx.Agent.WriteOCSPResponse(userID, domainName, &buf)
+	assert.NoError(t, err)
+	assert.Equal(t, "ocsp response 2", buf.String())
+}
+
+func TestUpdateOCSPResponsesReissuesRevokedCertificates(t *testing.T) {
+	domainName := "www.example.com"
+	fx := newAgentFixture(t, domainName)
+	fx.OCSPFetcher.Status = acme.OCSPRevoked
+	userID := fx.MustRegisterDomain(domainName)
+
+	err := fx.Agent.UpdateOCSPResponses()
+	assert.NoError(t, err)
+	fx.FakeCA.AssertObtained(2)
+
+	domain, err := fx.DomainRepository.GetDomain(domainName)
+	assert.NoError(t, err)
+	assert.False(t, domain.Revoked)
+	assert.True(t, domain.OCSPResponse.IsZero())
+
+	err = fx.Agent.WriteOCSPResponse(userID, domainName, &bytes.Buffer{})
+	errors.AssertMatches(t, errors.New(errors.NotFound), err)
+
+	fx.OCSPFetcher.SetStatus(acme.OCSPGood)
+	err = fx.Agent.UpdateOCSPResponses()
+	assert.NoError(t, err)
+	fx.FakeCA.AssertObtained(2)
+	err = fx.Agent.WriteOCSPResponse(userID, domainName, &bytes.Buffer{})
+	assert.NoError(t, err)
+}
+
+func TestUpdateOCSPResponsesReissuesCertificatesAdmittedByRoleRules(t *testing.T) {
+	domainName := "www.example.com"
+	fx := newAgentFixture(t, domainName)
+	fx.OCSPFetcher.Status = acme.OCSPRevoked
+	fx.Agent.Policy = &policy.Policy{
+		Default: policy.Rules{AllowedSuffixes: []string{"example.org"}},
+		Roles: map[auth.Role]policy.Rules{
+			auth.Operator: {AllowedSuffixes: []string{"example.com"}},
+		},
+	}
+	userID := uuid.Must(uuid.NewRandom())
+	err := fx.Agent.RegisterUser(context.Background(), userID, "")
+	assert.NoError(t, err)
+
+	ctx := auth.AddClaimsToContext(context.Background(), &auth.Claims{Roles: []auth.Role{auth.Operator}})
+	err = fx.Agent.RegisterDomain(ctx, userID, domainName)
+	assert.NoError(t, err)
+	fx.FakeCA.AssertObtained(1)
+
+	// The certificate is re-issued in the background. The roles which
+	// admitted the domain are not known there.
+	err = fx.Agent.UpdateOCSPResponses()
+	assert.NoError(t, err)
+	fx.FakeCA.AssertObtained(2)
+}
+
+func TestUpdateOCSPResponsesReissuesCertificatesExceedingQuota(t *testing.T) {
+	domainName := "www.example.com"
+	fx := newAgentFixture(t, domainName)
+	fx.Agent.Quotas = acme.Quotas{Window: time.Hour, PerNameSet: 1}
+	fx.OCSPFetcher.Status = acme.OCSPRevoked
+	fx.MustRegisterDomain(domainName)
+
+	// Renewals are not subject to the quotas, but are recorded.
+	err := fx.Agent.UpdateOCSPResponses()
+	assert.NoError(t, err)
+	fx.FakeCA.AssertObtained(2)
+
+	issuances, err := fx.IssuanceRepository.ListIssuances(time.Time{})
+	assert.NoError(t, err)
+	assert.Len(t, issuances, 2)
+}
+
+func TestRegisterDomainWithBoltRepositories(t *testing.T) {
+	domainName := "www.example.com"
+	fx := newAgentFixture(t, domainName)
+	dbFx := db.NewTestFixture(t)
+	fx.Agent.Domains = dbFx.DB.DomainRepository()
+	fx.Agent.Users = dbFx.DB.UserRepository()
+	fx.Agent.Issuances = dbFx.DB.IssuanceRepository()
+	fx.OCSPFetcher.Status = acme.OCSPRevoked
+
+	// The repositories of the database block all other writers while
+	// UpdateDomain runs. Fail instead of hanging if the Agent writes
+	// anything else during UpdateDomain. The database cannot be closed
+	// in this case.
+	done := make(chan error)
+	go func() {
+		userID := uuid.Must(uuid.NewRandom())
+		if err := fx.Agent.RegisterUser(context.Background(), userID, ""); err != nil {
+			done <- err
+			return
+		}
+		if err := fx.Agent.RegisterDomain(context.Background(), userID, domainName); err != nil {
+			done <- err
+			return
+		}
+		done <- fx.Agent.UpdateOCSPResponses()
+	}()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("agent did not return")
+	}
+	defer dbFx.Close()
+	fx.FakeCA.AssertObtained(2)
+
+	issuances, err := dbFx.DB.IssuanceRepository().ListIssuances(time.Now().Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Len(t, issuances, 2)
+}
+
+func TestDomainOwner(t *testing.T) {
+	domainName := "www.example.com"
+	fx := newAgentFixture(t, domainName)
+	userID := fx.MustRegisterDomain(domainName)
+
+	owner, err := fx.Agent.DomainOwner(domainName)
+	assert.NoError(t, err)
+	assert.Equal(t, userID, owner)
+
+	_, err = fx.Agent.DomainOwner("www.example.org")
+	errors.AssertMatches(t, errors.New(errors.NotFound), err)
+}
+
+func TestWriteOCSPResponseForWrongUser(t *testing.T) {
+	domainName := "www.example.com"
+	fx := newAgentFixture(t, domainName)
+	fx.MustRegisterDomain(domainName)
+
+	err := fx.Agent.UpdateOCSPResponses()
+	assert.NoError(t, err)
+
+	otherUserID := uuid.Must(uuid.NewRandom())
+	err = fx.Agent.WriteOCSPResponse(otherUserID, domainName, &bytes.Buffer{})
+	errors.AssertMatches(t, errors.New(errors.Unauthorized), err)
+}
+
+func TestRecommendCAARecords(t *testing.T) {
+	domainName := "www.example.com"
+	fx := newAgentFixture(t, domainName)
+
+	userID := uuid.Must(uuid.NewRandom())
+	err := fx.Agent.RegisterUser(context.Background(), userID, "")
+	assert.NoError(t, err)
+	user, err := fx.UserRepository.GetUser(userID)
+	assert.NoError(t, err)
+
+	records, err := fx.Agent.RecommendCAARecords(context.Background(), userID, domainName)
+	assert.NoError(t, err)
+	expected := []acme.CAARecord{
+		{
+			Name:  domainName,
+			Tag:   "issue",
+			Value: "ca.example.com; accounturi=" + user.AccountURL,
+		},
+	}
+	assert.Equal(t, expected, records)
+}
+
+func TestRecommendCAARecordsForUnknownUser(t *testing.T) {
+	fx := newAgentFixture(t, "www.example.com")
+
+	userID := uuid.Must(uuid.NewRandom())
+	_, err := fx.Agent.RecommendCAARecords(context.Background(), userID, "www.example.com")
+	errors.AssertMatches(t, errors.New(errors.NotFound), err)
+}
+
 type agentFixture struct {
-	FakeCA           *acme.FileBasedCertificateObtainer
-	UserRepository   *acme.InMemoryUserRepository
-	DomainRepository *acme.InMemoryDomainRepository
-	AccountCreator   *acme.InMemoryAccountCreator
-	Agent            *acme.Agent
+	FakeCA             *acme.FileBasedCertificateObtainer
+	UserRepository     *acme.InMemoryUserRepository
+	DomainRepository   *acme.InMemoryDomainRepository
+	AccountCreator     *acme.InMemoryAccountCreator
+	OCSPFetcher        *acme.FakeOCSPFetcher
+	CAARecommender     *acme.FakeCAARecommender
+	IssuanceRepository *acme.InMemoryIssuanceRepository
+	Agent              *acme.Agent
+	t                  *testing.T
+}
+
+// MustRegisterDomain registers a new user and the domain for this user. It
+// returns the ID of the new user.
+func (fx agentFixture) MustRegisterDomain(domainName string) uuid.UUID {
+	userID := uuid.Must(uuid.NewRandom())
+	if err := fx.Agent.RegisterUser(context.Background(), userID, ""); err != nil {
+		fx.t.Fatal(err)
+	}
+	if err := fx.Agent.RegisterDomain(context.Background(), userID, domainName); err != nil {
+		fx.t.Fatal(err)
+	}
+	return userID
 }
 
 func newAgentFixture(t *testing.T, commonName string) agentFixture {
@@ -119,17 +508,28 @@ func newAgentFixture(t *testing.T, commonName string) agentFixture {
 	userRepository := &acme.InMemoryUserRepository{}
 	domainRepository := &acme.InMemoryDomainRepository{}
 	accountCreator := &acme.InMemoryAccountCreator{}
+	ocspFetcher := &acme.FakeOCSPFetcher{}
+	caaRecommender := &acme.FakeCAARecommender{IssuerDomain: "ca.example.com"}
+	issuanceRepository := &acme.InMemoryIssuanceRepository{}
 	agent := &acme.Agent{
 		Domains:      domainRepository,
 		Users:        userRepository,
 		Certificates: fakeCA,
 		ACMEAccounts: accountCreator,
+		OCSP:         ocspFetcher,
+		CAA:          caaRecommender,
+		Issuances:    issuanceRepository,
+		Quotas:       acme.DefaultQuotas,
 	}
 	return agentFixture{
-		FakeCA:           fakeCA,
-		UserRepository:   userRepository,
-		DomainRepository: domainRepository,
-		AccountCreator:   accountCreator,
-		Agent:            agent,
+		FakeCA:             fakeCA,
+		UserRepository:     userRepository,
+		DomainRepository:   domainRepository,
+		AccountCreator:     accountCreator,
+		OCSPFetcher:        ocspFetcher,
+		CAARecommender:     caaRecommender,
+		IssuanceRepository: issuanceRepository,
+		Agent:              agent,
+		t:                  t,
 	}
 }
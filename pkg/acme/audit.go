@@ -0,0 +1,45 @@
+package acme
+
+import (
+	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/fhofherr/acmeproxy/pkg/security"
+	"github.com/google/uuid"
+)
+
+// Operations the Agent records in its AuditTrail.
+const (
+	AuditRegisterDomain    = "acme/RegisterDomain"
+	AuditObtainCertificate = "acme/ObtainCertificate"
+	AuditWritePrivateKey   = "acme/WritePrivateKey"
+)
+
+// AuditTrail wraps the Record method.
+//
+// Record appends an entry to the audit log.
+type AuditTrail interface {
+	Record(security.AuditEntry) error
+}
+
+// audit records in a.Audit that the user identified by userID performed
+// operation on the domain with the passed name. err is the error the
+// operation returned, if any.
+//
+// audit returns err if it is not nil. Otherwise it returns the error
+// recording the entry. An operation which cannot be recorded thus fails.
+func (a *Agent) audit(userID uuid.UUID, operation, domainName string, err error) error {
+	const op errors.Op = "acme/agent.audit"
+
+	if a.Audit == nil {
+		return err
+	}
+	e := security.AuditEntry{
+		Subject:   userID.String(),
+		Operation: operation,
+		Resource:  security.AuditDomain(domainName),
+		Outcome:   security.AuditOutcomeOf(err),
+	}
+	if auditErr := a.Audit.Record(e); auditErr != nil && err == nil {
+		return errors.New(op, "record audit entry", auditErr)
+	}
+	return err
+}
@@ -0,0 +1,67 @@
+package acme_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/fhofherr/acmeproxy/pkg/acme"
+	"github.com/fhofherr/acmeproxy/pkg/api/auth"
+	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/fhofherr/acmeproxy/pkg/security"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditTrail(t *testing.T) {
+	domainName := "www.example.com"
+	fx := newAgentFixture(t, domainName)
+	auditLog := &auth.AuditLog{Repository: &security.InMemoryAuditRepository{}}
+	fx.Agent.Audit = auditLog
+	fx.OCSPFetcher.Status = acme.OCSPRevoked
+	userID := fx.MustRegisterDomain(domainName)
+
+	err := fx.Agent.WritePrivateKey(userID, domainName, &bytes.Buffer{})
+	assert.NoError(t, err)
+	err = fx.Agent.WritePrivateKey(userID, "www.example.org", &bytes.Buffer{})
+	errors.AssertMatches(t, errors.New(errors.NotFound), err)
+	err = fx.Agent.UpdateOCSPResponses()
+	assert.NoError(t, err)
+
+	entries, err := auditLog.Query(security.AuditFilter{})
+	if !assert.NoError(t, err) || !assert.Len(t, entries, 5) {
+		return
+	}
+	for i, expected := range []struct {
+		operation string
+		resource  string
+		outcome   security.AuditOutcome
+	}{
+		{acme.AuditObtainCertificate, security.AuditDomain(domainName), security.AuditSuccess},
+		{acme.AuditRegisterDomain, security.AuditDomain(domainName), security.AuditSuccess},
+		{acme.AuditWritePrivateKey, security.AuditDomain(domainName), security.AuditSuccess},
+		{acme.AuditWritePrivateKey, security.AuditDomain("www.example.org"), security.AuditFailure},
+		{acme.AuditObtainCertificate, security.AuditDomain(domainName), security.AuditSuccess},
+	} {
+		assert.Equal(t, expected.operation, entries[i].Operation)
+		assert.Equal(t, expected.resource, entries[i].Resource)
+		assert.Equal(t, expected.outcome, entries[i].Outcome)
+		assert.Equal(t, userID.String(), entries[i].Subject)
+	}
+}
+
+func TestWritePrivateKeyWithoutAuditTrail(t *testing.T) {
+	domainName := "www.example.com"
+	fx := newAgentFixture(t, domainName)
+	userID := fx.MustRegisterDomain(domainName)
+	fx.Agent.Audit = failingAuditTrail{}
+
+	buf := bytes.Buffer{}
+	err := fx.Agent.WritePrivateKey(userID, domainName, &buf)
+	assert.Error(t, err)
+	assert.Zero(t, buf.Len())
+}
+
+type failingAuditTrail struct{}
+
+func (failingAuditTrail) Record(security.AuditEntry) error {
+	return errors.New("audit log unavailable")
+}
@@ -0,0 +1,31 @@
+package acme
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// CAARecord is a DNS Certification Authority Authorization resource record as
+// described in RFC 8659.
+type CAARecord struct {
+	Name  string // Domain name the record is published at.
+	Flag  uint8  // Flags of the record; 128 marks the property as critical.
+	Tag   string // Property tag, e.g. issue, issuewild, or iodef.
+	Value string // Property value.
+}
+
+// String returns the CAA record in zone file presentation format.
+func (r CAARecord) String() string {
+	name := strings.TrimSuffix(r.Name, ".")
+	return fmt.Sprintf("%s. IN CAA %d %s %q", name, r.Flag, r.Tag, r.Value)
+}
+
+// CAARecommender wraps the RecommendCAARecords method.
+//
+// RecommendCAARecords returns the CAA records domainName has to publish in
+// order to allow only the ACME account identified by accountURL to obtain
+// certificates for it.
+type CAARecommender interface {
+	RecommendCAARecords(ctx context.Context, domainName, accountURL string) ([]CAARecord, error)
+}
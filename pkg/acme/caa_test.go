@@ -0,0 +1,42 @@
+package acme_test
+
+import (
+	"testing"
+
+	"github.com/fhofherr/acmeproxy/pkg/acme"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCAARecordString(t *testing.T) {
+	tests := []struct {
+		name     string
+		record   acme.CAARecord
+		expected string
+	}{
+		{
+			name: "issue record",
+			record: acme.CAARecord{
+				Name:  "www.example.com",
+				Tag:   "issue",
+				Value: "ca.example.com; accounturi=https://ca.example.com/acct/1",
+			},
+			expected: `www.example.com. IN CAA 0 issue "ca.example.com; accounturi=https://ca.example.com/acct/1"`,
+		},
+		{
+			name: "fully qualified name",
+			record: acme.CAARecord{
+				Name:  "example.com.",
+				Flag:  128,
+				Tag:   "issuewild",
+				Value: ";",
+			},
+			expected: `example.com. IN CAA 128 issuewild ";"`,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.record.String())
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package acme
+
+import (
+	"time"
+
+	"github.com/fhofherr/acmeproxy/pkg/certutil"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var certificateExpiryDesc = prometheus.NewDesc(
+	"acmeproxy_certificate_expiry_days",
+	"Days until the certificate of the domain expires.",
+	[]string{"domain"}, nil,
+)
+
+// Describe implements prometheus.Collector.
+func (a *Agent) Describe(ch chan<- *prometheus.Desc) {
+	ch <- certificateExpiryDesc
+}
+
+// Collect implements prometheus.Collector. It reports the days until the
+// certificate of each domain expires. Domains without a valid certificate
+// are skipped.
+func (a *Agent) Collect(ch chan<- prometheus.Metric) {
+	domains, err := a.Domains.ListDomains()
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(certificateExpiryDesc, err)
+		return
+	}
+	now := time.Now()
+	for _, d := range domains {
+		if len(d.Certificate) == 0 {
+			continue
+		}
+		cert, err := certutil.ParseCertificate(d.Certificate, true)
+		if err != nil {
+			continue
+		}
+		days := cert.NotAfter.Sub(now).Hours() / 24
+		ch <- prometheus.MustNewConstMetric(certificateExpiryDesc, prometheus.GaugeValue, days, d.Name)
+	}
+}
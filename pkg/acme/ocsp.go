@@ -0,0 +1,58 @@
+package acme
+
+import (
+	"reflect"
+	"time"
+)
+
+// OCSPStatus represents the revocation status of a certificate as reported by
+// an OCSP responder.
+type OCSPStatus int
+
+const (
+	// OCSPGood signals that the OCSP responder vouches for the certificate.
+	OCSPGood OCSPStatus = iota
+	// OCSPRevoked signals that the certificate has been revoked.
+	OCSPRevoked
+	// OCSPUnknown signals that the OCSP responder does not know about the
+	// certificate.
+	OCSPUnknown
+)
+
+// OCSPResponse represents an OCSP response for a certificate along with the
+// meta information the Agent needs to decide when to refresh it.
+type OCSPResponse struct {
+	Raw        []byte     // DER encoded OCSP response as sent by the responder.
+	Status     OCSPStatus // Revocation status of the certificate.
+	ThisUpdate time.Time  // Time at which the status was known to be correct.
+	NextUpdate time.Time  // Time at or before which newer information will be available.
+}
+
+// IsZero returns true if this OCSP response is equal to its zero value.
+func (r OCSPResponse) IsZero() bool {
+	return reflect.DeepEqual(r, OCSPResponse{})
+}
+
+// dueForRefresh returns true if r should be replaced by a newer response.
+//
+// A response is due for a refresh once half of its validity period has
+// passed. This makes sure a newer response is available long before
+// NextUpdate. Responses without NextUpdate are always due for a refresh, as
+// the responder signals that newer information is available at any time.
+func (r OCSPResponse) dueForRefresh(now time.Time) bool {
+	if len(r.Raw) == 0 || r.NextUpdate.IsZero() {
+		return true
+	}
+	validity := r.NextUpdate.Sub(r.ThisUpdate)
+	return !now.Before(r.ThisUpdate.Add(validity / 2))
+}
+
+// OCSPFetcher wraps the FetchOCSPResponse method which obtains an OCSP
+// response for a certificate from the OCSP responder of the certificate's
+// issuer.
+//
+// The certificate passed to FetchOCSPResponse is PEM encoded and bundled with
+// its issuer certificate.
+type OCSPFetcher interface {
+	FetchOCSPResponse(certificate []byte) (*OCSPResponse, error)
+}
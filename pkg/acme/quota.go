@@ -0,0 +1,172 @@
+package acme
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/net/publicsuffix"
+)
+
+// Issuance records that the Agent obtained a certificate for a user.
+type Issuance struct {
+	UserID   uuid.UUID // ID of the user the certificate was issued for.
+	Domains  []string  // Domain names contained in the certificate.
+	IssuedAt time.Time // Time the certificate was obtained.
+}
+
+// IssuanceRepository keeps track of the certificates obtained by the Agent.
+//
+// RecordIssuance persists an Issuance. ListIssuances returns all issuances
+// recorded at or after since, ordered by the time they were issued.
+// PruneIssuances removes all issuances issued before the passed time.
+type IssuanceRepository interface {
+	RecordIssuance(Issuance) error
+	ListIssuances(since time.Time) ([]Issuance, error)
+	PruneIssuances(before time.Time) error
+}
+
+// quotaReservations contains the issuances of the certificates the Agent is
+// currently obtaining. mu serializes checking the Quotas with reserving and
+// recording issuances.
+type quotaReservations struct {
+	mu      sync.Mutex
+	pending map[*Issuance]struct{}
+}
+
+// add adds iss to the reservations. The caller must hold r.mu.
+func (r *quotaReservations) add(iss *Issuance) {
+	if r.pending == nil {
+		r.pending = make(map[*Issuance]struct{})
+	}
+	r.pending[iss] = struct{}{}
+}
+
+// remove removes iss from the reservations. The caller must hold r.mu.
+func (r *quotaReservations) remove(iss *Issuance) {
+	delete(r.pending, iss)
+}
+
+// list returns all reserved issuances. The caller must hold r.mu.
+func (r *quotaReservations) list() []Issuance {
+	issuances := make([]Issuance, 0, len(r.pending))
+	for iss := range r.pending {
+		issuances = append(issuances, *iss)
+	}
+	return issuances
+}
+
+// Quotas limit the number of certificates the Agent obtains within a sliding
+// window of time.
+//
+// The quotas mirror the rate limits of ACME certificate authorities. They
+// prevent a single user from exhausting the limits of the CA for everybody
+// else. A limit of zero disables the respective quota.
+type Quotas struct {
+	Window              time.Duration // Length of the sliding window.
+	PerUser             int           // Certificates obtained for a single user.
+	PerRegisteredDomain int           // Certificates containing a name below the same registered domain (eTLD+1).
+	PerNameSet          int           // Certificates for exactly the same set of names.
+}
+
+// DefaultQuotas mirror the certificates per registered domain and duplicate
+// certificate limits of Let's Encrypt's production environment.
+var DefaultQuotas = Quotas{
+	Window:              7 * 24 * time.Hour,
+	PerRegisteredDomain: 50,
+	PerNameSet:          5,
+}
+
+// exceeded checks if obtaining a certificate for domains on behalf of the user
+// identified by userID would exceed any of the quotas.
+//
+// If a quota is exceeded exceeded returns the time at which all exceeded
+// quotas reset, and a description of the latest resetting quota. Otherwise it
+// returns the zero time.
+func (q Quotas) exceeded(issuances []Issuance, userID uuid.UUID, domains []string) (time.Time, string) {
+	var (
+		resetAt time.Time
+		reason  string
+	)
+	check := func(limit int, desc string, match func(Issuance) bool) {
+		if limit <= 0 {
+			return
+		}
+		var matched []time.Time
+		for _, iss := range issuances {
+			if match(iss) {
+				matched = append(matched, iss.IssuedAt)
+			}
+		}
+		if len(matched) < limit {
+			return
+		}
+		sort.Slice(matched, func(i, j int) bool {
+			return matched[i].Before(matched[j])
+		})
+		// Enough issuances have to leave the window to get below the limit
+		// again.
+		t := matched[len(matched)-limit].Add(q.Window)
+		if t.After(resetAt) {
+			resetAt = t
+			reason = fmt.Sprintf("%d certificates per %s", limit, desc)
+		}
+	}
+
+	check(q.PerUser, "user", func(iss Issuance) bool {
+		return iss.UserID == userID
+	})
+	for _, rd := range registeredDomains(domains) {
+		rd := rd
+		check(q.PerRegisteredDomain, fmt.Sprintf("registered domain %s", rd), func(iss Issuance) bool {
+			for _, other := range registeredDomains(iss.Domains) {
+				if other == rd {
+					return true
+				}
+			}
+			return false
+		})
+	}
+	nameSet := nameSetKey(domains)
+	check(q.PerNameSet, fmt.Sprintf("name set %s", nameSet), func(iss Issuance) bool {
+		return nameSetKey(iss.Domains) == nameSet
+	})
+	return resetAt, reason
+}
+
+// registeredDomains returns the distinct registered domains (eTLD+1) of
+// domains.
+//
+// If the registered domain of a name cannot be determined, e.g. because the
+// name is a public suffix, the name itself is used.
+func registeredDomains(domains []string) []string {
+	seen := make(map[string]bool, len(domains))
+	var rds []string
+	for _, d := range domains {
+		name := strings.ToLower(strings.TrimPrefix(d, "*."))
+		rd, err := publicsuffix.EffectiveTLDPlusOne(name)
+		if err != nil {
+			rd = name
+		}
+		if seen[rd] {
+			continue
+		}
+		seen[rd] = true
+		rds = append(rds, rd)
+	}
+	return rds
+}
+
+// nameSetKey returns a key identifying the set of domains independent of
+// their order.
+func nameSetKey(domains []string) string {
+	names := make([]string, len(domains))
+	for i, d := range domains {
+		names[i] = strings.ToLower(d)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
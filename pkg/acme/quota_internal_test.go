@@ -0,0 +1,109 @@
+package acme
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuotasExceeded(t *testing.T) {
+	now := time.Date(2019, 10, 18, 12, 0, 0, 0, time.UTC)
+	window := 7 * 24 * time.Hour
+	userID := uuid.Must(uuid.NewRandom())
+	otherUserID := uuid.Must(uuid.NewRandom())
+
+	issued := func(userID uuid.UUID, ago time.Duration, domains ...string) Issuance {
+		return Issuance{UserID: userID, Domains: domains, IssuedAt: now.Add(-ago)}
+	}
+
+	tests := []struct {
+		name      string
+		quotas    Quotas
+		issuances []Issuance
+		domains   []string
+		resetAt   time.Time
+	}{
+		{
+			name:    "no issuances",
+			quotas:  Quotas{Window: window, PerUser: 1, PerRegisteredDomain: 1, PerNameSet: 1},
+			domains: []string{"www.example.com"},
+		},
+		{
+			name:   "user quota exceeded",
+			quotas: Quotas{Window: window, PerUser: 2},
+			issuances: []Issuance{
+				issued(userID, 3*time.Hour, "a.example.com"),
+				issued(userID, 2*time.Hour, "b.example.org"),
+				issued(otherUserID, time.Hour, "c.example.net"),
+			},
+			domains: []string{"www.example.com"},
+			resetAt: now.Add(-3 * time.Hour).Add(window),
+		},
+		{
+			name:   "user quota not exceeded",
+			quotas: Quotas{Window: window, PerUser: 2},
+			issuances: []Issuance{
+				issued(userID, 3*time.Hour, "a.example.com"),
+				issued(otherUserID, time.Hour, "c.example.net"),
+			},
+			domains: []string{"www.example.com"},
+		},
+		{
+			name:   "registered domain quota counts all users",
+			quotas: Quotas{Window: window, PerRegisteredDomain: 2},
+			issuances: []Issuance{
+				issued(otherUserID, 3*time.Hour, "a.example.co.uk"),
+				issued(otherUserID, 2*time.Hour, "b.other.co.uk"),
+				issued(otherUserID, time.Hour, "*.b.example.co.uk"),
+			},
+			domains: []string{"www.example.co.uk"},
+			resetAt: now.Add(-3 * time.Hour).Add(window),
+		},
+		{
+			name:   "registered domain quota not exceeded",
+			quotas: Quotas{Window: window, PerRegisteredDomain: 2},
+			issuances: []Issuance{
+				issued(otherUserID, 3*time.Hour, "a.example.co.uk"),
+				issued(otherUserID, 2*time.Hour, "b.other.co.uk"),
+			},
+			domains: []string{"www.example.co.uk"},
+		},
+		{
+			name:   "name set quota ignores order",
+			quotas: Quotas{Window: window, PerNameSet: 2},
+			issuances: []Issuance{
+				issued(userID, 5*time.Hour, "b.example.com", "a.example.com"),
+				issued(userID, 4*time.Hour, "a.example.com"),
+				issued(userID, 3*time.Hour, "a.example.com", "b.example.com"),
+				issued(userID, 2*time.Hour, "a.example.com", "b.example.com"),
+			},
+			domains: []string{"a.example.com", "b.example.com"},
+			resetAt: now.Add(-3 * time.Hour).Add(window),
+		},
+		{
+			name:   "latest reset wins",
+			quotas: Quotas{Window: window, PerUser: 2, PerNameSet: 1},
+			issuances: []Issuance{
+				issued(userID, 5*time.Hour, "a.example.com"),
+				issued(userID, 4*time.Hour, "b.example.com"),
+				issued(otherUserID, time.Hour, "www.example.com"),
+			},
+			domains: []string{"www.example.com"},
+			resetAt: now.Add(-time.Hour).Add(window),
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			resetAt, reason := tt.quotas.exceeded(tt.issuances, userID, tt.domains)
+			assert.Equal(t, tt.resetAt, resetAt)
+			if tt.resetAt.IsZero() {
+				assert.Empty(t, reason)
+			} else {
+				assert.NotEmpty(t, reason)
+			}
+		})
+	}
+}
@@ -11,18 +11,22 @@ import (
 // by the Agent.
 //
 // The UpdateDomain method atomically saves or updates a Domain. GetDomain finds
-// a domain by its domain name.
+// a domain by its domain name. ListDomains returns all domains stored in the
+// repository.
 type DomainRepository interface {
 	UpdateDomain(string, func(d *Domain) error) (Domain, error)
 	GetDomain(string) (Domain, error)
+	ListDomains() ([]Domain, error)
 }
 
 // Domain represents a domain managed by the Agent.
 type Domain struct {
-	UserID      uuid.UUID
-	Name        string
-	Certificate []byte
-	PrivateKey  []byte
+	UserID       uuid.UUID
+	Name         string
+	Certificate  []byte
+	PrivateKey   []byte
+	OCSPResponse OCSPResponse // Most recent OCSP response for Certificate; zero value if none was fetched yet.
+	Revoked      bool         // Certificate was reported as revoked and has to be re-issued.
 }
 
 // IsZero returns true if this domain is equal to its zero value.
@@ -34,10 +38,15 @@ func (d Domain) IsZero() bool {
 // the Agent.
 //
 // The UpdateUser method atomically saves or updates a User. GetUser finds an
-// user by its unique ID.
+// user by its unique ID. ListUsers returns at most limit users whose ID is
+// greater than after, ordered by their ID. If limit is zero ListUsers returns
+// all those users. DeleteUser removes the user with the passed ID; it does
+// nothing if the user does not exist.
 type UserRepository interface {
 	UpdateUser(uuid.UUID, func(c *User) error) (User, error)
 	GetUser(uuid.UUID) (User, error)
+	ListUsers(after uuid.UUID, limit int) ([]User, error)
+	DeleteUser(uuid.UUID) error
 }
 
 // User represents a user of the Agent.
@@ -45,6 +54,9 @@ type User struct {
 	ID         uuid.UUID         // Unique identifier of the user.
 	Key        crypto.PrivateKey // Private key used to identify the account with the ACME certificate authority.
 	AccountURL string            // URL of the user's account at the ACME certificate authority.
+	Email      string            // Contact address of the user; may be empty.
+	Labels     map[string]string // Arbitrary labels attached to the user by an administrator.
+	PublicKey  []byte            // PEM encoded public key associated with the user; may be empty.
 }
 
 // IsZero returns true if this user is equal to its zero value.
@@ -1,15 +1,20 @@
 package acme
 
 import (
+	"bytes"
+	"context"
 	"crypto"
 	"fmt"
 	"io/ioutil"
 	"math/rand"
 	"reflect"
+	"sort"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/fhofherr/acmeproxy/pkg/requestid"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 )
@@ -23,7 +28,7 @@ type InMemoryAccountCreator struct {
 }
 
 // CreateAccount creates an random account URL and returns it.
-func (ac *InMemoryAccountCreator) CreateAccount(key crypto.PrivateKey, email string) (string, error) {
+func (ac *InMemoryAccountCreator) CreateAccount(_ context.Context, key crypto.PrivateKey, email string) (string, error) {
 	ac.mu.Lock()
 	defer ac.mu.Unlock()
 	if ac.accounts == nil {
@@ -108,6 +113,21 @@ func (r *InMemoryDomainRepository) GetDomain(domainName string) (Domain, error)
 	return Domain{}, errors.New(op, errors.NotFound, fmt.Sprintf("domain name: %s", domainName))
 }
 
+// ListDomains returns all domains stored in the repository ordered by their
+// name.
+func (r *InMemoryDomainRepository) ListDomains() ([]Domain, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	domains := make([]Domain, 0, len(r.domains))
+	for _, d := range r.domains {
+		domains = append(domains, d)
+	}
+	sort.Slice(domains, func(i, j int) bool {
+		return domains[i].Name < domains[j].Name
+	})
+	return domains, nil
+}
+
 // InMemoryUserRepository is a simple in-memory implementation of
 // UserRepository.
 type InMemoryUserRepository struct {
@@ -156,21 +176,60 @@ func (r *InMemoryUserRepository) GetUser(id uuid.UUID) (User, error) {
 	return User{}, errors.New(op, errors.NotFound, fmt.Sprintf("user id: %v", id))
 }
 
+// ListUsers returns at most limit users whose ID is greater than after,
+// ordered by their ID. It returns all those users if limit is zero.
+func (r *InMemoryUserRepository) ListUsers(after uuid.UUID, limit int) ([]User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	users := make([]User, 0, len(r.users))
+	for id, u := range r.users {
+		if bytes.Compare(id[:], after[:]) > 0 {
+			users = append(users, u)
+		}
+	}
+	sort.Slice(users, func(i, j int) bool {
+		return bytes.Compare(users[i].ID[:], users[j].ID[:]) < 0
+	})
+	if limit > 0 && len(users) > limit {
+		users = users[:limit]
+	}
+	return users, nil
+}
+
+// DeleteUser removes the user with the passed id from the
+// InMemoryUserRepository.
+func (r *InMemoryUserRepository) DeleteUser(id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.users, id)
+	return nil
+}
+
 // FileBasedCertificateObtainer reads certificates from files and returns them
 // when ObtainCertificate is called.
 type FileBasedCertificateObtainer struct {
 	T        *testing.T // test using this instance of FakeCA.
 	CertFile string     // file containing PEM encoded the certificate returned by ObtainCertificate
 	KeyFile  string     // file containing PEM encoded the private key returned by ObtainCertificate
+
+	obtained   int
+	requestIDs []string
+	mu         sync.Mutex
 }
 
 // ObtainCertificate reads CertFail and KeyFile and returns their contents.
 func (c *FileBasedCertificateObtainer) ObtainCertificate(
+	ctx context.Context,
 	req CertificateRequest,
 ) (
 	*CertificateInfo,
 	error,
 ) {
+	c.mu.Lock()
+	c.obtained++
+	c.requestIDs = append(c.requestIDs, requestid.FromContext(ctx))
+	c.mu.Unlock()
+
 	cert := c.readCertFile()
 	key, err := ioutil.ReadFile(c.KeyFile)
 	if err != nil {
@@ -192,6 +251,23 @@ func (c *FileBasedCertificateObtainer) AssertIssuedCertificate(cert []byte) {
 	}
 }
 
+// AssertObtained asserts that ObtainCertificate was called exactly n times.
+func (c *FileBasedCertificateObtainer) AssertObtained(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.obtained != n {
+		c.T.Errorf("expected %d obtained certificates; got %d", n, c.obtained)
+	}
+}
+
+// AssertRequestIDs asserts that ObtainCertificate was called with contexts
+// carrying the passed request IDs in this order.
+func (c *FileBasedCertificateObtainer) AssertRequestIDs(ids ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	assert.Equal(c.T, ids, c.requestIDs)
+}
+
 func (c *FileBasedCertificateObtainer) readCertFile() []byte {
 	cert, err := ioutil.ReadFile(c.CertFile)
 	if err != nil {
@@ -199,3 +275,112 @@ func (c *FileBasedCertificateObtainer) readCertFile() []byte {
 	}
 	return cert
 }
+
+// FakeOCSPFetcher creates fake OCSP responses for arbitrary certificates.
+//
+// Each response has the status configured in Status. ThisUpdate is set to the
+// current time, NextUpdate to the current time plus Validity.
+type FakeOCSPFetcher struct {
+	Status   OCSPStatus
+	Validity time.Duration
+
+	fetched int
+	mu      sync.Mutex
+}
+
+// FetchOCSPResponse returns a fake OCSP response for certificate.
+func (f *FakeOCSPFetcher) FetchOCSPResponse(certificate []byte) (*OCSPResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.fetched++
+	now := time.Now().UTC().Truncate(time.Second)
+	return &OCSPResponse{
+		Raw:        []byte(fmt.Sprintf("ocsp response %d", f.fetched)),
+		Status:     f.Status,
+		ThisUpdate: now,
+		NextUpdate: now.Add(f.Validity),
+	}, nil
+}
+
+// SetStatus changes the status of all OCSP responses returned from now on.
+func (f *FakeOCSPFetcher) SetStatus(status OCSPStatus) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Status = status
+}
+
+// AssertFetched asserts that FetchOCSPResponse was called exactly n times.
+func (f *FakeOCSPFetcher) AssertFetched(t *testing.T, n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.fetched != n {
+		t.Errorf("expected %d fetched ocsp responses; got %d", n, f.fetched)
+	}
+}
+
+// FakeCAARecommender recommends CAA records allowing IssuerDomain to issue
+// certificates to a single account.
+type FakeCAARecommender struct {
+	IssuerDomain string
+}
+
+// RecommendCAARecords returns a single issue record for domainName which
+// restricts issuance to accountURL.
+func (f *FakeCAARecommender) RecommendCAARecords(
+	_ context.Context, domainName, accountURL string,
+) ([]CAARecord, error) {
+	return []CAARecord{
+		{
+			Name:  domainName,
+			Tag:   "issue",
+			Value: fmt.Sprintf("%s; accounturi=%s", f.IssuerDomain, accountURL),
+		},
+	}, nil
+}
+
+// InMemoryIssuanceRepository is an implementation of IssuanceRepository which
+// stores all issuances in memory.
+type InMemoryIssuanceRepository struct {
+	issuances []Issuance
+	mu        sync.Mutex
+}
+
+// RecordIssuance records iss in memory.
+func (r *InMemoryIssuanceRepository) RecordIssuance(iss Issuance) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.issuances = append(r.issuances, iss)
+	return nil
+}
+
+// ListIssuances returns all issuances recorded at or after since, ordered by
+// their IssuedAt time.
+func (r *InMemoryIssuanceRepository) ListIssuances(since time.Time) ([]Issuance, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var issuances []Issuance
+	for _, iss := range r.issuances {
+		if !iss.IssuedAt.Before(since) {
+			issuances = append(issuances, iss)
+		}
+	}
+	sort.Slice(issuances, func(i, j int) bool {
+		return issuances[i].IssuedAt.Before(issuances[j].IssuedAt)
+	})
+	return issuances, nil
+}
+
+// PruneIssuances removes all issuances issued before the passed time.
+func (r *InMemoryIssuanceRepository) PruneIssuances(before time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var issuances []Issuance
+	for _, iss := range r.issuances {
+		if !iss.IssuedAt.Before(before) {
+			issuances = append(issuances, iss)
+		}
+	}
+	r.issuances = issuances
+	return nil
+}
@@ -0,0 +1,162 @@
+package acme
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/fhofherr/acmeproxy/pkg/certutil"
+	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/google/uuid"
+)
+
+// GetUser returns the user with the passed userID.
+//
+// GetUser returns an error of kind NotFound if the user does not exist.
+func (a *Agent) GetUser(userID uuid.UUID) (User, error) {
+	const op errors.Op = "acme/agent.GetUser"
+
+	user, err := a.Users.GetUser(userID)
+	if err != nil {
+		return User{}, errors.New(op, err)
+	}
+	if user.IsZero() {
+		return User{}, errors.New(op, errors.NotFound, fmt.Sprintf("user: %v", userID))
+	}
+	return user, nil
+}
+
+// ListUsers returns at most limit users whose ID is greater than after,
+// ordered by their ID. Passing the ID of the last user returned by the
+// previous call as after pages through all users.
+func (a *Agent) ListUsers(after uuid.UUID, limit int) ([]User, error) {
+	const op errors.Op = "acme/agent.ListUsers"
+
+	users, err := a.Users.ListUsers(after, limit)
+	return users, errors.Wrap(err, op)
+}
+
+// UpdateUser replaces the contact address, the labels, and the public key of
+// the user with the passed userID by those of u. All other fields of u are
+// ignored.
+//
+// UpdateUser does not change the contact address of the user's account at
+// the ACME certificate authority. It returns an error of kind NotFound if the
+// user does not exist, and an error of kind InvalidArgument if u.PublicKey is
+// not empty and not a PEM encoded public key.
+func (a *Agent) UpdateUser(userID uuid.UUID, u User) (User, error) {
+	const op errors.Op = "acme/agent.UpdateUser"
+
+	if len(u.PublicKey) > 0 {
+		if _, err := certutil.ReadPublicKey(bytes.NewReader(u.PublicKey), true); err != nil {
+			return User{}, errors.New(op, errors.InvalidArgument, "invalid public key", err)
+		}
+	}
+	user, err := a.Users.UpdateUser(userID, func(c *User) error {
+		if c.IsZero() {
+			return errors.New(op, errors.NotFound, fmt.Sprintf("user: %v", userID))
+		}
+		c.Email = u.Email
+		c.Labels = u.Labels
+		c.PublicKey = u.PublicKey
+		return nil
+	})
+	return user, errors.Wrap(err, op)
+}
+
+// DeleteUser removes the user with the passed userID.
+//
+// DeleteUser refuses to remove users which still own domains with an error
+// of kind InvalidArgument. Their domains have to be transferred to another
+// user first. The user's account at the ACME certificate authority is left
+// untouched.
+func (a *Agent) DeleteUser(userID uuid.UUID) error {
+	const op errors.Op = "acme/agent.DeleteUser"
+
+	a.owners.Lock()
+	defer a.owners.Unlock()
+	if _, err := a.GetUser(userID); err != nil {
+		return errors.New(op, err)
+	}
+	domains, err := a.Domains.ListDomains()
+	if err != nil {
+		return errors.New(op, "list domains", err)
+	}
+	n := 0
+	for _, d := range domains {
+		if d.UserID == userID {
+			n++
+		}
+	}
+	if n > 0 {
+		return errors.New(op, errors.InvalidArgument, fmt.Sprintf("user owns %d domains", n))
+	}
+	return errors.Wrap(a.Users.DeleteUser(userID), op)
+}
+
+// TransferDomains transfers the domains with the passed domainNames from the
+// user identified by from to the user identified by to. If domainNames is
+// empty TransferDomains transfers all domains of from. It returns the names
+// of the transferred domains.
+//
+// Both users must exist. Each of domainNames must belong to from, and must be
+// permitted for to by the CheckTransfer method of the Agent's Policy.
+// Certificates are renewed using the ACME account of to.
+func (a *Agent) TransferDomains(from, to uuid.UUID, domainNames []string) ([]string, error) {
+	const op errors.Op = "acme/agent.TransferDomains"
+
+	a.owners.Lock()
+	defer a.owners.Unlock()
+	if _, err := a.GetUser(from); err != nil {
+		return nil, errors.New(op, err)
+	}
+	if _, err := a.GetUser(to); err != nil {
+		return nil, errors.New(op, err)
+	}
+	if len(domainNames) == 0 {
+		domains, err := a.Domains.ListDomains()
+		if err != nil {
+			return nil, errors.New(op, "list domains", err)
+		}
+		for _, d := range domains {
+			if d.UserID == from {
+				domainNames = append(domainNames, d.Name)
+			}
+		}
+	}
+	if len(domainNames) == 0 {
+		return nil, nil
+	}
+	domainNames, err := a.checkTransfer(to, domainNames)
+	if err != nil {
+		return nil, errors.New(op, err)
+	}
+	transferred := make([]string, 0, len(domainNames))
+	for _, name := range domainNames {
+		_, err := a.Domains.UpdateDomain(name, func(d *Domain) error {
+			if d.IsZero() || d.UserID != from {
+				msg := fmt.Sprintf("domain not owned by user %v: %s", from, name)
+				return errors.New(op, errors.InvalidArgument, msg)
+			}
+			d.UserID = to
+			return nil
+		})
+		if err != nil {
+			return transferred, errors.New(op, fmt.Sprintf("transfer domain: %s", name), err)
+		}
+		transferred = append(transferred, name)
+	}
+	return transferred, nil
+}
+
+func (a *Agent) checkTransfer(to uuid.UUID, domainNames []string) ([]string, error) {
+	const op errors.Op = "acme/agent.checkTransfer"
+
+	if a.Policy == nil {
+		return domainNames, nil
+	}
+	names, err := a.Policy.CheckTransfer(to, domainNames)
+	if err != nil {
+		return nil, errors.New(op, fmt.Sprintf("check domain policy for user: %v", to), err)
+	}
+	return names, nil
+}
@@ -0,0 +1,265 @@
+package acme_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+
+	"github.com/fhofherr/acmeproxy/pkg/acme"
+	"github.com/fhofherr/acmeproxy/pkg/certutil"
+	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/fhofherr/acmeproxy/pkg/policy"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetUser(t *testing.T) {
+	fx := newAgentFixture(t, "www.example.com")
+
+	userID := uuid.Must(uuid.NewRandom())
+	_, err := fx.Agent.GetUser(userID)
+	errors.AssertMatches(t, errors.New(errors.NotFound), err)
+
+	err = fx.Agent.RegisterUser(context.Background(), userID, "jane.doe@example.com")
+	assert.NoError(t, err)
+	user, err := fx.Agent.GetUser(userID)
+	assert.NoError(t, err)
+	assert.Equal(t, userID, user.ID)
+	assert.Equal(t, "jane.doe@example.com", user.Email)
+}
+
+func TestUpdateUser(t *testing.T) {
+	key := certutil.KeyMust(certutil.NewPrivateKey(certutil.EC256))
+	bs, err := x509.MarshalPKIXPublicKey(key.(crypto.Signer).Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: bs})
+
+	tests := []struct {
+		name   string
+		update acme.User
+		err    error
+	}{
+		{
+			name: "replace editable fields",
+			update: acme.User{
+				Email:     "john.doe@example.com",
+				Labels:    map[string]string{"team": "web"},
+				PublicKey: publicKey,
+			},
+		},
+		{
+			name:   "clear editable fields",
+			update: acme.User{},
+		},
+		{
+			name:   "invalid public key",
+			update: acme.User{PublicKey: []byte("not a public key")},
+			err:    errors.New(errors.InvalidArgument),
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			fx := newAgentFixture(t, "www.example.com")
+			userID := uuid.Must(uuid.NewRandom())
+			err := fx.Agent.RegisterUser(context.Background(), userID, "jane.doe@example.com")
+			assert.NoError(t, err)
+			original, err := fx.Agent.GetUser(userID)
+			assert.NoError(t, err)
+
+			// The ID of the update must be ignored.
+			tt.update.ID = uuid.Must(uuid.NewRandom())
+			user, err := fx.Agent.UpdateUser(userID, tt.update)
+			if tt.err != nil {
+				errors.AssertMatches(t, tt.err, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, userID, user.ID)
+			assert.Equal(t, original.Key, user.Key)
+			assert.Equal(t, original.AccountURL, user.AccountURL)
+			assert.Equal(t, tt.update.Email, user.Email)
+			assert.Equal(t, tt.update.Labels, user.Labels)
+			assert.Equal(t, tt.update.PublicKey, user.PublicKey)
+		})
+	}
+}
+
+func TestUpdateMissingUser(t *testing.T) {
+	fx := newAgentFixture(t, "www.example.com")
+
+	userID := uuid.Must(uuid.NewRandom())
+	_, err := fx.Agent.UpdateUser(userID, acme.User{Email: "jane.doe@example.com"})
+	errors.AssertMatches(t, errors.New(errors.NotFound), err)
+
+	users, err := fx.Agent.ListUsers(uuid.Nil, 0)
+	assert.NoError(t, err)
+	assert.Empty(t, users)
+}
+
+func TestDeleteUser(t *testing.T) {
+	domainName := "www.example.com"
+	fx := newAgentFixture(t, domainName)
+	userID := fx.MustRegisterDomain(domainName)
+
+	err := fx.Agent.DeleteUser(userID)
+	errors.AssertMatches(t, errors.New(errors.InvalidArgument, "user owns 1 domains"), err)
+
+	otherUserID := uuid.Must(uuid.NewRandom())
+	err = fx.Agent.RegisterUser(context.Background(), otherUserID, "")
+	assert.NoError(t, err)
+	_, err = fx.Agent.TransferDomains(userID, otherUserID, nil)
+	assert.NoError(t, err)
+
+	err = fx.Agent.DeleteUser(userID)
+	assert.NoError(t, err)
+	_, err = fx.Agent.GetUser(userID)
+	errors.AssertMatches(t, errors.New(errors.NotFound), err)
+
+	err = fx.Agent.DeleteUser(userID)
+	errors.AssertMatches(t, errors.New(errors.NotFound), err)
+}
+
+func TestDeleteUserWhileRegisteringDomain(t *testing.T) {
+	domainName := "www.example.com"
+	fx := newAgentFixture(t, domainName)
+	userID := uuid.Must(uuid.NewRandom())
+	err := fx.Agent.RegisterUser(context.Background(), userID, "")
+	assert.NoError(t, err)
+
+	fx.Agent.Certificates = obtainerFunc(func(
+		ctx context.Context, req acme.CertificateRequest,
+	) (*acme.CertificateInfo, error) {
+		// Remove the user while its certificate is obtained.
+		if err := fx.Agent.DeleteUser(userID); err != nil {
+			return nil, err
+		}
+		return fx.FakeCA.ObtainCertificate(ctx, req)
+	})
+	err = fx.Agent.RegisterDomain(context.Background(), userID, domainName)
+	errors.AssertMatches(t, errors.New(errors.NotFound), err)
+
+	domain, err := fx.DomainRepository.GetDomain(domainName)
+	if err == nil {
+		assert.True(t, domain.IsZero(), "domain registered to removed user")
+	}
+}
+
+type obtainerFunc func(context.Context, acme.CertificateRequest) (*acme.CertificateInfo, error)
+
+func (f obtainerFunc) ObtainCertificate(
+	ctx context.Context, req acme.CertificateRequest,
+) (*acme.CertificateInfo, error) {
+	return f(ctx, req)
+}
+
+func TestTransferDomains(t *testing.T) {
+	to := uuid.Must(uuid.NewRandom())
+	tests := []struct {
+		name        string
+		domainNames []string
+		policy      *policy.Policy
+		transferred []string
+		err         error
+	}{
+		{
+			name:        "all domains",
+			transferred: []string{"example.com", "www.example.com"},
+		},
+		{
+			name:        "named domains",
+			domainNames: []string{"WWW.Example.com."},
+			policy:      &policy.Policy{},
+			transferred: []string{"www.example.com"},
+		},
+		{
+			name:        "domain of other user",
+			domainNames: []string{"www.example.org"},
+			err:         errors.New(errors.InvalidArgument),
+		},
+		{
+			name: "rejected by policy",
+			policy: &policy.Policy{
+				DeniedNames: []string{"www.example.com"},
+			},
+			err: errors.New(errors.Unauthorized),
+		},
+		{
+			name:        "rejected by rules of recipient",
+			domainNames: []string{"www.example.com"},
+			policy: &policy.Policy{
+				Users: map[uuid.UUID]policy.Rules{
+					to: {AllowedSuffixes: []string{"example.org"}},
+				},
+			},
+			err: errors.New(errors.Unauthorized),
+		},
+		{
+			name:        "permitted by rules of recipient",
+			domainNames: []string{"www.example.com"},
+			policy: &policy.Policy{
+				Default: policy.Rules{AllowedSuffixes: []string{"example.org"}},
+				Users: map[uuid.UUID]policy.Rules{
+					to: {AllowedSuffixes: []string{"example.com"}},
+				},
+			},
+			transferred: []string{"www.example.com"},
+		},
+		{
+			name:        "default rules do not apply to recipient",
+			domainNames: []string{"www.example.com"},
+			policy: &policy.Policy{
+				Default: policy.Rules{AllowedSuffixes: []string{"example.org"}},
+			},
+			transferred: []string{"www.example.com"},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			fx := newAgentFixture(t, "www.example.com")
+			from := fx.MustRegisterDomain("www.example.com")
+			err := fx.Agent.RegisterDomain(context.Background(), from, "example.com")
+			assert.NoError(t, err)
+			other := fx.MustRegisterDomain("www.example.org")
+			err = fx.Agent.RegisterUser(context.Background(), to, "")
+			assert.NoError(t, err)
+			if tt.policy != nil {
+				fx.Agent.Policy = tt.policy
+			}
+
+			transferred, err := fx.Agent.TransferDomains(from, to, tt.domainNames)
+			if tt.err != nil {
+				errors.AssertMatches(t, tt.err, err)
+				domain, err := fx.DomainRepository.GetDomain("www.example.org")
+				assert.NoError(t, err)
+				assert.Equal(t, other, domain.UserID)
+				return
+			}
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, tt.transferred, transferred)
+			for _, name := range tt.transferred {
+				domain, err := fx.DomainRepository.GetDomain(name)
+				assert.NoError(t, err)
+				assert.Equal(t, to, domain.UserID)
+			}
+		})
+	}
+}
+
+func TestTransferDomainsToMissingUser(t *testing.T) {
+	domainName := "www.example.com"
+	fx := newAgentFixture(t, domainName)
+	from := fx.MustRegisterDomain(domainName)
+
+	_, err := fx.Agent.TransferDomains(from, uuid.Must(uuid.NewRandom()), nil)
+	errors.AssertMatches(t, errors.New(errors.NotFound), err)
+
+	domain, err := fx.DomainRepository.GetDomain(domainName)
+	assert.NoError(t, err)
+	assert.Equal(t, from, domain.UserID)
+}
@@ -0,0 +1,154 @@
+package acme
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/google/uuid"
+)
+
+// WatchCertificates watches the certificates of the domains identified by
+// domainNames.
+//
+// WatchCertificates first sends the current state of each domain on the
+// returned channel. Afterwards it sends a domain again every time the Agent
+// obtains a new certificate for it. The channel is closed once ctx is done.
+// Receivers falling behind do not block the Agent. They receive only the
+// most recent state of each domain instead.
+//
+// WatchCertificates returns an error if one of the domains was not
+// registered, or was registered to a different userID.
+func (a *Agent) WatchCertificates(ctx context.Context, userID uuid.UUID, domainNames []string) (<-chan Domain, error) {
+	const op errors.Op = "acme/agent.WatchCertificates"
+
+	w := &certificateWatcher{
+		domainNames: make(map[string]bool, len(domainNames)),
+		pending:     make(map[string]Domain, len(domainNames)),
+		notify:      make(chan struct{}, 1),
+	}
+	for _, name := range domainNames {
+		w.domainNames[name] = true
+	}
+	// Register the watcher before reading the current state of the domains.
+	// Otherwise we could miss a certificate obtained in between.
+	a.watchers.add(w)
+	for name := range w.domainNames {
+		domain, err := a.Domains.GetDomain(name)
+		if err == nil && domain.IsZero() {
+			err = errors.New(op, errors.NotFound, fmt.Sprintf("domain: %s", name))
+		}
+		if err == nil && domain.UserID != userID {
+			err = errors.New(op, errors.Unauthorized, fmt.Sprintf("domain %s not registered to user: %v", name, userID))
+		}
+		if err != nil {
+			a.watchers.remove(w)
+			return nil, errors.New(op, fmt.Sprintf("get domain: %s", name), err)
+		}
+		w.update(domain, false)
+	}
+
+	c := make(chan Domain)
+	go func() {
+		defer close(c)
+		defer a.watchers.remove(w)
+		w.send(ctx, c)
+	}()
+	return c, nil
+}
+
+// certificateWatchers is the set of watchers the Agent notifies about new
+// certificates. The zero value is ready to use.
+type certificateWatchers struct {
+	mu       sync.Mutex
+	watchers map[*certificateWatcher]bool
+}
+
+func (ws *certificateWatchers) add(w *certificateWatcher) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	if ws.watchers == nil {
+		ws.watchers = make(map[*certificateWatcher]bool)
+	}
+	ws.watchers[w] = true
+}
+
+func (ws *certificateWatchers) remove(w *certificateWatcher) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	delete(ws.watchers, w)
+}
+
+// notify informs all watchers of the domain about its new certificate.
+func (ws *certificateWatchers) notify(domain Domain) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	for w := range ws.watchers {
+		if w.domainNames[domain.Name] {
+			w.update(domain, true)
+		}
+	}
+}
+
+// certificateWatcher collects the domains not yet sent to a receiver. It keeps
+// only the most recent state of each domain.
+type certificateWatcher struct {
+	domainNames map[string]bool // Never modified after the watcher was added.
+
+	mu      sync.Mutex
+	pending map[string]Domain
+	order   []string
+	notify  chan struct{}
+}
+
+// update marks domain as pending. If replace is false update keeps an already
+// pending state of the domain.
+func (w *certificateWatcher) update(domain Domain, replace bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, ok := w.pending[domain.Name]; ok {
+		if replace {
+			w.pending[domain.Name] = domain
+		}
+		return
+	}
+	w.pending[domain.Name] = domain
+	w.order = append(w.order, domain.Name)
+	select {
+	case w.notify <- struct{}{}:
+	default:
+	}
+}
+
+// take removes and returns the pending domains in the order they became
+// pending.
+func (w *certificateWatcher) take() []Domain {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	domains := make([]Domain, 0, len(w.order))
+	for _, name := range w.order {
+		domains = append(domains, w.pending[name])
+		delete(w.pending, name)
+	}
+	w.order = w.order[:0]
+	return domains
+}
+
+// send sends the pending domains on c until ctx is done.
+func (w *certificateWatcher) send(ctx context.Context, c chan<- Domain) {
+	for {
+		select {
+		case <-w.notify:
+		case <-ctx.Done():
+			return
+		}
+		for _, domain := range w.take() {
+			select {
+			case c <- domain:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package acme_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fhofherr/acmeproxy/pkg/acme"
+	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatchCertificates(t *testing.T) {
+	domainName := "www.example.com"
+	fx := newAgentFixture(t, domainName)
+	fx.OCSPFetcher.Status = acme.OCSPRevoked
+	userID := fx.MustRegisterDomain(domainName)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c, err := fx.Agent.WatchCertificates(ctx, userID, []string{domainName})
+	if !assert.NoError(t, err) {
+		return
+	}
+	domain := receiveDomain(t, c)
+	assert.Equal(t, domainName, domain.Name)
+	fx.FakeCA.AssertIssuedCertificate(domain.Certificate)
+
+	err = fx.Agent.UpdateOCSPResponses()
+	assert.NoError(t, err)
+	fx.FakeCA.AssertObtained(2)
+
+	domain = receiveDomain(t, c)
+	assert.Equal(t, domainName, domain.Name)
+	assert.False(t, domain.Revoked)
+
+	cancel()
+	select {
+	case _, ok := <-c:
+		assert.False(t, ok, "channel not closed")
+	case <-time.After(100 * time.Millisecond):
+		t.Error("channel not closed after 100ms")
+	}
+}
+
+func TestWatchCertificatesOfOtherUser(t *testing.T) {
+	domainName := "www.example.com"
+	fx := newAgentFixture(t, domainName)
+	fx.MustRegisterDomain(domainName)
+
+	otherUserID := uuid.Must(uuid.NewRandom())
+	_, err := fx.Agent.WatchCertificates(context.Background(), otherUserID, []string{domainName})
+	errors.AssertMatches(t, errors.New(errors.Unauthorized), err)
+}
+
+func TestWatchCertificatesOfUnknownDomain(t *testing.T) {
+	fx := newAgentFixture(t, "www.example.com")
+
+	userID := uuid.Must(uuid.NewRandom())
+	_, err := fx.Agent.WatchCertificates(context.Background(), userID, []string{"www.example.com"})
+	errors.AssertMatches(t, errors.New(errors.NotFound), err)
+}
+
+func receiveDomain(t *testing.T, c <-chan acme.Domain) acme.Domain {
+	select {
+	case domain := <-c:
+		return domain
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("no domain received after 100ms")
+		return acme.Domain{}
+	}
+}
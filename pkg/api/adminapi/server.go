@@ -0,0 +1,217 @@
+package adminapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/fhofherr/acmeproxy/pkg/acme"
+	"github.com/fhofherr/acmeproxy/pkg/certutil"
+	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/fhofherr/acmeproxy/pkg/version"
+	"github.com/fhofherr/golf/log"
+	"github.com/go-chi/chi"
+)
+
+const (
+	// checkTimeout limits the duration of all readiness checks together.
+	checkTimeout = 5 * time.Second
+
+	// readHeaderTimeout limits the time a client may take to send the
+	// headers of a request.
+	readHeaderTimeout = 10 * time.Second
+
+	// readTimeout limits the time a client may take to send a whole
+	// request.
+	readTimeout = 30 * time.Second
+
+	// idleTimeout is the time after which idle keep-alive connections are
+	// closed.
+	idleTimeout = 2 * time.Minute
+)
+
+// Check checks a sub-system of acmeproxy. It returns an error if the
+// sub-system does not work.
+type Check func(ctx context.Context) error
+
+// DomainLister wraps the ListDomains method.
+//
+// ListDomains returns all domains managed by acmeproxy.
+type DomainLister interface {
+	ListDomains() ([]acme.Domain, error)
+}
+
+// Status is the JSON document served on /status.
+type Status struct {
+	Version       Version            `json:"version"`
+	UptimeSeconds float64            `json:"uptime_seconds"`
+	Domains       int                `json:"domains"`
+	NextExpiry    *CertificateExpiry `json:"next_expiry,omitempty"` // Soonest expiring certificate; nil if there is none.
+}
+
+// Version describes the build of the running acmeproxy binary.
+type Version struct {
+	GitTag    string `json:"git_tag,omitempty"`
+	GitHash   string `json:"git_hash,omitempty"`
+	BuildTime string `json:"build_time,omitempty"`
+}
+
+// CertificateExpiry describes when the certificate of a domain expires.
+type CertificateExpiry struct {
+	Domain   string    `json:"domain"`
+	NotAfter time.Time `json:"not_after"`
+}
+
+// Server serves the administrative endpoints of acmeproxy.
+//
+// The liveness probe /livez responds with status 200 as long as Server is
+// able to serve requests. The readiness probe /readyz runs the
+// ReadinessChecks and responds with status 200 if all of them pass, or with
+// status 503 otherwise. Its body contains the result of each check, either
+// "ok" or "failed". The errors of failed checks are logged to Logger. /status
+// responds with the Status of acmeproxy. All responses are JSON documents.
+//
+// Server is meant to listen on an address reachable by the operators and
+// their orchestration only. It does not authenticate its callers.
+type Server struct {
+	ReadinessChecks map[string]Check
+	Domains         DomainLister
+	Logger          log.Logger
+	httpServer      *http.Server
+	started         time.Time
+	once            sync.Once
+}
+
+func (s *Server) initialize() error {
+	const op errors.Op = "adminapi/server.initialize"
+	var err error
+
+	s.once.Do(func() {
+		if s.Domains == nil {
+			err = errors.New(op, "no domain lister set")
+			return
+		}
+		s.started = time.Now()
+		s.httpServer = &http.Server{
+			Handler:           s.newRouter(),
+			ReadHeaderTimeout: readHeaderTimeout,
+			ReadTimeout:       readTimeout,
+			IdleTimeout:       idleTimeout,
+		}
+	})
+
+	return err
+}
+
+// Serve accepts incoming connections on the listener l.
+//
+// Serve initializes the Server if this has not been done yet. Since Server
+// uses net/http.Server internally Serve returns net/http.ErrServerClosed once
+// the Server is shut down.
+func (s *Server) Serve(l net.Listener) error {
+	const op errors.Op = "adminapi/server.Serve"
+
+	if err := s.initialize(); err != nil {
+		return errors.New(op, err)
+	}
+	return errors.Wrap(s.httpServer.Serve(l), op, "serve http")
+}
+
+// Shutdown gracefully stops the Server.
+func (s *Server) Shutdown(ctx context.Context) error {
+	const op errors.Op = "adminapi/server.Shutdown"
+
+	if s.httpServer == nil {
+		return errors.New(op, "not started")
+	}
+	return errors.Wrap(s.httpServer.Shutdown(ctx), op, "shutdown")
+}
+
+func (s *Server) newRouter() http.Handler {
+	r := chi.NewRouter()
+	r.Get("/livez", s.live)
+	r.Get("/readyz", s.ready)
+	r.Get("/status", s.status)
+	return r
+}
+
+func (s *Server) live(w http.ResponseWriter, req *http.Request) {
+	s.writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (s *Server) ready(w http.ResponseWriter, req *http.Request) {
+	const op errors.Op = "adminapi/server.ready"
+
+	ctx, cancel := context.WithTimeout(req.Context(), checkTimeout)
+	defer cancel()
+
+	code := http.StatusOK
+	results := make(map[string]string, len(s.ReadinessChecks))
+	for name, check := range s.ReadinessChecks {
+		if err := check(ctx); err != nil {
+			// The error may reveal internals of acmeproxy. Only the log
+			// gets to see it.
+			errors.Log(s.Logger, errors.New(op, fmt.Sprintf("readiness check failed: %s", name), err))
+			code = http.StatusServiceUnavailable
+			results[name] = "failed"
+			continue
+		}
+		results[name] = "ok"
+	}
+	s.writeJSON(w, code, results)
+}
+
+func (s *Server) status(w http.ResponseWriter, req *http.Request) {
+	const op errors.Op = "adminapi/server.status"
+
+	domains, err := s.Domains.ListDomains()
+	if err != nil {
+		errors.Log(s.Logger, errors.New(op, "list domains", err))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	st := Status{
+		Version: Version{
+			GitTag:    version.GitTag,
+			GitHash:   version.GitHash,
+			BuildTime: version.BuildTime,
+		},
+		UptimeSeconds: time.Since(s.started).Seconds(),
+		Domains:       len(domains),
+		NextExpiry:    nextExpiry(domains),
+	}
+	s.writeJSON(w, http.StatusOK, st)
+}
+
+func (s *Server) writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	const op errors.Op = "adminapi/server.writeJSON"
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		errors.Log(s.Logger, errors.New(op, "write response", err))
+	}
+}
+
+// nextExpiry returns the expiry of the certificate expiring soonest, or nil
+// if none of the domains has a valid certificate.
+func nextExpiry(domains []acme.Domain) *CertificateExpiry {
+	var next *CertificateExpiry
+	for _, d := range domains {
+		if len(d.Certificate) == 0 {
+			continue
+		}
+		cert, err := certutil.ParseCertificate(d.Certificate, true)
+		if err != nil {
+			continue
+		}
+		if next == nil || cert.NotAfter.Before(next.NotAfter) {
+			next = &CertificateExpiry{Domain: d.Name, NotAfter: cert.NotAfter}
+		}
+	}
+	return next
+}
@@ -0,0 +1,128 @@
+package adminapi_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/fhofherr/acmeproxy/pkg/acme"
+	"github.com/fhofherr/acmeproxy/pkg/api/adminapi"
+	"github.com/fhofherr/acmeproxy/pkg/certutil"
+	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/fhofherr/acmeproxy/pkg/internal/netutil"
+	"github.com/fhofherr/acmeproxy/pkg/version"
+	"github.com/fhofherr/golf/log"
+	"github.com/stretchr/testify/assert"
+)
+
+type domainList []acme.Domain
+
+func (l domainList) ListDomains() ([]acme.Domain, error) {
+	return l, nil
+}
+
+func TestServer_Live(t *testing.T) {
+	addr := startServer(t, &adminapi.Server{Domains: domainList{}})
+
+	var body map[string]string
+	code := getJSON(t, fmt.Sprintf("http://%s/livez", addr), &body)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, map[string]string{"status": "ok"}, body)
+}
+
+func TestServer_Ready(t *testing.T) {
+	var dirErr error
+	server := &adminapi.Server{
+		ReadinessChecks: map[string]adminapi.Check{
+			"database": func(context.Context) error {
+				return nil
+			},
+			"acme-directory": func(context.Context) error {
+				return dirErr
+			},
+		},
+		Domains: domainList{},
+		Logger:  &log.TestLogger{},
+	}
+	addr := startServer(t, server)
+	url := fmt.Sprintf("http://%s/readyz", addr)
+
+	var body map[string]string
+	code := getJSON(t, url, &body)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, map[string]string{"database": "ok", "acme-directory": "ok"}, body)
+
+	dirErr = errors.New("directory unavailable")
+	body = nil
+	code = getJSON(t, url, &body)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, map[string]string{"database": "ok", "acme-directory": "failed"}, body)
+	server.Logger.(*log.TestLogger).AssertHasMatchingLogEntries(t, 1, func(e log.TestLogEntry) bool {
+		return e["level"] == "error" && e["message"] == "readiness check failed: acme-directory"
+	})
+}
+
+func TestServer_Status(t *testing.T) {
+	pk, err := certutil.NewPrivateKey(certutil.EC256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert := certutil.CreateSelfSignedCertificate(t, "www.example.com", pk)
+	var buf bytes.Buffer
+	if err := certutil.WriteCertificate(cert, &buf, true); err != nil {
+		t.Fatal(err)
+	}
+	domains := domainList{
+		{Name: "www.example.com", Certificate: buf.Bytes()},
+		{Name: "pending.example.com"},
+	}
+	oldTag := version.GitTag
+	version.GitTag = "v0.2.0"
+	defer func() { version.GitTag = oldTag }()
+
+	addr := startServer(t, &adminapi.Server{Domains: domains})
+
+	var st adminapi.Status
+	code := getJSON(t, fmt.Sprintf("http://%s/status", addr), &st)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "v0.2.0", st.Version.GitTag)
+	assert.True(t, st.UptimeSeconds >= 0)
+	assert.Equal(t, 2, st.Domains)
+	if assert.NotNil(t, st.NextExpiry) {
+		assert.Equal(t, "www.example.com", st.NextExpiry.Domain)
+		assert.True(t, cert.NotAfter.Equal(st.NextExpiry.NotAfter))
+	}
+}
+
+func TestServer_CannotServeWithoutDomainLister(t *testing.T) {
+	errC := make(chan error)
+	server := &adminapi.Server{}
+	go func(errC chan<- error) {
+		errC <- netutil.ListenAndServe(server)
+	}(errC)
+	assert.Error(t, netutil.GetErr(t, errC))
+}
+
+func startServer(t *testing.T, server *adminapi.Server) string {
+	addrC := make(chan string)
+	go netutil.ListenAndServe(server, netutil.NotifyAddr(addrC)) // nolint: errcheck
+	addr := netutil.GetAddr(t, addrC)
+	t.Cleanup(func() {
+		server.Shutdown(context.Background()) //nolint: errcheck
+	})
+	return addr
+}
+
+func getJSON(t *testing.T, url string, v interface{}) int {
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(v))
+	return resp.StatusCode
+}
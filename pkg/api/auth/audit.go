@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"time"
+
+	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/fhofherr/acmeproxy/pkg/security"
+)
+
+// AuditLog is an append-only trail of security-relevant operations.
+//
+// AuditLog persists its entries using its Repository. Entries recorded longer
+// than Retention ago, and the oldest entries exceeding MaxEntries, are removed
+// by CollectGarbage. A zero Retention or MaxEntries disables the respective
+// limit.
+type AuditLog struct {
+	Repository security.AuditRepository
+	Retention  time.Duration
+	MaxEntries int
+}
+
+// Record adds e to the audit log. It sets the time of e to the current time
+// if it is zero.
+func (l *AuditLog) Record(e security.AuditEntry) error {
+	const op errors.Op = "auth/auditLog.Record"
+
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	return errors.Wrap(l.Repository.RecordAuditEntry(e), op, "record audit entry")
+}
+
+// Query returns the entries matching f ordered by the time they were
+// recorded.
+func (l *AuditLog) Query(f security.AuditFilter) ([]security.AuditEntry, error) {
+	const op errors.Op = "auth/auditLog.Query"
+
+	if !f.Since.IsZero() && !f.Until.IsZero() && f.Until.Before(f.Since) {
+		return nil, errors.New(op, errors.InvalidArgument, "until before since")
+	}
+	if f.Limit < 0 {
+		return nil, errors.New(op, errors.InvalidArgument, "negative limit")
+	}
+	entries, err := l.Repository.ListAuditEntries(f)
+	return entries, errors.Wrap(err, op, "list audit entries")
+}
+
+// CollectGarbage removes the entries exceeding the retention limits of l at
+// now.
+func (l *AuditLog) CollectGarbage(now time.Time) error {
+	const op errors.Op = "auth/auditLog.CollectGarbage"
+
+	var before time.Time
+	if l.Retention > 0 {
+		before = now.Add(-l.Retention)
+	}
+	if before.IsZero() && l.MaxEntries <= 0 {
+		return nil
+	}
+	return errors.Wrap(l.Repository.PruneAuditEntries(before, l.MaxEntries), op, "prune audit entries")
+}
@@ -0,0 +1,69 @@
+package auth_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fhofherr/acmeproxy/pkg/api/auth"
+	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/fhofherr/acmeproxy/pkg/security"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditLogQuery(t *testing.T) {
+	log := &auth.AuditLog{Repository: &security.InMemoryAuditRepository{}}
+	now := time.Date(2019, 10, 18, 12, 0, 0, 0, time.UTC)
+	entries := []security.AuditEntry{
+		{Time: now.Add(-2 * time.Hour), Subject: "alice", Resource: security.AuditDomain("www.example.com")},
+		{Time: now.Add(-time.Hour), Subject: "bob", Resource: security.AuditDomain("www.example.com")},
+		{Time: now, Subject: "alice", Resource: security.AuditDomain("mail.example.com")},
+	}
+	for _, e := range entries {
+		assert.NoError(t, log.Record(e))
+	}
+
+	tests := []struct {
+		name     string
+		filter   security.AuditFilter
+		expected []security.AuditEntry
+	}{
+		{name: "all entries", expected: entries},
+		{name: "since", filter: security.AuditFilter{Since: now.Add(-time.Hour)}, expected: entries[1:]},
+		{name: "until", filter: security.AuditFilter{Until: now.Add(-time.Hour)}, expected: entries[:1]},
+		{name: "subject", filter: security.AuditFilter{Subject: "alice"}, expected: []security.AuditEntry{entries[0], entries[2]}},
+		{
+			name:     "resource",
+			filter:   security.AuditFilter{Resource: security.AuditDomain("www.example.com")},
+			expected: entries[:2],
+		},
+		{name: "limit", filter: security.AuditFilter{Limit: 1}, expected: entries[:1]},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := log.Query(tt.filter)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, actual)
+		})
+	}
+
+	_, err := log.Query(security.AuditFilter{Since: now, Until: now.Add(-time.Hour)})
+	errors.AssertMatches(t, errors.New(errors.InvalidArgument), err)
+}
+
+func TestAuditLogCollectGarbage(t *testing.T) {
+	repo := &security.InMemoryAuditRepository{}
+	log := &auth.AuditLog{Repository: repo, Retention: 24 * time.Hour, MaxEntries: 2}
+	now := time.Date(2019, 10, 18, 12, 0, 0, 0, time.UTC)
+	for _, d := range []time.Duration{48 * time.Hour, 3 * time.Hour, 2 * time.Hour, time.Hour} {
+		assert.NoError(t, log.Record(security.AuditEntry{Time: now.Add(-d)}))
+	}
+
+	assert.NoError(t, log.CollectGarbage(now))
+	entries, err := log.Query(security.AuditFilter{})
+	assert.NoError(t, err)
+	assert.Equal(t, []security.AuditEntry{
+		{Time: now.Add(-2 * time.Hour)},
+		{Time: now.Add(-time.Hour)},
+	}, entries)
+}
@@ -0,0 +1,196 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/google/uuid"
+)
+
+// Action represents an operation the bearer of a token wants to perform.
+type Action string
+
+const (
+	// RegisterUser registers a new acmeproxy user.
+	RegisterUser Action = "register-user"
+	// ReadDomain reads information about a domain, e.g. its OCSP response or
+	// its certificate.
+	ReadDomain Action = "read-domain"
+	// WriteDomain registers, modifies or removes a domain.
+	WriteDomain Action = "write-domain"
+	// RevokeToken revokes a token before it expires.
+	RevokeToken Action = "revoke-token"
+	// ManageUsers reads, modifies, or removes acmeproxy users, and transfers
+	// domains between them.
+	ManageUsers Action = "manage-users"
+	// ReadStatus reads the status of the acmeproxy server.
+	ReadStatus Action = "read-status"
+	// ReadAuditLog reads the audit log of the acmeproxy server.
+	ReadAuditLog Action = "read-audit-log"
+)
+
+// Resource identifies the object an Action is performed on.
+//
+// UserID is the ID of the user owning the resource. Domain is the name of the
+// domain the action is performed on. Domain is empty for actions which do not
+// target a specific domain.
+type Resource struct {
+	UserID uuid.UUID
+	Domain string
+}
+
+// Scope limits a token to specific resources.
+//
+// If Users is not empty the token is valid only for resources owned by one of
+// the listed users. If Domains is not empty the token is valid only for
+// resources whose domain matches one of the listed patterns. A pattern either
+// is a domain name, or a domain name whose first label is "*". The latter
+// matches any domain name with exactly one additional label in place of the
+// "*".
+type Scope struct {
+	Users   []uuid.UUID `json:"users,omitempty"`
+	Domains []string    `json:"domains,omitempty"`
+}
+
+// permission describes the actions a role grants.
+//
+// If ownOnly is true the actions may only be performed on resources owned by
+// the subject of the token.
+type permission struct {
+	actions map[Action]bool
+	ownOnly bool
+}
+
+var permissions = map[Role]permission{
+	Admin: {
+		actions: map[Action]bool{
+			RegisterUser: true, ReadDomain: true, WriteDomain: true, RevokeToken: true, ManageUsers: true,
+			ReadStatus: true, ReadAuditLog: true,
+		},
+	},
+	Operator: {
+		actions: map[Action]bool{ReadDomain: true, WriteDomain: true, ReadStatus: true},
+	},
+	User: {
+		actions: map[Action]bool{ReadDomain: true, WriteDomain: true},
+		ownOnly: true,
+	},
+	ReadOnly: {
+		actions: map[Action]bool{ReadDomain: true},
+		ownOnly: true,
+	},
+}
+
+// Authorize checks if the claims stored in ctx allow to perform action on res.
+//
+// Authorize returns an error of kind Unauthorized if ctx does not contain any
+// claims. It returns an error of kind PermissionDenied if none of the roles
+// in the claims grant the action, or if the scope of the claims does not
+// include res. Claims without any roles are treated as
+// if they had the User role. This only applies to tokens issued by acmeproxy.
+// Claims obtained from OIDC tokens or client certificates always have at
+// least one role.
+func Authorize(ctx context.Context, action Action, res Resource) error {
+	const op errors.Op = "auth/Authorize"
+
+	cs, ok := ClaimsFromContext(ctx)
+	if !ok || cs == nil {
+		return errors.New(op, errors.Unauthorized, "no claims in context")
+	}
+	return errors.Wrap(cs.Authorize(action, res), op)
+}
+
+// Authorize checks if the claims allow to perform action on res.
+//
+// See the package level Authorize function for details.
+func (c *Claims) Authorize(action Action, res Resource) error {
+	const op errors.Op = "auth/claims.Authorize"
+
+	if c == nil {
+		return errors.New(op, errors.Unauthorized, "no token present")
+	}
+	if !c.grants(action, res) {
+		msg := fmt.Sprintf("action not permitted: %s", action)
+		return errors.New(op, errors.PermissionDenied, msg)
+	}
+	if err := c.Scope.includes(res); err != nil {
+		return errors.New(op, err)
+	}
+	return nil
+}
+
+func (c *Claims) grants(action Action, res Resource) bool {
+	roles := c.Roles
+	if len(roles) == 0 {
+		roles = []Role{User}
+	}
+	for _, r := range roles {
+		p, ok := permissions[r]
+		if !ok || !p.actions[action] {
+			continue
+		}
+		if !p.ownOnly || c.isOwner(res) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Claims) isOwner(res Resource) bool {
+	subject, err := uuid.Parse(c.Subject)
+	if err != nil {
+		return false
+	}
+	return subject == res.UserID
+}
+
+func (s *Scope) includes(res Resource) error {
+	const op errors.Op = "auth/scope.includes"
+
+	if s == nil {
+		return nil
+	}
+	if len(s.Users) > 0 && !s.includesUser(res.UserID) {
+		return errors.New(op, errors.PermissionDenied, "user not in scope")
+	}
+	if len(s.Domains) > 0 && !s.includesDomain(res.Domain) {
+		msg := fmt.Sprintf("domain not in scope: %s", res.Domain)
+		return errors.New(op, errors.PermissionDenied, msg)
+	}
+	return nil
+}
+
+func (s *Scope) includesUser(userID uuid.UUID) bool {
+	for _, id := range s.Users {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Scope) includesDomain(domainName string) bool {
+	if domainName == "" {
+		return false
+	}
+	domainName = strings.ToLower(strings.TrimSuffix(domainName, "."))
+	for _, pattern := range s.Domains {
+		if matchDomainPattern(strings.ToLower(strings.TrimSuffix(pattern, ".")), domainName) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchDomainPattern(pattern, domainName string) bool {
+	if !strings.HasPrefix(pattern, "*.") {
+		return pattern == domainName
+	}
+	idx := strings.Index(domainName, ".")
+	if idx <= 0 {
+		return false
+	}
+	return domainName[idx+1:] == pattern[2:]
+}
@@ -0,0 +1,149 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/fhofherr/acmeproxy/pkg/api/auth"
+	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/google/uuid"
+)
+
+func TestAuthorize(t *testing.T) {
+	owner := uuid.Must(uuid.NewRandom())
+	other := uuid.Must(uuid.NewRandom())
+	ownDomain := auth.Resource{UserID: owner, Domain: "www.example.com"}
+	otherDomain := auth.Resource{UserID: other, Domain: "www.example.com"}
+
+	tests := []struct {
+		name   string
+		claims *auth.Claims
+		action auth.Action
+		res    auth.Resource
+		err    error
+	}{
+		{
+			name:   "no claims",
+			action: auth.ReadDomain,
+			res:    ownDomain,
+			err:    errors.New(errors.Unauthorized, "no claims in context"),
+		},
+		{
+			name:   "admin may register users",
+			claims: claimsFor(owner, nil, auth.Admin),
+			action: auth.RegisterUser,
+		},
+		{
+			name:   "operator may not register users",
+			claims: claimsFor(owner, nil, auth.Operator),
+			action: auth.RegisterUser,
+			err:    errors.New(errors.PermissionDenied, "action not permitted: register-user"),
+		},
+		{
+			name:   "operator may not manage users",
+			claims: claimsFor(owner, nil, auth.Operator),
+			action: auth.ManageUsers,
+			err:    errors.New(errors.PermissionDenied, "action not permitted: manage-users"),
+		},
+		{
+			name:   "operator may write domains of other users",
+			claims: claimsFor(owner, nil, auth.Operator),
+			action: auth.WriteDomain,
+			res:    otherDomain,
+		},
+		{
+			name:   "user may write own domains",
+			claims: claimsFor(owner, nil, auth.User),
+			action: auth.WriteDomain,
+			res:    ownDomain,
+		},
+		{
+			name:   "user may not read domains of other users",
+			claims: claimsFor(owner, nil, auth.User),
+			action: auth.ReadDomain,
+			res:    otherDomain,
+			err:    errors.New(errors.PermissionDenied),
+		},
+		{
+			name:   "claims without roles are treated as user",
+			claims: claimsFor(owner, nil),
+			action: auth.ReadDomain,
+			res:    ownDomain,
+		},
+		{
+			name:   "read-only may read own domains",
+			claims: claimsFor(owner, nil, auth.ReadOnly),
+			action: auth.ReadDomain,
+			res:    ownDomain,
+		},
+		{
+			name:   "read-only may not write own domains",
+			claims: claimsFor(owner, nil, auth.ReadOnly),
+			action: auth.WriteDomain,
+			res:    ownDomain,
+			err:    errors.New(errors.PermissionDenied, "action not permitted: write-domain"),
+		},
+		{
+			name:   "unknown roles grant nothing",
+			claims: claimsFor(owner, nil, auth.Role("unknown")),
+			action: auth.ReadDomain,
+			res:    ownDomain,
+			err:    errors.New(errors.PermissionDenied),
+		},
+		{
+			name:   "user outside of scope",
+			claims: claimsFor(owner, &auth.Scope{Users: []uuid.UUID{owner}}, auth.Operator),
+			action: auth.ReadDomain,
+			res:    otherDomain,
+			err:    errors.New(errors.PermissionDenied, "user not in scope"),
+		},
+		{
+			name:   "domain matches scope",
+			claims: claimsFor(owner, &auth.Scope{Domains: []string{"www.example.com"}}, auth.User),
+			action: auth.ReadDomain,
+			res:    auth.Resource{UserID: owner, Domain: "WWW.example.com."},
+		},
+		{
+			name:   "domain matches wildcard scope",
+			claims: claimsFor(owner, &auth.Scope{Domains: []string{"*.example.com"}}, auth.User),
+			action: auth.ReadDomain,
+			res:    ownDomain,
+		},
+		{
+			name:   "wildcard scope matches exactly one label",
+			claims: claimsFor(owner, &auth.Scope{Domains: []string{"*.example.com"}}, auth.User),
+			action: auth.ReadDomain,
+			res:    auth.Resource{UserID: owner, Domain: "a.b.example.com"},
+			err:    errors.New(errors.PermissionDenied, "domain not in scope: a.b.example.com"),
+		},
+		{
+			name:   "domain scoped token without domain",
+			claims: claimsFor(owner, &auth.Scope{Domains: []string{"www.example.com"}}, auth.Admin),
+			action: auth.RegisterUser,
+			err:    errors.New(errors.PermissionDenied),
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.claims != nil {
+				ctx = auth.AddClaimsToContext(ctx, tt.claims)
+			}
+			err := auth.Authorize(ctx, tt.action, tt.res)
+			errors.AssertMatches(t, tt.err, err)
+		})
+	}
+}
+
+func claimsFor(userID uuid.UUID, scope *auth.Scope, roles ...auth.Role) *auth.Claims {
+	return &auth.Claims{
+		StandardClaims: jwt.StandardClaims{
+			Subject: userID.String(),
+		},
+		Roles: roles,
+		Scope: scope,
+	}
+}
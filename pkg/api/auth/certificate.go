@@ -0,0 +1,138 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/google/uuid"
+)
+
+// CertificateIdentity maps client certificates to an acmeproxy user and its
+// roles. Each identity needs at least one role.
+//
+// A certificate matches the CertificateIdentity if its SHA-256 fingerprint is
+// equal to Fingerprint, if it contains URI as URI subject alternative name,
+// or if it contains DNSName as DNS subject alternative name. Exactly one of
+// Fingerprint, URI, and DNSName must be set. URI is usually a SPIFFE ID like
+// spiffe://example.com/host/web-1. Fingerprint is hex encoded and may contain
+// colons.
+type CertificateIdentity struct {
+	Fingerprint string    `json:"fingerprint,omitempty"`
+	URI         string    `json:"uri,omitempty"`
+	DNSName     string    `json:"dnsName,omitempty"`
+	UserID      uuid.UUID `json:"userID"`
+	Roles       []Role    `json:"roles,omitempty"`
+	Scope       *Scope    `json:"scope,omitempty"`
+}
+
+// CertificateMapper maps verified client certificates to Claims.
+//
+// If a certificate matches several Identities, identities matching the
+// fingerprint take precedence over identities matching an URI, which in turn
+// take precedence over identities matching a DNS name.
+type CertificateMapper struct {
+	Identities []CertificateIdentity `json:"identities"`
+}
+
+// LoadCertificateMapper reads a JSON encoded CertificateMapper from r.
+func LoadCertificateMapper(r io.Reader) (*CertificateMapper, error) {
+	const op errors.Op = "auth/LoadCertificateMapper"
+
+	var m CertificateMapper
+	if err := json.NewDecoder(r).Decode(&m); err != nil {
+		return nil, errors.New(op, errors.InvalidArgument, "decode certificate mapper", err)
+	}
+	for i, id := range m.Identities {
+		n := 0
+		for _, s := range []string{id.Fingerprint, id.URI, id.DNSName} {
+			if s != "" {
+				n++
+			}
+		}
+		if n != 1 {
+			msg := fmt.Sprintf("identity %d: exactly one of fingerprint, uri, and dnsName required", i)
+			return nil, errors.New(op, errors.InvalidArgument, msg)
+		}
+		if id.UserID == uuid.Nil {
+			return nil, errors.New(op, errors.InvalidArgument, fmt.Sprintf("identity %d: no user id", i))
+		}
+		if len(id.Roles) == 0 {
+			return nil, errors.New(op, errors.InvalidArgument, fmt.Sprintf("identity %d: no roles", i))
+		}
+		for _, r := range id.Roles {
+			if _, err := ParseRole(string(r)); err != nil {
+				return nil, errors.New(op, fmt.Sprintf("identity %d", i), err)
+			}
+		}
+	}
+	return &m, nil
+}
+
+// Claims returns the claims of the user cert belongs to. The caller must have
+// verified cert before.
+//
+// Claims returns an error of kind Unauthorized if cert matches none of the
+// Identities of m, or if the matching identity has no roles.
+func (m *CertificateMapper) Claims(cert *x509.Certificate) (*Claims, error) {
+	const op errors.Op = "auth/certificateMapper.Claims"
+
+	id, ok := m.match(cert)
+	if !ok {
+		return nil, errors.New(op, errors.Unauthorized, "unknown client certificate")
+	}
+	// Claims without roles would be granted the rights of the User role.
+	if len(id.Roles) == 0 {
+		return nil, errors.New(op, errors.Unauthorized, "no roles for client certificate")
+	}
+	return &Claims{
+		StandardClaims: jwt.StandardClaims{
+			Subject:   id.UserID.String(),
+			ExpiresAt: cert.NotAfter.Unix(),
+			NotBefore: cert.NotBefore.Unix(),
+		},
+		Roles: id.Roles,
+		Scope: id.Scope,
+	}, nil
+}
+
+func (m *CertificateMapper) match(cert *x509.Certificate) (CertificateIdentity, bool) {
+	sum := sha256.Sum256(cert.Raw)
+	fingerprint := hex.EncodeToString(sum[:])
+	for _, id := range m.Identities {
+		if id.Fingerprint != "" && normalizeFingerprint(id.Fingerprint) == fingerprint {
+			return id, true
+		}
+	}
+	for _, id := range m.Identities {
+		if id.URI == "" {
+			continue
+		}
+		for _, uri := range cert.URIs {
+			if uri.String() == id.URI {
+				return id, true
+			}
+		}
+	}
+	for _, id := range m.Identities {
+		if id.DNSName == "" {
+			continue
+		}
+		for _, name := range cert.DNSNames {
+			if strings.EqualFold(name, id.DNSName) {
+				return id, true
+			}
+		}
+	}
+	return CertificateIdentity{}, false
+}
+
+func normalizeFingerprint(fp string) string {
+	return strings.ToLower(strings.Replace(fp, ":", "", -1))
+}
@@ -0,0 +1,155 @@
+package auth_test
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fhofherr/acmeproxy/pkg/api/auth"
+	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCertificateMapper_Claims(t *testing.T) {
+	spiffeID, err := url.Parse("spiffe://example.com/host/web-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert := &x509.Certificate{
+		Raw:       []byte("certificate"),
+		DNSNames:  []string{"web-1.example.com"},
+		URIs:      []*url.URL{spiffeID},
+		NotBefore: time.Date(2019, 10, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:  time.Date(2019, 12, 31, 0, 0, 0, 0, time.UTC),
+	}
+	sum := sha256.Sum256(cert.Raw)
+	fingerprint := hex.EncodeToString(sum[:])
+
+	fingerprintUser := uuid.Must(uuid.NewRandom())
+	uriUser := uuid.Must(uuid.NewRandom())
+	dnsUser := uuid.Must(uuid.NewRandom())
+
+	tests := []struct {
+		name       string
+		identities []auth.CertificateIdentity
+		userID     uuid.UUID
+		roles      []auth.Role
+		err        error
+	}{
+		{
+			name: "fingerprint takes precedence",
+			identities: []auth.CertificateIdentity{
+				{DNSName: "web-1.example.com", UserID: dnsUser, Roles: []auth.Role{auth.User}},
+				{URI: spiffeID.String(), UserID: uriUser, Roles: []auth.Role{auth.User}},
+				{Fingerprint: strings.ToUpper(fingerprint), UserID: fingerprintUser, Roles: []auth.Role{auth.Operator}},
+			},
+			userID: fingerprintUser,
+			roles:  []auth.Role{auth.Operator},
+		},
+		{
+			name: "uri takes precedence over dns name",
+			identities: []auth.CertificateIdentity{
+				{DNSName: "WEB-1.example.com", UserID: dnsUser, Roles: []auth.Role{auth.User}},
+				{URI: spiffeID.String(), UserID: uriUser, Roles: []auth.Role{auth.ReadOnly}},
+			},
+			userID: uriUser,
+			roles:  []auth.Role{auth.ReadOnly},
+		},
+		{
+			name: "matches dns name",
+			identities: []auth.CertificateIdentity{
+				{URI: "spiffe://example.com/host/web-2", UserID: uriUser, Roles: []auth.Role{auth.User}},
+				{DNSName: "WEB-1.example.com", UserID: dnsUser, Roles: []auth.Role{auth.User}},
+			},
+			userID: dnsUser,
+			roles:  []auth.Role{auth.User},
+		},
+		{
+			name: "identity without roles",
+			identities: []auth.CertificateIdentity{
+				{DNSName: "web-1.example.com", UserID: dnsUser},
+			},
+			err: errors.New(errors.Unauthorized, "no roles for client certificate"),
+		},
+		{
+			name: "unknown certificate",
+			identities: []auth.CertificateIdentity{
+				{DNSName: "web-2.example.com", UserID: dnsUser},
+			},
+			err: errors.New(errors.Unauthorized, "unknown client certificate"),
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			m := &auth.CertificateMapper{Identities: tt.identities}
+			claims, err := m.Claims(cert)
+			errors.AssertMatches(t, tt.err, err)
+			if tt.err != nil {
+				return
+			}
+			assert.Equal(t, tt.userID.String(), claims.Subject)
+			assert.Equal(t, tt.roles, claims.Roles)
+			assert.Equal(t, cert.NotAfter.Unix(), claims.ExpiresAt)
+		})
+	}
+}
+
+func TestLoadCertificateMapper(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		err  error
+	}{
+		{
+			name: "valid identities",
+			json: `{"identities": [
+				{"uri": "spiffe://example.com/host/web-1", "userID": "a4f3b6a0-2c7e-4c1e-9a53-1b4f1c7c2d55", "roles": ["operator"]},
+				{"fingerprint": "ab:cd", "userID": "a4f3b6a0-2c7e-4c1e-9a53-1b4f1c7c2d55", "roles": ["read-only"]}
+			]}`,
+		},
+		{
+			name: "missing roles",
+			json: `{"identities": [{"dnsName": "web-1.example.com", "userID": "a4f3b6a0-2c7e-4c1e-9a53-1b4f1c7c2d55"}]}`,
+			err:  errors.New(errors.InvalidArgument, "identity 0: no roles"),
+		},
+		{
+			name: "unknown role",
+			json: `{"identities": [
+				{"dnsName": "web-1.example.com", "userID": "a4f3b6a0-2c7e-4c1e-9a53-1b4f1c7c2d55", "roles": ["root"]}
+			]}`,
+			err: errors.New(errors.InvalidArgument),
+		},
+		{
+			name: "several matchers",
+			json: `{"identities": [
+				{"uri": "spiffe://example.com/host/web-1", "dnsName": "web-1.example.com", "userID": "a4f3b6a0-2c7e-4c1e-9a53-1b4f1c7c2d55"}
+			]}`,
+			err: errors.New(errors.InvalidArgument, "identity 0: exactly one of fingerprint, uri, and dnsName required"),
+		},
+		{
+			name: "missing user id",
+			json: `{"identities": [{"dnsName": "web-1.example.com"}]}`,
+			err:  errors.New(errors.InvalidArgument, "identity 0: no user id"),
+		},
+		{
+			name: "invalid json",
+			json: `{"identities": `,
+			err:  errors.New(errors.InvalidArgument),
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := auth.LoadCertificateMapper(strings.NewReader(tt.json))
+			errors.AssertMatches(t, tt.err, err)
+		})
+	}
+}
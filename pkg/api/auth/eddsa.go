@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"crypto/ed25519"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/fhofherr/acmeproxy/pkg/errors"
+)
+
+// signingMethodEdDSA implements the EdDSA signing method using Ed25519 keys
+// as defined in RFC 8037. jwt-go does not support EdDSA on its own.
+var signingMethodEdDSA = &eddsaSigningMethod{}
+
+func init() {
+	jwt.RegisterSigningMethod(signingMethodEdDSA.Alg(), func() jwt.SigningMethod {
+		return signingMethodEdDSA
+	})
+}
+
+type eddsaSigningMethod struct{}
+
+func (m *eddsaSigningMethod) Alg() string {
+	return "EdDSA"
+}
+
+func (m *eddsaSigningMethod) Verify(signingString, signature string, key interface{}) error {
+	const op errors.Op = "auth/eddsaSigningMethod.Verify"
+
+	pk, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return jwt.ErrInvalidKeyType
+	}
+	sig, err := jwt.DecodeSegment(signature)
+	if err != nil {
+		return errors.New(op, "decode signature", err)
+	}
+	if !ed25519.Verify(pk, []byte(signingString), sig) {
+		return jwt.ErrSignatureInvalid
+	}
+	return nil
+}
+
+func (m *eddsaSigningMethod) Sign(signingString string, key interface{}) (string, error) {
+	pk, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return "", jwt.ErrInvalidKeyType
+	}
+	return jwt.EncodeSegment(ed25519.Sign(pk, []byte(signingString))), nil
+}
@@ -0,0 +1,179 @@
+package auth
+
+import (
+	"crypto"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fhofherr/acmeproxy/pkg/certutil"
+	"github.com/fhofherr/acmeproxy/pkg/errors"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+// KeySet contains the public keys used to verify tokens. Each key is
+// identified by its key ID (kid).
+//
+// The keys are loaded from Path. If Path is a directory KeySet loads every
+// PEM encoded public key with the extension ".pem" in the directory. The file
+// name without the extension is the key ID. Otherwise KeySet expects Path to
+// be a JSON Web Key Set (JWKS) document.
+//
+// KeySet may be reloaded at any time by calling Reload. Keys which are not
+// contained in Path anymore are retired. Retired keys remain usable for
+// GracePeriod after they were retired. This allows to rotate the key used to
+// sign tokens without rejecting tokens signed with the previous key.
+//
+// KeySet is safe for concurrent use. The zero value of KeySet contains no
+// keys.
+type KeySet struct {
+	Path        string
+	GracePeriod time.Duration
+
+	mu   sync.RWMutex
+	keys map[string]keySetEntry
+	now  func() time.Time
+}
+
+type keySetEntry struct {
+	Key       crypto.PublicKey
+	RetiredAt time.Time
+}
+
+// Reload reads the keys from ks.Path and replaces the keys of ks.
+func (ks *KeySet) Reload() error {
+	const op errors.Op = "auth/keySet.Reload"
+
+	fi, err := os.Stat(ks.Path)
+	if err != nil {
+		return errors.New(op, err)
+	}
+	var keys map[string]crypto.PublicKey
+	if fi.IsDir() {
+		keys, err = readPEMKeyDir(ks.Path)
+	} else {
+		keys, err = readJWKSFile(ks.Path)
+	}
+	if err != nil {
+		return errors.New(op, fmt.Sprintf("read keys: %s", ks.Path), err)
+	}
+	ks.Replace(keys)
+	return nil
+}
+
+// Replace replaces the keys contained in ks with keys.
+//
+// Keys contained in ks but not in keys are retired. Retired keys are removed
+// once their grace period passed.
+func (ks *KeySet) Replace(keys map[string]crypto.PublicKey) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	now := ks.timeNow()
+	newKeys := make(map[string]keySetEntry, len(keys))
+	for kid, e := range ks.keys {
+		if _, ok := keys[kid]; ok {
+			continue
+		}
+		if e.RetiredAt.IsZero() {
+			e.RetiredAt = now
+		}
+		if !ks.expired(e, now) {
+			newKeys[kid] = e
+		}
+	}
+	for kid, k := range keys {
+		newKeys[kid] = keySetEntry{Key: k}
+	}
+	ks.keys = newKeys
+}
+
+// Key returns the key with the passed kid.
+//
+// Key returns an error of kind Unauthorized if ks does not contain a key with
+// the passed kid, or if the grace period of the key passed.
+func (ks *KeySet) Key(kid string) (crypto.PublicKey, error) {
+	const op errors.Op = "auth/keySet.Key"
+
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	e, ok := ks.keys[kid]
+	if !ok || ks.expired(e, ks.timeNow()) {
+		return nil, errors.New(op, errors.Unauthorized, fmt.Sprintf("unknown key id: %s", kid))
+	}
+	return e.Key, nil
+}
+
+func (ks *KeySet) expired(e keySetEntry, now time.Time) bool {
+	return !e.RetiredAt.IsZero() && !now.Before(e.RetiredAt.Add(ks.GracePeriod))
+}
+
+func (ks *KeySet) timeNow() time.Time {
+	if ks.now != nil {
+		return ks.now()
+	}
+	return time.Now()
+}
+
+// ReadJWKS reads the public keys contained in the JSON Web Key Set read from
+// r. Every key in the set must have a key ID.
+func ReadJWKS(r io.Reader) (map[string]crypto.PublicKey, error) {
+	const op errors.Op = "auth/ReadJWKS"
+	var jwks jose.JSONWebKeySet
+
+	if err := json.NewDecoder(r).Decode(&jwks); err != nil {
+		return nil, errors.New(op, errors.InvalidArgument, "decode jwks", err)
+	}
+	keys := make(map[string]crypto.PublicKey, len(jwks.Keys))
+	for _, k := range jwks.Keys {
+		if k.KeyID == "" {
+			return nil, errors.New(op, errors.InvalidArgument, "key without key id")
+		}
+		if !k.Valid() || !k.IsPublic() {
+			return nil, errors.New(op, errors.InvalidArgument, fmt.Sprintf("not a valid public key: %s", k.KeyID))
+		}
+		keys[k.KeyID] = k.Key
+	}
+	return keys, nil
+}
+
+func readJWKSFile(path string) (map[string]crypto.PublicKey, error) {
+	const op errors.Op = "auth/readJWKSFile"
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.New(op, err)
+	}
+	defer f.Close()
+	keys, err := ReadJWKS(f)
+	return keys, errors.Wrap(err, op)
+}
+
+func readPEMKeyDir(dir string) (map[string]crypto.PublicKey, error) {
+	const op errors.Op = "auth/readPEMKeyDir"
+
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, errors.New(op, err)
+	}
+	keys := make(map[string]crypto.PublicKey, len(fis))
+	for _, fi := range fis {
+		if fi.IsDir() || filepath.Ext(fi.Name()) != ".pem" {
+			continue
+		}
+		kid := strings.TrimSuffix(fi.Name(), ".pem")
+		key, err := certutil.ReadPublicKeyFromFile(filepath.Join(dir, fi.Name()), true)
+		if err != nil {
+			return nil, errors.New(op, fmt.Sprintf("read key: %s", kid), err)
+		}
+		keys[kid] = key
+	}
+	return keys, nil
+}
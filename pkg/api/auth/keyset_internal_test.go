@@ -0,0 +1,41 @@
+package auth
+
+import (
+	"crypto"
+	"testing"
+	"time"
+
+	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeySetRetiresKeysAfterGracePeriod(t *testing.T) {
+	now := time.Date(2019, 10, 18, 12, 0, 0, 0, time.UTC)
+	ks := &KeySet{
+		GracePeriod: time.Hour,
+		now: func() time.Time {
+			return now
+		},
+	}
+	oldKey := crypto.PublicKey("old key")
+	newKey := crypto.PublicKey("new key")
+
+	ks.Replace(map[string]crypto.PublicKey{"old": oldKey})
+	ks.Replace(map[string]crypto.PublicKey{"new": newKey})
+
+	now = now.Add(59 * time.Minute)
+	key, err := ks.Key("old")
+	assert.NoError(t, err)
+	assert.Equal(t, oldKey, key)
+
+	// Reloading the key set does not extend the grace period of retired
+	// keys.
+	ks.Replace(map[string]crypto.PublicKey{"new": newKey})
+
+	now = now.Add(time.Minute)
+	_, err = ks.Key("old")
+	errors.AssertMatches(t, errors.New(errors.Unauthorized), err)
+	key, err = ks.Key("new")
+	assert.NoError(t, err)
+	assert.Equal(t, newKey, key)
+}
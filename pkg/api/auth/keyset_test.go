@@ -0,0 +1,131 @@
+package auth_test
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/fhofherr/acmeproxy/pkg/api/auth"
+	"github.com/fhofherr/acmeproxy/pkg/certutil"
+	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/fhofherr/acmeproxy/pkg/internal/testsupport"
+	"github.com/stretchr/testify/assert"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+func TestReadJWKS(t *testing.T) {
+	key1 := newTestKey(t)
+	key2 := newTestKey(t)
+	jwks := jose.JSONWebKeySet{
+		Keys: []jose.JSONWebKey{
+			{Key: publicKey(t, key1), KeyID: "key-1", Algorithm: "ES256", Use: "sig"},
+			{Key: publicKey(t, key2), KeyID: "key-2", Algorithm: "ES256", Use: "sig"},
+		},
+	}
+	bs, err := json.Marshal(jwks)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := auth.ReadJWKS(bytes.NewReader(bs))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]crypto.PublicKey{
+		"key-1": publicKey(t, key1),
+		"key-2": publicKey(t, key2),
+	}, keys)
+
+	_, err = auth.ReadJWKS(bytes.NewReader([]byte(`{"keys": [{"kty": "EC"}]}`)))
+	errors.AssertMatches(t, errors.New(errors.InvalidArgument), err)
+}
+
+func TestReloadKeySetFromPEMDirectory(t *testing.T) {
+	dir, cleanup := testsupport.CreateTmpDir(t)
+	defer cleanup()
+
+	key := newTestKey(t)
+	writePublicKey(t, filepath.Join(dir, "key-1.pem"), publicKey(t, key))
+	if err := ioutil.WriteFile(filepath.Join(dir, "README"), []byte("ignored"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	ks := &auth.KeySet{Path: dir}
+	err := ks.Reload()
+	assert.NoError(t, err)
+	actual, err := ks.Key("key-1")
+	assert.NoError(t, err)
+	assert.Equal(t, publicKey(t, key), actual)
+	_, err = ks.Key("README")
+	errors.AssertMatches(t, errors.New(errors.Unauthorized), err)
+}
+
+func TestParseTokenWithKeySet(t *testing.T) {
+	key := newTestKey(t)
+	ks := &auth.KeySet{}
+	ks.Replace(map[string]crypto.PublicKey{"key-1": publicKey(t, key)})
+	claims := &auth.Claims{
+		StandardClaims: jwt.StandardClaims{
+			Subject: "jdoe",
+		},
+	}
+
+	tests := []struct {
+		name string
+		kid  string
+		err  error
+	}{
+		{
+			name: "known key id",
+			kid:  "key-1",
+		},
+		{
+			name: "unknown key id",
+			kid:  "key-2",
+			err:  errors.New(errors.Unauthorized, "unknown key id: key-2"),
+		},
+		{
+			name: "missing key id",
+			err:  errors.New(errors.Unauthorized, "missing key id"),
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := auth.NewTokenWithKeyID(claims, auth.ES256, key, tt.kid)
+			if err != nil {
+				t.Fatal(err)
+			}
+			actual, err := auth.ParseTokenWithKeySet(token, ks)
+			errors.AssertMatches(t, tt.err, err)
+			if tt.err != nil {
+				return
+			}
+			assert.Equal(t, claims, actual)
+		})
+	}
+}
+
+func newTestKey(t *testing.T) crypto.PrivateKey {
+	key, err := certutil.NewPrivateKey(certutil.EC256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func writePublicKey(t *testing.T, path string, key crypto.PublicKey) {
+	bs, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bs = pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: bs})
+	if err := ioutil.WriteFile(path, bs, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
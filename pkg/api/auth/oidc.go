@@ -0,0 +1,349 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/fhofherr/acmeproxy/pkg/errors"
+)
+
+// DefaultOIDCRefreshInterval is the interval after which OIDCProvider
+// reloads the discovery document and the keys of the OIDC issuer.
+const DefaultOIDCRefreshInterval = time.Hour
+
+// oidcMinRefreshInterval limits how often OIDCProvider reloads the keys of
+// the issuer when it encounters tokens signed with an unknown key.
+const oidcMinRefreshInterval = time.Minute
+
+// oidcRefreshTimeout limits the duration of a reload of the discovery
+// document and the keys not started by a call to Refresh.
+const oidcRefreshTimeout = 10 * time.Second
+
+// oidcHTTPClient is used by OIDCProvider if it has no HTTPClient.
+var oidcHTTPClient = &http.Client{Timeout: oidcRefreshTimeout}
+
+// OIDCProvider validates ID and access tokens issued by an OpenID Connect
+// issuer.
+//
+// OIDCProvider obtains the keys of the issuer from the JWKS referenced in the
+// issuer's discovery document. It caches the keys and reloads them every
+// RefreshInterval, or if it encounters a token signed with an unknown key.
+// Reloads caused by an expired RefreshInterval run in the background; the
+// cached keys remain in use until they complete. Only tokens signed with an
+// unknown key wait for the reload.
+//
+// Issuer is the URL of the issuer. It must be equal to the "iss" claim of the
+// tokens. If ClientID is not empty the "aud" claim of the tokens must contain
+// it.
+//
+// UserIDClaim is the name of the claim containing the ID of the acmeproxy
+// user. It defaults to "sub". GroupsClaim is the name of the claim containing
+// the groups of the bearer of the token. It defaults to "groups".
+// RoleMappings maps the groups to acmeproxy roles. Groups without mapping are
+// ignored. Tokens none of whose groups map to a role grant no access and are
+// rejected.
+//
+// OIDCProvider is safe for concurrent use.
+type OIDCProvider struct {
+	Issuer          string
+	ClientID        string
+	UserIDClaim     string
+	GroupsClaim     string
+	RoleMappings    map[string]Role
+	RefreshInterval time.Duration // DefaultOIDCRefreshInterval if zero.
+	HTTPClient      *http.Client  // A client with a timeout of 10s if nil.
+
+	mu          sync.Mutex
+	keys        KeySet
+	refreshedAt time.Time
+	running     *oidcRefresh // nil if no reload is running.
+	now         func() time.Time
+}
+
+// oidcRefresh is a reload of the discovery document and the keys running in
+// the background. Err is set before done is closed.
+type oidcRefresh struct {
+	done chan struct{}
+	err  error
+}
+
+type oidcDiscoveryDocument struct {
+	Issuer  string `json:"issuer"`
+	JWKSURI string `json:"jwks_uri"`
+}
+
+// ParseToken parses the passed token and verifies its signature using the
+// keys of the issuer. It returns the acmeproxy claims derived from the claims
+// of the token.
+//
+// ParseToken has the signature of a grpcapi.TokenParser.
+func (p *OIDCProvider) ParseToken(token string) (*Claims, error) {
+	const op errors.Op = "auth/oidcProvider.ParseToken"
+
+	var mc jwt.MapClaims
+	_, err := jwt.ParseWithClaims(token, &mc, func(tok *jwt.Token) (interface{}, error) {
+		kid, _ := tok.Header["kid"].(string)
+		if kid == "" {
+			return nil, errors.New(op, errors.Unauthorized, "missing key id")
+		}
+		key, err := p.key(kid)
+		if err != nil {
+			return nil, err
+		}
+		if err := methodOk(tok.Method, key); err != nil {
+			return nil, err
+		}
+		return key, nil
+	})
+	if err := handleValidationError(op, err); err != nil {
+		return nil, errors.New(op, err)
+	}
+	claims, err := p.mapClaims(mc)
+	return claims, errors.Wrap(err, op)
+}
+
+// Refresh reloads the discovery document and the keys of the issuer.
+func (p *OIDCProvider) Refresh(ctx context.Context) error {
+	const op errors.Op = "auth/oidcProvider.Refresh"
+
+	p.mu.Lock()
+	p.refreshedAt = p.timeNow()
+	p.mu.Unlock()
+	return errors.Wrap(p.refresh(ctx), op)
+}
+
+func (p *OIDCProvider) key(kid string) (interface{}, error) {
+	const op errors.Op = "auth/oidcProvider.key"
+
+	p.mu.Lock()
+	now := p.timeNow()
+	var r *oidcRefresh
+	if p.refreshedAt.IsZero() {
+		// There are no cached keys yet. Wait for them to be loaded.
+		r = p.startRefresh()
+	} else if now.Sub(p.refreshedAt) >= p.refreshInterval() {
+		p.startRefresh()
+	}
+	p.mu.Unlock()
+	if r != nil {
+		<-r.done
+		if r.err != nil {
+			return nil, errors.New(op, r.err)
+		}
+	}
+
+	key, err := p.keys.Key(kid)
+	if err == nil {
+		return key, nil
+	}
+	p.mu.Lock()
+	r = p.running
+	if r == nil && now.Sub(p.refreshedAt) >= oidcMinRefreshInterval {
+		r = p.startRefresh()
+	}
+	p.mu.Unlock()
+	if r == nil {
+		return nil, errors.Wrap(err, op)
+	}
+	<-r.done
+	if r.err != nil {
+		return nil, errors.New(op, r.err)
+	}
+	key, err = p.keys.Key(kid)
+	return key, errors.Wrap(err, op)
+}
+
+// startRefresh starts reloading the discovery document and the keys in the
+// background unless a reload is already running. It returns the running
+// reload. The caller must hold p.mu.
+func (p *OIDCProvider) startRefresh() *oidcRefresh {
+	if p.running != nil {
+		return p.running
+	}
+	// Remember the attempt even if it fails. Otherwise every token presented
+	// while the issuer is unavailable would trigger another attempt.
+	p.refreshedAt = p.timeNow()
+	r := &oidcRefresh{done: make(chan struct{})}
+	p.running = r
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), oidcRefreshTimeout)
+		defer cancel()
+
+		r.err = p.refresh(ctx)
+		p.mu.Lock()
+		p.running = nil
+		p.mu.Unlock()
+		close(r.done)
+	}()
+	return r
+}
+
+func (p *OIDCProvider) refresh(ctx context.Context) error {
+	const op errors.Op = "auth/oidcProvider.refresh"
+
+	var doc oidcDiscoveryDocument
+	discoveryURL := strings.TrimSuffix(p.Issuer, "/") + "/.well-known/openid-configuration"
+	body, err := p.get(ctx, discoveryURL)
+	if err != nil {
+		return errors.New(op, "get discovery document", err)
+	}
+	defer body.Close()
+	if err := json.NewDecoder(body).Decode(&doc); err != nil {
+		return errors.New(op, "decode discovery document", err)
+	}
+	if doc.Issuer != p.Issuer {
+		return errors.New(op, fmt.Sprintf("issuer mismatch: %s", doc.Issuer))
+	}
+	if doc.JWKSURI == "" {
+		return errors.New(op, "discovery document without jwks_uri")
+	}
+	jwks, err := p.get(ctx, doc.JWKSURI)
+	if err != nil {
+		return errors.New(op, "get jwks", err)
+	}
+	defer jwks.Close()
+	keys, err := ReadJWKS(jwks)
+	if err != nil {
+		return errors.New(op, err)
+	}
+	p.keys.Replace(keys)
+	return nil
+}
+
+func (p *OIDCProvider) get(ctx context.Context, url string) (io.ReadCloser, error) {
+	const op errors.Op = "auth/oidcProvider.get"
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, errors.New(op, err)
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := p.httpClient().Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, errors.New(op, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, errors.New(op, fmt.Sprintf("unexpected status: %d", resp.StatusCode))
+	}
+	return resp.Body, nil
+}
+
+func (p *OIDCProvider) mapClaims(mc jwt.MapClaims) (*Claims, error) {
+	const op errors.Op = "auth/oidcProvider.mapClaims"
+
+	if iss, _ := mc["iss"].(string); iss != p.Issuer {
+		return nil, errors.New(op, errors.Unauthorized, fmt.Sprintf("issuer mismatch: %s", iss))
+	}
+	if _, ok := mc["exp"]; !ok {
+		return nil, errors.New(op, errors.Unauthorized, "missing claim: exp")
+	}
+	aud := stringsClaim(mc["aud"])
+	if p.ClientID != "" && !containsString(aud, p.ClientID) {
+		return nil, errors.New(op, errors.Unauthorized, "audience mismatch")
+	}
+	userIDClaim := p.UserIDClaim
+	if userIDClaim == "" {
+		userIDClaim = "sub"
+	}
+	userID, _ := mc[userIDClaim].(string)
+	if userID == "" {
+		return nil, errors.New(op, errors.Unauthorized, fmt.Sprintf("missing claim: %s", userIDClaim))
+	}
+	groupsClaim := p.GroupsClaim
+	if groupsClaim == "" {
+		groupsClaim = "groups"
+	}
+
+	claims := &Claims{
+		StandardClaims: jwt.StandardClaims{
+			Subject:   userID,
+			Issuer:    p.Issuer,
+			ExpiresAt: int64Claim(mc["exp"]),
+			IssuedAt:  int64Claim(mc["iat"]),
+			NotBefore: int64Claim(mc["nbf"]),
+		},
+	}
+	claims.Id, _ = mc["jti"].(string)
+	if len(aud) > 0 {
+		claims.Audience = aud[0]
+	}
+	for _, g := range stringsClaim(mc[groupsClaim]) {
+		if r, ok := p.RoleMappings[g]; ok {
+			claims.Roles = append(claims.Roles, r)
+		}
+	}
+	// Claims without roles would be granted the rights of the User role.
+	if len(claims.Roles) == 0 {
+		return nil, errors.New(op, errors.Unauthorized, "no role mapped to groups")
+	}
+	return claims, nil
+}
+
+func (p *OIDCProvider) refreshInterval() time.Duration {
+	if p.RefreshInterval > 0 {
+		return p.RefreshInterval
+	}
+	return DefaultOIDCRefreshInterval
+}
+
+func (p *OIDCProvider) httpClient() *http.Client {
+	if p.HTTPClient != nil {
+		return p.HTTPClient
+	}
+	return oidcHTTPClient
+}
+
+func (p *OIDCProvider) timeNow() time.Time {
+	if p.now != nil {
+		return p.now()
+	}
+	return time.Now()
+}
+
+// stringsClaim returns the value of a claim which may either be a single
+// string or a list of strings.
+func stringsClaim(v interface{}) []string {
+	switch vv := v.(type) {
+	case string:
+		return []string{vv}
+	case []interface{}:
+		ss := make([]string, 0, len(vv))
+		for _, e := range vv {
+			if s, ok := e.(string); ok {
+				ss = append(ss, s)
+			}
+		}
+		return ss
+	default:
+		return nil
+	}
+}
+
+func int64Claim(v interface{}) int64 {
+	switch vv := v.(type) {
+	case float64:
+		return int64(vv)
+	case json.Number:
+		n, _ := vv.Int64()
+		return n
+	default:
+		return 0
+	}
+}
+
+func containsString(ss []string, s string) bool {
+	for _, e := range ss {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
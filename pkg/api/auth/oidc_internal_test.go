@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOIDCProvider_CachesKeys(t *testing.T) {
+	issuer := NewOIDCIssuer(t)
+	defer issuer.Close()
+
+	now := time.Now()
+	p := &OIDCProvider{
+		Issuer:          issuer.URL,
+		RoleMappings:    map[string]Role{"acmeproxy-users": User},
+		RefreshInterval: time.Hour,
+		now:             func() time.Time { return now },
+	}
+	newToken := func() string {
+		return issuer.IssueToken(t, jwt.MapClaims{
+			"sub":    "jdoe",
+			"exp":    now.Add(24 * time.Hour).Unix(),
+			"groups": []string{"acmeproxy-users"},
+		})
+	}
+
+	_, err := p.ParseToken(newToken())
+	assert.NoError(t, err)
+	_, err = p.ParseToken(newToken())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, issuer.JWKSRequests(), "keys were not cached")
+
+	// Tokens signed with an unknown key trigger a refresh, but not more
+	// often than oidcMinRefreshInterval.
+	issuer.RotateKey(t)
+	_, err = p.ParseToken(newToken())
+	assert.Error(t, err)
+	assert.Equal(t, 1, issuer.JWKSRequests())
+
+	now = now.Add(oidcMinRefreshInterval)
+	_, err = p.ParseToken(newToken())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, issuer.JWKSRequests(), "keys were not refreshed for unknown key id")
+
+	// Keys are refreshed in the background once the refresh interval passed.
+	// The cached keys remain in use meanwhile.
+	now = now.Add(time.Hour)
+	_, err = p.ParseToken(newToken())
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return issuer.JWKSRequests() == 3
+	}, time.Second, 10*time.Millisecond, "keys were not refreshed after refresh interval")
+}
+
+func TestOIDCProvider_UsesCachedKeysWhileIssuerUnavailable(t *testing.T) {
+	issuer := NewOIDCIssuer(t)
+
+	now := time.Now()
+	p := &OIDCProvider{
+		Issuer:          issuer.URL,
+		RoleMappings:    map[string]Role{"acmeproxy-users": User},
+		RefreshInterval: time.Hour,
+		now:             func() time.Time { return now },
+	}
+	token := issuer.IssueToken(t, jwt.MapClaims{
+		"sub":    "jdoe",
+		"exp":    now.Add(24 * time.Hour).Unix(),
+		"groups": []string{"acmeproxy-users"},
+	})
+
+	_, err := p.ParseToken(token)
+	assert.NoError(t, err)
+
+	issuer.Close()
+	now = now.Add(time.Hour)
+	_, err = p.ParseToken(token)
+	assert.NoError(t, err)
+}
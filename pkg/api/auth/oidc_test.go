@@ -0,0 +1,165 @@
+package auth_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/fhofherr/acmeproxy/pkg/api/auth"
+	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOIDCProvider_ParseToken(t *testing.T) {
+	issuer := auth.NewOIDCIssuer(t)
+	defer issuer.Close()
+
+	exp := time.Now().Add(time.Hour).Unix()
+	tests := []struct {
+		name        string
+		provider    *auth.OIDCProvider
+		tokenClaims jwt.MapClaims
+		claims      *auth.Claims
+		err         error
+	}{
+		{
+			name: "maps groups to roles",
+			provider: &auth.OIDCProvider{
+				RoleMappings: map[string]auth.Role{
+					"acmeproxy-admins": auth.Admin,
+					"acmeproxy-ops":    auth.Operator,
+				},
+			},
+			tokenClaims: jwt.MapClaims{
+				"sub":    "a4f3b6a0-2c7e-4c1e-9a53-1b4f1c7c2d55",
+				"exp":    exp,
+				"jti":    "token-id",
+				"groups": []string{"everyone", "acmeproxy-ops"},
+			},
+			claims: &auth.Claims{
+				StandardClaims: jwt.StandardClaims{
+					Subject:   "a4f3b6a0-2c7e-4c1e-9a53-1b4f1c7c2d55",
+					ExpiresAt: exp,
+					Id:        "token-id",
+				},
+				Roles: []auth.Role{auth.Operator},
+			},
+		},
+		{
+			name: "uses custom claims",
+			provider: &auth.OIDCProvider{
+				ClientID:     "acmeproxy",
+				UserIDClaim:  "acmeproxy_user",
+				GroupsClaim:  "roles",
+				RoleMappings: map[string]auth.Role{"admin": auth.Admin},
+			},
+			tokenClaims: jwt.MapClaims{
+				"sub":            "jdoe",
+				"acmeproxy_user": "a4f3b6a0-2c7e-4c1e-9a53-1b4f1c7c2d55",
+				"aud":            []string{"other-client", "acmeproxy"},
+				"exp":            exp,
+				"roles":          "admin",
+			},
+			claims: &auth.Claims{
+				StandardClaims: jwt.StandardClaims{
+					Subject:   "a4f3b6a0-2c7e-4c1e-9a53-1b4f1c7c2d55",
+					Audience:  "other-client",
+					ExpiresAt: exp,
+				},
+				Roles: []auth.Role{auth.Admin},
+			},
+		},
+		{
+			name: "rejects tokens without mapped groups",
+			provider: &auth.OIDCProvider{
+				RoleMappings: map[string]auth.Role{"acmeproxy-admins": auth.Admin},
+			},
+			tokenClaims: jwt.MapClaims{
+				"sub":    "jdoe",
+				"exp":    exp,
+				"groups": []string{"everyone"},
+			},
+			err: errors.New(errors.Unauthorized, "no role mapped to groups"),
+		},
+		{
+			name: "rejects tokens of other issuers",
+			tokenClaims: jwt.MapClaims{
+				"iss": "https://issuer.example.com",
+				"sub": "jdoe",
+				"exp": exp,
+			},
+			err: errors.New(errors.Unauthorized, "issuer mismatch: https://issuer.example.com"),
+		},
+		{
+			name:     "rejects tokens for other clients",
+			provider: &auth.OIDCProvider{ClientID: "acmeproxy"},
+			tokenClaims: jwt.MapClaims{
+				"sub": "jdoe",
+				"aud": "other-client",
+				"exp": exp,
+			},
+			err: errors.New(errors.Unauthorized, "audience mismatch"),
+		},
+		{
+			name: "rejects tokens without expiry",
+			tokenClaims: jwt.MapClaims{
+				"sub": "jdoe",
+			},
+			err: errors.New(errors.Unauthorized, "missing claim: exp"),
+		},
+		{
+			name: "rejects tokens without user id",
+			tokenClaims: jwt.MapClaims{
+				"exp": exp,
+			},
+			err: errors.New(errors.Unauthorized, "missing claim: sub"),
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			p := tt.provider
+			if p == nil {
+				p = &auth.OIDCProvider{}
+			}
+			p.Issuer = issuer.URL
+			if tt.claims != nil {
+				tt.claims.Issuer = issuer.URL
+			}
+			token := issuer.IssueToken(t, tt.tokenClaims)
+			claims, err := p.ParseToken(token)
+			errors.AssertMatches(t, tt.err, err)
+			if tt.err != nil {
+				return
+			}
+			assert.Equal(t, tt.claims, claims)
+		})
+	}
+}
+
+func TestOIDCProvider_ParseToken_ExpiredToken(t *testing.T) {
+	issuer := auth.NewOIDCIssuer(t)
+	defer issuer.Close()
+
+	p := &auth.OIDCProvider{Issuer: issuer.URL}
+	token := issuer.IssueToken(t, jwt.MapClaims{
+		"sub": "jdoe",
+		"exp": time.Now().Add(-time.Minute).Unix(),
+	})
+	_, err := p.ParseToken(token)
+	assert.Error(t, err)
+}
+
+func TestOIDCProvider_ParseToken_UnavailableIssuer(t *testing.T) {
+	issuer := auth.NewOIDCIssuer(t)
+	token := issuer.IssueToken(t, jwt.MapClaims{
+		"sub": "jdoe",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+	issuer.Close()
+
+	p := &auth.OIDCProvider{Issuer: issuer.URL}
+	_, err := p.ParseToken(token)
+	assert.Error(t, err)
+}
@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"sync"
+	"time"
+
+	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/fhofherr/acmeproxy/pkg/security"
+)
+
+// Denylist keeps track of revoked tokens.
+//
+// Denylist persists the revoked tokens using its Repository. It keeps a copy
+// of all revoked tokens in memory to avoid accessing the Repository for every
+// check. The copy is loaded from the Repository the first time Denylist is
+// used.
+//
+// Tokens without an ID cannot be revoked.
+type Denylist struct {
+	Repository security.RevokedTokenRepository
+
+	mu     sync.RWMutex
+	tokens map[string]time.Time
+}
+
+// RevokeToken adds the token with the passed id to the denylist.
+func (d *Denylist) RevokeToken(id string, expiresAt time.Time) error {
+	const op errors.Op = "auth/denylist.RevokeToken"
+
+	if id == "" {
+		return errors.New(op, errors.InvalidArgument, "empty token id")
+	}
+	if err := d.load(); err != nil {
+		return errors.New(op, err)
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	rt := security.RevokedToken{ID: id, ExpiresAt: expiresAt}
+	if err := d.Repository.SaveRevokedToken(rt); err != nil {
+		return errors.New(op, "save revoked token", err)
+	}
+	d.tokens[id] = expiresAt
+	return nil
+}
+
+// IsRevoked checks if the token with the passed id has been revoked.
+func (d *Denylist) IsRevoked(id string) (bool, error) {
+	const op errors.Op = "auth/denylist.IsRevoked"
+
+	if id == "" {
+		return false, nil
+	}
+	if err := d.load(); err != nil {
+		return false, errors.New(op, err)
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	_, ok := d.tokens[id]
+	return ok, nil
+}
+
+// CollectGarbage removes all tokens from the denylist that expired before
+// now. Expired tokens are rejected anyway.
+func (d *Denylist) CollectGarbage(now time.Time) error {
+	const op errors.Op = "auth/denylist.CollectGarbage"
+
+	if err := d.load(); err != nil {
+		return errors.New(op, err)
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var errcol errors.Collection
+	for id, expiresAt := range d.tokens {
+		if expiresAt.IsZero() || !expiresAt.Before(now) {
+			continue
+		}
+		if err := d.Repository.DeleteRevokedToken(id); err != nil {
+			errcol = errors.Append(errcol, err, op, "delete revoked token")
+			continue
+		}
+		delete(d.tokens, id)
+	}
+	return errcol.ErrorOrNil()
+}
+
+func (d *Denylist) load() error {
+	const op errors.Op = "auth/denylist.load"
+
+	d.mu.RLock()
+	loaded := d.tokens != nil
+	d.mu.RUnlock()
+	if loaded {
+		return nil
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.tokens != nil {
+		return nil
+	}
+	rts, err := d.Repository.ListRevokedTokens()
+	if err != nil {
+		return errors.New(op, "list revoked tokens", err)
+	}
+	d.tokens = make(map[string]time.Time, len(rts))
+	for _, rt := range rts {
+		d.tokens[rt.ID] = rt.ExpiresAt
+	}
+	return nil
+}
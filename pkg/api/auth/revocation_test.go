@@ -0,0 +1,59 @@
+package auth_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fhofherr/acmeproxy/pkg/api/auth"
+	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/fhofherr/acmeproxy/pkg/security"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRevokeToken(t *testing.T) {
+	repo := &security.InMemoryRevokedTokenRepository{}
+	denylist := &auth.Denylist{Repository: repo}
+	expiresAt := time.Date(2019, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	revoked, err := denylist.IsRevoked("token-1")
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+
+	err = denylist.RevokeToken("token-1", expiresAt)
+	assert.NoError(t, err)
+	revoked, err = denylist.IsRevoked("token-1")
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+
+	// A new denylist using the same repository knows the revoked token.
+	denylist = &auth.Denylist{Repository: repo}
+	revoked, err = denylist.IsRevoked("token-1")
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+
+	err = denylist.RevokeToken("", expiresAt)
+	errors.AssertMatches(t, errors.New(errors.InvalidArgument), err)
+}
+
+func TestDenylistCollectGarbage(t *testing.T) {
+	repo := &security.InMemoryRevokedTokenRepository{}
+	denylist := &auth.Denylist{Repository: repo}
+	now := time.Date(2019, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	assert.NoError(t, denylist.RevokeToken("expired", now.Add(-time.Minute)))
+	assert.NoError(t, denylist.RevokeToken("valid", now.Add(time.Minute)))
+	assert.NoError(t, denylist.RevokeToken("never-expires", time.Time{}))
+
+	err := denylist.CollectGarbage(now)
+	assert.NoError(t, err)
+
+	rts, err := repo.ListRevokedTokens()
+	assert.NoError(t, err)
+	assert.Equal(t, []security.RevokedToken{
+		{ID: "never-expires"},
+		{ID: "valid", ExpiresAt: now.Add(time.Minute)},
+	}, rts)
+	revoked, err := denylist.IsRevoked("expired")
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+}
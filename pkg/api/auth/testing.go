@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+// OIDCIssuer is a minimal OpenID Connect issuer serving a discovery document
+// and a JWKS containing a single ES256 key.
+//
+// It is intended for testing OIDCProvider. Create instances of OIDCIssuer
+// using NewOIDCIssuer.
+type OIDCIssuer struct {
+	URL string
+
+	server       *httptest.Server
+	mu           sync.Mutex
+	kid          string
+	key          *ecdsa.PrivateKey
+	keyRotations int
+	jwksRequests int
+}
+
+// NewOIDCIssuer creates and starts a new OIDCIssuer. Callers must call Close
+// once they are done.
+func NewOIDCIssuer(t *testing.T) *OIDCIssuer {
+	iss := &OIDCIssuer{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", iss.serveDiscoveryDocument)
+	mux.HandleFunc("/jwks", iss.serveJWKS)
+	iss.server = httptest.NewServer(mux)
+	iss.URL = iss.server.URL
+	iss.RotateKey(t)
+	return iss
+}
+
+// RotateKey replaces the key of the issuer with a new key. Tokens signed with
+// the previous key cannot be verified anymore.
+func (i *OIDCIssuer) RotateKey(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.keyRotations++
+	i.kid = fmt.Sprintf("key-%d", i.keyRotations)
+	i.key = key
+}
+
+// IssueToken signs the passed claims using the current key of the issuer. It
+// sets the "iss" claim to the URL of the issuer if claims do not contain it.
+func (i *OIDCIssuer) IssueToken(t *testing.T, claims jwt.MapClaims) string {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if _, ok := claims["iss"]; !ok {
+		claims["iss"] = i.URL
+	}
+	tok := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+	tok.Header["kid"] = i.kid
+	token, err := tok.SignedString(i.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// JWKSRequests returns the number of times the JWKS has been requested.
+func (i *OIDCIssuer) JWKSRequests() int {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.jwksRequests
+}
+
+// Close shuts the issuer down.
+func (i *OIDCIssuer) Close() {
+	i.server.Close()
+}
+
+func (i *OIDCIssuer) serveDiscoveryDocument(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(oidcDiscoveryDocument{ // nolint: errcheck
+		Issuer:  i.URL,
+		JWKSURI: i.URL + "/jwks",
+	})
+}
+
+func (i *OIDCIssuer) serveJWKS(w http.ResponseWriter, req *http.Request) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.jwksRequests++
+	jwks := jose.JSONWebKeySet{
+		Keys: []jose.JSONWebKey{
+			{Key: i.key.Public(), KeyID: i.kid, Algorithm: "ES256", Use: "sig"},
+		},
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(jwks) // nolint: errcheck
+}
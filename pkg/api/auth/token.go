@@ -2,6 +2,9 @@ package auth
 
 import (
 	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
 	"fmt"
 
 	"github.com/dgrijalva/jwt-go"
@@ -17,46 +20,144 @@ const (
 	ES256 Algorithm = iota
 	// ES512 represents the ES512 signing algorithm
 	ES512
+	// ES384 represents the ES384 signing algorithm
+	ES384
+	// EdDSA represents the EdDSA signing algorithm using Ed25519 keys
+	EdDSA
+	// RS256 represents the RS256 signing algorithm
+	RS256
+	// PS256 represents the PS256 signing algorithm
+	PS256
 )
 
+var algorithmNames = map[Algorithm]string{
+	ES256: "ES256",
+	ES384: "ES384",
+	ES512: "ES512",
+	EdDSA: "EdDSA",
+	RS256: "RS256",
+	PS256: "PS256",
+}
+
+// ParseAlgorithm returns the Algorithm with the passed name, e.g. "ES256".
+func ParseAlgorithm(name string) (Algorithm, error) {
+	const op errors.Op = "auth/ParseAlgorithm"
+
+	for alg, algName := range algorithmNames {
+		if algName == name {
+			return alg, nil
+		}
+	}
+	return Algorithm(-1), errors.New(op, errors.InvalidArgument, fmt.Sprintf("unknown algorithm: %s", name))
+}
+
+func (a Algorithm) String() string {
+	if name, ok := algorithmNames[a]; ok {
+		return name
+	}
+	return "unknown algorithm"
+}
+
 func (a Algorithm) signingMethod() (jwt.SigningMethod, error) {
 	const op errors.Op = "auth/algorithm.SigningMethod"
 
 	switch a {
 	case ES256:
 		return jwt.SigningMethodES256, nil
+	case ES384:
+		return jwt.SigningMethodES384, nil
 	case ES512:
-		return jwt.SigningMethodES256, nil
+		return jwt.SigningMethodES512, nil
+	case EdDSA:
+		return signingMethodEdDSA, nil
+	case RS256:
+		return jwt.SigningMethodRS256, nil
+	case PS256:
+		return jwt.SigningMethodPS256, nil
 	default:
 		return nil, errors.New(op, "unknown algorithm")
 	}
 }
 
-func (a Algorithm) methodOk(method jwt.SigningMethod) bool {
-	switch a {
-	case ES256:
-		return method == jwt.SigningMethodES256
-	case ES512:
-		return method == jwt.SigningMethodES512
-	default:
-		return false
+// algorithmsForKey returns the algorithms which may be used to verify a token
+// with key.
+func algorithmsForKey(key crypto.PublicKey) ([]Algorithm, error) {
+	const op errors.Op = "auth/algorithmsForKey"
+
+	switch pk := key.(type) {
+	case *ecdsa.PublicKey:
+		switch pk.Curve.Params().Name {
+		case "P-256":
+			return []Algorithm{ES256}, nil
+		case "P-384":
+			return []Algorithm{ES384}, nil
+		case "P-521":
+			return []Algorithm{ES512}, nil
+		}
+	case *rsa.PublicKey:
+		return []Algorithm{RS256, PS256}, nil
+	case ed25519.PublicKey:
+		return []Algorithm{EdDSA}, nil
 	}
+	return nil, errors.New(op, errors.Unauthorized, "unsupported key type")
+}
+
+// methodOk checks if method is the signing method of one of the algorithms
+// suitable for key.
+func methodOk(method jwt.SigningMethod, key crypto.PublicKey) error {
+	const op errors.Op = "auth/methodOk"
+
+	algs, err := algorithmsForKey(key)
+	if err != nil {
+		return errors.New(op, err)
+	}
+	for _, alg := range algs {
+		if sm, _ := alg.signingMethod(); sm == method {
+			return nil
+		}
+	}
+	return errors.New(op, errors.Unauthorized, "signing algorithm mismatch")
 }
 
 // Role is the type of the role names in the JWT.
 type Role string
 
 const (
-	// Admin signals that the bearer of the token is an administrator
+	// Admin signals that the bearer of the token is an administrator. Admins
+	// may perform any action.
 	Admin Role = "admin"
+	// Operator signals that the bearer of the token operates acmeproxy on
+	// behalf of its users. Operators may read and modify the domains of all
+	// users but may not manage users.
+	Operator Role = "operator"
+	// User signals that the bearer of the token is a regular acmeproxy user.
+	// Users may read and modify their own domains.
+	User Role = "user"
+	// ReadOnly signals that the bearer of the token may only read the domains
+	// of the user it was issued for.
+	ReadOnly Role = "read-only"
 )
 
+// ParseRole returns the Role named s. It returns an error of kind
+// InvalidArgument if acmeproxy does not know the role.
+func ParseRole(s string) (Role, error) {
+	const op errors.Op = "auth/ParseRole"
+
+	r := Role(s)
+	if _, ok := permissions[r]; !ok {
+		return "", errors.New(op, errors.InvalidArgument, fmt.Sprintf("unknown role: %s", s))
+	}
+	return r, nil
+}
+
 // Claims represents the claims of a JWT token a valid client of acmeproxy
-// may present. In contains the JWT standard claims as well as additional
-// claims which might be necessary in the future.
+// may present. In contains the JWT standard claims as well as the roles
+// granted to the bearer of the token, and an optional scope limiting the
+// token to specific resources.
 type Claims struct {
 	jwt.StandardClaims
 	Roles []Role `json:"roles,omitempty"`
+	Scope *Scope `json:"scope,omitempty"`
 }
 
 // NewToken creates and signs the a JWT containing the passed claims.
@@ -66,11 +167,23 @@ type Claims struct {
 func NewToken(c *Claims, alg Algorithm, key crypto.PrivateKey) (string, error) {
 	const op errors.Op = "auth/NewToken"
 
+	token, err := NewTokenWithKeyID(c, alg, key, "")
+	return token, errors.Wrap(err, op)
+}
+
+// NewTokenWithKeyID creates and signs a JWT just like NewToken. Additionally
+// it adds kid as key ID to the header of the token, unless kid is empty.
+func NewTokenWithKeyID(c *Claims, alg Algorithm, key crypto.PrivateKey, kid string) (string, error) {
+	const op errors.Op = "auth/NewTokenWithKeyID"
+
 	sm, err := alg.signingMethod()
 	if err != nil {
 		return "", errors.New(op, err)
 	}
 	token := jwt.NewWithClaims(sm, c)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
 	tokenStr, err := token.SignedString(key)
 	if err != nil {
 		return "", errors.New(op, "sign token", err)
@@ -78,23 +191,46 @@ func NewToken(c *Claims, alg Algorithm, key crypto.PrivateKey) (string, error) {
 	return tokenStr, nil
 }
 
-// ParseToken parses the passed token and verifies its signature. It ensures
-// that only tokens whose signing algorithm matches the passed algorithm are
-// accepted.
-func ParseToken(token string, alg Algorithm, key crypto.PublicKey) (*Claims, error) {
+// ParseToken parses the passed token and verifies its signature.
+//
+// The signing algorithm is negotiated from the type of key: ECDSA keys accept
+// the ES* algorithm matching their curve, RSA keys accept RS256 and PS256, and
+// Ed25519 keys accept EdDSA. Tokens signed using any other algorithm are
+// rejected.
+func ParseToken(token string, key crypto.PublicKey) (*Claims, error) {
 	const op errors.Op = "auth/ParseToken"
+
+	return parseToken(op, token, func(*jwt.Token) (interface{}, error) {
+		return key, nil
+	})
+}
+
+// ParseTokenWithKeySet parses the passed token and verifies its signature
+// just like ParseToken. It verifies the signature with the key from ks
+// identified by the key ID (kid) in the token's header. Tokens without key ID
+// are rejected.
+func ParseTokenWithKeySet(token string, ks *KeySet) (*Claims, error) {
+	const op errors.Op = "auth/ParseTokenWithKeySet"
+
+	return parseToken(op, token, func(tok *jwt.Token) (interface{}, error) {
+		kid, _ := tok.Header["kid"].(string)
+		if kid == "" {
+			return nil, errors.New(op, errors.Unauthorized, "missing key id")
+		}
+		return ks.Key(kid)
+	})
+}
+
+func parseToken(op errors.Op, token string, keyFunc jwt.Keyfunc) (*Claims, error) {
 	var claims Claims
 
-	// Validate the algorithm and check if it belongs to a supported singing
-	// method
-	if _, err := alg.signingMethod(); err != nil {
-		// Don't wrap err here as we do not want deeply nested errors.
-		// Just responding with an adequate message seems more elegant.
-		return &claims, errors.New(op, errors.Unauthorized, "unknown algorithm")
-	}
 	_, err := jwt.ParseWithClaims(token, &claims, func(tok *jwt.Token) (interface{}, error) {
-		if !alg.methodOk(tok.Method) {
-			return nil, errors.New(op, errors.Unauthorized, "signing algorithm mismatch")
+		key, err := keyFunc(tok)
+		if err != nil {
+			return nil, err
+		}
+		if err := methodOk(tok.Method, key); err != nil {
+			return nil, err
 		}
 		return key, nil
 	})
@@ -104,6 +240,22 @@ func ParseToken(token string, alg Algorithm, key crypto.PublicKey) (*Claims, err
 	return &claims, nil
 }
 
+// DecodeToken decodes the passed token without verifying its signature. It
+// returns the token's header and claims.
+//
+// DecodeToken must only be used to inspect tokens. Use ParseToken to obtain
+// the claims of tokens presented by clients.
+func DecodeToken(token string) (map[string]interface{}, *Claims, error) {
+	const op errors.Op = "auth/DecodeToken"
+	var claims Claims
+
+	tok, _, err := new(jwt.Parser).ParseUnverified(token, &claims)
+	if err != nil {
+		return nil, nil, errors.New(op, errors.InvalidArgument, "decode token", err)
+	}
+	return tok.Header, &claims, nil
+}
+
 func handleValidationError(op errors.Op, err error) error {
 	if err == nil {
 		return nil
@@ -1,63 +1,60 @@
 package auth
 
 import (
+	"crypto"
 	"testing"
 
 	"github.com/dgrijalva/jwt-go"
+	"github.com/fhofherr/acmeproxy/pkg/certutil"
+	"github.com/fhofherr/acmeproxy/pkg/errors"
 	"github.com/stretchr/testify/assert"
 )
 
-func TestAlgorithm_MethodOk(t *testing.T) {
+func TestMethodOk(t *testing.T) {
 	tests := []struct {
-		alg    Algorithm
-		method jwt.SigningMethod
-		ok     bool
+		keyType certutil.KeyType
+		method  jwt.SigningMethod
+		ok      bool
 	}{
-		{
-			alg:    Algorithm(-1),
-			method: jwt.SigningMethodES256,
-		},
-		{
-			alg:    Algorithm(-1),
-			method: jwt.SigningMethodES384,
-		},
-		{
-			alg:    Algorithm(-1),
-			method: jwt.SigningMethodES512,
-		},
-		{
-			alg:    ES256,
-			method: jwt.SigningMethodES256,
-			ok:     true,
-		},
-		{
-			alg:    ES256,
-			method: jwt.SigningMethodES384,
-		},
-		{
-			alg:    ES256,
-			method: jwt.SigningMethodES512,
-		},
-		{
-			alg:    ES512,
-			method: jwt.SigningMethodES256,
-		},
-		{
-			alg:    ES512,
-			method: jwt.SigningMethodES384,
-		},
-		{
-			alg:    ES512,
-			method: jwt.SigningMethodES512,
-			ok:     true,
-		},
+		{keyType: certutil.EC256, method: jwt.SigningMethodES256, ok: true},
+		{keyType: certutil.EC256, method: jwt.SigningMethodES384},
+		{keyType: certutil.EC256, method: jwt.SigningMethodES512},
+		{keyType: certutil.EC384, method: jwt.SigningMethodES384, ok: true},
+		{keyType: certutil.EC384, method: jwt.SigningMethodES256},
+		{keyType: certutil.EC521, method: jwt.SigningMethodES512, ok: true},
+		{keyType: certutil.EC521, method: jwt.SigningMethodES256},
+		{keyType: certutil.RSA2048, method: jwt.SigningMethodRS256, ok: true},
+		{keyType: certutil.RSA2048, method: jwt.SigningMethodPS256, ok: true},
+		{keyType: certutil.RSA2048, method: jwt.SigningMethodRS512},
+		{keyType: certutil.RSA2048, method: jwt.SigningMethodHS256},
+		{keyType: certutil.Ed25519, method: signingMethodEdDSA, ok: true},
+		{keyType: certutil.Ed25519, method: jwt.SigningMethodES256},
 	}
 
+	keys := make(map[certutil.KeyType]crypto.PublicKey)
 	for _, tt := range tests {
 		tt := tt
 		t.Run("", func(t *testing.T) {
-			ok := tt.alg.methodOk(tt.method)
-			assert.Equal(t, tt.ok, ok)
+			key, ok := keys[tt.keyType]
+			if !ok {
+				pk, err := certutil.NewPrivateKey(tt.keyType)
+				if err != nil {
+					t.Fatal(err)
+				}
+				key = pk.(crypto.Signer).Public()
+				keys[tt.keyType] = key
+			}
+			err := methodOk(tt.method, key)
+			if tt.ok {
+				assert.NoError(t, err)
+				return
+			}
+			errors.AssertMatches(t, errors.New(errors.Unauthorized, "signing algorithm mismatch"), err)
 		})
 	}
 }
+
+func TestMethodOk_UnsupportedKeyType(t *testing.T) {
+	err := methodOk(jwt.SigningMethodHS256, []byte("secret"))
+	errors.AssertMatches(t, errors.New(errors.Unauthorized, "unsupported key type"), err)
+}
@@ -66,9 +66,17 @@ func TestNewToken(t *testing.T) {
 	}
 }
 
-func TestParseToken_InvalidAlgorithm(t *testing.T) {
-	_, err := auth.ParseToken("", auth.Algorithm(-1), nil)
-	assert.True(t, errors.Is(err, errors.New(errors.Unauthorized, "unknown algorithm")))
+func TestParseToken_UnsupportedKeyType(t *testing.T) {
+	key, err := certutil.NewPrivateKey(certutil.EC256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := auth.NewToken(&auth.Claims{}, auth.ES256, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = auth.ParseToken(token, []byte("secret"))
+	assert.True(t, errors.Is(err, errors.New(errors.Unauthorized, "unsupported key type")))
 }
 
 func TestParseToken(t *testing.T) {
@@ -81,9 +89,10 @@ func TestParseToken(t *testing.T) {
 		claims      *auth.Claims
 	}{
 		{
-			name:     "returns error on signing algorithm mismatch",
-			parseAlg: auth.ES512,
-			err:      errors.New(errors.Unauthorized, "signing algorithm mismatch"),
+			name:        "returns error on signing algorithm mismatch",
+			parseAlg:    auth.ES512,
+			useParseKey: true,
+			err:         errors.New(errors.Unauthorized, "signing algorithm mismatch"),
 		},
 		{
 			name:        "returns error on invalid signature",
@@ -103,6 +112,51 @@ func TestParseToken(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:     "returns claims of ES384 token",
+			tokenAlg: auth.ES384,
+			claims: &auth.Claims{
+				StandardClaims: jwt.StandardClaims{
+					Subject: "jdoe",
+				},
+			},
+		},
+		{
+			name:     "returns claims of ES512 token",
+			tokenAlg: auth.ES512,
+			claims: &auth.Claims{
+				StandardClaims: jwt.StandardClaims{
+					Subject: "jdoe",
+				},
+			},
+		},
+		{
+			name:     "returns claims of EdDSA token",
+			tokenAlg: auth.EdDSA,
+			claims: &auth.Claims{
+				StandardClaims: jwt.StandardClaims{
+					Subject: "jdoe",
+				},
+			},
+		},
+		{
+			name:     "returns claims of RS256 token",
+			tokenAlg: auth.RS256,
+			claims: &auth.Claims{
+				StandardClaims: jwt.StandardClaims{
+					Subject: "jdoe",
+				},
+			},
+		},
+		{
+			name:     "returns claims of PS256 token",
+			tokenAlg: auth.PS256,
+			claims: &auth.Claims{
+				StandardClaims: jwt.StandardClaims{
+					Subject: "jdoe",
+				},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -144,7 +198,7 @@ func TestParseToken(t *testing.T) {
 				}
 				pk = publicKey(t, parseKey)
 			}
-			claims, err := auth.ParseToken(string(token), tt.parseAlg, pk)
+			claims, err := auth.ParseToken(string(token), pk)
 
 			assert.Truef(t, errors.Is(err, tt.err), "expected %v; got %v", tt.err, err)
 			if tt.err != nil {
@@ -155,12 +209,56 @@ func TestParseToken(t *testing.T) {
 	}
 }
 
+func TestDecodeToken(t *testing.T) {
+	key, err := certutil.NewPrivateKey(certutil.EC256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &auth.Claims{
+		StandardClaims: jwt.StandardClaims{
+			Subject:  "jdoe",
+			Audience: "acmeproxy",
+			Id:       "some-id",
+		},
+		Roles: []auth.Role{auth.Admin},
+	}
+	token, err := auth.NewToken(expected, auth.ES256, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header, claims, err := auth.DecodeToken(token)
+	assert.NoError(t, err)
+	assert.Equal(t, "ES256", header["alg"])
+	assert.Equal(t, expected, claims)
+
+	_, _, err = auth.DecodeToken("not a token")
+	assert.True(t, errors.IsKind(err, errors.InvalidArgument))
+}
+
+func TestParseAlgorithm(t *testing.T) {
+	algs := []auth.Algorithm{auth.ES256, auth.ES384, auth.ES512, auth.EdDSA, auth.RS256, auth.PS256}
+	for _, alg := range algs {
+		actual, err := auth.ParseAlgorithm(alg.String())
+		assert.NoError(t, err)
+		assert.Equal(t, alg, actual)
+	}
+	_, err := auth.ParseAlgorithm("none")
+	assert.True(t, errors.IsKind(err, errors.InvalidArgument))
+}
+
 func keyTypeForAlg(t *testing.T, alg auth.Algorithm) certutil.KeyType {
 	switch alg {
 	case auth.ES256:
 		return certutil.EC256
+	case auth.ES384:
+		return certutil.EC384
 	case auth.ES512:
 		return certutil.EC521
+	case auth.EdDSA:
+		return certutil.Ed25519
+	case auth.RS256, auth.PS256:
+		return certutil.RSA2048
 	default:
 		t.Fatalf("Can't determine key type for algorithm")
 		return certutil.KeyType(-1)
@@ -223,3 +321,13 @@ func TestCheckRoles_NilCaller(t *testing.T) {
 	err := claims.CheckRoles(auth.Admin)
 	errors.AssertMatches(t, template, err)
 }
+
+func TestParseRole(t *testing.T) {
+	for _, r := range []auth.Role{auth.Admin, auth.Operator, auth.User, auth.ReadOnly} {
+		role, err := auth.ParseRole(string(r))
+		assert.NoError(t, err)
+		assert.Equal(t, r, role)
+	}
+	_, err := auth.ParseRole("root")
+	errors.AssertMatches(t, errors.New(errors.InvalidArgument), err)
+}
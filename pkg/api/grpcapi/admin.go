@@ -2,10 +2,17 @@ package grpcapi
 
 import (
 	"context"
+	"fmt"
+	"time"
 
+	"github.com/fhofherr/acmeproxy/pkg/acme"
 	"github.com/fhofherr/acmeproxy/pkg/api/auth"
 	"github.com/fhofherr/acmeproxy/pkg/api/grpcapi/internal/pb"
 	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/fhofherr/acmeproxy/pkg/security"
+	"github.com/fhofherr/acmeproxy/pkg/version"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/empty"
 	"github.com/google/uuid"
 )
 
@@ -17,18 +24,61 @@ import (
 // implementations should do nothing and especially the must not return an
 // error.
 type UserRegisterer interface {
-	RegisterUser(userID uuid.UUID, email string) error
+	RegisterUser(ctx context.Context, userID uuid.UUID, email string) error
+}
+
+// UserManager wraps the methods used to administer existing users.
+//
+// GetUser returns the user with the passed ID. ListUsers returns at most
+// limit users whose ID is greater than after, ordered by their ID. UpdateUser
+// replaces the email, the labels, and the public key of a user with those of
+// the passed user. DeleteUser removes a user. TransferDomains transfers the
+// domains with the passed names between two users; if no names are passed it
+// transfers all domains of the first user. It returns the names of the
+// transferred domains.
+//
+// Implementations should return errors of kind NotFound for missing users, and
+// errors of kind InvalidArgument for requests they refuse to perform.
+type UserManager interface {
+	GetUser(userID uuid.UUID) (acme.User, error)
+	ListUsers(after uuid.UUID, limit int) ([]acme.User, error)
+	UpdateUser(userID uuid.UUID, u acme.User) (acme.User, error)
+	DeleteUser(userID uuid.UUID) error
+	TransferDomains(from, to uuid.UUID, domainNames []string) ([]string, error)
+}
+
+const (
+	defaultUsersPageSize = 100
+	maxUsersPageSize     = 1000
+)
+
+// Status describes the state of a running Server.
+//
+// Version, GitHash, and BuildTime identify the acmeproxy binary the Server
+// runs in. Version is empty if the binary was not built from a tag. Domains is
+// the number of domains managed by acmeproxy.
+type Status struct {
+	Version   string
+	GitHash   string
+	BuildTime string
+	StartedAt time.Time
+	Domains   int
 }
 
 type adminServer struct {
 	UserRegisterer UserRegisterer
+	TokenRevoker   TokenRevoker
+	UserManager    UserManager
+	DomainLister   DomainLister
+	AuditTrail     AuditTrail
+	StartedAt      time.Time
 }
 
 func (s *adminServer) RegisterUser(ctx context.Context, email *pb.Email) (*pb.User, error) {
 	const op errors.Op = "grpcapi/adminServer.RegisterUser"
 
-	if err := auth.CheckRoles(ctx, auth.Admin); err != nil {
-		return nil, pb.ToGRPCStatusError(err)
+	if err := auth.Authorize(ctx, auth.RegisterUser, auth.Resource{}); err != nil {
+		return nil, pb.ToGRPCStatusError(errors.New(op, err))
 	}
 
 	userID, err := uuid.NewRandom()
@@ -44,7 +94,7 @@ func (s *adminServer) RegisterUser(ctx context.Context, email *pb.Email) (*pb.Us
 	}
 
 	// TODO test RegisterUser returns error
-	if err := s.UserRegisterer.RegisterUser(userID, email.GetAddr()); err != nil {
+	if err := s.UserRegisterer.RegisterUser(ctx, userID, email.GetAddr()); err != nil {
 		return nil, pb.ToGRPCStatusError(err)
 	}
 	return &pb.User{
@@ -53,6 +103,227 @@ func (s *adminServer) RegisterUser(ctx context.Context, email *pb.Email) (*pb.Us
 	}, nil
 }
 
+func (s *adminServer) RevokeToken(ctx context.Context, rt *pb.RevokedToken) (*empty.Empty, error) {
+	const op errors.Op = "grpcapi/adminServer.RevokeToken"
+
+	if err := auth.Authorize(ctx, auth.RevokeToken, auth.Resource{}); err != nil {
+		return nil, pb.ToGRPCStatusError(errors.New(op, err))
+	}
+	var expiresAt time.Time
+	if rt.GetExpiresAt() != nil {
+		ts, err := ptypes.Timestamp(rt.GetExpiresAt())
+		if err != nil {
+			err = errors.New(op, errors.InvalidArgument, "invalid expiry", err)
+			return nil, pb.ToGRPCStatusError(err)
+		}
+		expiresAt = ts
+	}
+	if err := s.TokenRevoker.RevokeToken(rt.GetId(), expiresAt); err != nil {
+		err = errors.New(op, fmt.Sprintf("revoke token: %s", rt.GetId()), err)
+		return nil, pb.ToGRPCStatusError(err)
+	}
+	return &empty.Empty{}, nil
+}
+
+func (s *adminServer) GetUser(ctx context.Context, id *pb.UserID) (*pb.User, error) {
+	const op errors.Op = "grpcapi/adminServer.GetUser"
+
+	if err := auth.Authorize(ctx, auth.ManageUsers, auth.Resource{}); err != nil {
+		return nil, pb.ToGRPCStatusError(errors.New(op, err))
+	}
+	userID, err := parseUserID(id.GetId())
+	if err != nil {
+		return nil, pb.ToGRPCStatusError(errors.New(op, err))
+	}
+	user, err := s.UserManager.GetUser(userID)
+	if err != nil {
+		return nil, pb.ToGRPCStatusError(errors.New(op, err))
+	}
+	return userToPB(user), nil
+}
+
+func (s *adminServer) ListUsers(ctx context.Context, req *pb.ListUsersRequest) (*pb.ListUsersResponse, error) {
+	const op errors.Op = "grpcapi/adminServer.ListUsers"
+
+	if err := auth.Authorize(ctx, auth.ManageUsers, auth.Resource{}); err != nil {
+		return nil, pb.ToGRPCStatusError(errors.New(op, err))
+	}
+	pageSize := int(req.GetPageSize())
+	if pageSize <= 0 {
+		pageSize = defaultUsersPageSize
+	}
+	if pageSize > maxUsersPageSize {
+		pageSize = maxUsersPageSize
+	}
+	var after uuid.UUID
+	if req.GetPageToken() != "" {
+		id, err := uuid.Parse(req.GetPageToken())
+		if err != nil {
+			err = errors.New(op, errors.InvalidArgument, "invalid page token", err)
+			return nil, pb.ToGRPCStatusError(err)
+		}
+		after = id
+	}
+	// Request one additional user to find out if there is a next page.
+	users, err := s.UserManager.ListUsers(after, pageSize+1)
+	if err != nil {
+		return nil, pb.ToGRPCStatusError(errors.New(op, err))
+	}
+	res := &pb.ListUsersResponse{}
+	if len(users) > pageSize {
+		users = users[:pageSize]
+		res.NextPageToken = users[pageSize-1].ID.String()
+	}
+	for _, u := range users {
+		res.Users = append(res.Users, userToPB(u))
+	}
+	return res, nil
+}
+
+func (s *adminServer) UpdateUser(ctx context.Context, u *pb.User) (*pb.User, error) {
+	const op errors.Op = "grpcapi/adminServer.UpdateUser"
+
+	if err := auth.Authorize(ctx, auth.ManageUsers, auth.Resource{}); err != nil {
+		return nil, pb.ToGRPCStatusError(errors.New(op, err))
+	}
+	update, err := userFromPB(u)
+	if err != nil {
+		return nil, pb.ToGRPCStatusError(errors.New(op, err))
+	}
+	user, err := s.UserManager.UpdateUser(update.ID, update)
+	if err != nil {
+		return nil, pb.ToGRPCStatusError(errors.New(op, err))
+	}
+	return userToPB(user), nil
+}
+
+func (s *adminServer) DeleteUser(ctx context.Context, id *pb.UserID) (*empty.Empty, error) {
+	const op errors.Op = "grpcapi/adminServer.DeleteUser"
+
+	if err := auth.Authorize(ctx, auth.ManageUsers, auth.Resource{}); err != nil {
+		return nil, pb.ToGRPCStatusError(errors.New(op, err))
+	}
+	userID, err := parseUserID(id.GetId())
+	if err != nil {
+		return nil, pb.ToGRPCStatusError(errors.New(op, err))
+	}
+	if err := s.UserManager.DeleteUser(userID); err != nil {
+		return nil, pb.ToGRPCStatusError(errors.New(op, err))
+	}
+	return &empty.Empty{}, nil
+}
+
+func (s *adminServer) TransferDomains(
+	ctx context.Context, req *pb.TransferDomainsRequest,
+) (*pb.TransferDomainsResponse, error) {
+	const op errors.Op = "grpcapi/adminServer.TransferDomains"
+
+	if err := auth.Authorize(ctx, auth.ManageUsers, auth.Resource{}); err != nil {
+		return nil, pb.ToGRPCStatusError(errors.New(op, err))
+	}
+	from, err := parseUserID(req.GetFrom())
+	if err != nil {
+		return nil, pb.ToGRPCStatusError(errors.New(op, "from", err))
+	}
+	to, err := parseUserID(req.GetTo())
+	if err != nil {
+		return nil, pb.ToGRPCStatusError(errors.New(op, "to", err))
+	}
+	names, err := s.UserManager.TransferDomains(from, to, req.GetDomainNames())
+	if err != nil {
+		return nil, pb.ToGRPCStatusError(errors.New(op, err))
+	}
+	return &pb.TransferDomainsResponse{DomainNames: names}, nil
+}
+
+func (s *adminServer) GetStatus(ctx context.Context, _ *empty.Empty) (*pb.Status, error) {
+	const op errors.Op = "grpcapi/adminServer.GetStatus"
+
+	if err := auth.Authorize(ctx, auth.ReadStatus, auth.Resource{}); err != nil {
+		return nil, pb.ToGRPCStatusError(errors.New(op, err))
+	}
+	domains, err := s.DomainLister.ListDomains()
+	if err != nil {
+		return nil, pb.ToGRPCStatusError(errors.New(op, "list domains", err))
+	}
+	startedAt, err := ptypes.TimestampProto(s.StartedAt)
+	if err != nil {
+		return nil, pb.ToGRPCStatusError(errors.New(op, "convert start time", err))
+	}
+	return &pb.Status{
+		Version:   version.GitTag,
+		GitHash:   version.GitHash,
+		BuildTime: version.BuildTime,
+		StartedAt: startedAt,
+		Domains:   int32(len(domains)),
+	}, nil
+}
+
+func (s *adminServer) ListAuditEntries(
+	ctx context.Context, req *pb.ListAuditEntriesRequest,
+) (*pb.ListAuditEntriesResponse, error) {
+	const op errors.Op = "grpcapi/adminServer.ListAuditEntries"
+
+	if err := auth.Authorize(ctx, auth.ReadAuditLog, auth.Resource{}); err != nil {
+		return nil, pb.ToGRPCStatusError(errors.New(op, err))
+	}
+	f, err := auditFilterFromPB(req)
+	if err != nil {
+		return nil, pb.ToGRPCStatusError(errors.New(op, err))
+	}
+	entries, err := s.AuditTrail.Query(f)
+	if err != nil {
+		return nil, pb.ToGRPCStatusError(errors.New(op, err))
+	}
+	res := &pb.ListAuditEntriesResponse{}
+	for _, e := range entries {
+		msg, err := auditEntryToPB(e)
+		if err != nil {
+			return nil, pb.ToGRPCStatusError(errors.New(op, err))
+		}
+		res.Entries = append(res.Entries, msg)
+	}
+	return res, nil
+}
+
+func parseUserID(bs []byte) (uuid.UUID, error) {
+	const op errors.Op = "grpcapi/parseUserID"
+
+	id, err := uuid.FromBytes(bs)
+	if err != nil {
+		return uuid.UUID{}, errors.New(op, errors.InvalidArgument, "invalid user id", err)
+	}
+	return id, nil
+}
+
+// userToPB converts u to its protocol buffer representation. The private key
+// of the user's ACME account never leaves the server.
+func userToPB(u acme.User) *pb.User {
+	return &pb.User{
+		Id:           u.ID[:],
+		Email:        u.Email,
+		AccountURL:   u.AccountURL,
+		Labels:       u.Labels,
+		PublicKeyPEM: u.PublicKey,
+	}
+}
+
+func userFromPB(u *pb.User) (acme.User, error) {
+	const op errors.Op = "grpcapi/userFromPB"
+
+	id, err := parseUserID(u.GetId())
+	if err != nil {
+		return acme.User{}, errors.New(op, err)
+	}
+	return acme.User{
+		ID:         id,
+		Email:      u.GetEmail(),
+		AccountURL: u.GetAccountURL(),
+		Labels:     u.GetLabels(),
+		PublicKey:  u.GetPublicKeyPEM(),
+	}, nil
+}
+
 type adminClient struct {
 	Client pb.AdminClient
 }
@@ -76,3 +347,144 @@ func (c *adminClient) RegisterUser(ctx context.Context, email string) (uuid.UUID
 	}
 	return userID, nil
 }
+
+func (c *adminClient) RevokeToken(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	const op errors.Op = "grpcapi/adminClient.RevokeToken"
+
+	req := &pb.RevokedToken{
+		Id: tokenID,
+	}
+	if !expiresAt.IsZero() {
+		ts, err := ptypes.TimestampProto(expiresAt)
+		if err != nil {
+			return errors.New(op, errors.InvalidArgument, "invalid expiry", err)
+		}
+		req.ExpiresAt = ts
+	}
+	if _, err := c.Client.RevokeToken(ctx, req); err != nil {
+		err = pb.FromGRPCStatusError(err)
+		return errors.New(op, fmt.Sprintf("revoke token: %s", tokenID), err)
+	}
+	return nil
+}
+
+func (c *adminClient) GetUser(ctx context.Context, userID uuid.UUID) (acme.User, error) {
+	const op errors.Op = "grpcapi/adminClient.GetUser"
+
+	user, err := c.Client.GetUser(ctx, &pb.UserID{Id: userID[:]})
+	if err != nil {
+		err = pb.FromGRPCStatusError(err)
+		return acme.User{}, errors.New(op, fmt.Sprintf("get user: %v", userID), err)
+	}
+	return userFromPB(user)
+}
+
+// ListUsers returns a page of at most pageSize users, and the token of the
+// next page. The token is empty if there are no more users. Pass an empty
+// pageToken to obtain the first page.
+func (c *adminClient) ListUsers(ctx context.Context, pageSize int, pageToken string) ([]acme.User, string, error) {
+	const op errors.Op = "grpcapi/adminClient.ListUsers"
+
+	req := &pb.ListUsersRequest{
+		PageSize:  int32(pageSize),
+		PageToken: pageToken,
+	}
+	res, err := c.Client.ListUsers(ctx, req)
+	if err != nil {
+		err = pb.FromGRPCStatusError(err)
+		return nil, "", errors.New(op, "list users", err)
+	}
+	users := make([]acme.User, 0, len(res.GetUsers()))
+	for _, u := range res.GetUsers() {
+		user, err := userFromPB(u)
+		if err != nil {
+			return nil, "", errors.New(op, err)
+		}
+		users = append(users, user)
+	}
+	return users, res.GetNextPageToken(), nil
+}
+
+func (c *adminClient) UpdateUser(ctx context.Context, u acme.User) (acme.User, error) {
+	const op errors.Op = "grpcapi/adminClient.UpdateUser"
+
+	user, err := c.Client.UpdateUser(ctx, userToPB(u))
+	if err != nil {
+		err = pb.FromGRPCStatusError(err)
+		return acme.User{}, errors.New(op, fmt.Sprintf("update user: %v", u.ID), err)
+	}
+	return userFromPB(user)
+}
+
+func (c *adminClient) DeleteUser(ctx context.Context, userID uuid.UUID) error {
+	const op errors.Op = "grpcapi/adminClient.DeleteUser"
+
+	if _, err := c.Client.DeleteUser(ctx, &pb.UserID{Id: userID[:]}); err != nil {
+		err = pb.FromGRPCStatusError(err)
+		return errors.New(op, fmt.Sprintf("delete user: %v", userID), err)
+	}
+	return nil
+}
+
+func (c *adminClient) TransferDomains(ctx context.Context, from, to uuid.UUID, domainNames []string) ([]string, error) {
+	const op errors.Op = "grpcapi/adminClient.TransferDomains"
+
+	req := &pb.TransferDomainsRequest{
+		From:        from[:],
+		To:          to[:],
+		DomainNames: domainNames,
+	}
+	res, err := c.Client.TransferDomains(ctx, req)
+	if err != nil {
+		err = pb.FromGRPCStatusError(err)
+		return nil, errors.New(op, fmt.Sprintf("transfer domains from %v to %v", from, to), err)
+	}
+	return res.GetDomainNames(), nil
+}
+
+func (c *adminClient) GetStatus(ctx context.Context) (Status, error) {
+	const op errors.Op = "grpcapi/adminClient.GetStatus"
+
+	res, err := c.Client.GetStatus(ctx, &empty.Empty{})
+	if err != nil {
+		err = pb.FromGRPCStatusError(err)
+		return Status{}, errors.New(op, "get status", err)
+	}
+	startedAt, err := ptypes.Timestamp(res.GetStartedAt())
+	if err != nil {
+		return Status{}, errors.New(op, "convert start time", err)
+	}
+	return Status{
+		Version:   res.GetVersion(),
+		GitHash:   res.GetGitHash(),
+		BuildTime: res.GetBuildTime(),
+		StartedAt: startedAt,
+		Domains:   int(res.GetDomains()),
+	}, nil
+}
+
+// ListAuditEntries returns the entries of the audit log matching f ordered by
+// the time they were recorded. The server limits the number of entries
+// returned if f.Limit is zero or too large.
+func (c *adminClient) ListAuditEntries(ctx context.Context, f security.AuditFilter) ([]security.AuditEntry, error) {
+	const op errors.Op = "grpcapi/adminClient.ListAuditEntries"
+
+	req, err := auditFilterToPB(f)
+	if err != nil {
+		return nil, errors.New(op, err)
+	}
+	res, err := c.Client.ListAuditEntries(ctx, req)
+	if err != nil {
+		err = pb.FromGRPCStatusError(err)
+		return nil, errors.New(op, "list audit entries", err)
+	}
+	entries := make([]security.AuditEntry, 0, len(res.GetEntries()))
+	for _, msg := range res.GetEntries() {
+		e, err := auditEntryFromPB(msg)
+		if err != nil {
+			return nil, errors.New(op, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
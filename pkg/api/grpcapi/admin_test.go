@@ -6,9 +6,11 @@ import (
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
+	"github.com/fhofherr/acmeproxy/pkg/acme"
 	"github.com/fhofherr/acmeproxy/pkg/api/auth"
 	"github.com/fhofherr/acmeproxy/pkg/api/grpcapi"
 	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/fhofherr/acmeproxy/pkg/security"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -34,7 +36,7 @@ func TestRegisterUser_Authorization(t *testing.T) {
 					Subject: "jdoe@example.com",
 				},
 			},
-			err: errors.New(errors.Unauthorized),
+			err: errors.New(errors.PermissionDenied),
 		},
 		{
 			name:  "valid token but not an admin",
@@ -45,7 +47,7 @@ func TestRegisterUser_Authorization(t *testing.T) {
 				},
 				Roles: []auth.Role{auth.Role("role1"), auth.Role("role2")},
 			},
-			err: errors.New(errors.Unauthorized),
+			err: errors.New(errors.PermissionDenied),
 		},
 		{
 			name:  "valid token and an admin",
@@ -89,3 +91,375 @@ func TestRegisterUser_Authorization(t *testing.T) {
 		})
 	}
 }
+
+func TestRevokeToken(t *testing.T) {
+	expiresAt := time.Date(2019, 10, 18, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		claims *auth.Claims
+		err    error
+	}{
+		{
+			name: "not an admin",
+			claims: &auth.Claims{
+				StandardClaims: jwt.StandardClaims{
+					Subject: "jdoe@example.com",
+				},
+				Roles: []auth.Role{auth.Operator},
+			},
+			err: errors.New(errors.PermissionDenied),
+		},
+		{
+			name: "admin revokes token",
+			claims: &auth.Claims{
+				StandardClaims: jwt.StandardClaims{
+					Subject: "jdoe@example.com",
+				},
+				Roles: []auth.Role{auth.Admin},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			fx := grpcapi.NewTestFixture(t)
+			fx.Token = "valid"
+			fx.Claims = tt.claims
+
+			addr := fx.Start()
+			defer fx.Stop()
+
+			client := fx.NewClient(addr, "valid")
+
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+
+			err := client.RevokeToken(ctx, "some-token-id", expiresAt)
+			errors.AssertMatches(t, tt.err, err)
+
+			revoked, err := fx.Denylist.IsRevoked("some-token-id")
+			assert.NoError(t, err)
+			assert.Equal(t, tt.err == nil, revoked)
+		})
+	}
+}
+
+func TestManageUsers_Authorization(t *testing.T) {
+	userID := uuid.Must(uuid.NewRandom())
+	tests := []struct {
+		name  string
+		roles []auth.Role
+		err   error
+	}{
+		{
+			name:  "operator",
+			roles: []auth.Role{auth.Operator},
+			err:   errors.New(errors.PermissionDenied),
+		},
+		{
+			name:  "admin",
+			roles: []auth.Role{auth.Admin},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			fx := grpcapi.NewTestFixture(t)
+			fx.Token = "valid"
+			fx.Claims = &auth.Claims{
+				StandardClaims: jwt.StandardClaims{
+					Subject: "jdoe@example.com",
+				},
+				Roles: tt.roles,
+			}
+
+			addr := fx.Start()
+			defer fx.Stop()
+
+			client := fx.NewClient(addr, "valid")
+
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+
+			fx.MockUserManager.
+				On("GetUser", userID).
+				Return(acme.User{ID: userID, Email: "jane.doe@example.com"}, nil)
+			fx.MockUserManager.On("DeleteUser", userID).Return(nil)
+
+			user, err := client.GetUser(ctx, userID)
+			errors.AssertMatches(t, tt.err, err)
+			err = client.DeleteUser(ctx, userID)
+			errors.AssertMatches(t, tt.err, err)
+			if tt.err != nil {
+				fx.MockUserManager.AssertNotCalled(t, "GetUser", userID)
+				fx.MockUserManager.AssertNotCalled(t, "DeleteUser", userID)
+				return
+			}
+			assert.Equal(t, acme.User{ID: userID, Email: "jane.doe@example.com"}, user)
+		})
+	}
+}
+
+func TestListUsers(t *testing.T) {
+	fx := grpcapi.NewTestFixture(t)
+	fx.Token = "valid"
+	fx.Claims = &auth.Claims{Roles: []auth.Role{auth.Admin}}
+
+	addr := fx.Start()
+	defer fx.Stop()
+
+	client := fx.NewClient(addr, "valid")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	users := []acme.User{
+		{ID: uuid.MustParse("0b3c1a2d-4e5f-4a6b-8c7d-9e0f1a2b3c4d"), Labels: map[string]string{"team": "web"}},
+		{ID: uuid.MustParse("3a0f5c1e-8a83-4c4e-9f5b-0f2b8c6f1d01")},
+		{ID: uuid.MustParse("7c8d9e0f-1a2b-4c3d-8e4f-5a6b7c8d9e0f")},
+	}
+	fx.MockUserManager.On("ListUsers", uuid.Nil, 3).Return(users, nil)
+	fx.MockUserManager.On("ListUsers", users[1].ID, 3).Return(users[2:], nil)
+
+	page, next, err := client.ListUsers(ctx, 2, "")
+	assert.NoError(t, err)
+	assert.Equal(t, users[:2], page)
+	assert.Equal(t, users[1].ID.String(), next)
+
+	page, next, err = client.ListUsers(ctx, 2, next)
+	assert.NoError(t, err)
+	assert.Equal(t, users[2:], page)
+	assert.Empty(t, next)
+
+	_, _, err = client.ListUsers(ctx, 2, "invalid")
+	errors.AssertMatches(t, errors.New(errors.InvalidArgument), err)
+}
+
+func TestUpdateUser(t *testing.T) {
+	fx := grpcapi.NewTestFixture(t)
+	fx.Token = "valid"
+	fx.Claims = &auth.Claims{Roles: []auth.Role{auth.Admin}}
+
+	addr := fx.Start()
+	defer fx.Stop()
+
+	client := fx.NewClient(addr, "valid")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	update := acme.User{
+		ID:        uuid.Must(uuid.NewRandom()),
+		Email:     "jane.doe@example.com",
+		Labels:    map[string]string{"team": "web"},
+		PublicKey: []byte("public key"),
+	}
+	updated := update
+	updated.AccountURL = "https://example.com/some/account"
+	fx.MockUserManager.On("UpdateUser", update.ID, update).Return(updated, nil)
+
+	user, err := client.UpdateUser(ctx, update)
+	assert.NoError(t, err)
+	assert.Equal(t, updated, user)
+
+	missing := acme.User{ID: uuid.Must(uuid.NewRandom())}
+	fx.MockUserManager.
+		On("UpdateUser", missing.ID, missing).
+		Return(acme.User{}, errors.New(errors.NotFound, "user not found"))
+	_, err = client.UpdateUser(ctx, missing)
+	errors.AssertMatches(t, errors.New(errors.NotFound), err)
+}
+
+func TestTransferDomains(t *testing.T) {
+	fx := grpcapi.NewTestFixture(t)
+	fx.Token = "valid"
+	fx.Claims = &auth.Claims{Roles: []auth.Role{auth.Admin}}
+
+	addr := fx.Start()
+	defer fx.Stop()
+
+	client := fx.NewClient(addr, "valid")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	from := uuid.Must(uuid.NewRandom())
+	to := uuid.Must(uuid.NewRandom())
+	names := []string{"www.example.com"}
+	fx.MockUserManager.On("TransferDomains", from, to, names).Return(names, nil)
+	fx.MockUserManager.
+		On("TransferDomains", to, from, []string(nil)).
+		Return(nil, errors.New(errors.InvalidArgument, "not owned"))
+
+	transferred, err := client.TransferDomains(ctx, from, to, names)
+	assert.NoError(t, err)
+	assert.Equal(t, names, transferred)
+
+	_, err = client.TransferDomains(ctx, to, from, nil)
+	errors.AssertMatches(t, errors.New(errors.InvalidArgument), err)
+	assert.NotEmpty(t, errors.GetRequestID(err))
+}
+
+func TestGetStatus(t *testing.T) {
+	tests := []struct {
+		name  string
+		roles []auth.Role
+		err   error
+	}{
+		{
+			name:  "user",
+			roles: []auth.Role{auth.User},
+			err:   errors.New(errors.PermissionDenied),
+		},
+		{
+			name:  "operator",
+			roles: []auth.Role{auth.Operator},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			fx := grpcapi.NewTestFixture(t)
+			fx.Token = "valid"
+			fx.Claims = &auth.Claims{Roles: tt.roles}
+
+			before := time.Now()
+			addr := fx.Start()
+			defer fx.Stop()
+
+			client := fx.NewClient(addr, "valid")
+
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+
+			fx.MockDomainLister.
+				On("ListDomains").
+				Return([]acme.Domain{{Name: "example.com"}, {Name: "example.org"}}, nil)
+
+			status, err := client.GetStatus(ctx)
+			errors.AssertMatches(t, tt.err, err)
+			if tt.err != nil {
+				return
+			}
+			assert.Equal(t, 2, status.Domains)
+			assert.False(t, status.StartedAt.Before(before.Truncate(time.Second)))
+		})
+	}
+}
+
+func TestAuditLog(t *testing.T) {
+	fx := grpcapi.NewTestFixture(t)
+	fx.Token = "valid"
+	fx.Claims = &auth.Claims{
+		StandardClaims: jwt.StandardClaims{
+			Id:      "token-id",
+			Subject: "jdoe@example.com",
+		},
+		Roles: []auth.Role{auth.Admin},
+	}
+
+	addr := fx.Start()
+	defer fx.Stop()
+
+	client := fx.NewClient(addr, "valid")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	from := uuid.Must(uuid.NewRandom())
+	to := uuid.Must(uuid.NewRandom())
+	names := []string{"www.example.com", "mail.example.com"}
+	fx.MockUserManager.On("TransferDomains", from, to, names).Return(names, nil)
+	fx.MockUserManager.On("DeleteUser", from).Return(errors.New(errors.NotFound, "no such user"))
+
+	_, err := client.TransferDomains(ctx, from, to, names)
+	assert.NoError(t, err)
+	err = client.DeleteUser(ctx, from)
+	errors.AssertMatches(t, errors.New(errors.NotFound), err)
+
+	entries, err := client.ListAuditEntries(ctx, security.AuditFilter{Subject: "jdoe@example.com"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.Len(t, entries, 3) {
+		return
+	}
+	for i, expected := range []struct {
+		operation string
+		resource  string
+		outcome   security.AuditOutcome
+	}{
+		{"/pb.Admin/TransferDomains", security.AuditDomain("www.example.com"), security.AuditSuccess},
+		{"/pb.Admin/TransferDomains", security.AuditDomain("mail.example.com"), security.AuditSuccess},
+		{"/pb.Admin/DeleteUser", security.AuditUser(from), security.AuditFailure},
+	} {
+		assert.Equal(t, expected.operation, entries[i].Operation)
+		assert.Equal(t, expected.resource, entries[i].Resource)
+		assert.Equal(t, expected.outcome, entries[i].Outcome)
+		assert.Equal(t, "token-id", entries[i].TokenID)
+		assert.Equal(t, []string{string(auth.Admin)}, entries[i].Roles)
+		assert.NotEmpty(t, entries[i].PeerAddr)
+	}
+
+	entries, err = client.ListAuditEntries(ctx, security.AuditFilter{Resource: security.AuditUser(from)})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestAuditLog_AuthenticationFailures(t *testing.T) {
+	fx := grpcapi.NewTestFixture(t)
+	fx.Token = "valid"
+
+	addr := fx.Start()
+	defer fx.Stop()
+
+	client := fx.NewClient(addr, "invalid")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	userID := uuid.Must(uuid.NewRandom())
+	err := client.DeleteUser(ctx, userID)
+	errors.AssertMatches(t, errors.New(errors.Unauthorized), err)
+	fx.MockUserManager.AssertNotCalled(t, "DeleteUser", userID)
+
+	entries, err := fx.AuditLog.Query(security.AuditFilter{})
+	assert.NoError(t, err)
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, "/pb.Admin/DeleteUser", entries[0].Operation)
+		assert.Equal(t, security.AuditUser(userID), entries[0].Resource)
+		assert.Equal(t, security.AuditDenied, entries[0].Outcome)
+		assert.Empty(t, entries[0].Subject)
+		assert.NotEmpty(t, entries[0].PeerAddr)
+	}
+}
+
+func TestListAuditEntries_Authorization(t *testing.T) {
+	fx := grpcapi.NewTestFixture(t)
+	fx.Token = "valid"
+	fx.Claims = &auth.Claims{
+		StandardClaims: jwt.StandardClaims{
+			Subject: "jdoe@example.com",
+		},
+		Roles: []auth.Role{auth.Operator},
+	}
+
+	addr := fx.Start()
+	defer fx.Stop()
+
+	client := fx.NewClient(addr, "valid")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err := client.ListAuditEntries(ctx, security.AuditFilter{})
+	errors.AssertMatches(t, errors.New(errors.PermissionDenied), err)
+
+	entries, err := fx.AuditLog.Query(security.AuditFilter{})
+	assert.NoError(t, err)
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, "/pb.Admin/ListAuditEntries", entries[0].Operation)
+		assert.Equal(t, security.AuditDenied, entries[0].Outcome)
+	}
+}
@@ -0,0 +1,248 @@
+package grpcapi
+
+import (
+	"context"
+	"time"
+
+	"github.com/fhofherr/acmeproxy/pkg/api/auth"
+	"github.com/fhofherr/acmeproxy/pkg/api/grpcapi/internal/pb"
+	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/fhofherr/acmeproxy/pkg/requestid"
+	"github.com/fhofherr/acmeproxy/pkg/security"
+	"github.com/fhofherr/golf/log"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+)
+
+// AuditTrail wraps the methods of the audit log.
+//
+// Record appends an entry to the audit log. Query returns the entries
+// matching the filter ordered by the time they were recorded.
+type AuditTrail interface {
+	Record(security.AuditEntry) error
+	Query(security.AuditFilter) ([]security.AuditEntry, error)
+}
+
+const (
+	defaultAuditEntriesLimit = 1000
+	maxAuditEntriesLimit     = 10000
+)
+
+// auditUnary records every call of an authenticated caller in trail. It
+// records an entry for each resource the call targets. Errors recording the
+// entries are logged but do not fail the call.
+func auditUnary(trail AuditTrail, logger log.Logger) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		start := time.Now()
+		res, err := handler(ctx, req)
+		recordAudit(ctx, trail, logger, unaryMethod(info), start, auditResources(req, res), err)
+		return res, err
+	}
+}
+
+// auditStream is the equivalent of auditUnary for streaming RPCs. It records
+// the call once the stream ends. The resources are taken from the first
+// message the caller sent.
+func auditStream(trail AuditTrail, logger log.Logger) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler,
+	) error {
+		start := time.Now()
+		as := &auditedServerStream{ServerStream: ss}
+		err := handler(srv, as)
+		recordAudit(ss.Context(), trail, logger, streamMethod(info), start, auditResources(as.req, nil), err)
+		return err
+	}
+}
+
+// auditedServerStream keeps the first message received by the wrapped
+// ServerStream.
+type auditedServerStream struct {
+	grpc.ServerStream
+	req interface{}
+}
+
+func (s *auditedServerStream) RecvMsg(m interface{}) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil && s.req == nil {
+		s.req = m
+	}
+	return err
+}
+
+// recordAudit records the call of method in trail. The entry lacks the
+// Subject, Roles, and TokenID if ctx does not contain the claims of the
+// caller, e.g. because the caller failed to authenticate.
+func recordAudit(
+	ctx context.Context, trail AuditTrail, logger log.Logger, method string, start time.Time, resources []string, err error,
+) {
+	const op errors.Op = "grpcapi/recordAudit"
+
+	if trail == nil || isPublicMethod(method) {
+		return
+	}
+	e := security.AuditEntry{
+		Time:      start,
+		Operation: method,
+		Outcome:   security.AuditOutcomeOf(pb.FromGRPCStatusError(err)),
+	}
+	if claims, ok := auth.ClaimsFromContext(ctx); ok && claims != nil {
+		e.Subject = claims.Subject
+		e.TokenID = claims.Id
+		for _, r := range claims.Roles {
+			e.Roles = append(e.Roles, string(r))
+		}
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		e.PeerAddr = p.Addr.String()
+	}
+	if len(resources) == 0 {
+		resources = []string{""}
+	}
+	for _, r := range resources {
+		e.Resource = r
+		if err := trail.Record(e); err != nil {
+			errors.Log(logger, requestid.Error(ctx, errors.New(op, err)))
+		}
+	}
+}
+
+// auditResources returns the resources targeted by a call with the request
+// req and the response res. res is nil if the call failed.
+func auditResources(req, res interface{}) []string {
+	// RegisterUser creates the user. Its ID is only known after the call.
+	if u, ok := res.(*pb.User); ok && u != nil {
+		return auditUserResources(u.GetId())
+	}
+	if tr, ok := res.(*pb.TransferDomainsResponse); ok && tr != nil {
+		return auditDomainResources(tr.GetDomainNames())
+	}
+	switch msg := req.(type) {
+	case *pb.User:
+		return auditUserResources(msg.GetId())
+	case *pb.UserID:
+		return auditUserResources(msg.GetId())
+	case *pb.RevokedToken:
+		return []string{security.AuditToken(msg.GetId())}
+	case *pb.TransferDomainsRequest:
+		return auditDomainResources(msg.GetDomainNames())
+	case *pb.Domain:
+		return []string{security.AuditDomain(msg.GetName())}
+	case *pb.ListDomainsRequest:
+		return auditUserResources(msg.GetUserID())
+	case *pb.WatchCertificatesRequest:
+		return auditDomainResources(msg.GetDomainNames())
+	default:
+		return nil
+	}
+}
+
+func auditUserResources(id []byte) []string {
+	userID, err := uuid.FromBytes(id)
+	if err != nil {
+		return nil
+	}
+	return []string{security.AuditUser(userID)}
+}
+
+func auditDomainResources(names []string) []string {
+	resources := make([]string, 0, len(names))
+	for _, n := range names {
+		resources = append(resources, security.AuditDomain(n))
+	}
+	return resources
+}
+
+func auditFilterFromPB(req *pb.ListAuditEntriesRequest) (security.AuditFilter, error) {
+	const op errors.Op = "grpcapi/auditFilterFromPB"
+
+	f := security.AuditFilter{
+		Subject:  req.GetSubject(),
+		Resource: req.GetResource(),
+		Limit:    int(req.GetLimit()),
+	}
+	if f.Limit <= 0 {
+		f.Limit = defaultAuditEntriesLimit
+	}
+	if f.Limit > maxAuditEntriesLimit {
+		f.Limit = maxAuditEntriesLimit
+	}
+	var err error
+	if req.GetSince() != nil {
+		if f.Since, err = ptypes.Timestamp(req.GetSince()); err != nil {
+			return f, errors.New(op, errors.InvalidArgument, "invalid since", err)
+		}
+	}
+	if req.GetUntil() != nil {
+		if f.Until, err = ptypes.Timestamp(req.GetUntil()); err != nil {
+			return f, errors.New(op, errors.InvalidArgument, "invalid until", err)
+		}
+	}
+	return f, nil
+}
+
+func auditFilterToPB(f security.AuditFilter) (*pb.ListAuditEntriesRequest, error) {
+	const op errors.Op = "grpcapi/auditFilterToPB"
+
+	req := &pb.ListAuditEntriesRequest{
+		Subject:  f.Subject,
+		Resource: f.Resource,
+		Limit:    int32(f.Limit),
+	}
+	var err error
+	if !f.Since.IsZero() {
+		if req.Since, err = ptypes.TimestampProto(f.Since); err != nil {
+			return nil, errors.New(op, errors.InvalidArgument, "invalid since", err)
+		}
+	}
+	if !f.Until.IsZero() {
+		if req.Until, err = ptypes.TimestampProto(f.Until); err != nil {
+			return nil, errors.New(op, errors.InvalidArgument, "invalid until", err)
+		}
+	}
+	return req, nil
+}
+
+func auditEntryToPB(e security.AuditEntry) (*pb.AuditEntry, error) {
+	const op errors.Op = "grpcapi/auditEntryToPB"
+
+	ts, err := ptypes.TimestampProto(e.Time)
+	if err != nil {
+		return nil, errors.New(op, "convert time", err)
+	}
+	msg := &pb.AuditEntry{
+		Time:      ts,
+		Subject:   e.Subject,
+		Roles:     e.Roles,
+		TokenID:   e.TokenID,
+		PeerAddr:  e.PeerAddr,
+		Operation: e.Operation,
+		Resource:  e.Resource,
+		Outcome:   string(e.Outcome),
+	}
+	return msg, nil
+}
+
+func auditEntryFromPB(msg *pb.AuditEntry) (security.AuditEntry, error) {
+	const op errors.Op = "grpcapi/auditEntryFromPB"
+
+	t, err := ptypes.Timestamp(msg.GetTime())
+	if err != nil {
+		return security.AuditEntry{}, errors.New(op, "convert time", err)
+	}
+	e := security.AuditEntry{
+		Time:      t,
+		Subject:   msg.GetSubject(),
+		Roles:     msg.GetRoles(),
+		TokenID:   msg.GetTokenID(),
+		PeerAddr:  msg.GetPeerAddr(),
+		Operation: msg.GetOperation(),
+		Resource:  msg.GetResource(),
+		Outcome:   security.AuditOutcome(msg.GetOutcome()),
+	}
+	return e, nil
+}
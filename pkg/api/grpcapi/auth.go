@@ -2,11 +2,17 @@ package grpcapi
 
 import (
 	"context"
+	"crypto/x509"
+	"fmt"
 	"strings"
+	"time"
 
 	"github.com/fhofherr/acmeproxy/pkg/api/auth"
 	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 )
 
 // TokenParser is a function that parses and validates the signature of the
@@ -16,6 +22,63 @@ import (
 // Otherwise it returns an error.
 type TokenParser func(string) (*auth.Claims, error)
 
+// TokenRevoker wraps the methods to revoke tokens and to check if a token has
+// been revoked.
+//
+// RevokeToken revokes the token with the passed tokenID. The token may be
+// forgotten once it expired at expiresAt. IsRevoked checks if the token with
+// the passed tokenID has been revoked.
+type TokenRevoker interface {
+	RevokeToken(tokenID string, expiresAt time.Time) error
+	IsRevoked(tokenID string) (bool, error)
+}
+
+// CertificateMapper is a function that maps the verified client certificate
+// of the caller to the claims of the acmeproxy user the certificate belongs
+// to.
+//
+// It returns an error if the certificate does not belong to any user.
+type CertificateMapper func(*x509.Certificate) (*auth.Claims, error)
+
+// authCtx authenticates the caller and adds its claims to ctx.
+//
+// If the caller presented a bearer token authCtx uses parse to obtain the
+// claims. Otherwise, if mapCert is not nil, authCtx uses mapCert to obtain
+// the claims from the caller's verified client certificate.
+func authCtx(ctx context.Context, parse TokenParser, mapCert CertificateMapper) (context.Context, error) {
+	const op errors.Op = "grpcapi/authCtx"
+
+	if mapCert != nil && !hasAuthorizationHeader(ctx) {
+		ctx, err := certAuthCtx(ctx, mapCert)
+		return ctx, errors.Wrap(err, op)
+	}
+	ctx, err := tokenAuthCtx(ctx, parse)
+	return ctx, errors.Wrap(err, op)
+}
+
+func hasAuthorizationHeader(ctx context.Context) bool {
+	md, ok := metadata.FromIncomingContext(ctx)
+	return ok && len(md.Get("authorization")) > 0
+}
+
+func certAuthCtx(ctx context.Context, mapCert CertificateMapper) (context.Context, error) {
+	const op errors.Op = "grpcapi/certAuthCtx"
+
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ctx, errors.New(op, errors.Unauthorized, "missing credentials")
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 || len(tlsInfo.State.VerifiedChains[0]) == 0 {
+		return ctx, errors.New(op, errors.Unauthorized, "missing credentials")
+	}
+	claims, err := mapCert(tlsInfo.State.VerifiedChains[0][0])
+	if err != nil {
+		return ctx, errors.New(op, err)
+	}
+	return auth.AddClaimsToContext(ctx, claims), nil
+}
+
 func tokenAuthCtx(ctx context.Context, parse TokenParser) (context.Context, error) {
 	const op errors.Op = "grpcapi/tokenAuthCtx"
 
@@ -38,3 +101,70 @@ func tokenAuthCtx(ctx context.Context, parse TokenParser) (context.Context, erro
 	}
 	return auth.AddClaimsToContext(ctx, claims), nil
 }
+
+// checkRevoked returns an error of kind Unauthorized if the token the claims
+// in ctx belong to has been revoked.
+func checkRevoked(ctx context.Context, revoker TokenRevoker) error {
+	const op errors.Op = "grpcapi/checkRevoked"
+
+	claims, ok := auth.ClaimsFromContext(ctx)
+	if !ok || claims == nil {
+		return errors.New(op, errors.Unauthorized, "no claims in context")
+	}
+	revoked, err := revoker.IsRevoked(claims.Id)
+	if err != nil {
+		return errors.New(op, "check revocation", err)
+	}
+	if revoked {
+		return errors.New(op, errors.Unauthorized, "token revoked")
+	}
+	return nil
+}
+
+// userIDFromContext returns the ID of the acmeproxy user the claims stored in
+// ctx were issued for.
+//
+// The ID of the user is expected in the subject of the claims.
+func userIDFromContext(ctx context.Context) (uuid.UUID, error) {
+	const op errors.Op = "grpcapi/userIDFromContext"
+
+	claims, ok := auth.ClaimsFromContext(ctx)
+	if !ok || claims == nil {
+		return uuid.UUID{}, errors.New(op, errors.Unauthorized, "no claims in context")
+	}
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return uuid.UUID{}, errors.New(op, errors.Unauthorized, "subject is not a user id")
+	}
+	return userID, nil
+}
+
+// authorizeDomain checks if the claims stored in ctx allow to perform action
+// on the domain with the passed name. The action is authorized against the
+// user owning the domain according to owners. Domains which are not
+// registered yet are treated as if they were owned by the subject of the
+// claims.
+//
+// authorizeDomain returns the ID of the user owning the domain.
+func authorizeDomain(
+	ctx context.Context, owners DomainOwnerFinder, action auth.Action, domainName string,
+) (uuid.UUID, error) {
+	const op errors.Op = "grpcapi/authorizeDomain"
+
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return uuid.UUID{}, errors.New(op, err)
+	}
+	owner, err := owners.DomainOwner(domainName)
+	if errors.IsKind(err, errors.NotFound) {
+		owner, err = userID, nil
+	}
+	if err != nil {
+		return uuid.UUID{}, errors.New(op, fmt.Sprintf("find owner of domain: %s", domainName), err)
+	}
+	res := auth.Resource{UserID: owner, Domain: domainName}
+	if err := auth.Authorize(ctx, action, res); err != nil {
+		return uuid.UUID{}, errors.New(op, err)
+	}
+	return owner, nil
+}
@@ -2,6 +2,8 @@ package grpcapi
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"testing"
 
@@ -9,7 +11,9 @@ import (
 	"github.com/fhofherr/acmeproxy/pkg/api/auth"
 	"github.com/fhofherr/acmeproxy/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 )
 
 func TestTokenAuthCtx(t *testing.T) {
@@ -87,3 +91,85 @@ func TestTokenAuthCtx_MissingMetadata(t *testing.T) {
 	_, err := tokenAuthCtx(context.Background(), nil)
 	assert.Truef(t, errors.Is(err, expectedErr), "expected %v; got %v", expectedErr, err)
 }
+
+func TestAuthCtx_ClientCertificate(t *testing.T) {
+	clientCert := &x509.Certificate{DNSNames: []string{"web-1.example.com"}}
+	certClaims := &auth.Claims{StandardClaims: jwt.StandardClaims{Subject: "certificate"}}
+	tokenClaims := &auth.Claims{StandardClaims: jwt.StandardClaims{Subject: "token"}}
+	parser := func(string) (*auth.Claims, error) {
+		return tokenClaims, nil
+	}
+	mapper := func(cert *x509.Certificate) (*auth.Claims, error) {
+		if cert != clientCert {
+			return nil, errors.New(errors.Unauthorized, "unknown client certificate")
+		}
+		return certClaims, nil
+	}
+	verifiedPeer := &peer.Peer{
+		AuthInfo: credentials.TLSInfo{
+			State: tls.ConnectionState{
+				VerifiedChains: [][]*x509.Certificate{{clientCert}},
+			},
+		},
+	}
+	unverifiedPeer := &peer.Peer{
+		AuthInfo: credentials.TLSInfo{
+			State: tls.ConnectionState{
+				PeerCertificates: []*x509.Certificate{clientCert},
+			},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		peer    *peer.Peer
+		headers map[string]string
+		mapper  CertificateMapper
+		claims  *auth.Claims
+		err     error
+	}{
+		{
+			name:   "accept verified client certificate",
+			peer:   verifiedPeer,
+			mapper: mapper,
+			claims: certClaims,
+		},
+		{
+			name:    "prefer bearer token",
+			peer:    verifiedPeer,
+			headers: map[string]string{"authorization": "Bearer valid"},
+			mapper:  mapper,
+			claims:  tokenClaims,
+		},
+		{
+			name:   "reject unverified client certificate",
+			peer:   unverifiedPeer,
+			mapper: mapper,
+			err:    errors.New(errors.Unauthorized, "missing credentials"),
+		},
+		{
+			name:   "reject missing peer",
+			mapper: mapper,
+			err:    errors.New(errors.Unauthorized, "missing credentials"),
+		},
+		{
+			name: "require bearer token without certificate mapper",
+			peer: verifiedPeer,
+			err:  errors.New(errors.Unauthorized, "missing bearer token"),
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctxIn := metadata.NewIncomingContext(context.Background(), metadata.New(tt.headers))
+			if tt.peer != nil {
+				ctxIn = peer.NewContext(ctxIn, tt.peer)
+			}
+			ctx, err := authCtx(ctxIn, parser, tt.mapper)
+			errors.AssertMatches(t, tt.err, err)
+			claims, _ := auth.ClaimsFromContext(ctx)
+			assert.Equal(t, tt.claims, claims)
+		})
+	}
+}
@@ -14,18 +14,24 @@ import (
 // Client is used to connect to the Server using grpc.
 type Client struct {
 	adminClient
+	domainsClient
 	conn *grpc.ClientConn
 }
 
 // NewClient creates a new Client connecting to the server listening on addr.
+//
+// If token is nil the Client does not send a bearer token. In this case
+// tlsConfig has to contain a client certificate the server accepts.
 func NewClient(addr string, token *AuthToken, tlsConfig *tls.Config) (*Client, error) {
 	const op errors.Op = "grpcapi/NewClient"
 
-	transportCreds := credentials.NewTLS(tlsConfig)
-	conn, err := grpc.Dial(addr,
-		grpc.WithTransportCredentials(transportCreds),
-		grpc.WithPerRPCCredentials(token),
-	)
+	opts := []grpc.DialOption{
+		grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)),
+	}
+	if token != nil {
+		opts = append(opts, grpc.WithPerRPCCredentials(token))
+	}
+	conn, err := grpc.Dial(addr, opts...)
 	if err != nil {
 		return &Client{}, errors.New(op, fmt.Sprintf("dial: %s", addr), err)
 	}
@@ -33,10 +39,23 @@ func NewClient(addr string, token *AuthToken, tlsConfig *tls.Config) (*Client, e
 		adminClient: adminClient{
 			Client: pb.NewAdminClient(conn),
 		},
+		domainsClient: domainsClient{
+			Client: pb.NewDomainsClient(conn),
+		},
 		conn: conn,
 	}, nil
 }
 
+// Close closes the connection to the server.
+func (c *Client) Close() error {
+	const op errors.Op = "grpcapi/client.Close"
+
+	if c.conn == nil {
+		return nil
+	}
+	return errors.Wrap(c.conn.Close(), op)
+}
+
 // AuthToken represents a fixed authorization token used to authenticate
 // the client with the server.
 type AuthToken struct {
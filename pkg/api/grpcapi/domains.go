@@ -0,0 +1,356 @@
+package grpcapi
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+
+	"github.com/fhofherr/acmeproxy/pkg/acme"
+	"github.com/fhofherr/acmeproxy/pkg/api/auth"
+	"github.com/fhofherr/acmeproxy/pkg/api/grpcapi/internal/pb"
+	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/golang/protobuf/proto"
+	"github.com/google/uuid"
+)
+
+// OCSPResponseWriter wraps the WriteOCSPResponse method.
+//
+// WriteOCSPResponse writes the DER encoded OCSP response for the certificate
+// of domainName to w. Implementations must return an error if domainName is
+// not registered to the user identified by userID. If no OCSP response is
+// available yet they should return an error of kind NotFound.
+type OCSPResponseWriter interface {
+	WriteOCSPResponse(userID uuid.UUID, domainName string, w io.Writer) error
+}
+
+// CAARecommender wraps the RecommendCAARecords method.
+//
+// RecommendCAARecords returns the CAA records the user identified by userID
+// should publish for domainName.
+type CAARecommender interface {
+	RecommendCAARecords(ctx context.Context, userID uuid.UUID, domainName string) ([]acme.CAARecord, error)
+}
+
+// DomainLister wraps the ListDomains method.
+//
+// ListDomains returns all domains managed by acmeproxy ordered by their name.
+type DomainLister interface {
+	ListDomains() ([]acme.Domain, error)
+}
+
+// DomainOwnerFinder wraps the DomainOwner method.
+//
+// DomainOwner returns the ID of the user the domain with the passed name is
+// registered to. Implementations return an error of kind NotFound if the
+// domain is not registered.
+type DomainOwnerFinder interface {
+	DomainOwner(domainName string) (uuid.UUID, error)
+}
+
+// CertificateWatcher wraps the WatchCertificates method.
+//
+// WatchCertificates sends the current state of the domains identified by
+// domainNames on the returned channel. Afterwards it sends a domain every time
+// a new certificate is obtained for it. The channel is closed once ctx is
+// done. Implementations must return an error if one of the domains is not
+// registered to the user identified by userID.
+type CertificateWatcher interface {
+	WatchCertificates(ctx context.Context, userID uuid.UUID, domainNames []string) (<-chan acme.Domain, error)
+}
+
+type domainsServer struct {
+	OCSPResponseWriter OCSPResponseWriter
+	CAARecommender     CAARecommender
+	DomainLister       DomainLister
+	DomainOwnerFinder  DomainOwnerFinder
+	CertificateWatcher CertificateWatcher
+}
+
+func (s *domainsServer) GetOCSPResponse(ctx context.Context, domain *pb.Domain) (*pb.OCSPResponse, error) {
+	const op errors.Op = "grpcapi/domainsServer.GetOCSPResponse"
+
+	userID, err := authorizeDomain(ctx, s.DomainOwnerFinder, auth.ReadDomain, domain.GetName())
+	if err != nil {
+		return nil, pb.ToGRPCStatusError(errors.New(op, err))
+	}
+	var buf bytes.Buffer
+	if err := s.OCSPResponseWriter.WriteOCSPResponse(userID, domain.GetName(), &buf); err != nil {
+		err = errors.New(op, fmt.Sprintf("write ocsp response: %s", domain.GetName()), err)
+		return nil, pb.ToGRPCStatusError(err)
+	}
+	return &pb.OCSPResponse{
+		Raw: buf.Bytes(),
+	}, nil
+}
+
+func (s *domainsServer) GetCAARecords(ctx context.Context, domain *pb.Domain) (*pb.CAARecords, error) {
+	const op errors.Op = "grpcapi/domainsServer.GetCAARecords"
+
+	userID, err := authorizeDomain(ctx, s.DomainOwnerFinder, auth.ReadDomain, domain.GetName())
+	if err != nil {
+		return nil, pb.ToGRPCStatusError(errors.New(op, err))
+	}
+	records, err := s.CAARecommender.RecommendCAARecords(ctx, userID, domain.GetName())
+	if err != nil {
+		err = errors.New(op, fmt.Sprintf("recommend caa records: %s", domain.GetName()), err)
+		return nil, pb.ToGRPCStatusError(err)
+	}
+	res := &pb.CAARecords{
+		Records: make([]*pb.CAARecord, 0, len(records)),
+	}
+	for _, r := range records {
+		res.Records = append(res.Records, &pb.CAARecord{
+			Name:  r.Name,
+			Flag:  uint32(r.Flag),
+			Tag:   r.Tag,
+			Value: r.Value,
+		})
+	}
+	return res, nil
+}
+
+func (s *domainsServer) ListDomains(ctx context.Context, req *pb.ListDomainsRequest) (*pb.ListDomainsResponse, error) {
+	const op errors.Op = "grpcapi/domainsServer.ListDomains"
+
+	var userID uuid.UUID
+	if len(req.GetUserID()) > 0 {
+		id, err := parseUserID(req.GetUserID())
+		if err != nil {
+			return nil, pb.ToGRPCStatusError(errors.New(op, err))
+		}
+		userID = id
+	}
+	domains, err := s.DomainLister.ListDomains()
+	if err != nil {
+		return nil, pb.ToGRPCStatusError(errors.New(op, "list domains", err))
+	}
+	res := &pb.ListDomainsResponse{}
+	for _, d := range domains {
+		if userID != uuid.Nil && d.UserID != userID {
+			continue
+		}
+		// Silently skip the domains the caller must not read. Callers with
+		// the user role thus only see their own domains.
+		resource := auth.Resource{UserID: d.UserID, Domain: d.Name}
+		if err := auth.Authorize(ctx, auth.ReadDomain, resource); err != nil {
+			continue
+		}
+		res.Domains = append(res.Domains, &pb.DomainInfo{
+			Name:           d.Name,
+			UserID:         d.UserID[:],
+			CertificatePEM: d.Certificate,
+			Revoked:        d.Revoked,
+		})
+	}
+	return res, nil
+}
+
+func (s *domainsServer) WatchCertificates(req *pb.WatchCertificatesRequest, stream pb.Domains_WatchCertificatesServer) error {
+	const op errors.Op = "grpcapi/domainsServer.WatchCertificates"
+
+	ctx := stream.Context()
+	if len(req.GetDomainNames()) == 0 {
+		return pb.ToGRPCStatusError(errors.New(op, errors.InvalidArgument, "no domain names"))
+	}
+	var userID uuid.UUID
+	for i, name := range req.GetDomainNames() {
+		id, err := authorizeDomain(ctx, s.DomainOwnerFinder, auth.ReadDomain, name)
+		if err != nil {
+			return pb.ToGRPCStatusError(errors.New(op, err))
+		}
+		if i > 0 && id != userID {
+			err := errors.New(op, errors.InvalidArgument, "domains of different users cannot be watched together")
+			return pb.ToGRPCStatusError(err)
+		}
+		userID = id
+	}
+	digests, err := parseResumeToken(req.GetResumeToken(), req.GetDomainNames())
+	if err != nil {
+		return pb.ToGRPCStatusError(errors.New(op, err))
+	}
+	domains, err := s.CertificateWatcher.WatchCertificates(ctx, userID, req.GetDomainNames())
+	if err != nil {
+		return pb.ToGRPCStatusError(errors.New(op, "watch certificates", err))
+	}
+	for d := range domains {
+		if len(d.Certificate) == 0 {
+			continue
+		}
+		// Skip the certificates the client received before it reconnected.
+		digest := sha256.Sum256(d.Certificate)
+		if bytes.Equal(digests[d.Name], digest[:]) {
+			continue
+		}
+		digests[d.Name] = digest[:]
+		token, err := proto.Marshal(&pb.ResumeToken{CertificateSHA256: digests})
+		if err != nil {
+			return pb.ToGRPCStatusError(errors.New(op, "marshal resume token", err))
+		}
+		update := &pb.CertificateUpdate{
+			DomainName:     d.Name,
+			CertificatePEM: d.Certificate,
+			ResumeToken:    token,
+		}
+		if err := stream.Send(update); err != nil {
+			return pb.ToGRPCStatusError(errors.New(op, fmt.Sprintf("send certificate: %s", d.Name), err))
+		}
+	}
+	return nil
+}
+
+// parseResumeToken returns the digests of the certificates the client
+// received for domainNames. It returns an empty map if token is empty.
+func parseResumeToken(token []byte, domainNames []string) (map[string][]byte, error) {
+	const op errors.Op = "grpcapi/parseResumeToken"
+
+	digests := make(map[string][]byte, len(domainNames))
+	if len(token) == 0 {
+		return digests, nil
+	}
+	var rt pb.ResumeToken
+	if err := proto.Unmarshal(token, &rt); err != nil {
+		return nil, errors.New(op, errors.InvalidArgument, "invalid resume token", err)
+	}
+	// Forget the domains the client stopped watching. This keeps the token
+	// small.
+	for _, name := range domainNames {
+		if digest, ok := rt.GetCertificateSHA256()[name]; ok {
+			digests[name] = digest
+		}
+	}
+	return digests, nil
+}
+
+type domainsClient struct {
+	Client pb.DomainsClient
+}
+
+func (c *domainsClient) GetOCSPResponse(ctx context.Context, domainName string) ([]byte, error) {
+	const op errors.Op = "grpcapi/domainsClient.GetOCSPResponse"
+
+	req := &pb.Domain{
+		Name: domainName,
+	}
+	res, err := c.Client.GetOCSPResponse(ctx, req)
+	if err != nil {
+		err = pb.FromGRPCStatusError(err)
+		return nil, errors.New(op, fmt.Sprintf("get ocsp response: %s", domainName), err)
+	}
+	return res.GetRaw(), nil
+}
+
+func (c *domainsClient) GetCAARecords(ctx context.Context, domainName string) ([]acme.CAARecord, error) {
+	const op errors.Op = "grpcapi/domainsClient.GetCAARecords"
+
+	req := &pb.Domain{
+		Name: domainName,
+	}
+	res, err := c.Client.GetCAARecords(ctx, req)
+	if err != nil {
+		err = pb.FromGRPCStatusError(err)
+		return nil, errors.New(op, fmt.Sprintf("get caa records: %s", domainName), err)
+	}
+	records := make([]acme.CAARecord, 0, len(res.GetRecords()))
+	for _, r := range res.GetRecords() {
+		records = append(records, acme.CAARecord{
+			Name:  r.GetName(),
+			Flag:  uint8(r.GetFlag()),
+			Tag:   r.GetTag(),
+			Value: r.GetValue(),
+		})
+	}
+	return records, nil
+}
+
+// ListDomains returns the domains of the user identified by userID the caller
+// may read. It returns all domains the caller may read if userID is uuid.Nil.
+//
+// The returned domains never contain a private key or an OCSP response.
+func (c *domainsClient) ListDomains(ctx context.Context, userID uuid.UUID) ([]acme.Domain, error) {
+	const op errors.Op = "grpcapi/domainsClient.ListDomains"
+
+	req := &pb.ListDomainsRequest{}
+	if userID != uuid.Nil {
+		req.UserID = userID[:]
+	}
+	res, err := c.Client.ListDomains(ctx, req)
+	if err != nil {
+		err = pb.FromGRPCStatusError(err)
+		return nil, errors.New(op, "list domains", err)
+	}
+	domains := make([]acme.Domain, 0, len(res.GetDomains()))
+	for _, d := range res.GetDomains() {
+		id, err := parseUserID(d.GetUserID())
+		if err != nil {
+			return nil, errors.New(op, err)
+		}
+		domains = append(domains, acme.Domain{
+			Name:        d.GetName(),
+			UserID:      id,
+			Certificate: d.GetCertificatePEM(),
+			Revoked:     d.GetRevoked(),
+		})
+	}
+	return domains, nil
+}
+
+// CertificateUpdate contains a new certificate of a watched domain.
+//
+// ResumeToken identifies the certificates received so far. Pass it to
+// WatchCertificates to resume watching without receiving them again.
+type CertificateUpdate struct {
+	DomainName  string
+	Certificate []byte
+	ResumeToken []byte
+}
+
+// CertificateStream receives the certificate updates sent by the server.
+type CertificateStream struct {
+	stream pb.Domains_WatchCertificatesClient
+}
+
+// Recv blocks until the next certificate update arrives. It returns io.EOF
+// if the server ended the stream.
+func (s *CertificateStream) Recv() (CertificateUpdate, error) {
+	const op errors.Op = "grpcapi/certificateStream.Recv"
+
+	res, err := s.stream.Recv()
+	if err == io.EOF {
+		return CertificateUpdate{}, err
+	}
+	if err != nil {
+		err = pb.FromGRPCStatusError(err)
+		return CertificateUpdate{}, errors.New(op, "receive certificate update", err)
+	}
+	return CertificateUpdate{
+		DomainName:  res.GetDomainName(),
+		Certificate: res.GetCertificatePEM(),
+		ResumeToken: res.GetResumeToken(),
+	}, nil
+}
+
+// WatchCertificates watches the certificates of the domains identified by
+// domainNames until ctx is done.
+//
+// The server first sends the current certificate of each domain, and then
+// every new certificate it obtains. If resumeToken is not empty the server
+// skips the certificates the client already received. The updates never
+// contain a private key.
+func (c *domainsClient) WatchCertificates(
+	ctx context.Context, domainNames []string, resumeToken []byte,
+) (*CertificateStream, error) {
+	const op errors.Op = "grpcapi/domainsClient.WatchCertificates"
+
+	req := &pb.WatchCertificatesRequest{
+		DomainNames: domainNames,
+		ResumeToken: resumeToken,
+	}
+	stream, err := c.Client.WatchCertificates(ctx, req)
+	if err != nil {
+		err = pb.FromGRPCStatusError(err)
+		return nil, errors.New(op, "watch certificates", err)
+	}
+	return &CertificateStream{stream: stream}, nil
+}
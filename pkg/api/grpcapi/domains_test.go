@@ -0,0 +1,449 @@
+package grpcapi_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/fhofherr/acmeproxy/pkg/acme"
+	"github.com/fhofherr/acmeproxy/pkg/api/auth"
+	"github.com/fhofherr/acmeproxy/pkg/api/grpcapi"
+	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGetOCSPResponse(t *testing.T) {
+	userID := uuid.Must(uuid.NewRandom())
+	otherUserID := uuid.Must(uuid.NewRandom())
+	domainName := "www.example.com"
+	tests := []struct {
+		name     string
+		token    string
+		claims   *auth.Claims
+		owner    uuid.UUID
+		response []byte
+		writeErr error
+		err      error
+	}{
+		{
+			name:  "invalid token",
+			token: "invalid",
+			err:   errors.New(errors.Unauthorized),
+		},
+		{
+			name:  "subject is not a user id",
+			token: "valid",
+			claims: &auth.Claims{
+				StandardClaims: jwt.StandardClaims{
+					Subject: "jdoe@example.com",
+				},
+			},
+			err: errors.New(errors.Unauthorized),
+		},
+		{
+			name:  "no ocsp response available",
+			token: "valid",
+			claims: &auth.Claims{
+				StandardClaims: jwt.StandardClaims{
+					Subject: userID.String(),
+				},
+			},
+			writeErr: errors.New(errors.NotFound),
+			err:      errors.New(errors.NotFound),
+		},
+		{
+			name:  "domain of other user",
+			token: "valid",
+			claims: &auth.Claims{
+				StandardClaims: jwt.StandardClaims{
+					Subject: userID.String(),
+				},
+			},
+			owner: otherUserID,
+			err:   errors.New(errors.PermissionDenied),
+		},
+		{
+			name:  "operator reads domain of other user",
+			token: "valid",
+			claims: &auth.Claims{
+				StandardClaims: jwt.StandardClaims{
+					Subject: userID.String(),
+				},
+				Roles: []auth.Role{auth.Operator},
+			},
+			owner:    otherUserID,
+			response: []byte("ocsp response"),
+		},
+		{
+			name:  "domain outside of token scope",
+			token: "valid",
+			claims: &auth.Claims{
+				StandardClaims: jwt.StandardClaims{
+					Subject: userID.String(),
+				},
+				Scope: &auth.Scope{
+					Domains: []string{"mail.example.com"},
+				},
+			},
+			err: errors.New(errors.PermissionDenied),
+		},
+		{
+			name:  "read-only token",
+			token: "valid",
+			claims: &auth.Claims{
+				StandardClaims: jwt.StandardClaims{
+					Subject: userID.String(),
+				},
+				Roles: []auth.Role{auth.ReadOnly},
+				Scope: &auth.Scope{
+					Domains: []string{"*.example.com"},
+				},
+			},
+			response: []byte("ocsp response"),
+		},
+		{
+			name:  "ocsp response available",
+			token: "valid",
+			claims: &auth.Claims{
+				StandardClaims: jwt.StandardClaims{
+					Subject: userID.String(),
+				},
+			},
+			response: []byte("ocsp response"),
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			fx := grpcapi.NewTestFixture(t)
+			fx.Token = "valid"
+			fx.Claims = tt.claims
+			owner := userID
+			if tt.owner != uuid.Nil {
+				owner = tt.owner
+				fx.DomainOwners[domainName] = owner
+			}
+
+			addr := fx.Start()
+			defer fx.Stop()
+
+			client := fx.NewClient(addr, tt.token)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+
+			fx.MockOCSPResponseWriter.
+				On("WriteOCSPResponse", owner, domainName, mock.Anything).
+				Return(tt.response, tt.writeErr)
+
+			raw, err := client.GetOCSPResponse(ctx, domainName)
+			errors.AssertMatches(t, tt.err, err)
+			assert.Equal(t, tt.response, raw)
+		})
+	}
+}
+
+func TestGetCAARecords(t *testing.T) {
+	userID := uuid.Must(uuid.NewRandom())
+	domainName := "www.example.com"
+	tests := []struct {
+		name      string
+		token     string
+		claims    *auth.Claims
+		records   []acme.CAARecord
+		returnErr error
+		err       error
+	}{
+		{
+			name:  "invalid token",
+			token: "invalid",
+			err:   errors.New(errors.Unauthorized),
+		},
+		{
+			name:  "unknown user",
+			token: "valid",
+			claims: &auth.Claims{
+				StandardClaims: jwt.StandardClaims{
+					Subject: userID.String(),
+				},
+			},
+			returnErr: errors.New(errors.NotFound),
+			err:       errors.New(errors.NotFound),
+		},
+		{
+			name:  "recommend records",
+			token: "valid",
+			claims: &auth.Claims{
+				StandardClaims: jwt.StandardClaims{
+					Subject: userID.String(),
+				},
+			},
+			records: []acme.CAARecord{
+				{
+					Name:  domainName,
+					Tag:   "issue",
+					Value: "ca.example.com; accounturi=https://ca.example.com/acct/1",
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			fx := grpcapi.NewTestFixture(t)
+			fx.Token = "valid"
+			fx.Claims = tt.claims
+
+			addr := fx.Start()
+			defer fx.Stop()
+
+			client := fx.NewClient(addr, tt.token)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+
+			fx.MockCAARecommender.
+				On("RecommendCAARecords", userID, domainName).
+				Return(tt.records, tt.returnErr)
+
+			records, err := client.GetCAARecords(ctx, domainName)
+			errors.AssertMatches(t, tt.err, err)
+			if tt.err == nil {
+				assert.Equal(t, tt.records, records)
+			}
+		})
+	}
+}
+
+func TestListDomains(t *testing.T) {
+	owner := uuid.Must(uuid.NewRandom())
+	other := uuid.Must(uuid.NewRandom())
+	domains := []acme.Domain{
+		{
+			Name:        "example.com",
+			UserID:      owner,
+			Certificate: []byte("certificate"),
+			PrivateKey:  []byte("private key"),
+		},
+		{Name: "example.org", UserID: other, Revoked: true},
+	}
+	tests := []struct {
+		name     string
+		claims   *auth.Claims
+		userID   uuid.UUID
+		expected []string
+	}{
+		{
+			name: "operator reads all domains",
+			claims: &auth.Claims{
+				StandardClaims: jwt.StandardClaims{Subject: owner.String()},
+				Roles:          []auth.Role{auth.Operator},
+			},
+			expected: []string{"example.com", "example.org"},
+		},
+		{
+			name: "operator reads domains of other user",
+			claims: &auth.Claims{
+				StandardClaims: jwt.StandardClaims{Subject: owner.String()},
+				Roles:          []auth.Role{auth.Operator},
+			},
+			userID:   other,
+			expected: []string{"example.org"},
+		},
+		{
+			name: "user reads own domains",
+			claims: &auth.Claims{
+				StandardClaims: jwt.StandardClaims{Subject: owner.String()},
+			},
+			expected: []string{"example.com"},
+		},
+		{
+			name: "user reads domains of other user",
+			claims: &auth.Claims{
+				StandardClaims: jwt.StandardClaims{Subject: owner.String()},
+			},
+			userID: other,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			fx := grpcapi.NewTestFixture(t)
+			fx.Token = "valid"
+			fx.Claims = tt.claims
+
+			addr := fx.Start()
+			defer fx.Stop()
+
+			client := fx.NewClient(addr, "valid")
+
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+
+			fx.MockDomainLister.On("ListDomains").Return(domains, nil)
+
+			actual, err := client.ListDomains(ctx, tt.userID)
+			assert.NoError(t, err)
+			var names []string
+			for _, d := range actual {
+				assert.Empty(t, d.PrivateKey)
+				names = append(names, d.Name)
+			}
+			assert.Equal(t, tt.expected, names)
+		})
+	}
+}
+
+func TestWatchCertificates(t *testing.T) {
+	userID := uuid.Must(uuid.NewRandom())
+	domainNames := []string{"example.com", "example.org"}
+
+	fx := grpcapi.NewTestFixture(t)
+	fx.Token = "valid"
+	fx.Claims = &auth.Claims{
+		StandardClaims: jwt.StandardClaims{Subject: userID.String()},
+	}
+	addr := fx.Start()
+	defer fx.Stop()
+
+	client := fx.NewClient(addr, "valid")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	fx.MockCertificateWatcher.
+		On("WatchCertificates", userID, domainNames).
+		Return([]acme.Domain{
+			{Name: "example.com", UserID: userID, Certificate: []byte("certificate 1")},
+			{Name: "example.org", UserID: userID, Certificate: []byte("certificate 2")},
+			{Name: "example.com", UserID: userID, Certificate: []byte("certificate 3")},
+		}, nil)
+
+	stream, err := client.WatchCertificates(ctx, domainNames, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	var updates []grpcapi.CertificateUpdate
+	for i := 0; i < 3; i++ {
+		update, err := stream.Recv()
+		if !assert.NoError(t, err) {
+			return
+		}
+		updates = append(updates, update)
+	}
+	assert.Equal(t, "example.com", updates[0].DomainName)
+	assert.Equal(t, []byte("certificate 1"), updates[0].Certificate)
+	assert.Equal(t, "example.org", updates[1].DomainName)
+	assert.Equal(t, []byte("certificate 2"), updates[1].Certificate)
+	assert.Equal(t, "example.com", updates[2].DomainName)
+	assert.Equal(t, []byte("certificate 3"), updates[2].Certificate)
+
+	// Resuming after the second update must only send the third one.
+	stream, err = client.WatchCertificates(ctx, domainNames, updates[1].ResumeToken)
+	if !assert.NoError(t, err) {
+		return
+	}
+	update, err := stream.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, "example.com", update.DomainName)
+	assert.Equal(t, []byte("certificate 3"), update.Certificate)
+}
+
+func TestWatchCertificates_Errors(t *testing.T) {
+	userID := uuid.Must(uuid.NewRandom())
+	otherUserID := uuid.Must(uuid.NewRandom())
+	tests := []struct {
+		name        string
+		token       string
+		roles       []auth.Role
+		owners      grpcapi.DomainOwners
+		domainNames []string
+		resumeToken []byte
+		watchErr    error
+		err         error
+	}{
+		{
+			name:        "invalid token",
+			token:       "invalid",
+			domainNames: []string{"example.com"},
+			err:         errors.New(errors.Unauthorized),
+		},
+		{
+			name:  "no domain names",
+			token: "valid",
+			err:   errors.New(errors.InvalidArgument),
+		},
+		{
+			name:        "invalid resume token",
+			token:       "valid",
+			domainNames: []string{"example.com"},
+			resumeToken: []byte("invalid"),
+			err:         errors.New(errors.InvalidArgument),
+		},
+		{
+			name:        "domain of other user",
+			token:       "valid",
+			owners:      grpcapi.DomainOwners{"example.com": otherUserID},
+			domainNames: []string{"example.com"},
+			err:         errors.New(errors.PermissionDenied),
+		},
+		{
+			name:  "domains of different users",
+			token: "valid",
+			roles: []auth.Role{auth.Operator},
+			owners: grpcapi.DomainOwners{
+				"example.com": userID,
+				"example.org": otherUserID,
+			},
+			domainNames: []string{"example.com", "example.org"},
+			err:         errors.New(errors.InvalidArgument),
+		},
+		{
+			name:        "rejected by watcher",
+			token:       "valid",
+			domainNames: []string{"example.com"},
+			watchErr:    errors.New(errors.NotFound),
+			err:         errors.New(errors.NotFound),
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			fx := grpcapi.NewTestFixture(t)
+			fx.Token = "valid"
+			fx.Claims = &auth.Claims{
+				StandardClaims: jwt.StandardClaims{Subject: userID.String()},
+				Roles:          tt.roles,
+			}
+			for name, owner := range tt.owners {
+				fx.DomainOwners[name] = owner
+			}
+			addr := fx.Start()
+			defer fx.Stop()
+
+			client := fx.NewClient(addr, tt.token)
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+
+			if tt.watchErr != nil {
+				fx.MockCertificateWatcher.
+					On("WatchCertificates", userID, tt.domainNames).
+					Return(nil, tt.watchErr)
+			}
+
+			stream, err := client.WatchCertificates(ctx, tt.domainNames, tt.resumeToken)
+			if !assert.NoError(t, err) {
+				return
+			}
+			_, err = stream.Recv()
+			errors.AssertMatches(t, tt.err, err)
+			fx.MockCertificateWatcher.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,488 @@
+package grpcapi
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/fhofherr/acmeproxy/pkg/api/grpcapi/internal/pb"
+	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/fhofherr/acmeproxy/pkg/requestid"
+	"github.com/go-chi/chi"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+const (
+	// gatewayReadHeaderTimeout limits the time a client may take to send the
+	// headers of a request.
+	gatewayReadHeaderTimeout = 10 * time.Second
+
+	// gatewayReadTimeout limits the time a client may take to send a whole
+	// request.
+	gatewayReadTimeout = 30 * time.Second
+
+	// gatewayIdleTimeout is the time after which idle keep-alive
+	// connections are closed.
+	gatewayIdleTimeout = 2 * time.Minute
+
+	// maxGatewayBodySize is the maximum size of a request body in bytes. The
+	// messages of the gRPC API are much smaller.
+	maxGatewayBodySize = 1 << 20
+)
+
+// Gateway serves the Admin and Domains services of Server as a REST API.
+//
+// The request and response bodies are the protobuf messages of the gRPC API
+// encoded as JSON. Gateway passes each request through the same
+// authentication and handlers as Server. Callers thus authenticate using the
+// Authorization header or a client certificate, just like gRPC clients.
+// Errors are returned as JSON object with an HTTP status code derived from
+// the Kind of the error.
+//
+// All routes are prefixed with the version of the API, currently /v1. If
+// TLSConfig is nil Gateway uses the TLSConfig of Server.
+//
+// Request bodies larger than 1 MiB are rejected. Clients have to send each
+// request within 30 seconds.
+//
+// Callers may pass a request ID in the X-Request-Id header. Gateway generates
+// one if they do not. It returns the request ID in the X-Request-Id header of
+// every response, and in the body of error responses.
+type Gateway struct {
+	Server     *Server
+	TLSConfig  *tls.Config
+	httpServer *http.Server
+	once       sync.Once
+	initErr    error
+}
+
+// gatewayMethod describes how a route of the Gateway maps to a method of the
+// gRPC API.
+//
+// newRequest creates the request message of the method from the HTTP request.
+// call invokes the method. status is the HTTP status code of successful
+// responses. Responses with status http.StatusNoContent have no body.
+type gatewayMethod struct {
+	fullMethod string
+	newRequest func(*http.Request) (proto.Message, error)
+	call       grpc.UnaryHandler
+	status     int
+}
+
+// gatewayError is the JSON representation of the errors returned by the
+// Gateway.
+type gatewayError struct {
+	Kind      string     `json:"kind,omitempty"`
+	Message   string     `json:"message"`
+	RetryAt   *time.Time `json:"retryAt,omitempty"`
+	RequestID string     `json:"requestID,omitempty"`
+}
+
+// Serve accepts incoming TLS connections on l.
+func (g *Gateway) Serve(l net.Listener) error {
+	const op errors.Op = "grpcapi/gateway.Serve"
+
+	if err := g.initialize(); err != nil {
+		return errors.New(op, err)
+	}
+	return errors.Wrap(g.httpServer.ServeTLS(l, "", ""), op, "serve https")
+}
+
+// Shutdown gracefully stops the Gateway.
+func (g *Gateway) Shutdown(ctx context.Context) error {
+	const op errors.Op = "grpcapi/gateway.Shutdown"
+
+	if g.httpServer == nil {
+		return errors.New(op, "not started")
+	}
+	return errors.Wrap(g.httpServer.Shutdown(ctx), op, "shutdown")
+}
+
+func (g *Gateway) initialize() error {
+	const op errors.Op = "grpcapi/gateway.initialize"
+
+	g.once.Do(func() {
+		if g.Server == nil {
+			g.initErr = errors.New(op, "no server provided")
+			return
+		}
+		if err := g.Server.initialize(); err != nil {
+			g.initErr = errors.New(op, err)
+			return
+		}
+		tlsConfig := g.TLSConfig
+		if tlsConfig == nil {
+			tlsConfig = g.Server.TLSConfig
+		}
+		g.httpServer = &http.Server{
+			Handler:           g.newRouter(),
+			TLSConfig:         tlsConfig,
+			ReadHeaderTimeout: gatewayReadHeaderTimeout,
+			ReadTimeout:       gatewayReadTimeout,
+			IdleTimeout:       gatewayIdleTimeout,
+		}
+	})
+	return g.initErr
+}
+
+func (g *Gateway) newRouter() http.Handler {
+	admin := g.Server.admin
+	domains := g.Server.domains
+
+	r := chi.NewRouter()
+	r.Use(requestid.Middleware)
+	r.Route("/v1", func(r chi.Router) {
+		r.Post("/users", g.handle(gatewayMethod{
+			fullMethod: "/pb.Admin/RegisterUser",
+			newRequest: bodyRequest(func() proto.Message { return &pb.Email{} }),
+			call: func(ctx context.Context, req interface{}) (interface{}, error) {
+				return admin.RegisterUser(ctx, req.(*pb.Email))
+			},
+			status: http.StatusCreated,
+		}))
+		r.Get("/users", g.handle(gatewayMethod{
+			fullMethod: "/pb.Admin/ListUsers",
+			newRequest: listUsersRequest,
+			call: func(ctx context.Context, req interface{}) (interface{}, error) {
+				return admin.ListUsers(ctx, req.(*pb.ListUsersRequest))
+			},
+		}))
+		r.Get("/users/{userID}", g.handle(gatewayMethod{
+			fullMethod: "/pb.Admin/GetUser",
+			newRequest: userIDRequest,
+			call: func(ctx context.Context, req interface{}) (interface{}, error) {
+				return admin.GetUser(ctx, req.(*pb.UserID))
+			},
+		}))
+		r.Put("/users/{userID}", g.handle(gatewayMethod{
+			fullMethod: "/pb.Admin/UpdateUser",
+			newRequest: updateUserRequest,
+			call: func(ctx context.Context, req interface{}) (interface{}, error) {
+				return admin.UpdateUser(ctx, req.(*pb.User))
+			},
+		}))
+		r.Delete("/users/{userID}", g.handle(gatewayMethod{
+			fullMethod: "/pb.Admin/DeleteUser",
+			newRequest: userIDRequest,
+			call: func(ctx context.Context, req interface{}) (interface{}, error) {
+				return admin.DeleteUser(ctx, req.(*pb.UserID))
+			},
+			status: http.StatusNoContent,
+		}))
+		r.Post("/users/{userID}/domain-transfers", g.handle(gatewayMethod{
+			fullMethod: "/pb.Admin/TransferDomains",
+			newRequest: transferDomainsRequest,
+			call: func(ctx context.Context, req interface{}) (interface{}, error) {
+				return admin.TransferDomains(ctx, req.(*pb.TransferDomainsRequest))
+			},
+		}))
+		r.Post("/revoked-tokens", g.handle(gatewayMethod{
+			fullMethod: "/pb.Admin/RevokeToken",
+			newRequest: bodyRequest(func() proto.Message { return &pb.RevokedToken{} }),
+			call: func(ctx context.Context, req interface{}) (interface{}, error) {
+				return admin.RevokeToken(ctx, req.(*pb.RevokedToken))
+			},
+			status: http.StatusNoContent,
+		}))
+		r.Get("/status", g.handle(gatewayMethod{
+			fullMethod: "/pb.Admin/GetStatus",
+			newRequest: emptyRequest,
+			call: func(ctx context.Context, req interface{}) (interface{}, error) {
+				return admin.GetStatus(ctx, nil)
+			},
+		}))
+		r.Get("/audit-entries", g.handle(gatewayMethod{
+			fullMethod: "/pb.Admin/ListAuditEntries",
+			newRequest: listAuditEntriesRequest,
+			call: func(ctx context.Context, req interface{}) (interface{}, error) {
+				return admin.ListAuditEntries(ctx, req.(*pb.ListAuditEntriesRequest))
+			},
+		}))
+		r.Get("/domains", g.handle(gatewayMethod{
+			fullMethod: "/pb.Domains/ListDomains",
+			newRequest: listDomainsRequest,
+			call: func(ctx context.Context, req interface{}) (interface{}, error) {
+				return domains.ListDomains(ctx, req.(*pb.ListDomainsRequest))
+			},
+		}))
+		r.Get("/domains/{domain}/ocsp-response", g.handle(gatewayMethod{
+			fullMethod: "/pb.Domains/GetOCSPResponse",
+			newRequest: domainRequest,
+			call: func(ctx context.Context, req interface{}) (interface{}, error) {
+				return domains.GetOCSPResponse(ctx, req.(*pb.Domain))
+			},
+		}))
+		r.Get("/domains/{domain}/caa-records", g.handle(gatewayMethod{
+			fullMethod: "/pb.Domains/GetCAARecords",
+			newRequest: domainRequest,
+			call: func(ctx context.Context, req interface{}) (interface{}, error) {
+				return domains.GetCAARecords(ctx, req.(*pb.Domain))
+			},
+		}))
+	})
+	r.NotFound(func(w http.ResponseWriter, req *http.Request) {
+		const op errors.Op = "grpcapi/gateway.notFound"
+
+		err := errors.New(op, errors.NotFound, fmt.Sprintf("no such route: %s", req.URL.Path))
+		writeGatewayError(w, requestid.Error(req.Context(), err))
+	})
+	return r
+}
+
+// handle returns a http.HandlerFunc serving m. It passes each request through
+// the middleware chain of unary calls of the Server, which also authenticates
+// the caller.
+func (g *Gateway) handle(m gatewayMethod) http.HandlerFunc {
+	if m.status == 0 {
+		m.status = http.StatusOK
+	}
+	return func(w http.ResponseWriter, req *http.Request) {
+		req.Body = http.MaxBytesReader(w, req.Body, maxGatewayBodySize)
+		msg, err := m.newRequest(req)
+		if err != nil {
+			writeGatewayError(w, requestid.Error(req.Context(), err))
+			return
+		}
+		info := &grpc.UnaryServerInfo{FullMethod: m.fullMethod}
+		res, err := g.Server.unaryChain(gatewayCtx(req), msg, info, m.call)
+		if err != nil {
+			writeGatewayError(w, pb.FromGRPCStatusError(err))
+			return
+		}
+		if m.status == http.StatusNoContent {
+			w.WriteHeader(m.status)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(m.status)
+		marshaler := jsonpb.Marshaler{EmitDefaults: true}
+		// The status has been sent already. There is nothing we can do about
+		// errors anymore.
+		_ = marshaler.Marshal(w, res.(proto.Message))
+	}
+}
+
+// gatewayCtx returns a context containing the credentials of the caller, the
+// request ID, and the caller's trace context in the same form the gRPC server
+// provides them.
+func gatewayCtx(req *http.Request) context.Context {
+	ctx := req.Context()
+	md := metadata.Pairs(requestid.MetadataKey, requestid.FromContext(ctx))
+	if h := req.Header.Get("Authorization"); h != "" {
+		md.Set("authorization", h)
+	}
+	if h := req.Header.Get(requestid.TraceParentHeader); h != "" {
+		md.Set(requestid.TraceParentHeader, h)
+	}
+	ctx = metadata.NewIncomingContext(ctx, md)
+	p := &peer.Peer{Addr: gatewayAddr(req.RemoteAddr)}
+	if req.TLS != nil {
+		p.AuthInfo = credentials.TLSInfo{State: *req.TLS}
+	}
+	return peer.NewContext(ctx, p)
+}
+
+// gatewayAddr is the address of a caller of the Gateway.
+type gatewayAddr string
+
+func (a gatewayAddr) Network() string {
+	return "tcp"
+}
+
+func (a gatewayAddr) String() string {
+	return string(a)
+}
+
+func writeGatewayError(w http.ResponseWriter, err error) {
+	body := gatewayError{
+		Message: fmt.Sprintf("%v", err),
+	}
+	kind := errors.GetKind(err)
+	if kind != errors.Unspecified {
+		body.Kind = kind.String()
+	}
+	body.RequestID = errors.GetRequestID(err)
+	if retryAt := errors.GetRetryAt(err).UTC(); !retryAt.IsZero() {
+		body.RetryAt = &retryAt
+		secs := int(time.Until(retryAt).Seconds()) + 1
+		if secs < 1 {
+			secs = 1
+		}
+		w.Header().Set("Retry-After", strconv.Itoa(secs))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpStatusFromErr(err))
+	_ = json.NewEncoder(w).Encode(struct {
+		Error gatewayError `json:"error"`
+	}{body})
+}
+
+func httpStatusFromErr(err error) int {
+	switch errors.GetKind(err) {
+	case errors.NotFound:
+		return http.StatusNotFound
+	case errors.Unauthorized:
+		return http.StatusUnauthorized
+	case errors.PermissionDenied:
+		return http.StatusForbidden
+	case errors.InvalidArgument:
+		return http.StatusBadRequest
+	case errors.RateLimited:
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func emptyRequest(*http.Request) (proto.Message, error) {
+	return nil, nil
+}
+
+// bodyRequest returns a function decoding the body of a request into the
+// message created by newMsg. An empty body results in an empty message.
+func bodyRequest(newMsg func() proto.Message) func(*http.Request) (proto.Message, error) {
+	return func(req *http.Request) (proto.Message, error) {
+		const op errors.Op = "grpcapi/bodyRequest"
+
+		msg := newMsg()
+		if err := jsonpb.Unmarshal(req.Body, msg); err != nil && err != io.EOF {
+			return nil, errors.New(op, errors.InvalidArgument, "decode request body", err)
+		}
+		return msg, nil
+	}
+}
+
+func userIDRequest(req *http.Request) (proto.Message, error) {
+	const op errors.Op = "grpcapi/userIDRequest"
+
+	id, err := userIDParam(req)
+	if err != nil {
+		return nil, errors.New(op, err)
+	}
+	return &pb.UserID{Id: id[:]}, nil
+}
+
+func updateUserRequest(req *http.Request) (proto.Message, error) {
+	const op errors.Op = "grpcapi/updateUserRequest"
+
+	id, err := userIDParam(req)
+	if err != nil {
+		return nil, errors.New(op, err)
+	}
+	msg, err := bodyRequest(func() proto.Message { return &pb.User{} })(req)
+	if err != nil {
+		return nil, errors.New(op, err)
+	}
+	u := msg.(*pb.User)
+	u.Id = id[:]
+	return u, nil
+}
+
+func transferDomainsRequest(req *http.Request) (proto.Message, error) {
+	const op errors.Op = "grpcapi/transferDomainsRequest"
+
+	id, err := userIDParam(req)
+	if err != nil {
+		return nil, errors.New(op, err)
+	}
+	msg, err := bodyRequest(func() proto.Message { return &pb.TransferDomainsRequest{} })(req)
+	if err != nil {
+		return nil, errors.New(op, err)
+	}
+	tr := msg.(*pb.TransferDomainsRequest)
+	tr.From = id[:]
+	return tr, nil
+}
+
+func listUsersRequest(req *http.Request) (proto.Message, error) {
+	const op errors.Op = "grpcapi/listUsersRequest"
+
+	msg := &pb.ListUsersRequest{
+		PageToken: req.URL.Query().Get("pageToken"),
+	}
+	if s := req.URL.Query().Get("pageSize"); s != "" {
+		n, err := strconv.ParseInt(s, 10, 32)
+		if err != nil {
+			return nil, errors.New(op, errors.InvalidArgument, "invalid page size", err)
+		}
+		msg.PageSize = int32(n)
+	}
+	return msg, nil
+}
+
+func listDomainsRequest(req *http.Request) (proto.Message, error) {
+	const op errors.Op = "grpcapi/listDomainsRequest"
+
+	msg := &pb.ListDomainsRequest{}
+	if s := req.URL.Query().Get("userID"); s != "" {
+		id, err := uuid.Parse(s)
+		if err != nil {
+			return nil, errors.New(op, errors.InvalidArgument, "invalid user id", err)
+		}
+		msg.UserID = id[:]
+	}
+	return msg, nil
+}
+
+// listAuditEntriesRequest reads the filter of the audit entries from the
+// query parameters since, until, subject, resource, and limit. Times are
+// expected in RFC 3339 format.
+func listAuditEntriesRequest(req *http.Request) (proto.Message, error) {
+	const op errors.Op = "grpcapi/listAuditEntriesRequest"
+
+	q := req.URL.Query()
+	msg := &pb.ListAuditEntriesRequest{
+		Subject:  q.Get("subject"),
+		Resource: q.Get("resource"),
+	}
+	for param, ts := range map[string]**timestamp.Timestamp{"since": &msg.Since, "until": &msg.Until} {
+		s := q.Get(param)
+		if s == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return nil, errors.New(op, errors.InvalidArgument, fmt.Sprintf("invalid %s", param), err)
+		}
+		if *ts, err = ptypes.TimestampProto(t); err != nil {
+			return nil, errors.New(op, errors.InvalidArgument, fmt.Sprintf("invalid %s", param), err)
+		}
+	}
+	if s := q.Get("limit"); s != "" {
+		n, err := strconv.ParseInt(s, 10, 32)
+		if err != nil {
+			return nil, errors.New(op, errors.InvalidArgument, "invalid limit", err)
+		}
+		msg.Limit = int32(n)
+	}
+	return msg, nil
+}
+
+func domainRequest(req *http.Request) (proto.Message, error) {
+	return &pb.Domain{Name: chi.URLParam(req, "domain")}, nil
+}
+
+func userIDParam(req *http.Request) (uuid.UUID, error) {
+	const op errors.Op = "grpcapi/userIDParam"
+
+	id, err := uuid.Parse(chi.URLParam(req, "userID"))
+	if err != nil {
+		return uuid.UUID{}, errors.New(op, errors.InvalidArgument, "invalid user id", err)
+	}
+	return id, nil
+}
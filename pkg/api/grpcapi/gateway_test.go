@@ -0,0 +1,226 @@
+package grpcapi_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fhofherr/acmeproxy/pkg/acme"
+	"github.com/fhofherr/acmeproxy/pkg/api/auth"
+	"github.com/fhofherr/acmeproxy/pkg/api/grpcapi"
+	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type gatewayErrorBody struct {
+	Error struct {
+		Kind      string     `json:"kind"`
+		Message   string     `json:"message"`
+		RetryAt   *time.Time `json:"retryAt"`
+		RequestID string     `json:"requestID"`
+	} `json:"error"`
+}
+
+func TestGateway_UpdateUser(t *testing.T) {
+	fx := grpcapi.NewTestFixture(t)
+	fx.Token = "valid"
+	fx.Claims = &auth.Claims{Roles: []auth.Role{auth.Admin}}
+
+	addr := fx.StartGateway()
+	defer fx.StopGateway()
+
+	userID := uuid.Must(uuid.NewRandom())
+	update := acme.User{
+		ID:     userID,
+		Email:  "jane.doe@example.com",
+		Labels: map[string]string{"team": "web"},
+	}
+	updated := update
+	updated.AccountURL = "https://example.com/some/account"
+	fx.MockUserManager.On("UpdateUser", userID, update).Return(updated, nil)
+
+	url := fmt.Sprintf("https://%s/v1/users/%s", addr, userID)
+	body := `{"email": "jane.doe@example.com", "labels": {"team": "web"}}`
+	req, err := http.NewRequest(http.MethodPut, url, strings.NewReader(body))
+	if !assert.NoError(t, err) {
+		return
+	}
+	req.Header.Set("Authorization", "Bearer valid")
+	req.Header.Set("X-Request-Id", "some-request")
+
+	resp, err := fx.NewHTTPClient().Do(req)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	assert.Equal(t, "some-request", resp.Header.Get("X-Request-Id"))
+
+	var actual struct {
+		Email      string            `json:"email"`
+		AccountURL string            `json:"accountURL"`
+		Labels     map[string]string `json:"labels"`
+	}
+	if !assert.NoError(t, json.NewDecoder(resp.Body).Decode(&actual)) {
+		return
+	}
+	assert.Equal(t, updated.Email, actual.Email)
+	assert.Equal(t, updated.AccountURL, actual.AccountURL)
+	assert.Equal(t, updated.Labels, actual.Labels)
+}
+
+func TestGateway_Errors(t *testing.T) {
+	userID := uuid.Must(uuid.NewRandom())
+	retryAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		token      string
+		body       string
+		setup      func(*grpcapi.TestFixture)
+		status     int
+		kind       errors.Kind
+		retryAfter bool
+	}{
+		{
+			name:   "missing token",
+			method: http.MethodGet,
+			path:   "/v1/status",
+			status: http.StatusUnauthorized,
+			kind:   errors.Unauthorized,
+		},
+		{
+			name:   "invalid user id",
+			method: http.MethodGet,
+			path:   "/v1/users/invalid",
+			token:  "valid",
+			status: http.StatusBadRequest,
+			kind:   errors.InvalidArgument,
+		},
+		{
+			name:   "invalid request body",
+			method: http.MethodPost,
+			path:   "/v1/revoked-tokens",
+			token:  "valid",
+			status: http.StatusBadRequest,
+			kind:   errors.InvalidArgument,
+		},
+		{
+			name:   "request body too large",
+			method: http.MethodPost,
+			path:   "/v1/revoked-tokens",
+			token:  "valid",
+			body:   strings.Repeat(" ", 1<<20) + `{"id": "token"}`,
+			status: http.StatusBadRequest,
+			kind:   errors.InvalidArgument,
+		},
+		{
+			name:   "unknown user",
+			method: http.MethodGet,
+			path:   "/v1/users/" + userID.String(),
+			token:  "valid",
+			setup: func(fx *grpcapi.TestFixture) {
+				fx.MockUserManager.
+					On("GetUser", userID).
+					Return(acme.User{}, errors.New(errors.NotFound, "user not found"))
+			},
+			status: http.StatusNotFound,
+			kind:   errors.NotFound,
+		},
+		{
+			name:   "permission denied",
+			method: http.MethodGet,
+			path:   "/v1/users/" + userID.String(),
+			token:  "valid",
+			setup: func(fx *grpcapi.TestFixture) {
+				fx.MockUserManager.
+					On("GetUser", userID).
+					Return(acme.User{}, errors.New(errors.PermissionDenied, "not allowed"))
+			},
+			status: http.StatusForbidden,
+			kind:   errors.PermissionDenied,
+		},
+		{
+			name:   "rate limited",
+			method: http.MethodGet,
+			path:   "/v1/users/" + userID.String(),
+			token:  "valid",
+			setup: func(fx *grpcapi.TestFixture) {
+				fx.MockUserManager.
+					On("GetUser", userID).
+					Return(acme.User{}, errors.New(errors.RateLimited, retryAt, "slow down"))
+			},
+			status:     http.StatusTooManyRequests,
+			kind:       errors.RateLimited,
+			retryAfter: true,
+		},
+		{
+			name:   "unknown route",
+			method: http.MethodGet,
+			path:   "/v2/status",
+			token:  "valid",
+			status: http.StatusNotFound,
+			kind:   errors.NotFound,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			fx := grpcapi.NewTestFixture(t)
+			fx.Token = "valid"
+			fx.Claims = &auth.Claims{Roles: []auth.Role{auth.Admin}}
+			if tt.setup != nil {
+				tt.setup(fx)
+			}
+
+			addr := fx.StartGateway()
+			defer fx.StopGateway()
+
+			reqBody := tt.body
+			if reqBody == "" {
+				reqBody = "{invalid"
+			}
+			url := fmt.Sprintf("https://%s%s", addr, tt.path)
+			req, err := http.NewRequest(tt.method, url, strings.NewReader(reqBody))
+			if !assert.NoError(t, err) {
+				return
+			}
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+
+			resp, err := fx.NewHTTPClient().Do(req)
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.status, resp.StatusCode)
+			assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+
+			var body gatewayErrorBody
+			if !assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body)) {
+				return
+			}
+			assert.Equal(t, tt.kind.String(), body.Error.Kind)
+			assert.NotEmpty(t, body.Error.Message)
+			assert.NotEmpty(t, body.Error.RequestID)
+			assert.Equal(t, resp.Header.Get("X-Request-Id"), body.Error.RequestID)
+			if tt.retryAfter {
+				assert.NotEmpty(t, resp.Header.Get("Retry-After"))
+				if assert.NotNil(t, body.Error.RetryAt) {
+					assert.True(t, retryAt.Equal(*body.Error.RetryAt))
+				}
+			}
+			fx.MockUserManager.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/fhofherr/golf/log"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// DefaultHealthCheckInterval is the interval in which Server runs its
+// HealthChecks if no other interval was configured.
+const DefaultHealthCheckInterval = 30 * time.Second
+
+// healthCheckTimeout limits the duration of a single HealthCheck.
+const healthCheckTimeout = 5 * time.Second
+
+// publicServices are the gRPC services callers may use without
+// authentication.
+var publicServices = []string{
+	"grpc.health.v1.Health",
+	"grpc.reflection.v1alpha.ServerReflection",
+}
+
+// isPublicMethod returns true if fullMethod belongs to one of the
+// publicServices.
+func isPublicMethod(fullMethod string) bool {
+	for _, svc := range publicServices {
+		if strings.HasPrefix(fullMethod, "/"+svc+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// HealthCheck checks the health of a sub-system of acmeproxy. It returns an
+// error if the sub-system does not work.
+type HealthCheck func(ctx context.Context) error
+
+// healthChecker periodically runs the checks and reports their results to
+// the health service.
+//
+// Every check is reported as a service named after its key in checks. The
+// overall health is reported as the service with the empty name. It is
+// serving only if all checks pass.
+type healthChecker struct {
+	Checks   map[string]HealthCheck
+	Interval time.Duration
+	Server   *health.Server
+	Logger   log.Logger
+	done     chan struct{}
+	once     sync.Once
+}
+
+func (h *healthChecker) run() {
+	interval := h.Interval
+	if interval <= 0 {
+		interval = DefaultHealthCheckInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		h.check()
+		select {
+		case <-ticker.C:
+		case <-h.done:
+			return
+		}
+	}
+}
+
+func (h *healthChecker) check() {
+	const op errors.Op = "grpcapi/healthChecker.check"
+
+	overall := healthpb.HealthCheckResponse_SERVING
+	for name, check := range h.Checks {
+		status := healthpb.HealthCheckResponse_SERVING
+		ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+		if err := check(ctx); err != nil {
+			errors.Log(h.Logger, errors.New(op, fmt.Sprintf("health check failed: %s", name), err))
+			status = healthpb.HealthCheckResponse_NOT_SERVING
+			overall = healthpb.HealthCheckResponse_NOT_SERVING
+		}
+		cancel()
+		h.Server.SetServingStatus(name, status)
+	}
+	h.Server.SetServingStatus("", overall)
+}
+
+func (h *healthChecker) stop() {
+	h.once.Do(func() {
+		close(h.done)
+	})
+	h.Server.Shutdown()
+}
@@ -1,34 +1,78 @@
 package grpcapi
 
 import (
-	"context"
+	"time"
 
-	"github.com/fhofherr/acmeproxy/pkg/api/grpcapi/internal/pb"
-	"github.com/fhofherr/acmeproxy/pkg/errors"
 	"github.com/fhofherr/golf/log"
 	"google.golang.org/grpc"
 )
 
+// unaryServerInterceptor configures the middleware chain unary calls pass
+// through.
+//
+// The chain turns panics into errors with code codes.Internal, assigns each
+// call a request ID, records a span for each call, records each call in
+// Metrics, logs each call with its duration and status code, and translates
+// errors into gRPC status errors. It limits the duration of each call to
+// CallTimeout unless CallTimeout is zero. It rejects calls from network
+// addresses exceeding their rate limit before it authenticates the caller,
+// and calls of callers exceeding their rate limit afterwards. Finally it records
+// the remaining calls in the AuditTrail. Calls of callers failing to
+// authenticate are recorded in the AuditTrail as well.
 type unaryServerInterceptor struct {
-	TokenParser TokenParser
-	Logger      log.Logger
+	TokenParser       TokenParser
+	CertificateMapper CertificateMapper
+	TokenRevoker      TokenRevoker
+	RateLimiter       *rateLimiter
+	AuditTrail        AuditTrail
+	Metrics           *CallMetrics
+	Logger            log.Logger
+	CallTimeout       time.Duration
 }
 
-func (u *unaryServerInterceptor) intercept(
-	ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
-) (
-	res interface{}, err error,
-) {
-	ctx, err = tokenAuthCtx(ctx, u.TokenParser)
-	if err != nil {
-		errors.Log(u.Logger, err)
-		err = pb.ToGRPCStatusError(err)
-		return
-	}
-	errors.LogFunc(u.Logger, func() error {
-		res, err = handler(ctx, req)
-		return pb.FromGRPCStatusError(err)
-	})
+// chain builds the middleware chain. The chain does not change once it is
+// built.
+func (u *unaryServerInterceptor) chain() grpc.UnaryServerInterceptor {
+	return chainUnaryInterceptors(
+		recoverUnary(u.Logger),
+		requestIDUnary,
+		traceUnary,
+		metricsUnary(u.Metrics),
+		logUnary(u.Logger),
+		translateErrorsUnary,
+		deadlineUnary(u.CallTimeout),
+		rateLimitAddressUnary(u.RateLimiter),
+		authUnary(u.TokenParser, u.CertificateMapper, u.TokenRevoker, u.AuditTrail, u.Logger),
+		rateLimitUnary(u.RateLimiter),
+		auditUnary(u.AuditTrail, u.Logger),
+	)
+}
+
+// streamServerInterceptor is the equivalent of unaryServerInterceptor for
+// streaming RPCs. Streams are not limited by a timeout.
+type streamServerInterceptor struct {
+	TokenParser       TokenParser
+	CertificateMapper CertificateMapper
+	TokenRevoker      TokenRevoker
+	RateLimiter       *rateLimiter
+	AuditTrail        AuditTrail
+	Metrics           *CallMetrics
+	Logger            log.Logger
+}
 
-	return
+// chain builds the middleware chain. The chain does not change once it is
+// built.
+func (s *streamServerInterceptor) chain() grpc.StreamServerInterceptor {
+	return chainStreamInterceptors(
+		recoverStream(s.Logger),
+		requestIDStream,
+		traceStream,
+		metricsStream(s.Metrics),
+		logStream(s.Logger),
+		translateErrorsStream,
+		rateLimitAddressStream(s.RateLimiter),
+		authStream(s.TokenParser, s.CertificateMapper, s.TokenRevoker, s.AuditTrail, s.Logger),
+		rateLimitStream(s.RateLimiter),
+		auditStream(s.AuditTrail, s.Logger),
+	)
 }
@@ -3,10 +3,14 @@ package grpcapi
 import (
 	"context"
 	"testing"
+	"time"
 
+	"github.com/dgrijalva/jwt-go"
 	"github.com/fhofherr/acmeproxy/pkg/api/auth"
 	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/fhofherr/acmeproxy/pkg/security"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
@@ -41,21 +45,42 @@ func TestUnaryServerInterceptor_ValidateToken(t *testing.T) {
 			handlerCalled: true,
 			code:          codes.OK,
 		},
+		{
+			name: "reject revoked token",
+			reqHeaders: map[string]string{
+				"authorization": "Bearer revoked token",
+			},
+			tokenParser: func(string) (*auth.Claims, error) {
+				return &auth.Claims{
+					StandardClaims: jwt.StandardClaims{
+						Id: "revoked-token-id",
+					},
+				}, nil
+			},
+			code: codes.Unauthenticated,
+		},
 	}
 
 	for _, tt := range tests {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
-			interceptor := &unaryServerInterceptor{
-				TokenParser: tt.tokenParser,
+			denylist := &auth.Denylist{
+				Repository: &security.InMemoryRevokedTokenRepository{},
+			}
+			if err := denylist.RevokeToken("revoked-token-id", time.Time{}); err != nil {
+				t.Fatal(err)
 			}
+			intercept := (&unaryServerInterceptor{
+				TokenParser:  tt.tokenParser,
+				TokenRevoker: denylist,
+			}).chain()
 			handlerCalled := false
 			handler := func(context.Context, interface{}) (interface{}, error) {
 				handlerCalled = true
 				return nil, nil
 			}
 			ctx := metadata.NewIncomingContext(context.Background(), metadata.New(tt.reqHeaders))
-			_, err := interceptor.intercept(ctx, nil, nil, handler)
+			_, err := intercept(ctx, nil, nil, handler)
 			assert.Equalf(t, tt.handlerCalled, handlerCalled, "handler should have been called: %t", tt.handlerCalled)
 
 			grpcError, ok := status.FromError(err)
@@ -66,3 +91,44 @@ func TestUnaryServerInterceptor_ValidateToken(t *testing.T) {
 		})
 	}
 }
+
+func TestStreamServerInterceptor_AddsClaimsToStreamContext(t *testing.T) {
+	claims := &auth.Claims{
+		StandardClaims: jwt.StandardClaims{
+			Id: "valid-token-id",
+		},
+	}
+	intercept := (&streamServerInterceptor{
+		TokenParser: func(string) (*auth.Claims, error) {
+			return claims, nil
+		},
+		TokenRevoker: &auth.Denylist{
+			Repository: &security.InMemoryRevokedTokenRepository{},
+		},
+	}).chain()
+	var actual *auth.Claims
+	handler := func(_ interface{}, ss grpc.ServerStream) error {
+		actual, _ = auth.ClaimsFromContext(ss.Context())
+		return nil
+	}
+	md := metadata.New(map[string]string{"authorization": "Bearer valid token"})
+	ss := &fakeServerStream{ctx: metadata.NewIncomingContext(context.Background(), md)}
+	err := intercept(nil, ss, nil, handler)
+	assert.NoError(t, err)
+	assert.Equal(t, claims, actual)
+}
+
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	header metadata.MD
+}
+
+func (s *fakeServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *fakeServerStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
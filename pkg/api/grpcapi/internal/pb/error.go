@@ -2,8 +2,12 @@ package pb
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -24,6 +28,13 @@ import (
 //     * err itself is transformed to an *ErrorDetails and attached to the
 //       status as details.
 //
+//     * If err carries a RetryAt time, an errdetails.RetryInfo containing the
+//       remaining duration until RetryAt is attached to the status as
+//       additional details.
+//
+//     * If err carries a RequestID, an errdetails.RequestInfo containing the
+//       ID is attached to the status as additional details.
+//
 // If err is an arbitrary error the following operations are used instead:
 //
 //     * The status code is set to google.golang.org/grpc/codes.Internal.
@@ -49,8 +60,18 @@ func ToGRPCStatusError(err error) error {
 	if msg == "" {
 		msg = fmt.Sprintf("%v", acpErr)
 	}
-	details := errToDetails(acpErr)
-	st, err := status.New(code, msg).WithDetails(details)
+	details := []proto.Message{errToDetails(acpErr)}
+	if retryAt := errors.GetRetryAt(acpErr); !retryAt.IsZero() {
+		delay := time.Until(retryAt)
+		if delay < 0 {
+			delay = 0
+		}
+		details = append(details, &errdetails.RetryInfo{RetryDelay: ptypes.DurationProto(delay)})
+	}
+	if requestID := errors.GetRequestID(acpErr); requestID != "" {
+		details = append(details, &errdetails.RequestInfo{RequestId: requestID})
+	}
+	st, err := status.New(code, msg).WithDetails(details...)
 	if err != nil {
 		// This should never happen as we know the details passed can be
 		// marshaled. Nevertheless we want to make sure, we get an error
@@ -103,17 +124,15 @@ func FromGRPCStatusError(err error) error {
 }
 
 func extractDetails(st *status.Status, errDetails **ErrorDetails) bool {
-	details := st.Details()
-	// ToGRPCStatusError only adds one detail. If this status has zero or more
-	// than one details it is not from us.
-	if len(details) != 1 {
-		return false
-	}
-	ed, ok := details[0].(*ErrorDetails)
-	if ok {
-		*errDetails = ed
+	// ToGRPCStatusError adds exactly one *ErrorDetails. If this status has
+	// none it is not from us.
+	for _, d := range st.Details() {
+		if ed, ok := d.(*ErrorDetails); ok {
+			*errDetails = ed
+			return true
+		}
 	}
-	return ok
+	return false
 }
 
 func codeFromErr(err error) codes.Code {
@@ -122,6 +141,10 @@ func codeFromErr(err error) codes.Code {
 		return codes.NotFound
 	case errors.Unauthorized:
 		return codes.Unauthenticated
+	case errors.PermissionDenied:
+		return codes.PermissionDenied
+	case errors.RateLimited:
+		return codes.ResourceExhausted
 	default:
 		return codes.Internal
 	}
@@ -129,9 +152,15 @@ func codeFromErr(err error) codes.Code {
 
 func errToDetails(err *errors.Error) *ErrorDetails {
 	details := &ErrorDetails{
-		Op:   string(err.Op),
-		Kind: int32(err.Kind),
-		Msg:  err.Msg,
+		Op:        string(err.Op),
+		Kind:      int32(err.Kind),
+		Msg:       err.Msg,
+		RequestID: err.RequestID,
+	}
+	if !err.RetryAt.IsZero() {
+		// Timestamps are only invalid for years outside 0001 to 9999.
+		// RetryAt is never that far off.
+		details.RetryAt, _ = ptypes.TimestampProto(err.RetryAt)
 	}
 	if err.Err == nil {
 		return details
@@ -152,9 +181,15 @@ func errToDetails(err *errors.Error) *ErrorDetails {
 
 func detailsToErr(details *ErrorDetails) *errors.Error {
 	err := &errors.Error{
-		Op:   errors.Op(details.Op),
-		Kind: errors.Kind(details.Kind),
-		Msg:  details.Msg,
+		Op:        errors.Op(details.Op),
+		Kind:      errors.Kind(details.Kind),
+		Msg:       details.Msg,
+		RequestID: details.RequestID,
+	}
+	if details.RetryAt != nil {
+		if retryAt, tsErr := ptypes.Timestamp(details.RetryAt); tsErr == nil {
+			err.RetryAt = retryAt
+		}
 	}
 	if details.Err == nil {
 		return err
@@ -6,6 +6,7 @@ package pb
 import (
 	fmt "fmt"
 	proto "github.com/golang/protobuf/proto"
+	timestamp "github.com/golang/protobuf/ptypes/timestamp"
 	math "math"
 )
 
@@ -27,10 +28,12 @@ type ErrorDetails struct {
 	// Types that are valid to be assigned to Err:
 	//	*ErrorDetails_Plain
 	//	*ErrorDetails_Nested
-	Err                  isErrorDetails_Err `protobuf_oneof:"err"`
-	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
-	XXX_unrecognized     []byte             `json:"-"`
-	XXX_sizecache        int32              `json:"-"`
+	Err                  isErrorDetails_Err   `protobuf_oneof:"err"`
+	RetryAt              *timestamp.Timestamp `protobuf:"bytes,6,opt,name=retryAt,proto3" json:"retryAt,omitempty"`
+	RequestID            string               `protobuf:"bytes,7,opt,name=requestID,proto3" json:"requestID,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
 }
 
 func (m *ErrorDetails) Reset()         { *m = ErrorDetails{} }
@@ -116,6 +119,20 @@ func (m *ErrorDetails) GetNested() *ErrorDetails {
 	return nil
 }
 
+func (m *ErrorDetails) GetRetryAt() *timestamp.Timestamp {
+	if m != nil {
+		return m.RetryAt
+	}
+	return nil
+}
+
+func (m *ErrorDetails) GetRequestID() string {
+	if m != nil {
+		return m.RequestID
+	}
+	return ""
+}
+
 // XXX_OneofWrappers is for the internal use of the proto package.
 func (*ErrorDetails) XXX_OneofWrappers() []interface{} {
 	return []interface{}{
@@ -133,17 +150,21 @@ func init() {
 }
 
 var fileDescriptor_d3e6af916b3620a1 = []byte{
-	// 187 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x4c, 0x8e, 0xc1, 0x6a, 0x84, 0x30,
-	0x10, 0x86, 0x4d, 0x34, 0x42, 0xa7, 0xa5, 0x48, 0x0e, 0x25, 0x47, 0xe9, 0x49, 0x7a, 0x30, 0xd0,
-	0xbe, 0x41, 0x69, 0xc1, 0x73, 0x5e, 0xa0, 0x24, 0x35, 0x48, 0xd0, 0x4d, 0x86, 0x31, 0xef, 0xb1,
-	0xaf, 0xbc, 0x44, 0xf7, 0xb0, 0xa7, 0xf9, 0x67, 0xbe, 0x0f, 0xe6, 0x07, 0x8d, 0xeb, 0xa2, 0x2d,
-	0x06, 0xbd, 0x10, 0xfe, 0x97, 0x19, 0x62, 0xf6, 0x14, 0xed, 0xa6, 0xd1, 0x69, 0x4f, 0x94, 0xe8,
-	0x6f, 0xf6, 0xd9, 0x86, 0x6d, 0x1f, 0x91, 0x52, 0x4e, 0x92, 0xa3, 0x7b, 0xbf, 0x32, 0x78, 0xf9,
-	0x2d, 0xec, 0xe7, 0x44, 0xf2, 0x15, 0x78, 0x42, 0xc5, 0x7a, 0x36, 0x3c, 0x19, 0x9e, 0x50, 0x4a,
-	0x68, 0xd6, 0x10, 0x67, 0xc5, 0x7b, 0x36, 0x08, 0x73, 0x64, 0xd9, 0x41, 0x7d, 0xd9, 0x17, 0x55,
-	0x1f, 0x52, 0x89, 0xf2, 0x0d, 0x04, 0x6e, 0x36, 0x44, 0xd5, 0x94, 0xdb, 0x54, 0x99, 0x73, 0x95,
-	0x1f, 0xd0, 0x46, 0xbf, 0x67, 0x3f, 0x2b, 0xd1, 0xb3, 0xe1, 0xf9, 0xb3, 0x1b, 0xd1, 0x8d, 0x8f,
-	0xff, 0xa6, 0xca, 0xdc, 0x8d, 0x6f, 0x01, 0xb5, 0x27, 0x72, 0xed, 0x51, 0xee, 0xeb, 0x16, 0x00,
-	0x00, 0xff, 0xff, 0xdd, 0xf6, 0xca, 0x2d, 0xcf, 0x00, 0x00, 0x00,
+	// 247 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x4c, 0x8e, 0xc1, 0x4a, 0xc4, 0x30,
+	0x14, 0x45, 0xa7, 0x9d, 0x69, 0x87, 0x79, 0x8a, 0x0c, 0x59, 0x48, 0x18, 0x04, 0x8b, 0xab, 0xe2,
+	0x22, 0x01, 0xf5, 0x07, 0x94, 0x11, 0xc6, 0x6d, 0x71, 0x2f, 0xad, 0x7d, 0x96, 0x30, 0x6d, 0xf2,
+	0x7c, 0xc9, 0x2c, 0xfc, 0x5e, 0x7f, 0x44, 0x9a, 0x5a, 0x74, 0x95, 0x9b, 0xcb, 0xb9, 0xc9, 0x01,
+	0x4d, 0xc7, 0x4e, 0xd7, 0x64, 0x74, 0xc7, 0xf4, 0x3e, 0x9e, 0xc6, 0x06, 0x64, 0x5b, 0xf7, 0x9a,
+	0x1a, 0x8d, 0xcc, 0x8e, 0xdf, 0x5a, 0x0c, 0xb5, 0xe9, 0xbd, 0x22, 0x76, 0xc1, 0x89, 0x94, 0x9a,
+	0xdd, 0x75, 0xe7, 0x5c, 0xd7, 0xa3, 0x8e, 0x4d, 0x73, 0xfa, 0xd0, 0xc1, 0x0c, 0xe8, 0x43, 0x3d,
+	0xd0, 0x04, 0xdd, 0x7c, 0x27, 0x70, 0xfe, 0x3c, 0x8e, 0xf7, 0xd3, 0x56, 0x5c, 0x40, 0xea, 0x48,
+	0x26, 0x45, 0x52, 0x6e, 0xaa, 0xd4, 0x91, 0x10, 0xb0, 0x3a, 0x1a, 0xdb, 0xca, 0xb4, 0x48, 0xca,
+	0xac, 0x8a, 0x59, 0x6c, 0x61, 0x39, 0xf8, 0x4e, 0x2e, 0x23, 0x34, 0x46, 0x71, 0x09, 0x19, 0xf5,
+	0xb5, 0xb1, 0x72, 0x35, 0x76, 0x87, 0x45, 0x35, 0x5d, 0xc5, 0x2d, 0xe4, 0x16, 0x7d, 0xc0, 0x56,
+	0x66, 0x45, 0x52, 0x9e, 0xdd, 0x6d, 0x15, 0x35, 0xea, 0xff, 0x7f, 0x87, 0x45, 0xf5, 0x4b, 0x88,
+	0x07, 0x58, 0x33, 0x06, 0xfe, 0x7a, 0x0c, 0x32, 0x8f, 0xf0, 0x4e, 0x4d, 0xf6, 0x6a, 0xb6, 0x57,
+	0xaf, 0xb3, 0x7d, 0x35, 0xa3, 0xe2, 0x0a, 0x36, 0x8c, 0x9f, 0x27, 0xf4, 0xe1, 0x65, 0x2f, 0xd7,
+	0xd1, 0xe8, 0xaf, 0x78, 0xca, 0x60, 0x89, 0xcc, 0x4d, 0x1e, 0x5f, 0xb8, 0xff, 0x19, 0x00, 0xe9,
+	0xe3, 0xbd, 0x9e, 0x44, 0x01, 0x00, 0x00,
 }
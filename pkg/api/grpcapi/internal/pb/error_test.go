@@ -3,11 +3,16 @@ package pb_test
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/fhofherr/acmeproxy/pkg/api/grpcapi/internal/pb"
 	"github.com/fhofherr/acmeproxy/pkg/errors"
 	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/duration"
+	"github.com/golang/protobuf/ptypes/timestamp"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -55,6 +60,21 @@ func TestGRPCErrorConversion(t *testing.T) {
 				},
 			),
 		},
+		{
+			name: "PermissionDenied if kind is PermissionDenied",
+			err: &errors.Error{
+				Kind: errors.PermissionDenied,
+				Msg:  "some message",
+			},
+			status: mustHaveDetails(
+				t,
+				status.New(codes.PermissionDenied, "some message"),
+				&pb.ErrorDetails{
+					Kind: int32(errors.PermissionDenied),
+					Msg:  "some message",
+				},
+			),
+		},
 		{
 			name: "Re-use kind of nested error",
 			err: &errors.Error{
@@ -77,6 +97,25 @@ func TestGRPCErrorConversion(t *testing.T) {
 				},
 			),
 		},
+		{
+			name: "ResourceExhausted if kind is RateLimited",
+			err: &errors.Error{
+				Kind:    errors.RateLimited,
+				Msg:     "some message",
+				RetryAt: time.Date(2019, 10, 18, 12, 0, 0, 0, time.UTC),
+			},
+			status: mustHaveDetails(
+				t,
+				status.New(codes.ResourceExhausted, "some message"),
+				&pb.ErrorDetails{
+					Kind:    int32(errors.RateLimited),
+					Msg:     "some message",
+					RetryAt: &timestamp.Timestamp{Seconds: 1571400000},
+				},
+				// RetryAt lies in the past. The client may retry immediately.
+				&errdetails.RetryInfo{RetryDelay: &duration.Duration{}},
+			),
+		},
 		{
 			name: "internal error on error of any other kind",
 			err: &errors.Error{
@@ -178,6 +217,45 @@ func TestGRPCErrorConversion(t *testing.T) {
 	}
 }
 
+func TestToGRPCStatusError_RetryInfo(t *testing.T) {
+	err := errors.New(errors.RateLimited, time.Now().Add(time.Minute), "slow down")
+	st, ok := status.FromError(pb.ToGRPCStatusError(err))
+	if !assert.True(t, ok) {
+		return
+	}
+	var retryInfo *errdetails.RetryInfo
+	for _, d := range st.Details() {
+		if ri, ok := d.(*errdetails.RetryInfo); ok {
+			retryInfo = ri
+		}
+	}
+	if !assert.NotNil(t, retryInfo) {
+		return
+	}
+	delay, perr := ptypes.Duration(retryInfo.GetRetryDelay())
+	assert.NoError(t, perr)
+	assert.True(t, delay > 50*time.Second && delay <= time.Minute, "unexpected delay: %v", delay)
+}
+
+func TestToGRPCStatusError_RequestInfo(t *testing.T) {
+	err := errors.New(errors.NotFound, errors.RequestID("some-request"), "no such thing")
+	statusErr := pb.ToGRPCStatusError(err)
+	st, ok := status.FromError(statusErr)
+	if !assert.True(t, ok) {
+		return
+	}
+	var requestInfo *errdetails.RequestInfo
+	for _, d := range st.Details() {
+		if ri, ok := d.(*errdetails.RequestInfo); ok {
+			requestInfo = ri
+		}
+	}
+	if assert.NotNil(t, requestInfo) {
+		assert.Equal(t, "some-request", requestInfo.GetRequestId())
+	}
+	assert.Equal(t, err, pb.FromGRPCStatusError(statusErr))
+}
+
 func TestFromGRPCStatusError_NonGRPCError(t *testing.T) {
 	err := fmt.Errorf("some error")
 	actual := pb.FromGRPCStatusError(err)
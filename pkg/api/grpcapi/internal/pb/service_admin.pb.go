@@ -7,6 +7,8 @@ import (
 	context "context"
 	fmt "fmt"
 	proto "github.com/golang/protobuf/proto"
+	empty "github.com/golang/protobuf/ptypes/empty"
+	timestamp "github.com/golang/protobuf/ptypes/timestamp"
 	grpc "google.golang.org/grpc"
 	codes "google.golang.org/grpc/codes"
 	status "google.golang.org/grpc/status"
@@ -64,8 +66,587 @@ func (m *Email) GetAddr() string {
 	return ""
 }
 
+// RevokedToken identifies a token which should not be accepted anymore.
+type RevokedToken struct {
+	Id                   string               `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	ExpiresAt            *timestamp.Timestamp `protobuf:"bytes,2,opt,name=expiresAt,proto3" json:"expiresAt,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *RevokedToken) Reset()         { *m = RevokedToken{} }
+func (m *RevokedToken) String() string { return proto.CompactTextString(m) }
+func (*RevokedToken) ProtoMessage()    {}
+func (*RevokedToken) Descriptor() ([]byte, []int) {
+	return fileDescriptor_840fc6a918fcbd8a, []int{1}
+}
+
+func (m *RevokedToken) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RevokedToken.Unmarshal(m, b)
+}
+func (m *RevokedToken) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RevokedToken.Marshal(b, m, deterministic)
+}
+func (m *RevokedToken) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RevokedToken.Merge(m, src)
+}
+func (m *RevokedToken) XXX_Size() int {
+	return xxx_messageInfo_RevokedToken.Size(m)
+}
+func (m *RevokedToken) XXX_DiscardUnknown() {
+	xxx_messageInfo_RevokedToken.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RevokedToken proto.InternalMessageInfo
+
+func (m *RevokedToken) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *RevokedToken) GetExpiresAt() *timestamp.Timestamp {
+	if m != nil {
+		return m.ExpiresAt
+	}
+	return nil
+}
+
+// UserID identifies a user.
+type UserID struct {
+	Id                   []byte   `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *UserID) Reset()         { *m = UserID{} }
+func (m *UserID) String() string { return proto.CompactTextString(m) }
+func (*UserID) ProtoMessage()    {}
+func (*UserID) Descriptor() ([]byte, []int) {
+	return fileDescriptor_840fc6a918fcbd8a, []int{2}
+}
+
+func (m *UserID) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_UserID.Unmarshal(m, b)
+}
+func (m *UserID) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_UserID.Marshal(b, m, deterministic)
+}
+func (m *UserID) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_UserID.Merge(m, src)
+}
+func (m *UserID) XXX_Size() int {
+	return xxx_messageInfo_UserID.Size(m)
+}
+func (m *UserID) XXX_DiscardUnknown() {
+	xxx_messageInfo_UserID.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_UserID proto.InternalMessageInfo
+
+func (m *UserID) GetId() []byte {
+	if m != nil {
+		return m.Id
+	}
+	return nil
+}
+
+// ListUsersRequest requests a page of at most pageSize users. pageToken is
+// the nextPageToken of the previous response, or empty for the first page.
+type ListUsersRequest struct {
+	PageSize             int32    `protobuf:"varint,1,opt,name=pageSize,proto3" json:"pageSize,omitempty"`
+	PageToken            string   `protobuf:"bytes,2,opt,name=pageToken,proto3" json:"pageToken,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListUsersRequest) Reset()         { *m = ListUsersRequest{} }
+func (m *ListUsersRequest) String() string { return proto.CompactTextString(m) }
+func (*ListUsersRequest) ProtoMessage()    {}
+func (*ListUsersRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_840fc6a918fcbd8a, []int{3}
+}
+
+func (m *ListUsersRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListUsersRequest.Unmarshal(m, b)
+}
+func (m *ListUsersRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListUsersRequest.Marshal(b, m, deterministic)
+}
+func (m *ListUsersRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListUsersRequest.Merge(m, src)
+}
+func (m *ListUsersRequest) XXX_Size() int {
+	return xxx_messageInfo_ListUsersRequest.Size(m)
+}
+func (m *ListUsersRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListUsersRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListUsersRequest proto.InternalMessageInfo
+
+func (m *ListUsersRequest) GetPageSize() int32 {
+	if m != nil {
+		return m.PageSize
+	}
+	return 0
+}
+
+func (m *ListUsersRequest) GetPageToken() string {
+	if m != nil {
+		return m.PageToken
+	}
+	return ""
+}
+
+// ListUsersResponse contains a page of users. nextPageToken is empty if there
+// are no more users.
+type ListUsersResponse struct {
+	Users                []*User  `protobuf:"bytes,1,rep,name=users,proto3" json:"users,omitempty"`
+	NextPageToken        string   `protobuf:"bytes,2,opt,name=nextPageToken,proto3" json:"nextPageToken,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListUsersResponse) Reset()         { *m = ListUsersResponse{} }
+func (m *ListUsersResponse) String() string { return proto.CompactTextString(m) }
+func (*ListUsersResponse) ProtoMessage()    {}
+func (*ListUsersResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_840fc6a918fcbd8a, []int{4}
+}
+
+func (m *ListUsersResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListUsersResponse.Unmarshal(m, b)
+}
+func (m *ListUsersResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListUsersResponse.Marshal(b, m, deterministic)
+}
+func (m *ListUsersResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListUsersResponse.Merge(m, src)
+}
+func (m *ListUsersResponse) XXX_Size() int {
+	return xxx_messageInfo_ListUsersResponse.Size(m)
+}
+func (m *ListUsersResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListUsersResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListUsersResponse proto.InternalMessageInfo
+
+func (m *ListUsersResponse) GetUsers() []*User {
+	if m != nil {
+		return m.Users
+	}
+	return nil
+}
+
+func (m *ListUsersResponse) GetNextPageToken() string {
+	if m != nil {
+		return m.NextPageToken
+	}
+	return ""
+}
+
+// TransferDomainsRequest requests to transfer the domains with the passed
+// domainNames from one user to another. All domains of the user identified by
+// from are transferred if domainNames is empty.
+type TransferDomainsRequest struct {
+	From                 []byte   `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
+	To                   []byte   `protobuf:"bytes,2,opt,name=to,proto3" json:"to,omitempty"`
+	DomainNames          []string `protobuf:"bytes,3,rep,name=domainNames,proto3" json:"domainNames,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *TransferDomainsRequest) Reset()         { *m = TransferDomainsRequest{} }
+func (m *TransferDomainsRequest) String() string { return proto.CompactTextString(m) }
+func (*TransferDomainsRequest) ProtoMessage()    {}
+func (*TransferDomainsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_840fc6a918fcbd8a, []int{5}
+}
+
+func (m *TransferDomainsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TransferDomainsRequest.Unmarshal(m, b)
+}
+func (m *TransferDomainsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TransferDomainsRequest.Marshal(b, m, deterministic)
+}
+func (m *TransferDomainsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TransferDomainsRequest.Merge(m, src)
+}
+func (m *TransferDomainsRequest) XXX_Size() int {
+	return xxx_messageInfo_TransferDomainsRequest.Size(m)
+}
+func (m *TransferDomainsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_TransferDomainsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TransferDomainsRequest proto.InternalMessageInfo
+
+func (m *TransferDomainsRequest) GetFrom() []byte {
+	if m != nil {
+		return m.From
+	}
+	return nil
+}
+
+func (m *TransferDomainsRequest) GetTo() []byte {
+	if m != nil {
+		return m.To
+	}
+	return nil
+}
+
+func (m *TransferDomainsRequest) GetDomainNames() []string {
+	if m != nil {
+		return m.DomainNames
+	}
+	return nil
+}
+
+// TransferDomainsResponse contains the names of the transferred domains.
+type TransferDomainsResponse struct {
+	DomainNames          []string `protobuf:"bytes,1,rep,name=domainNames,proto3" json:"domainNames,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *TransferDomainsResponse) Reset()         { *m = TransferDomainsResponse{} }
+func (m *TransferDomainsResponse) String() string { return proto.CompactTextString(m) }
+func (*TransferDomainsResponse) ProtoMessage()    {}
+func (*TransferDomainsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_840fc6a918fcbd8a, []int{6}
+}
+
+func (m *TransferDomainsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TransferDomainsResponse.Unmarshal(m, b)
+}
+func (m *TransferDomainsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TransferDomainsResponse.Marshal(b, m, deterministic)
+}
+func (m *TransferDomainsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TransferDomainsResponse.Merge(m, src)
+}
+func (m *TransferDomainsResponse) XXX_Size() int {
+	return xxx_messageInfo_TransferDomainsResponse.Size(m)
+}
+func (m *TransferDomainsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_TransferDomainsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TransferDomainsResponse proto.InternalMessageInfo
+
+func (m *TransferDomainsResponse) GetDomainNames() []string {
+	if m != nil {
+		return m.DomainNames
+	}
+	return nil
+}
+
+// Status describes the state of the acmeproxy server.
+type Status struct {
+	Version              string               `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`
+	GitHash              string               `protobuf:"bytes,2,opt,name=gitHash,proto3" json:"gitHash,omitempty"`
+	BuildTime            string               `protobuf:"bytes,3,opt,name=buildTime,proto3" json:"buildTime,omitempty"`
+	StartedAt            *timestamp.Timestamp `protobuf:"bytes,4,opt,name=startedAt,proto3" json:"startedAt,omitempty"`
+	Domains              int32                `protobuf:"varint,5,opt,name=domains,proto3" json:"domains,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *Status) Reset()         { *m = Status{} }
+func (m *Status) String() string { return proto.CompactTextString(m) }
+func (*Status) ProtoMessage()    {}
+func (*Status) Descriptor() ([]byte, []int) {
+	return fileDescriptor_840fc6a918fcbd8a, []int{7}
+}
+
+func (m *Status) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Status.Unmarshal(m, b)
+}
+func (m *Status) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Status.Marshal(b, m, deterministic)
+}
+func (m *Status) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Status.Merge(m, src)
+}
+func (m *Status) XXX_Size() int {
+	return xxx_messageInfo_Status.Size(m)
+}
+func (m *Status) XXX_DiscardUnknown() {
+	xxx_messageInfo_Status.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Status proto.InternalMessageInfo
+
+func (m *Status) GetVersion() string {
+	if m != nil {
+		return m.Version
+	}
+	return ""
+}
+
+func (m *Status) GetGitHash() string {
+	if m != nil {
+		return m.GitHash
+	}
+	return ""
+}
+
+func (m *Status) GetBuildTime() string {
+	if m != nil {
+		return m.BuildTime
+	}
+	return ""
+}
+
+func (m *Status) GetStartedAt() *timestamp.Timestamp {
+	if m != nil {
+		return m.StartedAt
+	}
+	return nil
+}
+
+func (m *Status) GetDomains() int32 {
+	if m != nil {
+		return m.Domains
+	}
+	return 0
+}
+
+// ListAuditEntriesRequest selects the audit entries recorded at or after
+// since and before until. Entries are selected only if their subject and
+// resource are equal to those of the request. Empty fields select all
+// entries. At most limit entries are returned.
+type ListAuditEntriesRequest struct {
+	Since                *timestamp.Timestamp `protobuf:"bytes,1,opt,name=since,proto3" json:"since,omitempty"`
+	Until                *timestamp.Timestamp `protobuf:"bytes,2,opt,name=until,proto3" json:"until,omitempty"`
+	Subject              string               `protobuf:"bytes,3,opt,name=subject,proto3" json:"subject,omitempty"`
+	Resource             string               `protobuf:"bytes,4,opt,name=resource,proto3" json:"resource,omitempty"`
+	Limit                int32                `protobuf:"varint,5,opt,name=limit,proto3" json:"limit,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *ListAuditEntriesRequest) Reset()         { *m = ListAuditEntriesRequest{} }
+func (m *ListAuditEntriesRequest) String() string { return proto.CompactTextString(m) }
+func (*ListAuditEntriesRequest) ProtoMessage()    {}
+func (*ListAuditEntriesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_840fc6a918fcbd8a, []int{8}
+}
+
+func (m *ListAuditEntriesRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListAuditEntriesRequest.Unmarshal(m, b)
+}
+func (m *ListAuditEntriesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListAuditEntriesRequest.Marshal(b, m, deterministic)
+}
+func (m *ListAuditEntriesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListAuditEntriesRequest.Merge(m, src)
+}
+func (m *ListAuditEntriesRequest) XXX_Size() int {
+	return xxx_messageInfo_ListAuditEntriesRequest.Size(m)
+}
+func (m *ListAuditEntriesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListAuditEntriesRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListAuditEntriesRequest proto.InternalMessageInfo
+
+func (m *ListAuditEntriesRequest) GetSince() *timestamp.Timestamp {
+	if m != nil {
+		return m.Since
+	}
+	return nil
+}
+
+func (m *ListAuditEntriesRequest) GetUntil() *timestamp.Timestamp {
+	if m != nil {
+		return m.Until
+	}
+	return nil
+}
+
+func (m *ListAuditEntriesRequest) GetSubject() string {
+	if m != nil {
+		return m.Subject
+	}
+	return ""
+}
+
+func (m *ListAuditEntriesRequest) GetResource() string {
+	if m != nil {
+		return m.Resource
+	}
+	return ""
+}
+
+func (m *ListAuditEntriesRequest) GetLimit() int32 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
+}
+
+// AuditEntry records a security-relevant operation performed by the caller
+// identified by subject.
+type AuditEntry struct {
+	Time                 *timestamp.Timestamp `protobuf:"bytes,1,opt,name=time,proto3" json:"time,omitempty"`
+	Subject              string               `protobuf:"bytes,2,opt,name=subject,proto3" json:"subject,omitempty"`
+	Roles                []string             `protobuf:"bytes,3,rep,name=roles,proto3" json:"roles,omitempty"`
+	TokenID              string               `protobuf:"bytes,4,opt,name=tokenID,proto3" json:"tokenID,omitempty"`
+	PeerAddr             string               `protobuf:"bytes,5,opt,name=peerAddr,proto3" json:"peerAddr,omitempty"`
+	Operation            string               `protobuf:"bytes,6,opt,name=operation,proto3" json:"operation,omitempty"`
+	Resource             string               `protobuf:"bytes,7,opt,name=resource,proto3" json:"resource,omitempty"`
+	Outcome              string               `protobuf:"bytes,8,opt,name=outcome,proto3" json:"outcome,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *AuditEntry) Reset()         { *m = AuditEntry{} }
+func (m *AuditEntry) String() string { return proto.CompactTextString(m) }
+func (*AuditEntry) ProtoMessage()    {}
+func (*AuditEntry) Descriptor() ([]byte, []int) {
+	return fileDescriptor_840fc6a918fcbd8a, []int{9}
+}
+
+func (m *AuditEntry) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AuditEntry.Unmarshal(m, b)
+}
+func (m *AuditEntry) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AuditEntry.Marshal(b, m, deterministic)
+}
+func (m *AuditEntry) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AuditEntry.Merge(m, src)
+}
+func (m *AuditEntry) XXX_Size() int {
+	return xxx_messageInfo_AuditEntry.Size(m)
+}
+func (m *AuditEntry) XXX_DiscardUnknown() {
+	xxx_messageInfo_AuditEntry.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AuditEntry proto.InternalMessageInfo
+
+func (m *AuditEntry) GetTime() *timestamp.Timestamp {
+	if m != nil {
+		return m.Time
+	}
+	return nil
+}
+
+func (m *AuditEntry) GetSubject() string {
+	if m != nil {
+		return m.Subject
+	}
+	return ""
+}
+
+func (m *AuditEntry) GetRoles() []string {
+	if m != nil {
+		return m.Roles
+	}
+	return nil
+}
+
+func (m *AuditEntry) GetTokenID() string {
+	if m != nil {
+		return m.TokenID
+	}
+	return ""
+}
+
+func (m *AuditEntry) GetPeerAddr() string {
+	if m != nil {
+		return m.PeerAddr
+	}
+	return ""
+}
+
+func (m *AuditEntry) GetOperation() string {
+	if m != nil {
+		return m.Operation
+	}
+	return ""
+}
+
+func (m *AuditEntry) GetResource() string {
+	if m != nil {
+		return m.Resource
+	}
+	return ""
+}
+
+func (m *AuditEntry) GetOutcome() string {
+	if m != nil {
+		return m.Outcome
+	}
+	return ""
+}
+
+// ListAuditEntriesResponse contains the selected audit entries ordered by
+// the time they were recorded.
+type ListAuditEntriesResponse struct {
+	Entries              []*AuditEntry `protobuf:"bytes,1,rep,name=entries,proto3" json:"entries,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
+}
+
+func (m *ListAuditEntriesResponse) Reset()         { *m = ListAuditEntriesResponse{} }
+func (m *ListAuditEntriesResponse) String() string { return proto.CompactTextString(m) }
+func (*ListAuditEntriesResponse) ProtoMessage()    {}
+func (*ListAuditEntriesResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_840fc6a918fcbd8a, []int{10}
+}
+
+func (m *ListAuditEntriesResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListAuditEntriesResponse.Unmarshal(m, b)
+}
+func (m *ListAuditEntriesResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListAuditEntriesResponse.Marshal(b, m, deterministic)
+}
+func (m *ListAuditEntriesResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListAuditEntriesResponse.Merge(m, src)
+}
+func (m *ListAuditEntriesResponse) XXX_Size() int {
+	return xxx_messageInfo_ListAuditEntriesResponse.Size(m)
+}
+func (m *ListAuditEntriesResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListAuditEntriesResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListAuditEntriesResponse proto.InternalMessageInfo
+
+func (m *ListAuditEntriesResponse) GetEntries() []*AuditEntry {
+	if m != nil {
+		return m.Entries
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*Email)(nil), "pb.Email")
+	proto.RegisterType((*RevokedToken)(nil), "pb.RevokedToken")
+	proto.RegisterType((*UserID)(nil), "pb.UserID")
+	proto.RegisterType((*ListUsersRequest)(nil), "pb.ListUsersRequest")
+	proto.RegisterType((*ListUsersResponse)(nil), "pb.ListUsersResponse")
+	proto.RegisterType((*TransferDomainsRequest)(nil), "pb.TransferDomainsRequest")
+	proto.RegisterType((*TransferDomainsResponse)(nil), "pb.TransferDomainsResponse")
+	proto.RegisterType((*Status)(nil), "pb.Status")
+	proto.RegisterType((*ListAuditEntriesRequest)(nil), "pb.ListAuditEntriesRequest")
+	proto.RegisterType((*AuditEntry)(nil), "pb.AuditEntry")
+	proto.RegisterType((*ListAuditEntriesResponse)(nil), "pb.ListAuditEntriesResponse")
 }
 
 func init() {
@@ -73,17 +654,57 @@ func init() {
 }
 
 var fileDescriptor_840fc6a918fcbd8a = []byte{
-	// 155 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0xd2, 0x2f, 0xc8, 0x4e, 0xd7,
-	0x4f, 0x2c, 0xc8, 0xd4, 0x4f, 0x2f, 0x2a, 0x48, 0x06, 0xd1, 0x99, 0x79, 0x25, 0xa9, 0x45, 0x79,
-	0x89, 0x39, 0xfa, 0x05, 0x49, 0xfa, 0xc5, 0xa9, 0x45, 0x65, 0x99, 0xc9, 0xa9, 0xf1, 0x89, 0x29,
-	0xb9, 0x99, 0x79, 0x7a, 0x05, 0x45, 0xf9, 0x25, 0xf9, 0x42, 0x4c, 0x05, 0x49, 0x52, 0x6a, 0xf8,
-	0x34, 0x95, 0x16, 0xa7, 0x16, 0x41, 0xd4, 0x2a, 0x49, 0x73, 0xb1, 0xba, 0xe6, 0x26, 0x66, 0xe6,
-	0x08, 0x09, 0x71, 0xb1, 0x24, 0xa6, 0xa4, 0x14, 0x49, 0x30, 0x2a, 0x30, 0x6a, 0x70, 0x06, 0x81,
-	0xd9, 0x46, 0x7a, 0x5c, 0xac, 0x8e, 0x20, 0x73, 0x85, 0x54, 0xb9, 0x78, 0x82, 0x52, 0xd3, 0x33,
-	0x8b, 0x4b, 0x52, 0x8b, 0x42, 0x8b, 0x53, 0x8b, 0x84, 0x38, 0xf5, 0x0a, 0x92, 0xf4, 0xc0, 0xfa,
-	0xa4, 0x38, 0x40, 0x4c, 0x90, 0xa0, 0x12, 0x43, 0x12, 0x1b, 0xd8, 0x4c, 0x63, 0x40, 0x00, 0x00,
-	0x00, 0xff, 0xff, 0x9f, 0x8b, 0xcc, 0x83, 0xb2, 0x00, 0x00, 0x00,
+	// 785 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x54, 0xcd, 0x6e, 0xdb, 0x46,
+	0x10, 0x16, 0x25, 0x51, 0x32, 0x47, 0xae, 0xeb, 0x2e, 0x5c, 0x9b, 0xa0, 0x8c, 0x56, 0x25, 0xda,
+	0x42, 0x27, 0xa9, 0x55, 0x2f, 0x4d, 0x72, 0x12, 0x20, 0xc3, 0x31, 0x60, 0x24, 0x01, 0x6d, 0x03,
+	0xc9, 0x25, 0x01, 0x25, 0x8e, 0x95, 0x8d, 0x49, 0x2e, 0xb3, 0xbb, 0x34, 0xec, 0x3c, 0x54, 0xde,
+	0x23, 0xaf, 0x93, 0x43, 0xce, 0xc1, 0xee, 0x92, 0xb4, 0xfe, 0xec, 0xf8, 0x44, 0xce, 0xcc, 0xb7,
+	0xb3, 0xdf, 0xcc, 0xec, 0x7c, 0x30, 0xcc, 0xae, 0xe6, 0xc3, 0x30, 0xa3, 0xc3, 0x39, 0xcf, 0x66,
+	0xea, 0x4b, 0x53, 0x89, 0x3c, 0x0d, 0xe3, 0x61, 0x36, 0x1d, 0x0a, 0xe4, 0xd7, 0x74, 0x86, 0xef,
+	0xc2, 0x28, 0xa1, 0xe9, 0x20, 0xe3, 0x4c, 0x32, 0x52, 0xcf, 0xa6, 0x5e, 0x77, 0xce, 0xd8, 0x3c,
+	0xc6, 0xa1, 0xf6, 0x4c, 0xf3, 0xcb, 0x21, 0x26, 0x99, 0xbc, 0x35, 0x00, 0xef, 0xf7, 0xd5, 0xa0,
+	0xa4, 0x09, 0x0a, 0x19, 0x26, 0x59, 0x01, 0xf8, 0xfb, 0xa1, 0x2b, 0x73, 0x81, 0xdc, 0xe0, 0xfc,
+	0x2e, 0xd8, 0x47, 0x49, 0x48, 0x63, 0x42, 0xa0, 0x19, 0x46, 0x11, 0x77, 0xad, 0x9e, 0xd5, 0x77,
+	0x02, 0xfd, 0xef, 0xbf, 0x86, 0xed, 0x00, 0xaf, 0xd9, 0x15, 0x46, 0xe7, 0xec, 0x0a, 0x53, 0xb2,
+	0x03, 0x75, 0x1a, 0x15, 0x88, 0x3a, 0x8d, 0xc8, 0xff, 0xe0, 0xe0, 0x4d, 0x46, 0x39, 0x8a, 0xb1,
+	0x74, 0xeb, 0x3d, 0xab, 0xdf, 0x19, 0x79, 0x03, 0xc3, 0x6c, 0x50, 0x32, 0x1b, 0x9c, 0x97, 0xcc,
+	0x82, 0x3b, 0xb0, 0xef, 0x42, 0xeb, 0x42, 0x20, 0x3f, 0x99, 0x2c, 0xe4, 0xdc, 0x56, 0x39, 0xfd,
+	0x53, 0xd8, 0x3d, 0xa5, 0x42, 0xaa, 0xa8, 0x08, 0xf0, 0x63, 0x8e, 0x42, 0x12, 0x0f, 0xb6, 0xb2,
+	0x70, 0x8e, 0x67, 0xf4, 0x13, 0x6a, 0xa4, 0x1d, 0x54, 0x36, 0x39, 0x04, 0x47, 0xfd, 0x6b, 0x82,
+	0x9a, 0x83, 0x13, 0xdc, 0x39, 0xfc, 0x37, 0xf0, 0xcb, 0x42, 0x36, 0x91, 0xb1, 0x54, 0x20, 0xf9,
+	0x0d, 0x6c, 0xd5, 0x01, 0xe1, 0x5a, 0xbd, 0x46, 0xbf, 0x33, 0xda, 0x1a, 0x64, 0xd3, 0x81, 0x42,
+	0x04, 0xc6, 0x4d, 0xfe, 0x84, 0x9f, 0x52, 0xbc, 0x91, 0xaf, 0x56, 0xd2, 0x2e, 0x3b, 0xfd, 0xb7,
+	0xb0, 0x7f, 0xce, 0xc3, 0x54, 0x5c, 0x22, 0x9f, 0xb0, 0x24, 0xa4, 0x69, 0x45, 0x97, 0x40, 0xf3,
+	0x92, 0xb3, 0xa4, 0x28, 0x4a, 0xff, 0xab, 0x32, 0x25, 0xd3, 0x89, 0xb6, 0x83, 0xba, 0x64, 0xa4,
+	0x07, 0x9d, 0x48, 0x9f, 0x7a, 0x11, 0x26, 0x28, 0xdc, 0x46, 0xaf, 0xd1, 0x77, 0x82, 0x45, 0x97,
+	0xff, 0x0c, 0x0e, 0xd6, 0xf2, 0x17, 0x05, 0xac, 0x1c, 0xb6, 0xd6, 0x0f, 0x7f, 0xb6, 0xa0, 0x75,
+	0x26, 0x43, 0x99, 0x0b, 0xe2, 0x42, 0xfb, 0x1a, 0xb9, 0xa0, 0x2c, 0x2d, 0x26, 0x57, 0x9a, 0x2a,
+	0x32, 0xa7, 0xf2, 0x79, 0x28, 0xde, 0x17, 0x15, 0x96, 0xa6, 0x6a, 0xea, 0x34, 0xa7, 0x71, 0xa4,
+	0x66, 0xe7, 0x36, 0x4c, 0x53, 0x2b, 0x87, 0x1a, 0xbb, 0x90, 0x21, 0x97, 0x18, 0x8d, 0xa5, 0xdb,
+	0xfc, 0xf1, 0xd8, 0x2b, 0xb0, 0xba, 0xd1, 0xb0, 0x14, 0xae, 0xad, 0xe7, 0x58, 0x9a, 0xfe, 0x17,
+	0x0b, 0x0e, 0xd4, 0xa4, 0xc6, 0x79, 0x44, 0xe5, 0x51, 0x2a, 0x39, 0xc5, 0xaa, 0x9f, 0xff, 0x80,
+	0x2d, 0x68, 0x3a, 0x33, 0xb3, 0x7f, 0xf8, 0x2e, 0x03, 0x54, 0x27, 0xf2, 0x54, 0xd2, 0xf8, 0x11,
+	0x8f, 0xd2, 0x00, 0x15, 0x33, 0x91, 0x4f, 0x3f, 0xe0, 0x4c, 0x16, 0xf5, 0x96, 0xa6, 0x7a, 0x7c,
+	0x1c, 0x05, 0xcb, 0xf9, 0x0c, 0x75, 0xb1, 0x4e, 0x50, 0xd9, 0x64, 0x0f, 0xec, 0x98, 0x26, 0x54,
+	0x16, 0xd5, 0x18, 0xc3, 0xff, 0x66, 0x01, 0x54, 0x75, 0xdc, 0x92, 0x01, 0x34, 0xd5, 0x76, 0x3e,
+	0x82, 0xbd, 0xc6, 0x2d, 0x52, 0xa9, 0x2f, 0x53, 0xd9, 0x03, 0x9b, 0xb3, 0xb8, 0x7a, 0x2e, 0xc6,
+	0x50, 0x78, 0xa9, 0x5e, 0xe4, 0xc9, 0xa4, 0xe0, 0x57, 0x9a, 0x7a, 0x6f, 0x10, 0xf9, 0x58, 0xed,
+	0xb5, 0x6d, 0xa8, 0x97, 0xb6, 0x1a, 0x31, 0xcb, 0x90, 0x87, 0x52, 0x3d, 0x8c, 0x96, 0x19, 0x71,
+	0xe5, 0x58, 0x2a, 0xba, 0xbd, 0x52, 0xb4, 0x0b, 0x6d, 0x96, 0xcb, 0x19, 0x4b, 0xd0, 0xdd, 0x32,
+	0xf7, 0x15, 0xa6, 0x3f, 0x01, 0x77, 0x7d, 0x86, 0xc5, 0x9b, 0xed, 0x43, 0x1b, 0x8d, 0xab, 0x58,
+	0xbb, 0x1d, 0xb5, 0x76, 0x77, 0x6d, 0x0a, 0xca, 0xf0, 0xe8, 0x6b, 0x03, 0xec, 0xb1, 0x12, 0x43,
+	0xf2, 0x97, 0xd2, 0x9f, 0x39, 0x15, 0x12, 0xb9, 0xda, 0x4f, 0xe2, 0xa8, 0x23, 0x5a, 0xae, 0xbc,
+	0x6a, 0x69, 0xfd, 0x1a, 0x79, 0x02, 0x1d, 0x23, 0x53, 0x46, 0xa5, 0x76, 0x55, 0x68, 0x51, 0xb7,
+	0xbc, 0xfd, 0xb5, 0x9e, 0x1f, 0x29, 0x2d, 0xf5, 0x6b, 0xe4, 0x0f, 0x68, 0x1f, 0xa3, 0x96, 0x07,
+	0x02, 0x65, 0xc6, 0x93, 0xc9, 0x52, 0xf6, 0xa7, 0xe0, 0x54, 0x12, 0x42, 0xf6, 0x54, 0x60, 0x55,
+	0x9f, 0xbc, 0x5f, 0x57, 0xbc, 0xa6, 0x64, 0xbf, 0x46, 0x7c, 0x80, 0x8b, 0x2c, 0x0a, 0x25, 0xea,
+	0x1b, 0xaa, 0xac, 0x4b, 0xf9, 0x47, 0x00, 0x13, 0x8c, 0x51, 0xe2, 0x1a, 0x8b, 0xfb, 0x69, 0x9f,
+	0xc2, 0xcf, 0x2b, 0xda, 0x40, 0x3c, 0x75, 0x70, 0xb3, 0x20, 0x79, 0xdd, 0x8d, 0xb1, 0x8a, 0xe5,
+	0xbf, 0xe0, 0x1c, 0xa3, 0x2c, 0xe4, 0xe2, 0x9e, 0x4b, 0x3d, 0x4d, 0xcc, 0x60, 0xfc, 0x1a, 0x79,
+	0x69, 0x54, 0x7a, 0x71, 0xd2, 0xa4, 0x5b, 0x76, 0x61, 0xc3, 0x0e, 0x7b, 0x87, 0x9b, 0x83, 0x25,
+	0x87, 0x69, 0x4b, 0x5f, 0xf7, 0xdf, 0xf7, 0x01, 0x00, 0x17, 0x41, 0x65, 0x9c, 0x2b, 0x07, 0x00,
+	0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -100,6 +721,23 @@ const _ = grpc.SupportPackageIsVersion4
 type AdminClient interface {
 	// RegisterUser registers a user with acmeproxy.
 	RegisterUser(ctx context.Context, in *Email, opts ...grpc.CallOption) (*User, error)
+	// RevokeToken revokes a token before it expires.
+	RevokeToken(ctx context.Context, in *RevokedToken, opts ...grpc.CallOption) (*empty.Empty, error)
+	// GetUser returns a single user.
+	GetUser(ctx context.Context, in *UserID, opts ...grpc.CallOption) (*User, error)
+	// ListUsers returns a page of users ordered by their id.
+	ListUsers(ctx context.Context, in *ListUsersRequest, opts ...grpc.CallOption) (*ListUsersResponse, error)
+	// UpdateUser replaces the email, the labels, and the public key of a user.
+	UpdateUser(ctx context.Context, in *User, opts ...grpc.CallOption) (*User, error)
+	// DeleteUser removes a user which does not own any domains.
+	DeleteUser(ctx context.Context, in *UserID, opts ...grpc.CallOption) (*empty.Empty, error)
+	// TransferDomains transfers domains from one user to another.
+	TransferDomains(ctx context.Context, in *TransferDomainsRequest, opts ...grpc.CallOption) (*TransferDomainsResponse, error)
+	// GetStatus returns the status of the acmeproxy server.
+	GetStatus(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*Status, error)
+	// ListAuditEntries returns the entries of the audit log matching the
+	// request ordered by the time they were recorded.
+	ListAuditEntries(ctx context.Context, in *ListAuditEntriesRequest, opts ...grpc.CallOption) (*ListAuditEntriesResponse, error)
 }
 
 type adminClient struct {
@@ -119,10 +757,99 @@ func (c *adminClient) RegisterUser(ctx context.Context, in *Email, opts ...grpc.
 	return out, nil
 }
 
+func (c *adminClient) RevokeToken(ctx context.Context, in *RevokedToken, opts ...grpc.CallOption) (*empty.Empty, error) {
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, "/pb.Admin/RevokeToken", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) GetUser(ctx context.Context, in *UserID, opts ...grpc.CallOption) (*User, error) {
+	out := new(User)
+	err := c.cc.Invoke(ctx, "/pb.Admin/GetUser", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) ListUsers(ctx context.Context, in *ListUsersRequest, opts ...grpc.CallOption) (*ListUsersResponse, error) {
+	out := new(ListUsersResponse)
+	err := c.cc.Invoke(ctx, "/pb.Admin/ListUsers", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) UpdateUser(ctx context.Context, in *User, opts ...grpc.CallOption) (*User, error) {
+	out := new(User)
+	err := c.cc.Invoke(ctx, "/pb.Admin/UpdateUser", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) DeleteUser(ctx context.Context, in *UserID, opts ...grpc.CallOption) (*empty.Empty, error) {
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, "/pb.Admin/DeleteUser", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) TransferDomains(ctx context.Context, in *TransferDomainsRequest, opts ...grpc.CallOption) (*TransferDomainsResponse, error) {
+	out := new(TransferDomainsResponse)
+	err := c.cc.Invoke(ctx, "/pb.Admin/TransferDomains", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) GetStatus(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*Status, error) {
+	out := new(Status)
+	err := c.cc.Invoke(ctx, "/pb.Admin/GetStatus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) ListAuditEntries(ctx context.Context, in *ListAuditEntriesRequest, opts ...grpc.CallOption) (*ListAuditEntriesResponse, error) {
+	out := new(ListAuditEntriesResponse)
+	err := c.cc.Invoke(ctx, "/pb.Admin/ListAuditEntries", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // AdminServer is the server API for Admin service.
 type AdminServer interface {
 	// RegisterUser registers a user with acmeproxy.
 	RegisterUser(context.Context, *Email) (*User, error)
+	// RevokeToken revokes a token before it expires.
+	RevokeToken(context.Context, *RevokedToken) (*empty.Empty, error)
+	// GetUser returns a single user.
+	GetUser(context.Context, *UserID) (*User, error)
+	// ListUsers returns a page of users ordered by their id.
+	ListUsers(context.Context, *ListUsersRequest) (*ListUsersResponse, error)
+	// UpdateUser replaces the email, the labels, and the public key of a user.
+	UpdateUser(context.Context, *User) (*User, error)
+	// DeleteUser removes a user which does not own any domains.
+	DeleteUser(context.Context, *UserID) (*empty.Empty, error)
+	// TransferDomains transfers domains from one user to another.
+	TransferDomains(context.Context, *TransferDomainsRequest) (*TransferDomainsResponse, error)
+	// GetStatus returns the status of the acmeproxy server.
+	GetStatus(context.Context, *empty.Empty) (*Status, error)
+	// ListAuditEntries returns the entries of the audit log matching the
+	// request ordered by the time they were recorded.
+	ListAuditEntries(context.Context, *ListAuditEntriesRequest) (*ListAuditEntriesResponse, error)
 }
 
 // UnimplementedAdminServer can be embedded to have forward compatible implementations.
@@ -132,6 +859,30 @@ type UnimplementedAdminServer struct {
 func (*UnimplementedAdminServer) RegisterUser(ctx context.Context, req *Email) (*User, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method RegisterUser not implemented")
 }
+func (*UnimplementedAdminServer) RevokeToken(ctx context.Context, req *RevokedToken) (*empty.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevokeToken not implemented")
+}
+func (*UnimplementedAdminServer) GetUser(ctx context.Context, req *UserID) (*User, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUser not implemented")
+}
+func (*UnimplementedAdminServer) ListUsers(ctx context.Context, req *ListUsersRequest) (*ListUsersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListUsers not implemented")
+}
+func (*UnimplementedAdminServer) UpdateUser(ctx context.Context, req *User) (*User, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateUser not implemented")
+}
+func (*UnimplementedAdminServer) DeleteUser(ctx context.Context, req *UserID) (*empty.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteUser not implemented")
+}
+func (*UnimplementedAdminServer) TransferDomains(ctx context.Context, req *TransferDomainsRequest) (*TransferDomainsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method TransferDomains not implemented")
+}
+func (*UnimplementedAdminServer) GetStatus(ctx context.Context, req *empty.Empty) (*Status, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStatus not implemented")
+}
+func (*UnimplementedAdminServer) ListAuditEntries(ctx context.Context, req *ListAuditEntriesRequest) (*ListAuditEntriesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListAuditEntries not implemented")
+}
 
 func RegisterAdminServer(s *grpc.Server, srv AdminServer) {
 	s.RegisterService(&_Admin_serviceDesc, srv)
@@ -155,6 +906,150 @@ func _Admin_RegisterUser_Handler(srv interface{}, ctx context.Context, dec func(
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Admin_RevokeToken_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevokedToken)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).RevokeToken(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Admin/RevokeToken",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).RevokeToken(ctx, req.(*RevokedToken))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_GetUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UserID)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).GetUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Admin/GetUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).GetUser(ctx, req.(*UserID))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_ListUsers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListUsersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).ListUsers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Admin/ListUsers",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).ListUsers(ctx, req.(*ListUsersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_UpdateUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(User)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).UpdateUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Admin/UpdateUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).UpdateUser(ctx, req.(*User))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_DeleteUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UserID)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).DeleteUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Admin/DeleteUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).DeleteUser(ctx, req.(*UserID))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_TransferDomains_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TransferDomainsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).TransferDomains(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Admin/TransferDomains",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).TransferDomains(ctx, req.(*TransferDomainsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_GetStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(empty.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).GetStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Admin/GetStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).GetStatus(ctx, req.(*empty.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_ListAuditEntries_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAuditEntriesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).ListAuditEntries(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Admin/ListAuditEntries",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).ListAuditEntries(ctx, req.(*ListAuditEntriesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _Admin_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "pb.Admin",
 	HandlerType: (*AdminServer)(nil),
@@ -163,6 +1058,38 @@ var _Admin_serviceDesc = grpc.ServiceDesc{
 			MethodName: "RegisterUser",
 			Handler:    _Admin_RegisterUser_Handler,
 		},
+		{
+			MethodName: "RevokeToken",
+			Handler:    _Admin_RevokeToken_Handler,
+		},
+		{
+			MethodName: "GetUser",
+			Handler:    _Admin_GetUser_Handler,
+		},
+		{
+			MethodName: "ListUsers",
+			Handler:    _Admin_ListUsers_Handler,
+		},
+		{
+			MethodName: "UpdateUser",
+			Handler:    _Admin_UpdateUser_Handler,
+		},
+		{
+			MethodName: "DeleteUser",
+			Handler:    _Admin_DeleteUser_Handler,
+		},
+		{
+			MethodName: "TransferDomains",
+			Handler:    _Admin_TransferDomains_Handler,
+		},
+		{
+			MethodName: "GetStatus",
+			Handler:    _Admin_GetStatus_Handler,
+		},
+		{
+			MethodName: "ListAuditEntries",
+			Handler:    _Admin_ListAuditEntries_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "pkg/api/grpcapi/internal/pb/service_admin.proto",
@@ -0,0 +1,756 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: pkg/api/grpcapi/internal/pb/service_domains.proto
+
+package pb
+
+import (
+	context "context"
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+// OCSPResponse wraps a DER encoded OCSP response.
+type OCSPResponse struct {
+	Raw                  []byte   `protobuf:"bytes,1,opt,name=raw,proto3" json:"raw,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *OCSPResponse) Reset()         { *m = OCSPResponse{} }
+func (m *OCSPResponse) String() string { return proto.CompactTextString(m) }
+func (*OCSPResponse) ProtoMessage()    {}
+func (*OCSPResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f98ca0d895ccdfd6, []int{0}
+}
+
+func (m *OCSPResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_OCSPResponse.Unmarshal(m, b)
+}
+func (m *OCSPResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_OCSPResponse.Marshal(b, m, deterministic)
+}
+func (m *OCSPResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_OCSPResponse.Merge(m, src)
+}
+func (m *OCSPResponse) XXX_Size() int {
+	return xxx_messageInfo_OCSPResponse.Size(m)
+}
+func (m *OCSPResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_OCSPResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_OCSPResponse proto.InternalMessageInfo
+
+func (m *OCSPResponse) GetRaw() []byte {
+	if m != nil {
+		return m.Raw
+	}
+	return nil
+}
+
+// CAARecord is a DNS CAA resource record as described in RFC 8659.
+type CAARecord struct {
+	Name                 string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Flag                 uint32   `protobuf:"varint,2,opt,name=flag,proto3" json:"flag,omitempty"`
+	Tag                  string   `protobuf:"bytes,3,opt,name=tag,proto3" json:"tag,omitempty"`
+	Value                string   `protobuf:"bytes,4,opt,name=value,proto3" json:"value,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CAARecord) Reset()         { *m = CAARecord{} }
+func (m *CAARecord) String() string { return proto.CompactTextString(m) }
+func (*CAARecord) ProtoMessage()    {}
+func (*CAARecord) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f98ca0d895ccdfd6, []int{1}
+}
+
+func (m *CAARecord) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CAARecord.Unmarshal(m, b)
+}
+func (m *CAARecord) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CAARecord.Marshal(b, m, deterministic)
+}
+func (m *CAARecord) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CAARecord.Merge(m, src)
+}
+func (m *CAARecord) XXX_Size() int {
+	return xxx_messageInfo_CAARecord.Size(m)
+}
+func (m *CAARecord) XXX_DiscardUnknown() {
+	xxx_messageInfo_CAARecord.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CAARecord proto.InternalMessageInfo
+
+func (m *CAARecord) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *CAARecord) GetFlag() uint32 {
+	if m != nil {
+		return m.Flag
+	}
+	return 0
+}
+
+func (m *CAARecord) GetTag() string {
+	if m != nil {
+		return m.Tag
+	}
+	return ""
+}
+
+func (m *CAARecord) GetValue() string {
+	if m != nil {
+		return m.Value
+	}
+	return ""
+}
+
+// CAARecords wraps a list of CAA records.
+type CAARecords struct {
+	Records              []*CAARecord `protobuf:"bytes,1,rep,name=records,proto3" json:"records,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
+}
+
+func (m *CAARecords) Reset()         { *m = CAARecords{} }
+func (m *CAARecords) String() string { return proto.CompactTextString(m) }
+func (*CAARecords) ProtoMessage()    {}
+func (*CAARecords) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f98ca0d895ccdfd6, []int{2}
+}
+
+func (m *CAARecords) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CAARecords.Unmarshal(m, b)
+}
+func (m *CAARecords) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CAARecords.Marshal(b, m, deterministic)
+}
+func (m *CAARecords) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CAARecords.Merge(m, src)
+}
+func (m *CAARecords) XXX_Size() int {
+	return xxx_messageInfo_CAARecords.Size(m)
+}
+func (m *CAARecords) XXX_DiscardUnknown() {
+	xxx_messageInfo_CAARecords.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CAARecords proto.InternalMessageInfo
+
+func (m *CAARecords) GetRecords() []*CAARecord {
+	if m != nil {
+		return m.Records
+	}
+	return nil
+}
+
+// ListDomainsRequest requests the domains of the user identified by userID,
+// or all domains if userID is empty.
+type ListDomainsRequest struct {
+	UserID               []byte   `protobuf:"bytes,1,opt,name=userID,proto3" json:"userID,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListDomainsRequest) Reset()         { *m = ListDomainsRequest{} }
+func (m *ListDomainsRequest) String() string { return proto.CompactTextString(m) }
+func (*ListDomainsRequest) ProtoMessage()    {}
+func (*ListDomainsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f98ca0d895ccdfd6, []int{3}
+}
+
+func (m *ListDomainsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListDomainsRequest.Unmarshal(m, b)
+}
+func (m *ListDomainsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListDomainsRequest.Marshal(b, m, deterministic)
+}
+func (m *ListDomainsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListDomainsRequest.Merge(m, src)
+}
+func (m *ListDomainsRequest) XXX_Size() int {
+	return xxx_messageInfo_ListDomainsRequest.Size(m)
+}
+func (m *ListDomainsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListDomainsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListDomainsRequest proto.InternalMessageInfo
+
+func (m *ListDomainsRequest) GetUserID() []byte {
+	if m != nil {
+		return m.UserID
+	}
+	return nil
+}
+
+// DomainInfo describes a domain managed by acmeproxy. It never contains the
+// private key of the domain's certificate.
+type DomainInfo struct {
+	Name                 string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	UserID               []byte   `protobuf:"bytes,2,opt,name=userID,proto3" json:"userID,omitempty"`
+	CertificatePEM       []byte   `protobuf:"bytes,3,opt,name=certificatePEM,proto3" json:"certificatePEM,omitempty"`
+	Revoked              bool     `protobuf:"varint,4,opt,name=revoked,proto3" json:"revoked,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DomainInfo) Reset()         { *m = DomainInfo{} }
+func (m *DomainInfo) String() string { return proto.CompactTextString(m) }
+func (*DomainInfo) ProtoMessage()    {}
+func (*DomainInfo) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f98ca0d895ccdfd6, []int{4}
+}
+
+func (m *DomainInfo) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DomainInfo.Unmarshal(m, b)
+}
+func (m *DomainInfo) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DomainInfo.Marshal(b, m, deterministic)
+}
+func (m *DomainInfo) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DomainInfo.Merge(m, src)
+}
+func (m *DomainInfo) XXX_Size() int {
+	return xxx_messageInfo_DomainInfo.Size(m)
+}
+func (m *DomainInfo) XXX_DiscardUnknown() {
+	xxx_messageInfo_DomainInfo.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DomainInfo proto.InternalMessageInfo
+
+func (m *DomainInfo) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *DomainInfo) GetUserID() []byte {
+	if m != nil {
+		return m.UserID
+	}
+	return nil
+}
+
+func (m *DomainInfo) GetCertificatePEM() []byte {
+	if m != nil {
+		return m.CertificatePEM
+	}
+	return nil
+}
+
+func (m *DomainInfo) GetRevoked() bool {
+	if m != nil {
+		return m.Revoked
+	}
+	return false
+}
+
+// ListDomainsResponse contains a list of domains ordered by their name.
+type ListDomainsResponse struct {
+	Domains              []*DomainInfo `protobuf:"bytes,1,rep,name=domains,proto3" json:"domains,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
+}
+
+func (m *ListDomainsResponse) Reset()         { *m = ListDomainsResponse{} }
+func (m *ListDomainsResponse) String() string { return proto.CompactTextString(m) }
+func (*ListDomainsResponse) ProtoMessage()    {}
+func (*ListDomainsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f98ca0d895ccdfd6, []int{5}
+}
+
+func (m *ListDomainsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListDomainsResponse.Unmarshal(m, b)
+}
+func (m *ListDomainsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListDomainsResponse.Marshal(b, m, deterministic)
+}
+func (m *ListDomainsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListDomainsResponse.Merge(m, src)
+}
+func (m *ListDomainsResponse) XXX_Size() int {
+	return xxx_messageInfo_ListDomainsResponse.Size(m)
+}
+func (m *ListDomainsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListDomainsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListDomainsResponse proto.InternalMessageInfo
+
+func (m *ListDomainsResponse) GetDomains() []*DomainInfo {
+	if m != nil {
+		return m.Domains
+	}
+	return nil
+}
+
+// WatchCertificatesRequest requests updates for the certificates of the
+// domains identified by domainNames. resumeToken is the token of the last
+// update a previous call returned, or empty.
+type WatchCertificatesRequest struct {
+	DomainNames          []string `protobuf:"bytes,1,rep,name=domainNames,proto3" json:"domainNames,omitempty"`
+	ResumeToken          []byte   `protobuf:"bytes,2,opt,name=resumeToken,proto3" json:"resumeToken,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *WatchCertificatesRequest) Reset()         { *m = WatchCertificatesRequest{} }
+func (m *WatchCertificatesRequest) String() string { return proto.CompactTextString(m) }
+func (*WatchCertificatesRequest) ProtoMessage()    {}
+func (*WatchCertificatesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f98ca0d895ccdfd6, []int{6}
+}
+
+func (m *WatchCertificatesRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_WatchCertificatesRequest.Unmarshal(m, b)
+}
+func (m *WatchCertificatesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_WatchCertificatesRequest.Marshal(b, m, deterministic)
+}
+func (m *WatchCertificatesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_WatchCertificatesRequest.Merge(m, src)
+}
+func (m *WatchCertificatesRequest) XXX_Size() int {
+	return xxx_messageInfo_WatchCertificatesRequest.Size(m)
+}
+func (m *WatchCertificatesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_WatchCertificatesRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_WatchCertificatesRequest proto.InternalMessageInfo
+
+func (m *WatchCertificatesRequest) GetDomainNames() []string {
+	if m != nil {
+		return m.DomainNames
+	}
+	return nil
+}
+
+func (m *WatchCertificatesRequest) GetResumeToken() []byte {
+	if m != nil {
+		return m.ResumeToken
+	}
+	return nil
+}
+
+// CertificateUpdate contains the certificate of a domain. It never contains
+// the private key of the certificate.
+type CertificateUpdate struct {
+	DomainName           string   `protobuf:"bytes,1,opt,name=domainName,proto3" json:"domainName,omitempty"`
+	CertificatePEM       []byte   `protobuf:"bytes,2,opt,name=certificatePEM,proto3" json:"certificatePEM,omitempty"`
+	ResumeToken          []byte   `protobuf:"bytes,3,opt,name=resumeToken,proto3" json:"resumeToken,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CertificateUpdate) Reset()         { *m = CertificateUpdate{} }
+func (m *CertificateUpdate) String() string { return proto.CompactTextString(m) }
+func (*CertificateUpdate) ProtoMessage()    {}
+func (*CertificateUpdate) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f98ca0d895ccdfd6, []int{7}
+}
+
+func (m *CertificateUpdate) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CertificateUpdate.Unmarshal(m, b)
+}
+func (m *CertificateUpdate) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CertificateUpdate.Marshal(b, m, deterministic)
+}
+func (m *CertificateUpdate) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CertificateUpdate.Merge(m, src)
+}
+func (m *CertificateUpdate) XXX_Size() int {
+	return xxx_messageInfo_CertificateUpdate.Size(m)
+}
+func (m *CertificateUpdate) XXX_DiscardUnknown() {
+	xxx_messageInfo_CertificateUpdate.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CertificateUpdate proto.InternalMessageInfo
+
+func (m *CertificateUpdate) GetDomainName() string {
+	if m != nil {
+		return m.DomainName
+	}
+	return ""
+}
+
+func (m *CertificateUpdate) GetCertificatePEM() []byte {
+	if m != nil {
+		return m.CertificatePEM
+	}
+	return nil
+}
+
+func (m *CertificateUpdate) GetResumeToken() []byte {
+	if m != nil {
+		return m.ResumeToken
+	}
+	return nil
+}
+
+// ResumeToken records the SHA-256 digests of the certificates a client
+// received, indexed by domain name. Clients treat its encoding as opaque.
+type ResumeToken struct {
+	CertificateSHA256    map[string][]byte `protobuf:"bytes,1,rep,name=certificateSHA256,proto3" json:"certificateSHA256,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *ResumeToken) Reset()         { *m = ResumeToken{} }
+func (m *ResumeToken) String() string { return proto.CompactTextString(m) }
+func (*ResumeToken) ProtoMessage()    {}
+func (*ResumeToken) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f98ca0d895ccdfd6, []int{8}
+}
+
+func (m *ResumeToken) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ResumeToken.Unmarshal(m, b)
+}
+func (m *ResumeToken) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ResumeToken.Marshal(b, m, deterministic)
+}
+func (m *ResumeToken) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ResumeToken.Merge(m, src)
+}
+func (m *ResumeToken) XXX_Size() int {
+	return xxx_messageInfo_ResumeToken.Size(m)
+}
+func (m *ResumeToken) XXX_DiscardUnknown() {
+	xxx_messageInfo_ResumeToken.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ResumeToken proto.InternalMessageInfo
+
+func (m *ResumeToken) GetCertificateSHA256() map[string][]byte {
+	if m != nil {
+		return m.CertificateSHA256
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*OCSPResponse)(nil), "pb.OCSPResponse")
+	proto.RegisterType((*CAARecord)(nil), "pb.CAARecord")
+	proto.RegisterType((*CAARecords)(nil), "pb.CAARecords")
+	proto.RegisterType((*ListDomainsRequest)(nil), "pb.ListDomainsRequest")
+	proto.RegisterType((*DomainInfo)(nil), "pb.DomainInfo")
+	proto.RegisterType((*ListDomainsResponse)(nil), "pb.ListDomainsResponse")
+	proto.RegisterType((*WatchCertificatesRequest)(nil), "pb.WatchCertificatesRequest")
+	proto.RegisterType((*CertificateUpdate)(nil), "pb.CertificateUpdate")
+	proto.RegisterType((*ResumeToken)(nil), "pb.ResumeToken")
+	proto.RegisterMapType((map[string][]byte)(nil), "pb.ResumeToken.CertificateSHA256Entry")
+}
+
+func init() {
+	proto.RegisterFile("pkg/api/grpcapi/internal/pb/service_domains.proto", fileDescriptor_f98ca0d895ccdfd6)
+}
+
+var fileDescriptor_f98ca0d895ccdfd6 = []byte{
+	// 529 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0x54, 0x5b, 0x6f, 0x12, 0x41,
+	0x14, 0xee, 0x42, 0x2d, 0x72, 0xb8, 0x58, 0x46, 0xc5, 0x0d, 0x31, 0x86, 0xcc, 0x43, 0xe5, 0x41,
+	0xc1, 0x62, 0x6a, 0x8c, 0x2f, 0x4a, 0xa0, 0xa9, 0x4d, 0xaa, 0x36, 0xd3, 0x1a, 0x1f, 0x4c, 0x34,
+	0xc3, 0x72, 0xc0, 0x0d, 0xb0, 0xbb, 0xce, 0x0e, 0x98, 0x9a, 0xf8, 0x83, 0xfc, 0x8d, 0xbe, 0x98,
+	0xd9, 0x19, 0xd8, 0x81, 0x45, 0x9f, 0x38, 0x97, 0xef, 0x5c, 0xbe, 0x33, 0x1f, 0x0b, 0xc7, 0xd1,
+	0x74, 0xd2, 0xe1, 0x91, 0xdf, 0x99, 0x88, 0xc8, 0x53, 0xbf, 0x7e, 0x20, 0x51, 0x04, 0x7c, 0xd6,
+	0x89, 0x86, 0x9d, 0x18, 0xc5, 0xd2, 0xf7, 0xf0, 0xeb, 0x28, 0x9c, 0x73, 0x3f, 0x88, 0xdb, 0x91,
+	0x08, 0x65, 0x48, 0x72, 0xd1, 0xb0, 0xd1, 0xfa, 0x5f, 0x99, 0x86, 0x6b, 0x34, 0x6d, 0x42, 0xf9,
+	0x43, 0xff, 0xea, 0x92, 0x61, 0x1c, 0x85, 0x41, 0x8c, 0xe4, 0x10, 0xf2, 0x82, 0xff, 0x70, 0x9d,
+	0xa6, 0xd3, 0x2a, 0x33, 0x65, 0xd2, 0xcf, 0x50, 0xec, 0xf7, 0x7a, 0x0c, 0xbd, 0x50, 0x8c, 0x08,
+	0x81, 0xfd, 0x80, 0xcf, 0x31, 0xc9, 0x17, 0x59, 0x62, 0xab, 0xd8, 0x78, 0xc6, 0x27, 0x6e, 0xae,
+	0xe9, 0xb4, 0x2a, 0x2c, 0xb1, 0x55, 0x1b, 0xc9, 0x27, 0x6e, 0x3e, 0x81, 0x29, 0x93, 0xdc, 0x83,
+	0x5b, 0x4b, 0x3e, 0x5b, 0xa0, 0xbb, 0x9f, 0xc4, 0xb4, 0x43, 0x4f, 0x00, 0xd6, 0xcd, 0x63, 0xf2,
+	0x18, 0x0a, 0x42, 0x9b, 0xae, 0xd3, 0xcc, 0xb7, 0x4a, 0xdd, 0x4a, 0x3b, 0x1a, 0xb6, 0xd7, 0x00,
+	0xb6, 0xca, 0xd2, 0x27, 0x40, 0x2e, 0xfc, 0x58, 0x0e, 0x34, 0x71, 0x86, 0xdf, 0x17, 0x18, 0x4b,
+	0x52, 0x87, 0x83, 0x45, 0x8c, 0xe2, 0x7c, 0x60, 0xd6, 0x37, 0x1e, 0xfd, 0x09, 0xa0, 0x91, 0xe7,
+	0xc1, 0x38, 0xdc, 0x49, 0x21, 0xad, 0xcc, 0xd9, 0x95, 0xe4, 0x08, 0xaa, 0x1e, 0x0a, 0xe9, 0x8f,
+	0x7d, 0x8f, 0x4b, 0xbc, 0x3c, 0x7d, 0x97, 0x30, 0x2a, 0xb3, 0xad, 0x28, 0x71, 0xd5, 0xe2, 0xcb,
+	0x70, 0x8a, 0xa3, 0x84, 0xde, 0x6d, 0xb6, 0x72, 0xe9, 0x6b, 0xb8, 0xbb, 0xb1, 0xa9, 0x39, 0x73,
+	0x0b, 0x0a, 0xe6, 0xd5, 0x0c, 0xd3, 0xaa, 0x62, 0x9a, 0x6e, 0xc9, 0x56, 0x69, 0xfa, 0x05, 0xdc,
+	0x4f, 0x5c, 0x7a, 0xdf, 0xfa, 0xe9, 0xc4, 0x35, 0xe1, 0x26, 0x94, 0x34, 0xec, 0x3d, 0x9f, 0xa3,
+	0xee, 0x54, 0x64, 0x76, 0x48, 0x21, 0x04, 0xc6, 0x8b, 0x39, 0x5e, 0x87, 0x53, 0x0c, 0x0c, 0x3b,
+	0x3b, 0x44, 0x7f, 0x41, 0xcd, 0x6a, 0xfd, 0x31, 0x1a, 0x71, 0x89, 0xe4, 0x11, 0x40, 0xda, 0xc5,
+	0x5c, 0xca, 0x8a, 0xec, 0xb8, 0x4b, 0x6e, 0xe7, 0x5d, 0xb6, 0xc6, 0xe7, 0xb3, 0xe3, 0x7f, 0x3b,
+	0x50, 0x62, 0xa9, 0x4f, 0xae, 0xa1, 0x66, 0xf5, 0xb8, 0x7a, 0xdb, 0xeb, 0x9e, 0xbc, 0x30, 0x27,
+	0x3a, 0x52, 0x27, 0xb2, 0xb0, 0xed, 0xfe, 0x36, 0xf0, 0x34, 0x90, 0xe2, 0x86, 0x65, 0x1b, 0x34,
+	0x06, 0x50, 0xdf, 0x0d, 0x56, 0x42, 0x9d, 0xe2, 0x8d, 0xa1, 0xa8, 0xcc, 0x54, 0xa8, 0x9a, 0x92,
+	0x76, 0x5e, 0xe5, 0x5e, 0x3a, 0xdd, 0x3f, 0x0e, 0x14, 0xcc, 0x43, 0x92, 0x63, 0xb8, 0x73, 0x86,
+	0x72, 0xe3, 0xaf, 0x03, 0xe9, 0x13, 0x36, 0x0e, 0x95, 0x6d, 0x67, 0xe9, 0x1e, 0x79, 0x0a, 0x95,
+	0x33, 0x94, 0x96, 0xdc, 0xed, 0x82, 0xea, 0x86, 0xd2, 0x63, 0xba, 0x47, 0xde, 0x40, 0xc9, 0x52,
+	0x0e, 0xa9, 0x2b, 0x40, 0x56, 0xf4, 0x8d, 0x07, 0x99, 0xf8, 0x7a, 0xe0, 0x05, 0xd4, 0x32, 0xd2,
+	0x21, 0x0f, 0x15, 0xfe, 0x5f, 0x8a, 0x6a, 0xdc, 0x4f, 0xd6, 0xd8, 0xd6, 0x03, 0xdd, 0x7b, 0xe6,
+	0x0c, 0x0f, 0x92, 0x0f, 0xc6, 0xf3, 0xbf, 0x03, 0x00, 0x5a, 0x89, 0xb1, 0xd3, 0x93, 0x04, 0x00,
+	0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// DomainsClient is the client API for Domains service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type DomainsClient interface {
+	// GetOCSPResponse returns the most recent OCSP response acmeproxy obtained
+	// for the certificate of a domain.
+	GetOCSPResponse(ctx context.Context, in *Domain, opts ...grpc.CallOption) (*OCSPResponse, error)
+	// GetCAARecords returns the CAA records the user should publish for
+	// a domain. The records allow only the user's ACME account to obtain
+	// certificates for the domain.
+	GetCAARecords(ctx context.Context, in *Domain, opts ...grpc.CallOption) (*CAARecords, error)
+	// ListDomains returns the domains the caller may read.
+	ListDomains(ctx context.Context, in *ListDomainsRequest, opts ...grpc.CallOption) (*ListDomainsResponse, error)
+	// WatchCertificates sends the current certificate of each requested domain
+	// and then every new certificate acmeproxy obtains for one of the domains.
+	// Each update carries a resume token. Clients pass the token of the last
+	// update they received when they reconnect. acmeproxy then sends only the
+	// certificates the client did not receive yet.
+	WatchCertificates(ctx context.Context, in *WatchCertificatesRequest, opts ...grpc.CallOption) (Domains_WatchCertificatesClient, error)
+}
+
+type domainsClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewDomainsClient(cc *grpc.ClientConn) DomainsClient {
+	return &domainsClient{cc}
+}
+
+func (c *domainsClient) GetOCSPResponse(ctx context.Context, in *Domain, opts ...grpc.CallOption) (*OCSPResponse, error) {
+	out := new(OCSPResponse)
+	err := c.cc.Invoke(ctx, "/pb.Domains/GetOCSPResponse", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *domainsClient) GetCAARecords(ctx context.Context, in *Domain, opts ...grpc.CallOption) (*CAARecords, error) {
+	out := new(CAARecords)
+	err := c.cc.Invoke(ctx, "/pb.Domains/GetCAARecords", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *domainsClient) ListDomains(ctx context.Context, in *ListDomainsRequest, opts ...grpc.CallOption) (*ListDomainsResponse, error) {
+	out := new(ListDomainsResponse)
+	err := c.cc.Invoke(ctx, "/pb.Domains/ListDomains", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *domainsClient) WatchCertificates(ctx context.Context, in *WatchCertificatesRequest, opts ...grpc.CallOption) (Domains_WatchCertificatesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Domains_serviceDesc.Streams[0], "/pb.Domains/WatchCertificates", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &domainsWatchCertificatesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Domains_WatchCertificatesClient interface {
+	Recv() (*CertificateUpdate, error)
+	grpc.ClientStream
+}
+
+type domainsWatchCertificatesClient struct {
+	grpc.ClientStream
+}
+
+func (x *domainsWatchCertificatesClient) Recv() (*CertificateUpdate, error) {
+	m := new(CertificateUpdate)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// DomainsServer is the server API for Domains service.
+type DomainsServer interface {
+	// GetOCSPResponse returns the most recent OCSP response acmeproxy obtained
+	// for the certificate of a domain.
+	GetOCSPResponse(context.Context, *Domain) (*OCSPResponse, error)
+	// GetCAARecords returns the CAA records the user should publish for
+	// a domain. The records allow only the user's ACME account to obtain
+	// certificates for the domain.
+	GetCAARecords(context.Context, *Domain) (*CAARecords, error)
+	// ListDomains returns the domains the caller may read.
+	ListDomains(context.Context, *ListDomainsRequest) (*ListDomainsResponse, error)
+	// WatchCertificates sends the current certificate of each requested domain
+	// and then every new certificate acmeproxy obtains for one of the domains.
+	// Each update carries a resume token. Clients pass the token of the last
+	// update they received when they reconnect. acmeproxy then sends only the
+	// certificates the client did not receive yet.
+	WatchCertificates(*WatchCertificatesRequest, Domains_WatchCertificatesServer) error
+}
+
+// UnimplementedDomainsServer can be embedded to have forward compatible implementations.
+type UnimplementedDomainsServer struct {
+}
+
+func (*UnimplementedDomainsServer) GetOCSPResponse(ctx context.Context, req *Domain) (*OCSPResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetOCSPResponse not implemented")
+}
+func (*UnimplementedDomainsServer) GetCAARecords(ctx context.Context, req *Domain) (*CAARecords, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCAARecords not implemented")
+}
+func (*UnimplementedDomainsServer) ListDomains(ctx context.Context, req *ListDomainsRequest) (*ListDomainsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListDomains not implemented")
+}
+func (*UnimplementedDomainsServer) WatchCertificates(req *WatchCertificatesRequest, srv Domains_WatchCertificatesServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchCertificates not implemented")
+}
+
+func RegisterDomainsServer(s *grpc.Server, srv DomainsServer) {
+	s.RegisterService(&_Domains_serviceDesc, srv)
+}
+
+func _Domains_GetOCSPResponse_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Domain)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DomainsServer).GetOCSPResponse(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Domains/GetOCSPResponse",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DomainsServer).GetOCSPResponse(ctx, req.(*Domain))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Domains_GetCAARecords_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Domain)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DomainsServer).GetCAARecords(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Domains/GetCAARecords",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DomainsServer).GetCAARecords(ctx, req.(*Domain))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Domains_ListDomains_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListDomainsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DomainsServer).ListDomains(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Domains/ListDomains",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DomainsServer).ListDomains(ctx, req.(*ListDomainsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Domains_WatchCertificates_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchCertificatesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DomainsServer).WatchCertificates(m, &domainsWatchCertificatesServer{stream})
+}
+
+type Domains_WatchCertificatesServer interface {
+	Send(*CertificateUpdate) error
+	grpc.ServerStream
+}
+
+type domainsWatchCertificatesServer struct {
+	grpc.ServerStream
+}
+
+func (x *domainsWatchCertificatesServer) Send(m *CertificateUpdate) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+var _Domains_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "pb.Domains",
+	HandlerType: (*DomainsServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetOCSPResponse",
+			Handler:    _Domains_GetOCSPResponse_Handler,
+		},
+		{
+			MethodName: "GetCAARecords",
+			Handler:    _Domains_GetCAARecords_Handler,
+		},
+		{
+			MethodName: "ListDomains",
+			Handler:    _Domains_ListDomains_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchCertificates",
+			Handler:       _Domains_WatchCertificates_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "pkg/api/grpcapi/internal/pb/service_domains.proto",
+}
@@ -21,11 +21,14 @@ var _ = math.Inf
 const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
 
 type User struct {
-	Id                   []byte   `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	Email                string   `protobuf:"bytes,2,opt,name=email,proto3" json:"email,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+	Id                   []byte            `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Email                string            `protobuf:"bytes,2,opt,name=email,proto3" json:"email,omitempty"`
+	AccountURL           string            `protobuf:"bytes,3,opt,name=accountURL,proto3" json:"accountURL,omitempty"`
+	Labels               map[string]string `protobuf:"bytes,4,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	PublicKeyPEM         []byte            `protobuf:"bytes,5,opt,name=publicKeyPEM,proto3" json:"publicKeyPEM,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
 }
 
 func (m *User) Reset()         { *m = User{} }
@@ -67,8 +70,30 @@ func (m *User) GetEmail() string {
 	return ""
 }
 
+func (m *User) GetAccountURL() string {
+	if m != nil {
+		return m.AccountURL
+	}
+	return ""
+}
+
+func (m *User) GetLabels() map[string]string {
+	if m != nil {
+		return m.Labels
+	}
+	return nil
+}
+
+func (m *User) GetPublicKeyPEM() []byte {
+	if m != nil {
+		return m.PublicKeyPEM
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*User)(nil), "pb.User")
+	proto.RegisterMapType((map[string]string)(nil), "pb.User.LabelsEntry")
 }
 
 func init() {
@@ -76,12 +101,19 @@ func init() {
 }
 
 var fileDescriptor_049ac733111d1c5a = []byte{
-	// 112 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0x52, 0x2b, 0xc8, 0x4e, 0xd7,
-	0x4f, 0x2c, 0xc8, 0xd4, 0x4f, 0x2f, 0x2a, 0x48, 0x06, 0xd1, 0x99, 0x79, 0x25, 0xa9, 0x45, 0x79,
-	0x89, 0x39, 0xfa, 0x05, 0x49, 0xfa, 0xa5, 0xc5, 0xa9, 0x45, 0x7a, 0x05, 0x45, 0xf9, 0x25, 0xf9,
-	0x42, 0x4c, 0x05, 0x49, 0x4a, 0x3a, 0x5c, 0x2c, 0xa1, 0xc5, 0xa9, 0x45, 0x42, 0x7c, 0x5c, 0x4c,
-	0x99, 0x29, 0x12, 0x8c, 0x0a, 0x8c, 0x1a, 0x3c, 0x41, 0x4c, 0x99, 0x29, 0x42, 0x22, 0x5c, 0xac,
-	0xa9, 0xb9, 0x89, 0x99, 0x39, 0x12, 0x4c, 0x0a, 0x8c, 0x1a, 0x9c, 0x41, 0x10, 0x4e, 0x12, 0x1b,
-	0x58, 0xa3, 0x31, 0x20, 0x00, 0x00, 0xff, 0xff, 0x87, 0xeb, 0x35, 0x38, 0x62, 0x00, 0x00, 0x00,
+	// 222 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x54, 0x8f, 0xcf, 0x4a, 0x03, 0x31,
+	0x10, 0xc6, 0x49, 0xb6, 0x2d, 0x74, 0x5a, 0x44, 0x42, 0x0f, 0xc1, 0x83, 0x2c, 0x3d, 0xc8, 0x1e,
+	0x64, 0x17, 0xf4, 0xa2, 0xde, 0x7b, 0xb2, 0x82, 0x04, 0xfa, 0x00, 0xc9, 0x76, 0x28, 0xa1, 0x31,
+	0x3b, 0x64, 0x13, 0x61, 0x1f, 0xd5, 0xb7, 0x91, 0x44, 0x85, 0x7a, 0x9a, 0xf9, 0xbe, 0xf9, 0xf7,
+	0x1b, 0xb8, 0xa3, 0xf3, 0xa9, 0xd3, 0x64, 0xbb, 0x53, 0xa0, 0x3e, 0x47, 0xeb, 0x23, 0x06, 0xaf,
+	0x5d, 0x47, 0xa6, 0x4b, 0x23, 0x86, 0x96, 0xc2, 0x10, 0x07, 0xc1, 0xc9, 0x6c, 0xbf, 0x18, 0xcc,
+	0x0e, 0x23, 0x06, 0x71, 0x05, 0xdc, 0x1e, 0x25, 0xab, 0x59, 0xb3, 0x56, 0xdc, 0x1e, 0xc5, 0x06,
+	0xe6, 0xf8, 0xa1, 0xad, 0x93, 0xbc, 0x66, 0xcd, 0x52, 0xfd, 0x08, 0x71, 0x0b, 0xa0, 0xfb, 0x7e,
+	0x48, 0x3e, 0x1e, 0xd4, 0x5e, 0x56, 0xa5, 0x74, 0xe1, 0x88, 0x7b, 0x58, 0x38, 0x6d, 0xd0, 0x8d,
+	0x72, 0x56, 0x57, 0xcd, 0xea, 0x61, 0xd3, 0x92, 0x69, 0xf3, 0xfe, 0x76, 0x5f, 0xec, 0x9d, 0x8f,
+	0x61, 0x52, 0xbf, 0x3d, 0x62, 0x0b, 0x6b, 0x4a, 0xc6, 0xd9, 0xfe, 0x15, 0xa7, 0xf7, 0xdd, 0x9b,
+	0x9c, 0x97, 0xeb, 0xff, 0xbc, 0x9b, 0x67, 0x58, 0x5d, 0x8c, 0x8a, 0x6b, 0xa8, 0xce, 0x38, 0x15,
+	0xce, 0xa5, 0xca, 0x69, 0x06, 0xfd, 0xd4, 0x2e, 0xe1, 0x1f, 0x68, 0x11, 0x2f, 0xfc, 0x89, 0x99,
+	0x45, 0x79, 0xf3, 0xf1, 0x7b, 0x00, 0xd3, 0x69, 0x39, 0x67, 0x10, 0x01, 0x00, 0x00,
 }
@@ -0,0 +1,84 @@
+package grpcapi
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// CallMetrics counts the calls of the gRPC API by method and status code, and
+// observes their latency by method. The latency of a streaming call is the
+// lifetime of its stream.
+//
+// CallMetrics implements prometheus.Collector. A nil *CallMetrics discards
+// all observations.
+type CallMetrics struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+// NewCallMetrics creates new CallMetrics.
+func NewCallMetrics() *CallMetrics {
+	return &CallMetrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "acmeproxy",
+			Subsystem: "grpc",
+			Name:      "requests_total",
+			Help:      "Number of finished gRPC calls.",
+		}, []string{"method", "code"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "acmeproxy",
+			Subsystem: "grpc",
+			Name:      "request_duration_seconds",
+			Help:      "Duration of gRPC calls.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method"}),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (m *CallMetrics) Describe(ch chan<- *prometheus.Desc) {
+	m.requests.Describe(ch)
+	m.duration.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (m *CallMetrics) Collect(ch chan<- prometheus.Metric) {
+	m.requests.Collect(ch)
+	m.duration.Collect(ch)
+}
+
+func (m *CallMetrics) observe(method string, start time.Time, err error) {
+	if m == nil {
+		return
+	}
+	m.requests.WithLabelValues(method, status.Code(err).String()).Inc()
+	m.duration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+}
+
+// metricsUnary records every call in m.
+func metricsUnary(m *CallMetrics) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		start := time.Now()
+		res, err := handler(ctx, req)
+		m.observe(unaryMethod(info), start, err)
+		return res, err
+	}
+}
+
+// metricsStream is the equivalent of metricsUnary for streaming RPCs.
+func metricsStream(m *CallMetrics) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler,
+	) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		m.observe(streamMethod(info), start, err)
+		return err
+	}
+}
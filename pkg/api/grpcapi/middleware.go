@@ -0,0 +1,381 @@
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fhofherr/acmeproxy/pkg/api/grpcapi/internal/pb"
+	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/fhofherr/acmeproxy/pkg/requestid"
+	"github.com/fhofherr/acmeproxy/pkg/tracing"
+	"github.com/fhofherr/golf/log"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// chainUnaryInterceptors combines interceptors into a single interceptor.
+// The first interceptor is the outermost one. It is called first and
+// returns last.
+func chainUnaryInterceptors(interceptors ...grpc.UnaryServerInterceptor) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		next := handler
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			interceptor, inner := interceptors[i], next
+			next = func(ctx context.Context, req interface{}) (interface{}, error) {
+				return interceptor(ctx, req, info, inner)
+			}
+		}
+		return next(ctx, req)
+	}
+}
+
+// chainStreamInterceptors is the equivalent of chainUnaryInterceptors for
+// streaming RPCs.
+func chainStreamInterceptors(interceptors ...grpc.StreamServerInterceptor) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler,
+	) error {
+		next := handler
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			interceptor, inner := interceptors[i], next
+			next = func(srv interface{}, ss grpc.ServerStream) error {
+				return interceptor(srv, ss, info, inner)
+			}
+		}
+		return next(srv, ss)
+	}
+}
+
+// requestIDUnary adds the ID of the request to the context passed to the inner
+// interceptors and the handler. It uses the ID the caller passed in the
+// x-request-id metadata, or the trace ID of the caller's traceparent. If the
+// caller passed neither requestIDUnary generates a new ID. The ID is sent back
+// to the caller in the x-request-id header.
+func requestIDUnary(
+	ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+) (interface{}, error) {
+	ctx, id := requestIDCtx(ctx)
+	// SetHeader fails for calls not made through a gRPC transport, i.e.
+	// calls of the Gateway. The Gateway sends the ID itself.
+	_ = grpc.SetHeader(ctx, metadata.Pairs(requestid.MetadataKey, id))
+	return handler(ctx, req)
+}
+
+// requestIDStream is the equivalent of requestIDUnary for streaming RPCs.
+func requestIDStream(
+	srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler,
+) error {
+	ctx, id := requestIDCtx(ss.Context())
+	_ = ss.SetHeader(metadata.Pairs(requestid.MetadataKey, id))
+	return handler(srv, &contextServerStream{ServerStream: ss, ctx: ctx})
+}
+
+func requestIDCtx(ctx context.Context) (context.Context, string) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	id := requestid.Select(firstMetadataValue(md, requestid.MetadataKey), firstMetadataValue(md, requestid.TraceParentHeader))
+	return requestid.NewContext(ctx, id), id
+}
+
+func firstMetadataValue(md metadata.MD, key string) string {
+	if vs := md.Get(key); len(vs) > 0 {
+		return vs[0]
+	}
+	return ""
+}
+
+// traceUnary records a span for every call. The span is a child of the
+// caller's span if the caller passed a W3C trace context in the traceparent
+// metadata.
+func traceUnary(
+	ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+) (interface{}, error) {
+	ctx, span := startCallSpan(ctx, unaryMethod(info))
+	res, err := handler(ctx, req)
+	tracing.End(span, err)
+	return res, err
+}
+
+// traceStream is the equivalent of traceUnary for streaming RPCs. The span
+// lasts until the stream ends.
+func traceStream(
+	srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler,
+) error {
+	ctx, span := startCallSpan(ss.Context(), streamMethod(info))
+	err := handler(srv, &contextServerStream{ServerStream: ss, ctx: ctx})
+	tracing.End(span, err)
+	return err
+}
+
+func startCallSpan(ctx context.Context, method string) (context.Context, trace.Span) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	ctx = tracing.Extract(ctx, metadataCarrier(md))
+	attrs := []attribute.KeyValue{semconv.RPCSystemGRPC}
+	if parts := strings.SplitN(strings.TrimPrefix(method, "/"), "/", 2); len(parts) == 2 {
+		attrs = append(attrs, semconv.RPCServiceKey.String(parts[0]), semconv.RPCMethodKey.String(parts[1]))
+	}
+	return tracing.StartServer(ctx, method, attrs...)
+}
+
+// metadataCarrier allows to extract trace contexts from gRPC metadata.
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	return firstMetadataValue(metadata.MD(c), key)
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// logUnary logs every call with its duration and status code. It logs the
+// errors of failed calls using errors.Log.
+func logUnary(logger log.Logger) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		start := time.Now()
+		res, err := handler(ctx, req)
+		logCall(ctx, logger, unaryMethod(info), start, err)
+		return res, err
+	}
+}
+
+// logStream is the equivalent of logUnary for streaming RPCs.
+func logStream(logger log.Logger) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler,
+	) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		logCall(ss.Context(), logger, streamMethod(info), start, err)
+		return err
+	}
+}
+
+// logCall logs the end of a call. Both log entries contain the ID of the
+// request.
+func logCall(ctx context.Context, logger log.Logger, method string, start time.Time, err error) {
+	if logger == nil {
+		return
+	}
+	level := "info"
+	if err != nil {
+		appErr := pb.FromGRPCStatusError(err)
+		level = errors.LogLevel(appErr)
+		errors.Log(logger, requestid.Error(ctx, appErr))
+	}
+	log.Log(requestid.Logger(ctx, logger),
+		"level", level,
+		"message", "grpc call finished",
+		"method", method,
+		"code", status.Code(err).String(),
+		"duration", time.Since(start).String())
+}
+
+// translateErrorsUnary converts the errors returned by the inner interceptors
+// and the handler into gRPC status errors. It attaches the ID of the request
+// to the errors.
+func translateErrorsUnary(
+	ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+) (interface{}, error) {
+	res, err := handler(ctx, req)
+	return res, toStatusError(ctx, err)
+}
+
+// translateErrorsStream is the equivalent of translateErrorsUnary for
+// streaming RPCs.
+func translateErrorsStream(
+	srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler,
+) error {
+	return toStatusError(ss.Context(), handler(srv, ss))
+}
+
+// toStatusError converts err into a gRPC status error using
+// pb.ToGRPCStatusError after attaching the request ID carried by ctx. Errors
+// caused by an expired or canceled context are converted to the respective
+// codes. toStatusError returns status errors unchanged.
+func toStatusError(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		// Most handlers convert errors to status errors themselves.
+		// Attach the request ID to those errors of acmeproxy, too.
+		var acpErr *errors.Error
+		if errors.As(pb.FromGRPCStatusError(err), &acpErr) && acpErr.RequestID == "" {
+			return pb.ToGRPCStatusError(requestid.Error(ctx, acpErr))
+		}
+		return err
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+	if errors.Is(err, context.Canceled) {
+		return status.Error(codes.Canceled, err.Error())
+	}
+	return pb.ToGRPCStatusError(requestid.Error(ctx, err))
+}
+
+// recoverUnary turns panics of the inner interceptors and the handler into
+// status errors with code codes.Internal.
+//
+// recoverUnary is the outermost interceptor. The interceptors logging the
+// call never see the calls that panicked. recoverUnary thus logs those calls
+// itself.
+func recoverUnary(logger log.Logger) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+	) (res interface{}, err error) {
+		start := time.Now()
+		defer func() {
+			if r := recover(); r != nil {
+				err = panicError(ctx, logger, unaryMethod(info), start, r)
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// recoverStream is the equivalent of recoverUnary for streaming RPCs.
+func recoverStream(logger log.Logger) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler,
+	) (err error) {
+		start := time.Now()
+		defer func() {
+			if r := recover(); r != nil {
+				err = panicError(ss.Context(), logger, streamMethod(info), start, r)
+			}
+		}()
+		return handler(srv, ss)
+	}
+}
+
+// panicError converts the value r recovered from a panic into a status error
+// and logs the call that panicked.
+func panicError(ctx context.Context, logger log.Logger, method string, start time.Time, r interface{}) error {
+	const op errors.Op = "grpcapi/recover"
+
+	err := toStatusError(ctx, errors.New(op, fmt.Sprintf("panic in %s: %v", method, r)))
+	logCall(ctx, logger, method, start, err)
+	return err
+}
+
+// deadlineUnary limits the duration of each call to timeout unless the
+// caller requested an earlier deadline. It does nothing if timeout is zero.
+//
+// There is no equivalent for streaming RPCs. Streams run until the caller
+// cancels them.
+func deadlineUnary(timeout time.Duration) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		if timeout <= 0 {
+			return handler(ctx, req)
+		}
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		return handler(ctx, req)
+	}
+}
+
+// authUnary authenticates the caller and adds its claims to the context
+// passed to the handler. Callers of public methods are not authenticated.
+// Failed authentications are recorded in trail together with the address of
+// the caller.
+func authUnary(
+	parse TokenParser, mapCert CertificateMapper, revoker TokenRevoker, trail AuditTrail, logger log.Logger,
+) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		method := unaryMethod(info)
+		if isPublicMethod(method) {
+			return handler(ctx, req)
+		}
+		start := time.Now()
+		ctx, err := authenticate(ctx, parse, mapCert, revoker)
+		if err != nil {
+			recordAudit(ctx, trail, logger, method, start, auditResources(req, nil), err)
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// authStream is the equivalent of authUnary for streaming RPCs. The
+// resources of a failed authentication are not recorded as the caller's
+// first message has not been received yet.
+func authStream(
+	parse TokenParser, mapCert CertificateMapper, revoker TokenRevoker, trail AuditTrail, logger log.Logger,
+) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler,
+	) error {
+		method := streamMethod(info)
+		if isPublicMethod(method) {
+			return handler(srv, ss)
+		}
+		start := time.Now()
+		ctx, err := authenticate(ss.Context(), parse, mapCert, revoker)
+		if err != nil {
+			recordAudit(ctx, trail, logger, method, start, nil, err)
+			return err
+		}
+		return handler(srv, &contextServerStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// contextServerStream replaces the context of the wrapped ServerStream, e.g.
+// with a context containing the claims of the caller.
+type contextServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextServerStream) Context() context.Context {
+	return s.ctx
+}
+
+// authenticate authenticates the caller and checks that its token has not
+// been revoked. It returns a context containing the caller's claims.
+func authenticate(
+	ctx context.Context, parse TokenParser, mapCert CertificateMapper, revoker TokenRevoker,
+) (context.Context, error) {
+	ctx, err := authCtx(ctx, parse, mapCert)
+	if err != nil {
+		return ctx, err
+	}
+	return ctx, checkRevoked(ctx, revoker)
+}
+
+func unaryMethod(info *grpc.UnaryServerInfo) string {
+	if info == nil {
+		return ""
+	}
+	return info.FullMethod
+}
+
+func streamMethod(info *grpc.StreamServerInfo) string {
+	if info == nil {
+		return ""
+	}
+	return info.FullMethod
+}
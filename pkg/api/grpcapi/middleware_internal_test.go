@@ -0,0 +1,284 @@
+package grpcapi
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fhofherr/acmeproxy/pkg/api/auth"
+	"github.com/fhofherr/acmeproxy/pkg/api/grpcapi/internal/pb"
+	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/fhofherr/acmeproxy/pkg/requestid"
+	"github.com/fhofherr/acmeproxy/pkg/security"
+	"github.com/fhofherr/acmeproxy/pkg/tracing"
+	"github.com/fhofherr/golf/log"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestChainUnaryInterceptors(t *testing.T) {
+	var calls []string
+	interceptor := func(name string) grpc.UnaryServerInterceptor {
+		return func(
+			ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+		) (interface{}, error) {
+			calls = append(calls, name+" before")
+			res, err := handler(ctx, req)
+			calls = append(calls, name+" after")
+			return res, err
+		}
+	}
+	handler := func(context.Context, interface{}) (interface{}, error) {
+		calls = append(calls, "handler")
+		return "response", nil
+	}
+	chain := chainUnaryInterceptors(interceptor("outer"), interceptor("inner"))
+	res, err := chain(context.Background(), "request", &grpc.UnaryServerInfo{}, handler)
+	assert.NoError(t, err)
+	assert.Equal(t, "response", res)
+	expected := []string{"outer before", "inner before", "handler", "inner after", "outer after"}
+	assert.Equal(t, expected, calls)
+}
+
+func TestUnaryServerInterceptor_Middleware(t *testing.T) {
+	tests := []struct {
+		name        string
+		callTimeout time.Duration
+		handler     grpc.UnaryHandler
+		code        codes.Code
+		level       string
+	}{
+		{
+			name: "successful call",
+			handler: func(context.Context, interface{}) (interface{}, error) {
+				return nil, nil
+			},
+			code:  codes.OK,
+			level: "info",
+		},
+		{
+			name: "translate errors",
+			handler: func(context.Context, interface{}) (interface{}, error) {
+				return nil, errors.New(errors.NotFound, "not found")
+			},
+			code:  codes.NotFound,
+			level: "info",
+		},
+		{
+			name: "recover from panics",
+			handler: func(context.Context, interface{}) (interface{}, error) {
+				panic("handler panicked")
+			},
+			code:  codes.Internal,
+			level: "error",
+		},
+		{
+			name:        "apply call timeout",
+			callTimeout: 10 * time.Millisecond,
+			handler: func(ctx context.Context, _ interface{}) (interface{}, error) {
+				if _, ok := ctx.Deadline(); !ok {
+					return nil, errors.New("no deadline")
+				}
+				<-ctx.Done()
+				return nil, ctx.Err()
+			},
+			code:  codes.DeadlineExceeded,
+			level: "error",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			logger := &log.TestLogger{}
+			intercept := (&unaryServerInterceptor{
+				TokenParser: func(string) (*auth.Claims, error) {
+					return &auth.Claims{}, nil
+				},
+				TokenRevoker: &auth.Denylist{
+					Repository: &security.InMemoryRevokedTokenRepository{},
+				},
+				Logger:      logger,
+				CallTimeout: tt.callTimeout,
+			}).chain()
+			md := metadata.New(map[string]string{"authorization": "Bearer valid token"})
+			ctx := metadata.NewIncomingContext(context.Background(), md)
+			info := &grpc.UnaryServerInfo{FullMethod: "/pb.Test/Call"}
+
+			_, err := intercept(ctx, nil, info, tt.handler)
+			assert.Equal(t, tt.code, status.Code(err))
+
+			logger.AssertHasMatchingLogEntries(t, 1, func(e log.TestLogEntry) bool {
+				return e["method"] == info.FullMethod && e["code"] == tt.code.String() && e["level"] == tt.level
+			})
+		})
+	}
+}
+
+func TestUnaryServerInterceptor_RequestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		md       metadata.MD
+		expected string
+	}{
+		{
+			name:     "request id passed by caller",
+			md:       metadata.Pairs(requestid.MetadataKey, "some-request"),
+			expected: "some-request",
+		},
+		{
+			name:     "trace id of traceparent",
+			md:       metadata.Pairs("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"),
+			expected: "4bf92f3577b34da6a3ce929d0e0e4736",
+		},
+		{
+			name:     "invalid request id",
+			md:       metadata.Pairs(requestid.MetadataKey, "some request"),
+			expected: "",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			logger := &log.TestLogger{}
+			intercept := (&unaryServerInterceptor{
+				TokenParser: func(string) (*auth.Claims, error) {
+					return &auth.Claims{}, nil
+				},
+				TokenRevoker: &auth.Denylist{
+					Repository: &security.InMemoryRevokedTokenRepository{},
+				},
+				Logger: logger,
+			}).chain()
+			md := metadata.Join(tt.md, metadata.Pairs("authorization", "Bearer valid token"))
+			ctx := metadata.NewIncomingContext(context.Background(), md)
+			info := &grpc.UnaryServerInfo{FullMethod: "/pb.Test/Call"}
+			var handlerID string
+			handler := func(ctx context.Context, _ interface{}) (interface{}, error) {
+				handlerID = requestid.FromContext(ctx)
+				return nil, errors.New(errors.NotFound, "not found")
+			}
+
+			_, err := intercept(ctx, nil, info, handler)
+			assert.NotEmpty(t, handlerID)
+			if tt.expected != "" {
+				assert.Equal(t, tt.expected, handlerID)
+			}
+			assert.Equal(t, handlerID, errors.GetRequestID(pb.FromGRPCStatusError(err)))
+			logger.AssertHasMatchingLogEntries(t, 2, func(e log.TestLogEntry) bool {
+				return e[requestid.LogKey] == handlerID
+			})
+		})
+	}
+}
+
+func TestStreamServerInterceptor_RequestID(t *testing.T) {
+	intercept := (&streamServerInterceptor{
+		TokenParser: func(string) (*auth.Claims, error) {
+			return &auth.Claims{}, nil
+		},
+		TokenRevoker: &auth.Denylist{
+			Repository: &security.InMemoryRevokedTokenRepository{},
+		},
+	}).chain()
+	var handlerID string
+	handler := func(_ interface{}, ss grpc.ServerStream) error {
+		handlerID = requestid.FromContext(ss.Context())
+		return nil
+	}
+	md := metadata.Pairs("authorization", "Bearer valid token", requestid.MetadataKey, "some-request")
+	ss := &fakeServerStream{ctx: metadata.NewIncomingContext(context.Background(), md)}
+	err := intercept(nil, ss, &grpc.StreamServerInfo{}, handler)
+	assert.NoError(t, err)
+	assert.Equal(t, "some-request", handlerID)
+	assert.Equal(t, []string{"some-request"}, ss.header.Get(requestid.MetadataKey))
+}
+
+func TestUnaryServerInterceptor_Tracing(t *testing.T) {
+	rec := tracing.Record(t)
+	intercept := (&unaryServerInterceptor{
+		TokenParser: func(string) (*auth.Claims, error) {
+			return &auth.Claims{}, nil
+		},
+		TokenRevoker: &auth.Denylist{
+			Repository: &security.InMemoryRevokedTokenRepository{},
+		},
+	}).chain()
+	md := metadata.Pairs(
+		"authorization", "Bearer valid token",
+		requestid.TraceParentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	)
+	ctx := metadata.NewIncomingContext(context.Background(), md)
+	info := &grpc.UnaryServerInfo{FullMethod: "/pb.Test/Call"}
+	handler := func(ctx context.Context, _ interface{}) (interface{}, error) {
+		_, span := tracing.Start(ctx, "test/handler")
+		tracing.End(span, nil)
+		return nil, errors.New(errors.NotFound, "not found")
+	}
+
+	_, err := intercept(ctx, nil, info, handler)
+	assert.Error(t, err)
+	spans := rec.Ended()
+	if !assert.Len(t, spans, 2) {
+		return
+	}
+	call := spans[1]
+	assert.Equal(t, "/pb.Test/Call", call.Name())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", call.SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", call.Parent().SpanID().String())
+	assert.Equal(t, call.SpanContext().SpanID(), spans[0].Parent().SpanID())
+	assert.Equal(t, otelcodes.Error, call.Status().Code)
+	assert.Contains(t, call.Attributes(), tracing.RequestIDKey.String("4bf92f3577b34da6a3ce929d0e0e4736"))
+}
+
+func TestUnaryServerInterceptor_Metrics(t *testing.T) {
+	metrics := NewCallMetrics()
+	intercept := (&unaryServerInterceptor{
+		TokenParser: func(string) (*auth.Claims, error) {
+			return &auth.Claims{}, nil
+		},
+		TokenRevoker: &auth.Denylist{
+			Repository: &security.InMemoryRevokedTokenRepository{},
+		},
+		Metrics: metrics,
+	}).chain()
+	md := metadata.Pairs("authorization", "Bearer valid token")
+	ctx := metadata.NewIncomingContext(context.Background(), md)
+	info := &grpc.UnaryServerInfo{FullMethod: "/pb.Test/Call"}
+	ok := func(context.Context, interface{}) (interface{}, error) {
+		return nil, nil
+	}
+	notFound := func(context.Context, interface{}) (interface{}, error) {
+		return nil, errors.New(errors.NotFound, "not found")
+	}
+
+	_, _ = intercept(ctx, nil, info, ok)
+	_, _ = intercept(ctx, nil, info, ok)
+	_, _ = intercept(ctx, nil, info, notFound)
+
+	assert.Equal(t, float64(2), testutil.ToFloat64(metrics.requests.WithLabelValues("/pb.Test/Call", "OK")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.requests.WithLabelValues("/pb.Test/Call", "NotFound")))
+	assert.Equal(t, 1, testutil.CollectAndCount(metrics.duration))
+}
+
+func TestStreamServerInterceptor_RecoversFromPanics(t *testing.T) {
+	intercept := (&streamServerInterceptor{
+		TokenParser: func(string) (*auth.Claims, error) {
+			return &auth.Claims{}, nil
+		},
+		TokenRevoker: &auth.Denylist{
+			Repository: &security.InMemoryRevokedTokenRepository{},
+		},
+	}).chain()
+	handler := func(interface{}, grpc.ServerStream) error {
+		panic("handler panicked")
+	}
+	md := metadata.New(map[string]string{"authorization": "Bearer valid token"})
+	ss := &fakeServerStream{ctx: metadata.NewIncomingContext(context.Background(), md)}
+	err := intercept(nil, ss, &grpc.StreamServerInfo{}, handler)
+	assert.Equal(t, codes.Internal, status.Code(err))
+}
@@ -0,0 +1,351 @@
+package grpcapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fhofherr/acmeproxy/pkg/api/auth"
+	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+)
+
+// rateLimitSweepInterval is the interval in which the rate limiter removes
+// the buckets of idle clients.
+const rateLimitSweepInterval = time.Minute
+
+// RateLimitRule limits the number of calls a client may make.
+//
+// A client may make Rate calls per second on average and at most Burst calls
+// at once. If Method is not empty the rule applies only to calls of the
+// method with this full name, e.g. /pb.Admin/RegisterUser. Otherwise it
+// applies to all calls of the client together. If Role is not empty the rule
+// applies only to clients having this role. Clients without roles have the
+// role auth.User.
+type RateLimitRule struct {
+	Method string    `json:"method,omitempty"`
+	Role   auth.Role `json:"role,omitempty"`
+	Rate   float64   `json:"rate"`
+	Burst  int       `json:"burst"`
+}
+
+// RateLimits contains the rules limiting the calls to the gRPC API.
+//
+// Only the most specific rule matching a call applies. Rules matching method
+// and role are more specific than rules matching only the method, which in
+// turn are more specific than rules matching only the role. Among equally
+// specific rules the one with the highest Rate applies. Calls no rule matches
+// are not limited.
+//
+// Rules apply once the caller has been authenticated. If PerAddress is not
+// nil it additionally limits the calls made from each network address before
+// the caller is authenticated. This includes the calls of callers failing to
+// authenticate, e.g. because they try to guess a token. Method and Role of
+// PerAddress must be empty.
+type RateLimits struct {
+	Rules      []RateLimitRule `json:"rules"`
+	PerAddress *RateLimitRule  `json:"perAddress,omitempty"`
+}
+
+// perAddressRule is the index of PerAddress in the bucketKeys of a
+// rateLimiter.
+const perAddressRule = -1
+
+// LoadRateLimits reads JSON encoded RateLimits from r.
+func LoadRateLimits(r io.Reader) (*RateLimits, error) {
+	const op errors.Op = "grpcapi/LoadRateLimits"
+
+	var rl RateLimits
+	if err := json.NewDecoder(r).Decode(&rl); err != nil {
+		return nil, errors.New(op, errors.InvalidArgument, "decode rate limits", err)
+	}
+	for i, rule := range rl.Rules {
+		if err := rule.validate(); err != nil {
+			return nil, errors.New(op, fmt.Sprintf("rule %d", i), err)
+		}
+	}
+	if rule := rl.PerAddress; rule != nil {
+		if rule.Method != "" || rule.Role != "" {
+			return nil, errors.New(op, errors.InvalidArgument, "per address: method or role not empty")
+		}
+		if err := rule.validate(); err != nil {
+			return nil, errors.New(op, "per address", err)
+		}
+	}
+	return &rl, nil
+}
+
+func (r RateLimitRule) validate() error {
+	const op errors.Op = "grpcapi/rateLimitRule.validate"
+
+	if r.Method != "" && !strings.HasPrefix(r.Method, "/") {
+		return errors.New(op, errors.InvalidArgument, "method is no full method name")
+	}
+	if r.Rate <= 0 || math.IsInf(r.Rate, 0) || math.IsNaN(r.Rate) {
+		return errors.New(op, errors.InvalidArgument, "rate not positive")
+	}
+	if r.Burst < 1 {
+		return errors.New(op, errors.InvalidArgument, "burst less than one")
+	}
+	return nil
+}
+
+// rule returns the rule with index i. The index perAddressRule denotes
+// PerAddress.
+func (rl *RateLimits) rule(i int) RateLimitRule {
+	if i == perAddressRule {
+		return *rl.PerAddress
+	}
+	return rl.Rules[i]
+}
+
+// match returns the index of the rule applying to a call of method by a
+// client having roles. It returns false if no rule matches.
+func (rl *RateLimits) match(method string, roles []auth.Role) (int, bool) {
+	best, bestScore := -1, 0
+	for i, rule := range rl.Rules {
+		if rule.Method != "" && rule.Method != method {
+			continue
+		}
+		if rule.Role != "" && !hasRole(roles, rule.Role) {
+			continue
+		}
+		score := 1
+		if rule.Method != "" {
+			score += 2
+		}
+		if rule.Role != "" {
+			score++
+		}
+		if score > bestScore || score == bestScore && rule.Rate > rl.Rules[best].Rate {
+			best, bestScore = i, score
+		}
+	}
+	return best, best >= 0
+}
+
+func hasRole(roles []auth.Role, role auth.Role) bool {
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// tokenBucket contains the tokens of a client. Every call takes one token.
+// The bucket refills with the rate of the rule it belongs to.
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// take refills the bucket and takes a token from it. If the bucket is empty
+// take returns false and the duration until the next token is available.
+func (b *tokenBucket) take(rule RateLimitRule, now time.Time) (time.Duration, bool) {
+	elapsed := now.Sub(b.last).Seconds()
+	if elapsed > 0 {
+		b.tokens = math.Min(float64(rule.Burst), b.tokens+elapsed*rule.Rate)
+		b.last = now
+	}
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0, true
+	}
+	wait := time.Duration((1 - b.tokens) / rule.Rate * float64(time.Second))
+	return wait, false
+}
+
+// full returns true if the bucket has been refilled completely at now.
+func (b *tokenBucket) full(rule RateLimitRule, now time.Time) bool {
+	return b.tokens+now.Sub(b.last).Seconds()*rule.Rate >= float64(rule.Burst)
+}
+
+type bucketKey struct {
+	client string
+	rule   int
+	method string
+}
+
+// rateLimiter enforces RateLimits.
+//
+// Clients are identified by the subject of their claims. Unauthenticated
+// clients are identified by their peer address. Each client has a
+// tokenBucket per rule. Rules with a Method have a separate bucket for each
+// method. The bucket of PerAddress is always keyed by the peer address.
+type rateLimiter struct {
+	Limits    *RateLimits
+	mu        sync.Mutex
+	buckets   map[bucketKey]*tokenBucket
+	lastSweep time.Time
+	timeNow   func() time.Time
+}
+
+// allow takes a token from the bucket of the client calling method. It
+// returns an error of kind RateLimited if the bucket is empty.
+func (l *rateLimiter) allow(ctx context.Context, method string) error {
+	const op errors.Op = "grpcapi/rateLimiter.allow"
+
+	if l == nil || l.Limits == nil || isPublicMethod(method) {
+		return nil
+	}
+	client, roles := rateLimitClient(ctx)
+	i, ok := l.Limits.match(method, roles)
+	if !ok {
+		return nil
+	}
+	key := bucketKey{client: client, rule: i}
+	if l.Limits.Rules[i].Method != "" {
+		key.method = method
+	}
+	return errors.Wrap(l.take(key, method), op)
+}
+
+// allowAddress takes a token from the PerAddress bucket of the network
+// address calling method. It returns an error of kind RateLimited if the
+// bucket is empty.
+func (l *rateLimiter) allowAddress(ctx context.Context, method string) error {
+	const op errors.Op = "grpcapi/rateLimiter.allowAddress"
+
+	if l == nil || l.Limits == nil || l.Limits.PerAddress == nil || isPublicMethod(method) {
+		return nil
+	}
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return nil
+	}
+	key := bucketKey{client: "addr:" + peerHost(p.Addr.String()), rule: perAddressRule}
+	return errors.Wrap(l.take(key, method), op)
+}
+
+// take takes a token from the bucket identified by key.
+func (l *rateLimiter) take(key bucketKey, method string) error {
+	const op errors.Op = "grpcapi/rateLimiter.take"
+
+	rule := l.Limits.rule(key.rule)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: float64(rule.Burst), last: now}
+		l.buckets[key] = b
+	}
+	wait, ok := b.take(rule, now)
+	if !ok {
+		return errors.New(op, errors.RateLimited, now.Add(wait), fmt.Sprintf("rate limit exceeded: %s", method))
+	}
+	return nil
+}
+
+// sweep removes the full buckets once per rateLimitSweepInterval. The caller
+// must hold l.mu.
+func (l *rateLimiter) sweep(now time.Time) {
+	if l.buckets == nil {
+		l.buckets = make(map[bucketKey]*tokenBucket)
+		l.lastSweep = now
+		return
+	}
+	if now.Sub(l.lastSweep) < rateLimitSweepInterval {
+		return
+	}
+	for k, b := range l.buckets {
+		if b.full(l.Limits.rule(k.rule), now) {
+			delete(l.buckets, k)
+		}
+	}
+	l.lastSweep = now
+}
+
+func (l *rateLimiter) now() time.Time {
+	if l.timeNow != nil {
+		return l.timeNow()
+	}
+	return time.Now()
+}
+
+// rateLimitClient identifies the client and returns its roles.
+func rateLimitClient(ctx context.Context) (string, []auth.Role) {
+	if cs, ok := auth.ClaimsFromContext(ctx); ok && cs != nil && cs.Subject != "" {
+		roles := cs.Roles
+		if len(roles) == 0 {
+			roles = []auth.Role{auth.User}
+		}
+		return "sub:" + cs.Subject, roles
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		return "addr:" + peerHost(p.Addr.String()), nil
+	}
+	return "", nil
+}
+
+// peerHost strips the port from addr. Clients open new connections from
+// different ports.
+func peerHost(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// rateLimitUnary rejects calls of clients exceeding their rate limit.
+func rateLimitUnary(l *rateLimiter) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		if err := l.allow(ctx, unaryMethod(info)); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// rateLimitStream is the equivalent of rateLimitUnary for streaming RPCs.
+// It limits the number of streams a client opens, not the number of
+// messages sent on a stream.
+func rateLimitStream(l *rateLimiter) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler,
+	) error {
+		if err := l.allow(ss.Context(), streamMethod(info)); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+// rateLimitAddressUnary rejects calls from network addresses exceeding their
+// PerAddress rate limit. It precedes the authentication of the caller.
+func rateLimitAddressUnary(l *rateLimiter) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		if err := l.allowAddress(ctx, unaryMethod(info)); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// rateLimitAddressStream is the equivalent of rateLimitAddressUnary for
+// streaming RPCs.
+func rateLimitAddressStream(l *rateLimiter) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler,
+	) error {
+		if err := l.allowAddress(ss.Context(), streamMethod(info)); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
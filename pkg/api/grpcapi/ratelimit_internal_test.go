@@ -0,0 +1,261 @@
+package grpcapi
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fhofherr/acmeproxy/pkg/api/auth"
+	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/fhofherr/acmeproxy/pkg/security"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestLoadRateLimits(t *testing.T) {
+	tests := []struct {
+		name   string
+		json   string
+		limits *RateLimits
+		err    error
+	}{
+		{
+			name: "valid rules",
+			json: `{"rules": [
+				{"rate": 10, "burst": 20},
+				{"method": "/pb.Admin/RegisterUser", "role": "user", "rate": 0.1, "burst": 1}
+			]}`,
+			limits: &RateLimits{Rules: []RateLimitRule{
+				{Rate: 10, Burst: 20},
+				{Method: "/pb.Admin/RegisterUser", Role: auth.User, Rate: 0.1, Burst: 1},
+			}},
+		},
+		{
+			name: "per address",
+			json: `{"perAddress": {"rate": 1, "burst": 5}}`,
+			limits: &RateLimits{
+				PerAddress: &RateLimitRule{Rate: 1, Burst: 5},
+			},
+		},
+		{
+			name: "per address with role",
+			json: `{"perAddress": {"role": "user", "rate": 1, "burst": 5}}`,
+			err:  errors.New(errors.InvalidArgument),
+		},
+		{
+			name: "per address without burst",
+			json: `{"perAddress": {"rate": 1}}`,
+			err:  errors.New(errors.InvalidArgument),
+		},
+		{
+			name: "invalid json",
+			json: `{"rules": `,
+			err:  errors.New(errors.InvalidArgument),
+		},
+		{
+			name: "method without leading slash",
+			json: `{"rules": [{"method": "pb.Admin/RegisterUser", "rate": 1, "burst": 1}]}`,
+			err:  errors.New(errors.InvalidArgument),
+		},
+		{
+			name: "rate not positive",
+			json: `{"rules": [{"rate": 0, "burst": 1}]}`,
+			err:  errors.New(errors.InvalidArgument),
+		},
+		{
+			name: "burst less than one",
+			json: `{"rules": [{"rate": 1, "burst": 0}]}`,
+			err:  errors.New(errors.InvalidArgument),
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			limits, err := LoadRateLimits(strings.NewReader(tt.json))
+			if tt.err != nil {
+				errors.AssertMatches(t, tt.err, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.limits, limits)
+		})
+	}
+}
+
+func TestRateLimits_Match(t *testing.T) {
+	limits := &RateLimits{Rules: []RateLimitRule{
+		{Rate: 10, Burst: 10},
+		{Rate: 20, Burst: 10},
+		{Role: auth.Admin, Rate: 100, Burst: 100},
+		{Method: "/pb.Admin/RegisterUser", Rate: 1, Burst: 1},
+		{Method: "/pb.Admin/RegisterUser", Role: auth.Operator, Rate: 5, Burst: 5},
+	}}
+	tests := []struct {
+		name   string
+		method string
+		roles  []auth.Role
+		rule   int
+		ok     bool
+	}{
+		{name: "highest rate among default rules", method: "/pb.Domains/ListDomains", rule: 1, ok: true},
+		{name: "role over default", method: "/pb.Domains/ListDomains", roles: []auth.Role{auth.Admin}, rule: 2, ok: true},
+		{name: "method over role", method: "/pb.Admin/RegisterUser", roles: []auth.Role{auth.Admin}, rule: 3, ok: true},
+		{
+			name:   "method and role over method",
+			method: "/pb.Admin/RegisterUser",
+			roles:  []auth.Role{auth.User, auth.Operator},
+			rule:   4,
+			ok:     true,
+		},
+		{name: "no match", method: "/pb.Domains/ListDomains", ok: false},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			rl := limits
+			if !tt.ok {
+				rl = &RateLimits{Rules: limits.Rules[2:]}
+			}
+			rule, ok := rl.match(tt.method, tt.roles)
+			assert.Equal(t, tt.ok, ok)
+			if tt.ok {
+				assert.Equal(t, tt.rule, rule)
+			}
+		})
+	}
+}
+
+func TestRateLimiter_Allow(t *testing.T) {
+	now := time.Date(2019, 10, 18, 12, 0, 0, 0, time.UTC)
+	limiter := &rateLimiter{
+		Limits: &RateLimits{Rules: []RateLimitRule{
+			{Rate: 1, Burst: 2},
+			{Method: "/pb.Admin/RegisterUser", Rate: 0.5, Burst: 1},
+		}},
+		timeNow: func() time.Time { return now },
+	}
+	alice := auth.AddClaimsToContext(context.Background(), claimsWithSubject("alice"))
+	bob := auth.AddClaimsToContext(context.Background(), claimsWithSubject("bob"))
+
+	assert.NoError(t, limiter.allow(alice, "/pb.Domains/ListDomains"))
+	assert.NoError(t, limiter.allow(alice, "/pb.Domains/GetOCSPResponse"))
+	err := limiter.allow(alice, "/pb.Domains/ListDomains")
+	errors.AssertMatches(t, errors.New(errors.RateLimited), err)
+	assert.Equal(t, now.Add(time.Second), errors.GetRetryAt(err))
+
+	// Buckets of methods and clients are independent.
+	assert.NoError(t, limiter.allow(alice, "/pb.Admin/RegisterUser"))
+	assert.NoError(t, limiter.allow(bob, "/pb.Domains/ListDomains"))
+	err = limiter.allow(alice, "/pb.Admin/RegisterUser")
+	assert.Equal(t, now.Add(2*time.Second), errors.GetRetryAt(err))
+
+	// Public methods are never limited.
+	assert.NoError(t, limiter.allow(alice, "/grpc.health.v1.Health/Check"))
+
+	now = now.Add(time.Second)
+	assert.NoError(t, limiter.allow(alice, "/pb.Domains/ListDomains"))
+	errors.AssertMatches(t, errors.New(errors.RateLimited), limiter.allow(alice, "/pb.Domains/ListDomains"))
+
+	now = now.Add(rateLimitSweepInterval)
+	assert.NoError(t, limiter.allow(bob, "/pb.Domains/ListDomains"))
+	assert.Len(t, limiter.buckets, 1)
+}
+
+func TestRateLimiter_PeerAddressFallback(t *testing.T) {
+	limiter := &rateLimiter{
+		Limits: &RateLimits{Rules: []RateLimitRule{{Rate: 0.001, Burst: 1}}},
+	}
+	ctx := func(port int) context.Context {
+		return peer.NewContext(context.Background(), &peer.Peer{
+			Addr: &net.TCPAddr{IP: net.IPv4(192, 0, 2, 1), Port: port},
+		})
+	}
+	assert.NoError(t, limiter.allow(ctx(40000), "/pb.Domains/ListDomains"))
+	err := limiter.allow(ctx(40001), "/pb.Domains/ListDomains")
+	errors.AssertMatches(t, errors.New(errors.RateLimited), err)
+}
+
+func TestUnaryServerInterceptor_RateLimit(t *testing.T) {
+	intercept := (&unaryServerInterceptor{
+		TokenParser: func(string) (*auth.Claims, error) {
+			return claimsWithSubject("alice"), nil
+		},
+		TokenRevoker: &auth.Denylist{
+			Repository: &security.InMemoryRevokedTokenRepository{},
+		},
+		RateLimiter: &rateLimiter{
+			Limits: &RateLimits{Rules: []RateLimitRule{{Rate: 0.1, Burst: 1}}},
+		},
+	}).chain()
+	md := metadata.New(map[string]string{"authorization": "Bearer valid token"})
+	ctx := metadata.NewIncomingContext(context.Background(), md)
+	info := &grpc.UnaryServerInfo{FullMethod: "/pb.Test/Call"}
+	handler := func(context.Context, interface{}) (interface{}, error) {
+		return nil, nil
+	}
+
+	_, err := intercept(ctx, nil, info, handler)
+	assert.NoError(t, err)
+	_, err = intercept(ctx, nil, info, handler)
+	st, _ := status.FromError(err)
+	assert.Equal(t, codes.ResourceExhausted, st.Code())
+
+	var retryInfo *errdetails.RetryInfo
+	for _, d := range st.Details() {
+		if ri, ok := d.(*errdetails.RetryInfo); ok {
+			retryInfo = ri
+		}
+	}
+	if !assert.NotNil(t, retryInfo) {
+		return
+	}
+	delay, err := ptypes.Duration(retryInfo.GetRetryDelay())
+	assert.NoError(t, err)
+	assert.True(t, delay > 9*time.Second && delay <= 10*time.Second, "unexpected delay: %v", delay)
+}
+
+func TestUnaryServerInterceptor_RateLimitByAddress(t *testing.T) {
+	intercept := (&unaryServerInterceptor{
+		TokenParser: func(string) (*auth.Claims, error) {
+			return nil, errors.New(errors.Unauthorized, "invalid token")
+		},
+		TokenRevoker: &auth.Denylist{
+			Repository: &security.InMemoryRevokedTokenRepository{},
+		},
+		RateLimiter: &rateLimiter{
+			Limits: &RateLimits{PerAddress: &RateLimitRule{Rate: 0.1, Burst: 2}},
+		},
+	}).chain()
+	md := metadata.New(map[string]string{"authorization": "Bearer guessed token"})
+	ctx := func(port int) context.Context {
+		ctx := metadata.NewIncomingContext(context.Background(), md)
+		return peer.NewContext(ctx, &peer.Peer{
+			Addr: &net.TCPAddr{IP: net.IPv4(192, 0, 2, 1), Port: port},
+		})
+	}
+	info := &grpc.UnaryServerInfo{FullMethod: "/pb.Test/Call"}
+	handler := func(context.Context, interface{}) (interface{}, error) {
+		return nil, nil
+	}
+
+	for port := 40000; port < 40002; port++ {
+		_, err := intercept(ctx(port), nil, info, handler)
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	}
+	_, err := intercept(ctx(40002), nil, info, handler)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
+
+func claimsWithSubject(sub string) *auth.Claims {
+	cs := &auth.Claims{}
+	cs.Subject = sub
+	return cs
+}
@@ -6,24 +6,71 @@ import (
 	"net"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/fhofherr/acmeproxy/pkg/api/grpcapi/internal/pb"
 	"github.com/fhofherr/acmeproxy/pkg/errors"
 	"github.com/fhofherr/golf/log"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
 )
 
 // Server represents the grpc API andler.
+//
+// Server authenticates callers using bearer tokens. If CertificateMapper is
+// not nil callers without bearer token may authenticate using a client
+// certificate instead. This requires TLSConfig to verify client certificates,
+// e.g. by setting ClientAuth to tls.VerifyClientCertIfGiven.
+//
+// Every call passes through a middleware chain. The chain turns panics into
+// errors with code codes.Internal, records the call in Metrics unless Metrics
+// is nil, logs the call with its duration and status code, and translates
+// errors into gRPC status errors. If CallTimeout is not zero it limits the
+// duration of unary calls. Streaming calls run until the caller cancels
+// them. If RateLimits is not nil the chain rejects calls of callers
+// exceeding their rate limit with codes.ResourceExhausted. The status
+// details tell the caller when to retry. Finally the chain records every
+// call of an authenticated caller, and every failed authentication, in the
+// AuditTrail. Admins query the AuditTrail using the ListAuditEntries RPC.
+//
+// Server registers the standard gRPC health service. It periodically runs
+// HealthChecks and reports each check as a service named after its key. The
+// service with the empty name reports the overall health of Server. It is
+// serving only if all checks pass. HealthCheckInterval controls the interval
+// between two runs; DefaultHealthCheckInterval is used if it is zero. If
+// Reflection is true Server registers the gRPC server reflection service.
+// Callers of the health and reflection services are not authenticated.
 type Server struct {
-	TokenParser    TokenParser
-	TLSConfig      *tls.Config
-	UserRegisterer UserRegisterer
-	Logger         log.Logger
-	grpcServer     *grpc.Server
-	once           sync.Once
-	started        uint32
-	initErr        error
+	TokenParser         TokenParser
+	CertificateMapper   CertificateMapper
+	TokenRevoker        TokenRevoker
+	TLSConfig           *tls.Config
+	UserRegisterer      UserRegisterer
+	OCSPResponseWriter  OCSPResponseWriter
+	CAARecommender      CAARecommender
+	UserManager         UserManager
+	DomainLister        DomainLister
+	DomainOwnerFinder   DomainOwnerFinder
+	CertificateWatcher  CertificateWatcher
+	AuditTrail          AuditTrail
+	CallTimeout         time.Duration
+	RateLimits          *RateLimits
+	HealthChecks        map[string]HealthCheck
+	HealthCheckInterval time.Duration
+	Reflection          bool
+	Metrics             *CallMetrics
+	Logger              log.Logger
+	grpcServer          *grpc.Server
+	health              *healthChecker
+	unaryChain          grpc.UnaryServerInterceptor
+	admin               *adminServer
+	domains             *domainsServer
+	once                sync.Once
+	started             uint32
+	initErr             error
 }
 
 // Serve accepts incomming connections.
@@ -38,6 +85,7 @@ func (s *Server) Serve(l net.Listener) error {
 	if !atomic.CompareAndSwapUint32(&s.started, 0, 1) {
 		return errors.New(op, "already started")
 	}
+	go s.health.run()
 	return errors.Wrap(s.grpcServer.Serve(l), op, "serve")
 }
 
@@ -57,18 +105,93 @@ func (s *Server) initialize() error {
 			s.initErr = errors.New(op, "no user registerer provided")
 			return
 		}
+		if s.OCSPResponseWriter == nil {
+			s.initErr = errors.New(op, "no ocsp response writer provided")
+			return
+		}
+		if s.CAARecommender == nil {
+			s.initErr = errors.New(op, "no caa recommender provided")
+			return
+		}
+		if s.TokenRevoker == nil {
+			s.initErr = errors.New(op, "no token revoker provided")
+			return
+		}
+		if s.UserManager == nil {
+			s.initErr = errors.New(op, "no user manager provided")
+			return
+		}
+		if s.DomainLister == nil {
+			s.initErr = errors.New(op, "no domain lister provided")
+			return
+		}
+		if s.DomainOwnerFinder == nil {
+			s.initErr = errors.New(op, "no domain owner finder provided")
+			return
+		}
+		if s.CertificateWatcher == nil {
+			s.initErr = errors.New(op, "no certificate watcher provided")
+			return
+		}
+		if s.AuditTrail == nil {
+			s.initErr = errors.New(op, "no audit trail provided")
+			return
+		}
+		limiter := &rateLimiter{Limits: s.RateLimits}
 		unaryInterceptor := &unaryServerInterceptor{
-			TokenParser: s.TokenParser,
-			Logger:      s.Logger,
+			TokenParser:       s.TokenParser,
+			CertificateMapper: s.CertificateMapper,
+			TokenRevoker:      s.TokenRevoker,
+			RateLimiter:       limiter,
+			AuditTrail:        s.AuditTrail,
+			Metrics:           s.Metrics,
+			Logger:            s.Logger,
+			CallTimeout:       s.CallTimeout,
+		}
+		streamInterceptor := &streamServerInterceptor{
+			TokenParser:       s.TokenParser,
+			CertificateMapper: s.CertificateMapper,
+			TokenRevoker:      s.TokenRevoker,
+			RateLimiter:       limiter,
+			AuditTrail:        s.AuditTrail,
+			Metrics:           s.Metrics,
+			Logger:            s.Logger,
 		}
+		s.unaryChain = unaryInterceptor.chain()
 		creds := credentials.NewTLS(s.TLSConfig)
 		s.grpcServer = grpc.NewServer(
 			grpc.Creds(creds),
-			grpc.UnaryInterceptor(unaryInterceptor.intercept),
+			grpc.UnaryInterceptor(s.unaryChain),
+			grpc.StreamInterceptor(streamInterceptor.chain()),
 		)
-		pb.RegisterAdminServer(s.grpcServer, &adminServer{
+		s.admin = &adminServer{
 			UserRegisterer: s.UserRegisterer,
-		})
+			TokenRevoker:   s.TokenRevoker,
+			UserManager:    s.UserManager,
+			DomainLister:   s.DomainLister,
+			AuditTrail:     s.AuditTrail,
+			StartedAt:      time.Now(),
+		}
+		s.domains = &domainsServer{
+			OCSPResponseWriter: s.OCSPResponseWriter,
+			CAARecommender:     s.CAARecommender,
+			DomainLister:       s.DomainLister,
+			DomainOwnerFinder:  s.DomainOwnerFinder,
+			CertificateWatcher: s.CertificateWatcher,
+		}
+		pb.RegisterAdminServer(s.grpcServer, s.admin)
+		pb.RegisterDomainsServer(s.grpcServer, s.domains)
+		s.health = &healthChecker{
+			Checks:   s.HealthChecks,
+			Interval: s.HealthCheckInterval,
+			Server:   health.NewServer(),
+			Logger:   s.Logger,
+			done:     make(chan struct{}),
+		}
+		healthpb.RegisterHealthServer(s.grpcServer, s.health.Server)
+		if s.Reflection {
+			reflection.Register(s.grpcServer)
+		}
 	})
 
 	return s.initErr
@@ -81,6 +204,7 @@ func (s *Server) Shutdown(ctx context.Context) error {
 	if atomic.LoadUint32(&s.started) == 0 {
 		return errors.New(op, "not started")
 	}
+	s.health.stop()
 	return errors.Wrap(s.stopGrpcServer(ctx), op)
 }
 
@@ -2,15 +2,24 @@ package grpcapi_test
 
 import (
 	"context"
+	"crypto/sha256"
 	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
 	"testing"
 	"time"
 
+	"github.com/fhofherr/acmeproxy/pkg/acme"
 	"github.com/fhofherr/acmeproxy/pkg/api/auth"
 	"github.com/fhofherr/acmeproxy/pkg/api/grpcapi"
 	"github.com/fhofherr/acmeproxy/pkg/errors"
 	"github.com/fhofherr/acmeproxy/pkg/internal/netutil"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
 )
 
 func TestServer_StartCannotBeCalledTwice(t *testing.T) {
@@ -50,6 +59,36 @@ func TestServer_CannotStartWithoutRequiredFields(t *testing.T) {
 			},
 			err: errors.New("no user registerer provided"),
 		},
+		{
+			name: "cannot start server without ocsp response writer",
+			server: &grpcapi.Server{
+				TLSConfig:      &tls.Config{},
+				TokenParser:    alwaysUnauthorized,
+				UserRegisterer: &grpcapi.MockUserRegisterer{},
+			},
+			err: errors.New("no ocsp response writer provided"),
+		},
+		{
+			name: "cannot start server without caa recommender",
+			server: &grpcapi.Server{
+				TLSConfig:          &tls.Config{},
+				TokenParser:        alwaysUnauthorized,
+				UserRegisterer:     &grpcapi.MockUserRegisterer{},
+				OCSPResponseWriter: &grpcapi.MockOCSPResponseWriter{},
+			},
+			err: errors.New("no caa recommender provided"),
+		},
+		{
+			name: "cannot start server without token revoker",
+			server: &grpcapi.Server{
+				TLSConfig:          &tls.Config{},
+				TokenParser:        alwaysUnauthorized,
+				UserRegisterer:     &grpcapi.MockUserRegisterer{},
+				OCSPResponseWriter: &grpcapi.MockOCSPResponseWriter{},
+				CAARecommender:     &grpcapi.MockCAARecommender{},
+			},
+			err: errors.New("no token revoker provided"),
+		},
 	}
 
 	for _, tt := range tests {
@@ -91,3 +130,114 @@ func TestServer_ShutdownClosesServerOnCanceledContext(t *testing.T) {
 func alwaysUnauthorized(string) (*auth.Claims, error) {
 	return nil, errors.New(errors.Unauthorized)
 }
+
+func TestServer_ClientCertificateAuthentication(t *testing.T) {
+	userID := uuid.Must(uuid.NewRandom())
+	domainName := "www.example.com"
+
+	fx := grpcapi.NewTestFixture(t)
+	// The fixture's certificate is self-signed. It doubles as client
+	// certificate and as the CA verifying it.
+	cert, err := x509.ParseCertificate(fx.TLSConfig.Certificates[0].Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(cert)
+	serverTLSConfig := fx.TLSConfig.Clone()
+	serverTLSConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	serverTLSConfig.ClientCAs = clientCAs
+	fx.Server.TLSConfig = serverTLSConfig
+
+	sum := sha256.Sum256(cert.Raw)
+	mapper := &auth.CertificateMapper{
+		Identities: []auth.CertificateIdentity{
+			{Fingerprint: hex.EncodeToString(sum[:]), UserID: userID, Roles: []auth.Role{auth.User}},
+		},
+	}
+	fx.Server.CertificateMapper = mapper.Claims
+
+	addr := fx.Start()
+	defer fx.Stop()
+
+	client, err := grpcapi.NewClient(addr, nil, fx.TLSConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	fx.MockCAARecommender.
+		On("RecommendCAARecords", userID, domainName).
+		Return([]acme.CAARecord{}, nil)
+	_, err = client.GetCAARecords(ctx, domainName)
+	assert.NoError(t, err)
+	fx.MockCAARecommender.AssertExpectations(t)
+}
+
+func TestServer_HealthAndReflectionWithoutToken(t *testing.T) {
+	fx := grpcapi.NewTestFixture(t)
+	fx.Server.HealthChecks = map[string]grpcapi.HealthCheck{
+		"database": func(context.Context) error { return nil },
+		"broken":   func(context.Context) error { return errors.New("broken") },
+	}
+	fx.Server.Reflection = true
+	addr := fx.Start()
+	defer fx.Stop()
+
+	conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(credentials.NewTLS(fx.TLSConfig)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	healthClient := healthpb.NewHealthClient(conn)
+	expected := map[string]healthpb.HealthCheckResponse_ServingStatus{
+		"":         healthpb.HealthCheckResponse_NOT_SERVING,
+		"database": healthpb.HealthCheckResponse_SERVING,
+		"broken":   healthpb.HealthCheckResponse_NOT_SERVING,
+	}
+	for service, status := range expected {
+		// The first health check runs concurrently to this test. Watch waits
+		// for its results.
+		stream, err := healthClient.Watch(ctx, &healthpb.HealthCheckRequest{Service: service})
+		if !assert.NoError(t, err) {
+			return
+		}
+		var res *healthpb.HealthCheckResponse
+		for res.GetStatus() != status {
+			res, err = stream.Recv()
+			if !assert.NoErrorf(t, err, "service %q", service) {
+				return
+			}
+		}
+	}
+
+	reflectionClient := reflectionpb.NewServerReflectionClient(conn)
+	stream, err := reflectionClient.ServerReflectionInfo(ctx)
+	if !assert.NoError(t, err) {
+		return
+	}
+	err = stream.Send(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+	})
+	assert.NoError(t, err)
+	res, err := stream.Recv()
+	if assert.NoError(t, err) {
+		assert.NotEmpty(t, res.GetListServicesResponse().GetService())
+	}
+
+	// All other services still require authentication.
+	client, err := grpcapi.NewClient(addr, nil, fx.TLSConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	_, err = client.GetStatus(ctx)
+	errors.AssertMatches(t, errors.New(errors.Unauthorized), err)
+}
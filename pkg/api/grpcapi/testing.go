@@ -4,15 +4,19 @@ import (
 	"context"
 	"crypto/tls"
 	"fmt"
+	"io"
+	"net/http"
 	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/fhofherr/acmeproxy/pkg/acme"
 	"github.com/fhofherr/acmeproxy/pkg/api/auth"
 	"github.com/fhofherr/acmeproxy/pkg/certutil"
 	"github.com/fhofherr/acmeproxy/pkg/errors"
 	"github.com/fhofherr/acmeproxy/pkg/internal/netutil"
 	"github.com/fhofherr/acmeproxy/pkg/internal/testsupport"
+	"github.com/fhofherr/acmeproxy/pkg/security"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/mock"
 )
@@ -20,12 +24,21 @@ import (
 // TestFixture contains an instance of Server and provides the necessary methods
 // to start and stop the server during testing.
 type TestFixture struct {
-	T                  *testing.T
-	Server             *Server
-	TLSConfig          *tls.Config
-	MockUserRegisterer *MockUserRegisterer
-	Token              string
-	Claims             *auth.Claims
+	T                      *testing.T
+	Server                 *Server
+	Gateway                *Gateway
+	TLSConfig              *tls.Config
+	MockUserRegisterer     *MockUserRegisterer
+	MockOCSPResponseWriter *MockOCSPResponseWriter
+	MockCAARecommender     *MockCAARecommender
+	MockUserManager        *MockUserManager
+	MockDomainLister       *MockDomainLister
+	DomainOwners           DomainOwners
+	MockCertificateWatcher *MockCertificateWatcher
+	Denylist               *auth.Denylist
+	AuditLog               *auth.AuditLog
+	Token                  string
+	Claims                 *auth.Claims
 }
 
 // NewTestFixture creates a new TestFixture.
@@ -48,18 +61,49 @@ func NewTestFixture(t *testing.T) *TestFixture {
 	}
 	mur := &MockUserRegisterer{}
 	mur.Test(t)
+	mow := &MockOCSPResponseWriter{}
+	mow.Test(t)
+	mcr := &MockCAARecommender{}
+	mcr.Test(t)
+	mum := &MockUserManager{}
+	mum.Test(t)
+	mdl := &MockDomainLister{}
+	mdl.Test(t)
+	mcw := &MockCertificateWatcher{}
+	mcw.Test(t)
 	fx := &TestFixture{
-		T:                  t,
-		TLSConfig:          tlsConfig,
-		MockUserRegisterer: mur,
+		T:                      t,
+		TLSConfig:              tlsConfig,
+		MockUserRegisterer:     mur,
+		MockOCSPResponseWriter: mow,
+		MockCAARecommender:     mcr,
+		MockUserManager:        mum,
+		MockDomainLister:       mdl,
+		DomainOwners:           make(DomainOwners),
+		MockCertificateWatcher: mcw,
+		Denylist: &auth.Denylist{
+			Repository: &security.InMemoryRevokedTokenRepository{},
+		},
+		AuditLog: &auth.AuditLog{
+			Repository: &security.InMemoryAuditRepository{},
+		},
 	}
 	server := &Server{
-		TLSConfig:      tlsConfig,
-		TokenParser:    fx.parseToken,
-		UserRegisterer: mur,
+		TLSConfig:          tlsConfig,
+		TokenParser:        fx.parseToken,
+		TokenRevoker:       fx.Denylist,
+		UserRegisterer:     mur,
+		OCSPResponseWriter: mow,
+		CAARecommender:     mcr,
+		UserManager:        mum,
+		DomainLister:       mdl,
+		DomainOwnerFinder:  fx.DomainOwners,
+		CertificateWatcher: mcw,
+		AuditTrail:         fx.AuditLog,
 	}
 
 	fx.Server = server
+	fx.Gateway = &Gateway{Server: server}
 	return fx
 }
 
@@ -103,6 +147,41 @@ func (fx *TestFixture) Stop() {
 	}
 }
 
+// StartGateway starts fx.Gateway in a separate go routine and returns the
+// gateways address.
+func (fx *TestFixture) StartGateway() string {
+	addrC := make(chan string)
+	go func() {
+		err := netutil.ListenAndServe(fx.Gateway, netutil.NotifyAddr(addrC))
+		if err != nil {
+			fx.T.Log(err)
+		}
+	}()
+	select {
+	case addr := <-addrC:
+		return addr
+	case <-time.After(10 * time.Millisecond):
+		fx.T.Fatal("timed out after 10ms")
+		return ""
+	}
+}
+
+// StopGateway stops the previously started gateway used by the TestFixture.
+func (fx *TestFixture) StopGateway() {
+	if err := fx.Gateway.Shutdown(context.Background()); err != nil {
+		fx.T.Fatal(err)
+	}
+}
+
+// NewHTTPClient creates a new http.Client connecting to the gateway contained
+// in this test fixture.
+func (fx *TestFixture) NewHTTPClient() *http.Client {
+	return &http.Client{
+		Transport: &http.Transport{TLSClientConfig: fx.TLSConfig},
+		Timeout:   time.Second,
+	}
+}
+
 // NewClient creates a new GRPCApi client connecting to the server contained in
 // this test fixture.
 func (fx *TestFixture) NewClient(addr, token string) *Client {
@@ -121,7 +200,146 @@ type MockUserRegisterer struct {
 
 // RegisterUser registers the fact it has been called with the user
 // MockUserRegisterer.
-func (m *MockUserRegisterer) RegisterUser(userID uuid.UUID, email string) error {
+func (m *MockUserRegisterer) RegisterUser(_ context.Context, userID uuid.UUID, email string) error {
 	args := m.Called(userID, email)
 	return args.Error(0)
 }
+
+// MockOCSPResponseWriter is a mock implementation of the OCSPResponseWriter
+// interface.
+type MockOCSPResponseWriter struct {
+	mock.Mock
+}
+
+// WriteOCSPResponse registers the fact that it has been called with the
+// MockOCSPResponseWriter. It writes the bytes passed as first return value to
+// w, unless they are nil.
+func (m *MockOCSPResponseWriter) WriteOCSPResponse(userID uuid.UUID, domainName string, w io.Writer) error {
+	args := m.Called(userID, domainName, w)
+	if bs, ok := args.Get(0).([]byte); ok && bs != nil {
+		if _, err := w.Write(bs); err != nil {
+			return err
+		}
+	}
+	return args.Error(1)
+}
+
+// MockCAARecommender is a mock implementation of the CAARecommender interface.
+type MockCAARecommender struct {
+	mock.Mock
+}
+
+// RecommendCAARecords registers the fact that it has been called with the
+// MockCAARecommender.
+func (m *MockCAARecommender) RecommendCAARecords(
+	ctx context.Context, userID uuid.UUID, domainName string,
+) ([]acme.CAARecord, error) {
+	args := m.Called(userID, domainName)
+	records, _ := args.Get(0).([]acme.CAARecord)
+	return records, args.Error(1)
+}
+
+// MockUserManager is a mock implementation of the UserManager interface.
+type MockUserManager struct {
+	mock.Mock
+}
+
+// GetUser registers the fact that it has been called with the
+// MockUserManager.
+func (m *MockUserManager) GetUser(userID uuid.UUID) (acme.User, error) {
+	args := m.Called(userID)
+	user, _ := args.Get(0).(acme.User)
+	return user, args.Error(1)
+}
+
+// ListUsers registers the fact that it has been called with the
+// MockUserManager.
+func (m *MockUserManager) ListUsers(after uuid.UUID, limit int) ([]acme.User, error) {
+	args := m.Called(after, limit)
+	users, _ := args.Get(0).([]acme.User)
+	return users, args.Error(1)
+}
+
+// UpdateUser registers the fact that it has been called with the
+// MockUserManager.
+func (m *MockUserManager) UpdateUser(userID uuid.UUID, u acme.User) (acme.User, error) {
+	args := m.Called(userID, u)
+	user, _ := args.Get(0).(acme.User)
+	return user, args.Error(1)
+}
+
+// DeleteUser registers the fact that it has been called with the
+// MockUserManager.
+func (m *MockUserManager) DeleteUser(userID uuid.UUID) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
+// TransferDomains registers the fact that it has been called with the
+// MockUserManager.
+func (m *MockUserManager) TransferDomains(from, to uuid.UUID, domainNames []string) ([]string, error) {
+	args := m.Called(from, to, domainNames)
+	names, _ := args.Get(0).([]string)
+	return names, args.Error(1)
+}
+
+// MockDomainLister is a mock implementation of the DomainLister interface.
+type MockDomainLister struct {
+	mock.Mock
+}
+
+// ListDomains registers the fact that it has been called with the
+// MockDomainLister.
+func (m *MockDomainLister) ListDomains() ([]acme.Domain, error) {
+	args := m.Called()
+	domains, _ := args.Get(0).([]acme.Domain)
+	return domains, args.Error(1)
+}
+
+// DomainOwners is an implementation of the DomainOwnerFinder interface
+// which maps the names of domains to the IDs of their owners.
+type DomainOwners map[string]uuid.UUID
+
+// DomainOwner returns the owner of the domain with the passed name, or an
+// error of kind NotFound if o does not contain the domain.
+func (o DomainOwners) DomainOwner(domainName string) (uuid.UUID, error) {
+	const op errors.Op = "grpcapi/DomainOwners.DomainOwner"
+
+	userID, ok := o[domainName]
+	if !ok {
+		return uuid.UUID{}, errors.New(op, errors.NotFound, fmt.Sprintf("domain not found: %s", domainName))
+	}
+	return userID, nil
+}
+
+// MockCertificateWatcher is a mock implementation of the CertificateWatcher
+// interface.
+type MockCertificateWatcher struct {
+	mock.Mock
+}
+
+// WatchCertificates registers the fact that it has been called with the
+// MockCertificateWatcher. It sends the domains passed as first return value
+// on the returned channel. The channel is closed once ctx is done.
+func (m *MockCertificateWatcher) WatchCertificates(
+	ctx context.Context, userID uuid.UUID, domainNames []string,
+) (<-chan acme.Domain, error) {
+	args := m.Called(userID, domainNames)
+	if err := args.Error(1); err != nil {
+		return nil, err
+	}
+	domains, _ := args.Get(0).([]acme.Domain)
+	c := make(chan acme.Domain)
+	go func() {
+		defer close(c)
+		for _, d := range domains {
+			select {
+			case c <- d:
+			case <-ctx.Done():
+				return
+			}
+		}
+		<-ctx.Done()
+	}()
+	return c, nil
+}
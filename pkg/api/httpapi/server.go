@@ -8,6 +8,7 @@ import (
 	"sync"
 
 	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/fhofherr/acmeproxy/pkg/requestid"
 	"github.com/go-chi/chi"
 )
 
@@ -17,6 +18,8 @@ type HandlerFactory interface {
 }
 
 // Server serves the public, non-encrypted part of acmeproxy's http API.
+//
+// Server assigns each request a request ID using requestid.Middleware.
 type Server struct {
 	Solver     HandlerFactory // Presents solutions to HTTP01 challenges to ACME CA.
 	httpServer *http.Server
@@ -70,6 +73,7 @@ func (s *Server) Shutdown(ctx context.Context) error {
 
 func (s *Server) newRouter() http.Handler {
 	r := chi.NewRouter()
+	r.Use(requestid.Middleware)
 
 	r.Get(
 		"/.well-known/acme-challenge/{token}",
@@ -0,0 +1,71 @@
+package metricsapi
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/go-chi/chi"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Server serves the metrics gathered by Gatherer on /metrics in the
+// Prometheus exposition format.
+//
+// Server is meant to listen on an address reachable by the monitoring
+// system only. It does not authenticate its callers.
+type Server struct {
+	Gatherer   prometheus.Gatherer
+	httpServer *http.Server
+	once       sync.Once
+}
+
+func (s *Server) initialize() error {
+	const op errors.Op = "metricsapi/server.initialize"
+	var err error
+
+	s.once.Do(func() {
+		if s.Gatherer == nil {
+			err = errors.New(op, "no gatherer set")
+			return
+		}
+		s.httpServer = &http.Server{
+			Handler: s.newRouter(),
+		}
+	})
+
+	return err
+}
+
+// Serve accepts incoming connections on the listener l.
+//
+// Serve initializes the Server if this has not been done yet. Since Server
+// uses net/http.Server internally Serve returns net/http.ErrServerClosed once
+// the Server is shut down.
+func (s *Server) Serve(l net.Listener) error {
+	const op errors.Op = "metricsapi/server.Serve"
+
+	if err := s.initialize(); err != nil {
+		return errors.New(op, err)
+	}
+	return errors.Wrap(s.httpServer.Serve(l), op, "serve http")
+}
+
+// Shutdown gracefully stops the Server.
+func (s *Server) Shutdown(ctx context.Context) error {
+	const op errors.Op = "metricsapi/server.Shutdown"
+
+	if s.httpServer == nil {
+		return errors.New(op, "not started")
+	}
+	return errors.Wrap(s.httpServer.Shutdown(ctx), op, "shutdown")
+}
+
+func (s *Server) newRouter() http.Handler {
+	r := chi.NewRouter()
+	r.Method(http.MethodGet, "/metrics", promhttp.HandlerFor(s.Gatherer, promhttp.HandlerOpts{}))
+	return r
+}
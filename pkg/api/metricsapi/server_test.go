@@ -0,0 +1,46 @@
+package metricsapi_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/fhofherr/acmeproxy/pkg/api/metricsapi"
+	"github.com/fhofherr/acmeproxy/pkg/internal/netutil"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_ServeMetrics(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	counter := prometheus.NewCounter(prometheus.CounterOpts{Name: "test_total", Help: "Test counter."})
+	registry.MustRegister(counter)
+	counter.Add(3)
+
+	server := &metricsapi.Server{Gatherer: registry}
+	addrC := make(chan string)
+	go netutil.ListenAndServe(server, netutil.NotifyAddr(addrC)) // nolint: errcheck
+	addr := netutil.GetAddr(t, addrC)
+	defer server.Shutdown(context.Background()) //nolint: errcheck
+
+	resp, err := http.Get(fmt.Sprintf("http://%s/metrics", addr))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(body), "test_total 3")
+}
+
+func TestServer_CannotServeWithoutGatherer(t *testing.T) {
+	errC := make(chan error)
+	server := &metricsapi.Server{}
+	go func(errC chan<- error) {
+		errC <- netutil.ListenAndServe(server)
+	}(errC)
+	assert.Error(t, netutil.GetErr(t, errC))
+}
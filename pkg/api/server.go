@@ -2,36 +2,149 @@ package api
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"path/filepath"
+	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/fhofherr/acmeproxy/pkg/acme"
 	"github.com/fhofherr/acmeproxy/pkg/acme/acmeclient"
+	"github.com/fhofherr/acmeproxy/pkg/api/adminapi"
+	"github.com/fhofherr/acmeproxy/pkg/api/auth"
+	"github.com/fhofherr/acmeproxy/pkg/api/grpcapi"
 	"github.com/fhofherr/acmeproxy/pkg/api/httpapi"
+	"github.com/fhofherr/acmeproxy/pkg/api/metricsapi"
 	"github.com/fhofherr/acmeproxy/pkg/db"
 	"github.com/fhofherr/acmeproxy/pkg/errors"
 	"github.com/fhofherr/acmeproxy/pkg/internal/netutil"
+	"github.com/fhofherr/acmeproxy/pkg/logging"
+	"github.com/fhofherr/acmeproxy/pkg/tracing"
 	"github.com/fhofherr/golf/log"
+	"github.com/go-acme/lego/lego"
 	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 )
 
+// DefaultOCSPUpdateInterval is the interval in which Server refreshes the
+// OCSP responses of its certificates if no other interval was configured.
+const DefaultOCSPUpdateInterval = time.Hour
+
+// revokedTokensGCInterval is the interval in which Server removes expired
+// tokens from its denylist.
+const revokedTokensGCInterval = time.Hour
+
+// auditLogGCInterval is the interval in which Server removes the entries
+// exceeding the retention limits from its audit log.
+const auditLogGCInterval = time.Hour
+
+// issuancesGCInterval is the interval in which Server removes the issuances
+// the quotas of the ACME agent no longer need.
+const issuancesGCInterval = time.Hour
+
 // Server is acmeproxy's public server.
 //
 // Server runs the ACME Agent responsible of obtaining certificates and storing
 // them for later retrieval. Users connect to the server through its public
 // API to retrieve their certificates.
 //
+// Server periodically refreshes the OCSP responses of all certificates it
+// obtained. The interval between two refreshes is controlled by
+// OCSPUpdateInterval. If OCSPUpdateInterval is zero, the
+// DefaultOCSPUpdateInterval is used. Each refresh re-issues the certificates
+// reported as revoked.
+//
+// If GRPCAPIAddr is not empty Server serves its gRPC API on this address. The
+// gRPC API requires GRPCAPITLSConfig and either TokenKeys or OIDCProvider.
+// TokenKeys contains the keys used to verify the bearer tokens presented by
+// clients. The signing algorithm of a token has to match the type of its key.
+// If OIDCProvider is not nil Server accepts the tokens of the OIDC issuer
+// instead. If ClientCertificates is not nil clients may authenticate using a
+// client certificate instead of a bearer token. GRPCAPITLSConfig has to verify
+// the client certificates in this case. Server periodically removes expired
+// tokens from the denylist of revoked tokens.
+//
+// Server records every call of an authenticated client of the gRPC API in its
+// audit log. It periodically removes entries older than AuditRetention, and
+// the oldest entries exceeding AuditMaxEntries. Zero disables the respective
+// limit.
+//
+// The gRPC API reports the health of the database, the ACME directory, the
+// OCSP update scheduler, and the renewals of revoked certificates through the
+// standard gRPC health service. The renewals are reported unhealthy if the
+// scheduler stopped or missed its interval.
+//
+// If RESTAPIAddr is not empty Server serves the gRPC API as REST API on this
+// address. The REST API uses the same TLS configuration and authentication
+// as the gRPC API. It does not require GRPCAPIAddr to be set.
+//
+// If MetricsAddr is not empty Server serves Prometheus metrics on /metrics
+// at this address. The metrics cover certificate orders and expiry, pending
+// HTTP01 challenges, calls of the gRPC API, and the database. MetricsAddr
+// should differ from HTTPAPIAddr, which has to be reachable by the ACME CA.
+//
+// If AdminAddr is not empty Server serves liveness and readiness probes on
+// /livez and /readyz at this address, and a JSON document describing its
+// version, uptime, domains, and the soonest expiring certificate on
+// /status. Server is ready if its database is open and the ACME directory
+// can be fetched.
+//
+// If TracerProvider is not nil Server installs it when it starts, and exports
+// the remaining spans when it is shut down.
+//
+// Server logs to Logger. The lego ACME library, the gRPC API, and the
+// client of the ACME CA log to their entry in ComponentLoggers instead, if
+// present. The keys of ComponentLoggers are the logging.Component constants.
+//
 // The zero value of Server represents a valid instance. Server may start
 // a multitude of Go routines.
 type Server struct {
-	ACMEDirectoryURL string
-	HTTPAPIAddr      string
-	DataDir          string
-	Logger           log.Logger
-	httpAPIServer    *httpapi.Server
-	acmeAgent        *acme.Agent
-	boltDB           *db.Bolt
+	ACMEDirectoryURL   string
+	ACMEResolverAddr   string // DNS server used for pre-flight and CAA checks; system resolver if empty.
+	ACMEHTTP01Port     int    // Port the ACME CA validates HTTP01 challenges on; 80 if zero.
+	HTTPAPIAddr        string
+	MetricsAddr        string // Metrics endpoint is disabled if empty.
+	AdminAddr          string // Admin endpoints are disabled if empty.
+	DataDir            string
+	OCSPUpdateInterval time.Duration
+	DomainPolicy       acme.DomainPolicy // Restricts the domains users may register; any domain if nil.
+	Quotas             acme.Quotas       // Limits the certificates obtained from the CA; acme.DefaultQuotas if zero.
+	GRPCAPIAddr        string            // gRPC API is disabled if empty.
+	RESTAPIAddr        string            // REST API is disabled if empty.
+	GRPCAPITLSConfig   *tls.Config
+	GRPCAPICallTimeout time.Duration       // Maximum duration of unary gRPC calls; unlimited if zero.
+	GRPCAPIReflection  bool                // Registers the gRPC server reflection service if true.
+	GRPCAPIRateLimits  *grpcapi.RateLimits // Limits the calls of each client; unlimited if nil.
+	TokenKeys          *auth.KeySet
+	OIDCProvider       *auth.OIDCProvider
+	ClientCertificates *auth.CertificateMapper
+	AuditRetention     time.Duration
+	AuditMaxEntries    int
+	TracerProvider     *tracing.Provider // Exports the spans of the Server; spans are discarded if nil.
+	Logger             log.Logger
+	ComponentLoggers   map[string]log.Logger
+	httpAPIServer      *httpapi.Server
+	metricsServer      *metricsapi.Server
+	adminServer        *adminapi.Server
+	grpcAPIServer      *grpcapi.Server
+	restAPIServer      *grpcapi.Gateway
+	denylist           *auth.Denylist
+	auditLog           *auth.AuditLog
+	acmeAgent          *acme.Agent
+	boltDB             *db.Bolt
+	done               chan struct{}
+
+	// Accessed atomically. A non-zero value means the go routine updating
+	// the OCSP responses is running.
+	updatingOCSPResponses uint32
+
+	// Time the go routine updating the OCSP responses last started to
+	// update them and to re-issue revoked certificates. Guarded by
+	// renewalsMu.
+	lastRenewalPass time.Time
+	renewalsMu      sync.Mutex
 
 	// Accessed atomically. A non-zero value means the server is currently
 	// starting or has already been started. It cannot be started again.
@@ -59,12 +172,141 @@ func (s *Server) Start() error {
 		return errors.Wrap(err, op)
 	})
 
+	if s.metricsServer != nil {
+		go errors.LogFunc(s.Logger, func() error {
+			err := netutil.ListenAndServe(s.metricsServer, netutil.WithAddr(s.MetricsAddr))
+			return errors.Wrap(err, op)
+		})
+	}
+	if s.adminServer != nil {
+		go errors.LogFunc(s.Logger, func() error {
+			err := netutil.ListenAndServe(s.adminServer, netutil.WithAddr(s.AdminAddr))
+			return errors.Wrap(err, op)
+		})
+	}
+
 	if err := s.registerAcmeproxyDomain(); err != nil {
 		return errors.New(op, err)
 	}
+	// The gRPC API checks if the OCSP responses are updated as soon as it
+	// starts. Mark the updates as running before they actually are.
+	atomic.StoreUint32(&s.updatingOCSPResponses, 1)
+	s.recordRenewalPass(time.Now())
+	if s.GRPCAPIAddr != "" {
+		go errors.LogFunc(s.Logger, func() error {
+			err := netutil.ListenAndServe(s.grpcAPIServer, netutil.WithAddr(s.GRPCAPIAddr))
+			return errors.Wrap(err, op)
+		})
+	}
+	if s.restAPIServer != nil {
+		go errors.LogFunc(s.Logger, func() error {
+			err := netutil.ListenAndServe(s.restAPIServer, netutil.WithAddr(s.RESTAPIAddr))
+			return errors.Wrap(err, op)
+		})
+	}
+	go s.updateOCSPResponses()
+	go s.collectRevokedTokens()
+	go s.collectAuditEntries()
+	go s.collectIssuances()
 	return nil
 }
 
+// updateOCSPResponses asks the ACME agent to update the OCSP responses of
+// all certificates right away, and periodically afterwards. It returns once
+// s.done is closed.
+func (s *Server) updateOCSPResponses() {
+	const op errors.Op = "server/server.updateOCSPResponses"
+
+	defer atomic.StoreUint32(&s.updatingOCSPResponses, 0)
+
+	update := func() error {
+		s.recordRenewalPass(time.Now())
+		return errors.Wrap(s.acmeAgent.UpdateOCSPResponses(), op)
+	}
+	errors.LogFunc(s.Logger, update)
+
+	ticker := time.NewTicker(s.ocspUpdateInterval())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			errors.LogFunc(s.Logger, update)
+		case <-s.done:
+			return
+		}
+	}
+}
+
+func (s *Server) ocspUpdateInterval() time.Duration {
+	if s.OCSPUpdateInterval <= 0 {
+		return DefaultOCSPUpdateInterval
+	}
+	return s.OCSPUpdateInterval
+}
+
+func (s *Server) recordRenewalPass(now time.Time) {
+	s.renewalsMu.Lock()
+	defer s.renewalsMu.Unlock()
+	s.lastRenewalPass = now
+}
+
+// collectRevokedTokens periodically removes expired tokens from the
+// denylist. It returns once s.done is closed.
+func (s *Server) collectRevokedTokens() {
+	const op errors.Op = "server/server.collectRevokedTokens"
+
+	ticker := time.NewTicker(revokedTokensGCInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			errors.LogFunc(s.Logger, func() error {
+				return errors.Wrap(s.denylist.CollectGarbage(now), op)
+			})
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// collectAuditEntries periodically removes the entries exceeding the
+// retention limits from the audit log. It returns once s.done is closed.
+func (s *Server) collectAuditEntries() {
+	const op errors.Op = "server/server.collectAuditEntries"
+
+	ticker := time.NewTicker(auditLogGCInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			errors.LogFunc(s.Logger, func() error {
+				return errors.Wrap(s.auditLog.CollectGarbage(now), op)
+			})
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// collectIssuances periodically removes the issuances which left the window
+// of the quotas of the ACME agent. It returns once s.done is closed.
+func (s *Server) collectIssuances() {
+	const op errors.Op = "server/server.collectIssuances"
+
+	ticker := time.NewTicker(issuancesGCInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			errors.LogFunc(s.Logger, func() error {
+				return errors.Wrap(s.acmeAgent.PruneIssuances(now), op)
+			})
+		case <-s.done:
+			return
+		}
+	}
+}
+
 // isStarted returns true if an attempt to start the server has been made. A
 // true return value does not indicate the server is actually running. Start
 // may have failed with an error, or Shutdown was called in the meantime.
@@ -81,41 +323,199 @@ func (s *Server) Shutdown(ctx context.Context) error {
 		return errors.New(op, "not started")
 	}
 
+	close(s.done)
+
 	var errcol errors.Collection
 	errcol = errors.Append(errcol, s.httpAPIServer.Shutdown(ctx), op)
+	if s.metricsServer != nil {
+		errcol = errors.Append(errcol, s.metricsServer.Shutdown(ctx), op)
+	}
+	if s.adminServer != nil {
+		errcol = errors.Append(errcol, s.adminServer.Shutdown(ctx), op)
+	}
+	if s.GRPCAPIAddr != "" {
+		errcol = errors.Append(errcol, s.grpcAPIServer.Shutdown(ctx), op)
+	}
+	if s.restAPIServer != nil {
+		errcol = errors.Append(errcol, s.restAPIServer.Shutdown(ctx), op)
+	}
 	errcol = errors.Append(errcol, s.boltDB.Close(), op)
+	errcol = errors.Append(errcol, s.TracerProvider.Shutdown(ctx), op)
 	return errcol.ErrorOrNil()
 }
 
 // initialize initializes the un-exported fields of Server. It must not
 // be called more than once.
 func (s *Server) initialize() {
+	s.done = make(chan struct{})
 	s.boltDB = &db.Bolt{
 		FilePath: filepath.Join(s.DataDir, "acmeproxy.db"),
 		FileMode: 0600,
 	}
-	acmeclient.InitializeLego(s.Logger)
+	s.TracerProvider.Install()
+	acmeclient.InitializeLego(s.componentLogger(logging.ComponentLego))
 	acmeClient := &acmeclient.Client{
 		DirectoryURL: s.ACMEDirectoryURL,
+		HTTP01Port:   s.ACMEHTTP01Port,
+		HTTP01Solver: acmeclient.HTTP01Solver{Logger: s.componentLogger(logging.ComponentAgent)},
+		Resolver:     acmeclient.NewResolver(s.ACMEResolverAddr),
+		CAAResolver:  &acmeclient.DNSCAAResolver{Addr: s.ACMEResolverAddr},
+		// lego's client trusts the same certificates lego uses to talk to
+		// the ACME CA.
+		HTTPClient: lego.NewConfig(nil).HTTPClient,
+		Logger:     s.componentLogger(logging.ComponentAgent),
+	}
+	s.auditLog = &auth.AuditLog{
+		Repository: s.boltDB.AuditRepository(),
+		Retention:  s.AuditRetention,
+		MaxEntries: s.AuditMaxEntries,
 	}
 	s.acmeAgent = &acme.Agent{
 		Domains:      s.boltDB.DomainRepository(),
 		Users:        s.boltDB.UserRepository(),
 		Certificates: acmeClient,
 		ACMEAccounts: acmeClient,
+		OCSP:         acmeClient,
+		CAA:          acmeClient,
+		Policy:       s.DomainPolicy,
+		Issuances:    s.boltDB.IssuanceRepository(),
+		Quotas:       s.Quotas,
+		Audit:        s.auditLog,
+	}
+	if s.acmeAgent.Quotas == (acme.Quotas{}) {
+		s.acmeAgent.Quotas = acme.DefaultQuotas
 	}
 	s.httpAPIServer = &httpapi.Server{
 		Solver: &acmeClient.HTTP01Solver,
 	}
+	s.denylist = &auth.Denylist{
+		Repository: s.boltDB.RevokedTokenRepository(),
+	}
+	if s.GRPCAPIAddr != "" || s.RESTAPIAddr != "" {
+		s.grpcAPIServer = &grpcapi.Server{
+			TokenParser:        s.parseToken,
+			TokenRevoker:       s.denylist,
+			TLSConfig:          s.GRPCAPITLSConfig,
+			UserRegisterer:     s.acmeAgent,
+			OCSPResponseWriter: s.acmeAgent,
+			CAARecommender:     s.acmeAgent,
+			UserManager:        s.acmeAgent,
+			DomainLister:       s.acmeAgent,
+			DomainOwnerFinder:  s.acmeAgent,
+			CertificateWatcher: s.acmeAgent,
+			AuditTrail:         s.auditLog,
+			CallTimeout:        s.GRPCAPICallTimeout,
+			RateLimits:         s.GRPCAPIRateLimits,
+			HealthChecks: map[string]grpcapi.HealthCheck{
+				"database":       s.checkDatabase,
+				"acme-directory": acmeClient.CheckDirectory,
+				"ocsp-updates":   s.checkOCSPUpdates,
+				"renewals":       s.checkRenewals,
+			},
+			Reflection: s.GRPCAPIReflection,
+			Logger:     s.componentLogger(logging.ComponentGRPC),
+		}
+		if s.ClientCertificates != nil {
+			s.grpcAPIServer.CertificateMapper = s.ClientCertificates.Claims
+		}
+	}
+	if s.RESTAPIAddr != "" {
+		s.restAPIServer = &grpcapi.Gateway{Server: s.grpcAPIServer}
+	}
+	if s.MetricsAddr != "" {
+		s.initializeMetrics(acmeClient)
+	}
+	if s.AdminAddr != "" {
+		s.adminServer = &adminapi.Server{
+			ReadinessChecks: map[string]adminapi.Check{
+				"database":       s.checkDatabase,
+				"acme-directory": acmeClient.CheckDirectory,
+			},
+			Domains: s.acmeAgent,
+			Logger:  s.Logger,
+		}
+	}
+}
+
+// componentLogger returns the logger of the named component, or Logger if
+// there is none.
+func (s *Server) componentLogger(name string) log.Logger {
+	if logger, ok := s.ComponentLoggers[name]; ok {
+		return logger
+	}
+	return s.Logger
+}
+
+// initializeMetrics creates the metrics of the components of Server and the
+// server exposing them.
+func (s *Server) initializeMetrics(acmeClient *acmeclient.Client) {
+	registry := prometheus.NewRegistry()
+	acmeClient.Metrics = acmeclient.NewOrderMetrics()
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		acmeClient.Metrics,
+		&acmeClient.HTTP01Solver,
+		s.acmeAgent,
+		s.boltDB,
+	)
+	if s.grpcAPIServer != nil {
+		s.grpcAPIServer.Metrics = grpcapi.NewCallMetrics()
+		registry.MustRegister(s.grpcAPIServer.Metrics)
+	}
+	s.metricsServer = &metricsapi.Server{Gatherer: registry}
+}
+
+func (s *Server) checkDatabase(context.Context) error {
+	return s.boltDB.Ping()
+}
+
+func (s *Server) checkOCSPUpdates(context.Context) error {
+	const op errors.Op = "server/server.checkOCSPUpdates"
+
+	if atomic.LoadUint32(&s.updatingOCSPResponses) == 0 {
+		return errors.New(op, "ocsp updates not running")
+	}
+	return nil
+}
+
+func (s *Server) checkRenewals(context.Context) error {
+	return s.checkRenewalsAt(time.Now())
+}
+
+// checkRenewalsAt returns an error if the go routine re-issuing revoked
+// certificates stopped, or if it did not start a pass within twice its
+// interval before now.
+func (s *Server) checkRenewalsAt(now time.Time) error {
+	const op errors.Op = "server/server.checkRenewals"
+
+	if atomic.LoadUint32(&s.updatingOCSPResponses) == 0 {
+		return errors.New(op, "renewal scheduler not running")
+	}
+	s.renewalsMu.Lock()
+	last := s.lastRenewalPass
+	s.renewalsMu.Unlock()
+	if since := now.Sub(last); since > 2*s.ocspUpdateInterval() {
+		return errors.New(op, fmt.Sprintf("renewal scheduler missed its interval: last pass %v ago", since.Round(time.Second)))
+	}
+	return nil
 }
+
+func (s *Server) parseToken(token string) (*auth.Claims, error) {
+	if s.OIDCProvider != nil {
+		return s.OIDCProvider.ParseToken(token)
+	}
+	return auth.ParseTokenWithKeySet(token, s.TokenKeys)
+}
+
 func (s *Server) registerAcmeproxyDomain() error {
 	const op errors.Op = "server/server.registerAcmeproxyDomain"
 
 	tmpUserID := uuid.Must(uuid.NewRandom())
-	if err := s.acmeAgent.RegisterUser(tmpUserID, ""); err != nil {
+	if err := s.acmeAgent.RegisterUser(context.Background(), tmpUserID, ""); err != nil {
 		return errors.New(op, "register default user", err)
 	}
-	if err := s.acmeAgent.RegisterDomain(tmpUserID, "www.example.com"); err != nil {
+	if err := s.acmeAgent.RegisterDomain(context.Background(), tmpUserID, "www.example.com"); err != nil {
 		return errors.New(op, fmt.Sprintf("register acmeproxy domain: %s", "www.example.com"), err)
 	}
 	return nil
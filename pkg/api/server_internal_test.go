@@ -0,0 +1,65 @@
+package api
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckRenewals(t *testing.T) {
+	now := time.Date(2019, 10, 18, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		running  bool
+		lastPass time.Time
+		interval time.Duration
+		healthy  bool
+	}{
+		{
+			name:     "recent pass",
+			running:  true,
+			lastPass: now.Add(-30 * time.Minute),
+			healthy:  true,
+		},
+		{
+			name:     "pass delayed by less than an interval",
+			running:  true,
+			lastPass: now.Add(-90 * time.Minute),
+			healthy:  true,
+		},
+		{
+			name:     "missed interval",
+			running:  true,
+			lastPass: now.Add(-3 * time.Hour),
+		},
+		{
+			name:     "custom interval",
+			running:  true,
+			lastPass: now.Add(-30 * time.Minute),
+			interval: 10 * time.Minute,
+		},
+		{
+			name:     "scheduler stopped",
+			lastPass: now,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Server{OCSPUpdateInterval: tt.interval}
+			if tt.running {
+				atomic.StoreUint32(&s.updatingOCSPResponses, 1)
+			}
+			s.recordRenewalPass(tt.lastPass)
+
+			err := s.checkRenewalsAt(now)
+			if tt.healthy {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Error(t, err)
+		})
+	}
+}
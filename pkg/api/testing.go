@@ -39,6 +39,8 @@ func NewTestFixture(t *testing.T) *TestFixture {
 		DataDir:          dataDir,
 		HTTPAPIAddr:      fmt.Sprintf("127.0.0.1:%d", pebble.HTTPPort()),
 		ACMEDirectoryURL: pebble.DirectoryURL(),
+		ACMEResolverAddr: "127.0.0.1:" + DNSPort,
+		ACMEHTTP01Port:   pebble.HTTPPort(),
 	}
 	return &TestFixture{
 		Server:     server,
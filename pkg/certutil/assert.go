@@ -53,6 +53,10 @@ func parseSigner(t *testing.T, kt KeyType, key []byte) crypto.Signer {
 		signer, err = x509.ParsePKCS1PrivateKey(block.Bytes)
 	case EC256, EC384, EC521:
 		signer, err = x509.ParseECPrivateKey(block.Bytes)
+	case Ed25519:
+		var key interface{}
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+		signer, _ = key.(crypto.Signer)
 	default:
 		t.Fatalf("Unsupported key type: %v", kt)
 	}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"crypto"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
@@ -37,6 +38,9 @@ const (
 	RSA4096
 	// RSA8192 represents an RSA key with a size of 8192 bits.
 	RSA8192
+	// Ed25519 represents an EdDSA key using Ed25519. Ed25519 keys can be used
+	// to sign tokens but not to request certificates.
+	Ed25519
 )
 
 // DetermineKeyType inspects the passed key and returns the appropriate
@@ -50,6 +54,8 @@ func DetermineKeyType(key crypto.PrivateKey) (KeyType, error) {
 		return determineECDSAKeyType(pk)
 	case *rsa.PrivateKey:
 		return determineRSAKeyType(pk)
+	case ed25519.PrivateKey:
+		return Ed25519, nil
 	default:
 		return -1, errors.New(op, "unsupported key type")
 	}
@@ -110,6 +116,8 @@ func NewPrivateKey(kt KeyType) (crypto.PrivateKey, error) {
 		pk, err = rsa.GenerateKey(rand.Reader, 4096)
 	case RSA8192:
 		pk, err = rsa.GenerateKey(rand.Reader, 8192)
+	case Ed25519:
+		_, pk, err = ed25519.GenerateKey(rand.Reader)
 	default:
 		return nil, errors.New(op, fmt.Sprintf("unknown key type: %v", kt))
 	}
@@ -121,7 +129,8 @@ func NewPrivateKey(kt KeyType) (crypto.PrivateKey, error) {
 //
 // The value of kt determines which the type of key to be read. To read an
 // ECDSA private key any of the EC* values can be used. Likewise to read an RSA
-// private key any of the RSA* values can be passed.
+// private key any of the RSA* values can be passed. Ed25519 private keys are
+// expected to be PKCS #8 encoded.
 func ReadPrivateKey(kt KeyType, r io.Reader, pemDecode bool) (crypto.PrivateKey, error) {
 	const op errors.Op = "certutil/ReadPrivateKey"
 
@@ -134,6 +143,8 @@ func ReadPrivateKey(kt KeyType, r io.Reader, pemDecode bool) (crypto.PrivateKey,
 		pk, err = readKey(r, pemDecode, parseECDSAKey)
 	case RSA2048, RSA4096, RSA8192:
 		pk, err = readKey(r, pemDecode, parseRSAKey)
+	case Ed25519:
+		pk, err = readKey(r, pemDecode, parseEd25519Key)
 	default:
 		return nil, errors.New(op, fmt.Sprintf("invalid key type: %v", kt))
 	}
@@ -143,6 +154,16 @@ func ReadPrivateKey(kt KeyType, r io.Reader, pemDecode bool) (crypto.PrivateKey,
 func readKey(r io.Reader, pemDecode bool, df func([]byte) (crypto.PrivateKey, error)) (crypto.PrivateKey, error) {
 	const op errors.Op = "certutil/readKey"
 
+	bs, err := readKeyBytes(r, pemDecode)
+	if err != nil {
+		return nil, errors.New(op, err)
+	}
+	return df(bs)
+}
+
+func readKeyBytes(r io.Reader, pemDecode bool) ([]byte, error) {
+	const op errors.Op = "certutil/readKeyBytes"
+
 	bs, err := ioutil.ReadAll(r)
 	if err != nil {
 		return nil, errors.New(op, "read key data", err)
@@ -157,7 +178,7 @@ func readKey(r io.Reader, pemDecode bool, df func([]byte) (crypto.PrivateKey, er
 		}
 		bs = block.Bytes
 	}
-	return df(bs)
+	return bs, nil
 }
 
 func parseECDSAKey(bs []byte) (crypto.PrivateKey, error) {
@@ -174,6 +195,19 @@ func parseRSAKey(bs []byte) (crypto.PrivateKey, error) {
 	return key, errors.Wrap(err, op, "parse RSA private key")
 }
 
+func parseEd25519Key(bs []byte) (crypto.PrivateKey, error) {
+	const op errors.Op = "certutil/parseEd25519Key"
+
+	key, err := x509.ParsePKCS8PrivateKey(bs)
+	if err != nil {
+		return nil, errors.New(op, "parse Ed25519 private key", err)
+	}
+	if _, ok := key.(ed25519.PrivateKey); !ok {
+		return nil, errors.New(op, "not an Ed25519 private key")
+	}
+	return key, nil
+}
+
 // ReadPrivateKeyFromFile reads a private key of type kt from the file
 // at the specified path. If pemDecode is true ReadPrivateKeyFromFile assumes
 // the key is PEM encoded and decodes it accordingly.
@@ -192,6 +226,39 @@ func ReadPrivateKeyFromFile(kt KeyType, path string, pemDecode bool) (crypto.Pri
 	return pk, nil
 }
 
+// ReadPublicKey reads a PKIX encoded public key from r.
+//
+// If pemDecode is true ReadPublicKey assumes the key is PEM encoded and
+// decodes it accordingly.
+func ReadPublicKey(r io.Reader, pemDecode bool) (crypto.PublicKey, error) {
+	const op errors.Op = "certutil/ReadPublicKey"
+
+	bs, err := readKeyBytes(r, pemDecode)
+	if err != nil {
+		return nil, errors.New(op, err)
+	}
+	key, err := x509.ParsePKIXPublicKey(bs)
+	return key, errors.Wrap(err, op, "parse public key")
+}
+
+// ReadPublicKeyFromFile reads a PKIX encoded public key from the file at the
+// specified path. If pemDecode is true ReadPublicKeyFromFile assumes the key
+// is PEM encoded and decodes it accordingly.
+func ReadPublicKeyFromFile(path string, pemDecode bool) (crypto.PublicKey, error) {
+	const op errors.Op = "certutil/ReadPublicKeyFromFile"
+
+	keyReader, err := os.Open(path)
+	if err != nil {
+		return nil, errors.New(op, "open key path", err)
+	}
+	defer keyReader.Close()
+	pk, err := ReadPublicKey(keyReader, pemDecode)
+	if err != nil {
+		return nil, errors.New(op, "read key from file", err)
+	}
+	return pk, nil
+}
+
 // WritePrivateKey writes a private key to a file.
 //
 // WritePrivateKey returns an error if the writing the key to w fails or if
@@ -218,6 +285,12 @@ func WritePrivateKey(key crypto.PrivateKey, w io.Writer, pemEncode bool) error {
 	case *rsa.PrivateKey:
 		bs = x509.MarshalPKCS1PrivateKey(pk)
 		typ = "RSA PRIVATE KEY"
+	case ed25519.PrivateKey:
+		typ = "PRIVATE KEY"
+		bs, err = x509.MarshalPKCS8PrivateKey(pk)
+		if err != nil {
+			return errors.New(op, "marshal Ed25519 private key", err)
+		}
 	default:
 		return errors.New(op, "unsupported private key")
 	}
@@ -2,9 +2,13 @@ package certutil_test
 
 import (
 	"bytes"
+	"crypto"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/rsa"
 	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -31,6 +35,7 @@ func TestNewPrivateKey(t *testing.T) {
 		{"RSA2048", certutil.RSA2048, (*rsa.PrivateKey)(nil)},
 		{"RSA4096", certutil.RSA4096, (*rsa.PrivateKey)(nil)},
 		{"RSA8192", certutil.RSA8192, (*rsa.PrivateKey)(nil)},
+		{"Ed25519", certutil.Ed25519, ed25519.PrivateKey(nil)},
 	}
 
 	for _, tt := range tests {
@@ -72,6 +77,8 @@ func TestReadPrivateKey(t *testing.T) {
 		{"rsa2048.der", certutil.RSA2048, false},
 		{"rsa4096.der", certutil.RSA4096, false},
 		{"rsa8192.der", certutil.RSA8192, false},
+		{"ed25519.pem", certutil.Ed25519, true},
+		{"ed25519.der", certutil.Ed25519, false},
 	}
 
 	for _, tt := range tests {
@@ -111,6 +118,45 @@ func TestReadPrivateKeyInvalidPEMBlock(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestReadPublicKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		keyType certutil.KeyType
+		pem     bool
+	}{
+		{"ec256.pem", certutil.EC256, true},
+		{"ec521.der", certutil.EC521, false},
+		{"rsa2048.pem", certutil.RSA2048, true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			pk, err := certutil.NewPrivateKey(tt.keyType)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected := pk.(crypto.Signer).Public()
+			bs, err := x509.MarshalPKIXPublicKey(expected)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.pem {
+				bs = pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: bs})
+			}
+			actual, err := certutil.ReadPublicKey(bytes.NewReader(bs), tt.pem)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, actual)
+		})
+	}
+}
+
+func TestReadPublicKeyInvalidKeyData(t *testing.T) {
+	r := strings.NewReader("invalid key data")
+	_, err := certutil.ReadPublicKey(r, false)
+	assert.Error(t, err)
+}
+
 func TestReadConcatenatedPEMBlocks(t *testing.T) {
 	certFiles := []string{
 		filepath.Join("testdata", t.Name(), "ec256_1.pem"),
@@ -150,6 +196,8 @@ func TestWritePrivateKey(t *testing.T) {
 		{"rsa2048.der", certutil.RSA2048, false},
 		{"rsa4096.der", certutil.RSA4096, false},
 		{"rsa8192.der", certutil.RSA8192, false},
+		{"ed25519.pem", certutil.Ed25519, true},
+		{"ed25519.der", certutil.Ed25519, false},
 	}
 	// Create tmpDir before we iterate over our test cases. This way the tmpDir
 	// has the test function's name as prefix and does not contain the test
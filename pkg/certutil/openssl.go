@@ -26,6 +26,8 @@ func CreateOpenSSLPrivateKey(t *testing.T, kt KeyType, keyPath string, pemEncode
 		createOpenSSLECPrivateKey(t, kt, dir, keyFile, pemEncode)
 	case RSA2048, RSA4096, RSA8192:
 		createOpenSSLRSAPrivateKey(t, kt, dir, keyFile, pemEncode)
+	case Ed25519:
+		createOpenSSLEd25519PrivateKey(t, dir, keyFile, pemEncode)
 	default:
 		t.Fatal("unsupported key type")
 	}
@@ -52,6 +54,17 @@ func createOpenSSLECPrivateKey(t *testing.T, kt KeyType, dir, keyFile string, pe
 	openssl(t, argv...)
 }
 
+func createOpenSSLEd25519PrivateKey(t *testing.T, dir, keyFile string, pemEncode bool) {
+	argv := []string{"genpkey", "-algorithm", "ed25519"}
+	if pemEncode {
+		argv = append(argv, "-outform", "pem")
+	} else {
+		argv = append(argv, "-outform", "der")
+	}
+	argv = append(argv, "-out", filepath.Join(dir, keyFile))
+	openssl(t, argv...)
+}
+
 func createOpenSSLRSAPrivateKey(t *testing.T, kt KeyType, dir, keyFile string, pemEncode bool) {
 	targetFile := filepath.Join(dir, keyFile)
 	if !pemEncode {
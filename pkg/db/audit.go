@@ -0,0 +1,110 @@
+package db
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/fhofherr/acmeproxy/pkg/db/internal/dbrecords"
+	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/fhofherr/acmeproxy/pkg/security"
+	"github.com/google/uuid"
+)
+
+type auditRepository struct {
+	BoltDB     *Bolt
+	BucketName string
+}
+
+// RecordAuditEntry stores e in the bolt database.
+func (r *auditRepository) RecordAuditEntry(e security.AuditEntry) error {
+	const op errors.Op = "db/auditRepository.RecordAuditEntry"
+
+	nonce, err := uuid.NewRandom()
+	if err != nil {
+		return errors.New(op, "create key nonce", err)
+	}
+	key := timeKey{Time: e.Time, Nonce: nonce}
+	err = r.BoltDB.updateBucket(r.BucketName, func(b *bucket) error {
+		b.writeRecord(key, &dbrecords.BinaryMarshaller{V: e})
+		return nil
+	})
+	return errors.Wrap(err, op, "write audit entry")
+}
+
+// ListAuditEntries reads the audit entries matching f from the bolt
+// database. The entries are ordered by the time they were recorded.
+func (r *auditRepository) ListAuditEntries(f security.AuditFilter) ([]security.AuditEntry, error) {
+	const op errors.Op = "db/auditRepository.ListAuditEntries"
+	var (
+		entries []security.AuditEntry
+		start   []byte
+		err     error
+	)
+
+	if !f.Since.IsZero() {
+		start, err = timeKey{Time: f.Since}.MarshalBinary()
+		if err != nil {
+			return nil, errors.New(op, err)
+		}
+	}
+	err = r.BoltDB.viewBucket(r.BucketName, func(b *bucket) error {
+		b.forEachFrom(start, func(_, v []byte) error {
+			var e security.AuditEntry
+			if err := dbrecords.UnmarshalBinary(v, &e); err != nil {
+				return err
+			}
+			if !f.Until.IsZero() && !e.Time.Before(f.Until) {
+				return errStopIteration
+			}
+			if f.Matches(e) {
+				entries = append(entries, e)
+			}
+			if f.Limit > 0 && len(entries) == f.Limit {
+				return errStopIteration
+			}
+			return nil
+		})
+		return nil
+	})
+	if errors.IsKind(err, errors.NotFound) {
+		// The bucket does not exist yet. Thus there are no audit entries.
+		return nil, nil
+	}
+	return entries, errors.Wrap(err, op, "read audit entries from bucket")
+}
+
+// PruneAuditEntries removes the audit entries recorded before the passed
+// time, and the oldest entries exceeding maxEntries from the bolt database.
+func (r *auditRepository) PruneAuditEntries(before time.Time, maxEntries int) error {
+	const op errors.Op = "db/auditRepository.PruneAuditEntries"
+	var (
+		end []byte
+		err error
+	)
+
+	if !before.IsZero() {
+		end, err = timeKey{Time: before}.MarshalBinary()
+		if err != nil {
+			return errors.New(op, err)
+		}
+	}
+	err = r.BoltDB.updateBucket(r.BucketName, func(b *bucket) error {
+		excess := 0
+		if maxEntries > 0 {
+			excess = b.count() - maxEntries
+		}
+		// Keys must not be deleted while iterating over them.
+		var keys [][]byte
+		b.forEachFrom(nil, func(k, _ []byte) error {
+			expired := end != nil && bytes.Compare(k, end) < 0
+			if !expired && len(keys) >= excess {
+				return errStopIteration
+			}
+			keys = append(keys, append([]byte(nil), k...))
+			return nil
+		})
+		b.deleteKeys(keys)
+		return nil
+	})
+	return errors.Wrap(err, op, "delete audit entries")
+}
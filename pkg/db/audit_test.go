@@ -0,0 +1,70 @@
+package db_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fhofherr/acmeproxy/pkg/db"
+	"github.com/fhofherr/acmeproxy/pkg/security"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordListAndPruneAuditEntries(t *testing.T) {
+	fx := db.NewTestFixture(t)
+	defer fx.Close()
+	repo := fx.DB.AuditRepository()
+
+	entries, err := repo.ListAuditEntries(security.AuditFilter{})
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+	assert.NoError(t, repo.PruneAuditEntries(time.Now(), 1))
+
+	now := time.Unix(1571385600, 0).UTC()
+	recorded := []security.AuditEntry{
+		{
+			Time:      now.Add(-48 * time.Hour),
+			Subject:   "alice",
+			Roles:     []string{"user"},
+			Operation: "/pb.Domains/WatchCertificates",
+			Resource:  security.AuditDomain("www.example.com"),
+			Outcome:   security.AuditSuccess,
+		},
+		{
+			Time:      now.Add(-time.Hour),
+			Subject:   "bob",
+			Operation: "/pb.Domains/WatchCertificates",
+			Resource:  security.AuditDomain("www.example.com"),
+			Outcome:   security.AuditDenied,
+		},
+		{Time: now.Add(-time.Hour), Subject: "alice", Operation: "/pb.Domains/ListDomains", Outcome: security.AuditSuccess},
+		{Time: now, Subject: "alice", Operation: "/pb.Admin/RegisterUser", Outcome: security.AuditFailure},
+	}
+	for _, e := range recorded {
+		assert.NoError(t, repo.RecordAuditEntry(e))
+	}
+
+	entries, err = repo.ListAuditEntries(security.AuditFilter{Since: now.Add(-24 * time.Hour), Until: now})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, recorded[1:3], entries)
+
+	entries, err = repo.ListAuditEntries(security.AuditFilter{Subject: "alice", Limit: 2})
+	assert.NoError(t, err)
+	if assert.Len(t, entries, 2) {
+		assert.Equal(t, recorded[0], entries[0])
+		assert.Equal(t, recorded[2], entries[1])
+	}
+
+	entries, err = repo.ListAuditEntries(security.AuditFilter{Resource: security.AuditDomain("www.example.com")})
+	assert.NoError(t, err)
+	assert.Equal(t, recorded[:2], entries)
+
+	assert.NoError(t, repo.PruneAuditEntries(now.Add(-24*time.Hour), 0))
+	entries, err = repo.ListAuditEntries(security.AuditFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 3)
+
+	assert.NoError(t, repo.PruneAuditEntries(time.Time{}, 1))
+	entries, err = repo.ListAuditEntries(security.AuditFilter{})
+	assert.NoError(t, err)
+	assert.Equal(t, recorded[3:], entries)
+}
@@ -2,11 +2,18 @@ package db
 
 import (
 	"encoding"
+	"encoding/binary"
+	"time"
 
 	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/google/uuid"
 	"go.etcd.io/bbolt"
 )
 
+// errStopIteration is returned by the functions passed to forEachFrom to
+// stop the iteration early.
+var errStopIteration = errors.New("stop iteration")
+
 type bucket struct {
 	Bkt *bbolt.Bucket
 	Err error
@@ -59,6 +66,92 @@ func (b *bucket) readRecord(id encoding.BinaryMarshaler, target encoding.BinaryU
 	}
 }
 
+func (b *bucket) deleteRecord(id encoding.BinaryMarshaler) {
+	const op errors.Op = "db/bucket.deleteRecord"
+
+	var idBytes []byte
+	if b.Err != nil {
+		return
+	}
+	idBytes, b.Err = id.MarshalBinary()
+	if b.Err != nil {
+		return
+	}
+	b.Err = b.Bkt.Delete(idBytes)
+	if b.Err != nil {
+		b.Err = errors.New(op, "delete record", b.Err)
+	}
+}
+
+// deleteKeys removes the records with the passed raw keys.
+func (b *bucket) deleteKeys(keys [][]byte) {
+	const op errors.Op = "db/bucket.deleteKeys"
+
+	for _, k := range keys {
+		if b.Err != nil {
+			return
+		}
+		if err := b.Bkt.Delete(k); err != nil {
+			b.Err = errors.New(op, "delete record", err)
+		}
+	}
+}
+
+// count returns the number of records in the bucket.
+//
+// count iterates over all records. Bucket.Stats is not used as it does not
+// reflect the changes of the current transaction.
+func (b *bucket) count() int {
+	n := 0
+	c := b.Bkt.Cursor()
+	for k, _ := c.First(); k != nil; k, _ = c.Next() {
+		n++
+	}
+	return n
+}
+
+// forEach calls f for the raw key and value of each record in the bucket.
+//
+// Neither key nor value must be retained by f, as they are only valid for the
+// duration of the transaction.
+func (b *bucket) forEach(f func(k, v []byte) error) {
+	const op errors.Op = "db/bucket.forEach"
+
+	if b.Err != nil {
+		return
+	}
+	b.Err = b.Bkt.ForEach(f)
+	if b.Err != nil {
+		b.Err = errors.New(op, "iterate records", b.Err)
+	}
+}
+
+// forEachFrom calls f for the raw key and value of each record whose key is
+// greater than or equal to start. The records are visited in byte-sorted
+// order of their keys. If f returns errStopIteration forEachFrom stops
+// without setting b.Err.
+//
+// Neither key nor value must be retained by f, as they are only valid for the
+// duration of the transaction.
+func (b *bucket) forEachFrom(start []byte, f func(k, v []byte) error) {
+	const op errors.Op = "db/bucket.forEachFrom"
+
+	if b.Err != nil {
+		return
+	}
+	c := b.Bkt.Cursor()
+	for k, v := c.Seek(start); k != nil; k, v = c.Next() {
+		err := f(k, v)
+		if err == errStopIteration {
+			return
+		}
+		if err != nil {
+			b.Err = errors.New(op, "iterate records", err)
+			return
+		}
+	}
+}
+
 // do executes f iff b.Err is nil. do makes it easy to write code that should
 // only execute if the bucket has no error yet.
 func (b *bucket) do(f func() error) {
@@ -67,3 +160,24 @@ func (b *bucket) do(f func() error) {
 	}
 	b.Err = f()
 }
+
+// timeKey orders records by time, e.g. the time an issuance was recorded.
+// The nonce distinguishes records with the same time.
+type timeKey struct {
+	Time  time.Time
+	Nonce uuid.UUID
+}
+
+// MarshalBinary encodes the key as the big-endian Unix time of the record in
+// nanoseconds followed by the nonce. This makes bolt's byte-sorted keys sort
+// by time.
+func (k timeKey) MarshalBinary() ([]byte, error) {
+	const op errors.Op = "db/timeKey.MarshalBinary"
+
+	if k.Time.Before(time.Unix(0, 0)) {
+		return nil, errors.New(op, errors.InvalidArgument, "time before unix epoch")
+	}
+	bs := make([]byte, 8, 8+len(k.Nonce))
+	binary.BigEndian.PutUint64(bs, uint64(k.Time.UnixNano()))
+	return append(bs, k.Nonce[:]...), nil
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/fhofherr/acmeproxy/pkg/acme"
 	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/fhofherr/acmeproxy/pkg/security"
 	"go.etcd.io/bbolt"
 )
 
@@ -18,7 +19,8 @@ const DefaultDBFileMode = 0600
 // Bolt represents the bbolt database used by acmeproxy to store its data.
 //
 // Bolt manages the data file used by bbolt and provides factory methods
-// for the various repositories used throughout acmeproxy.
+// for the various repositories used throughout acmeproxy. Bolt implements
+// prometheus.Collector reporting the statistics of the database.
 //
 // See https://github.com/etcd-io/bbolt for more information about bbolt.
 type Bolt struct {
@@ -65,6 +67,19 @@ func (b *Bolt) Close() error {
 	return errors.Wrap(b.db.Close(), op)
 }
 
+// Ping checks that the bolt database is open and can be read.
+func (b *Bolt) Ping() error {
+	const op errors.Op = "db/bolt.Ping"
+
+	b.mu.Lock()
+	db := b.db
+	b.mu.Unlock()
+	if db == nil {
+		return errors.New(op, "database not open")
+	}
+	return errors.Wrap(db.View(func(*bbolt.Tx) error { return nil }), op, "read database")
+}
+
 // UserRepository returns an instance of a user repository.
 func (b *Bolt) UserRepository() acme.UserRepository {
 	return &userRepository{
@@ -81,6 +96,30 @@ func (b *Bolt) DomainRepository() acme.DomainRepository {
 	}
 }
 
+// IssuanceRepository returns an instance of an issuance repository.
+func (b *Bolt) IssuanceRepository() acme.IssuanceRepository {
+	return &issuanceRepository{
+		BoltDB:     b,
+		BucketName: "issuances",
+	}
+}
+
+// RevokedTokenRepository returns an instance of a revoked token repository.
+func (b *Bolt) RevokedTokenRepository() security.RevokedTokenRepository {
+	return &revokedTokenRepository{
+		BoltDB:     b,
+		BucketName: "revoked_tokens",
+	}
+}
+
+// AuditRepository returns an instance of an audit repository.
+func (b *Bolt) AuditRepository() security.AuditRepository {
+	return &auditRepository{
+		BoltDB:     b,
+		BucketName: "audit_log",
+	}
+}
+
 func (b *Bolt) viewBucket(name string, view func(*bucket) error) error {
 	const op errors.Op = "db/bolt.viewBucket"
 
@@ -3,10 +3,12 @@ package db_test
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/fhofherr/acmeproxy/pkg/db"
 	"github.com/fhofherr/acmeproxy/pkg/internal/testsupport"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -101,3 +103,32 @@ func TestCloseNonOpenDB(t *testing.T) {
 	boltDB := db.Bolt{FilePath: filepath.Join(tmpDir, "test.db")}
 	assert.NoError(t, boltDB.Close())
 }
+
+func TestPingDB(t *testing.T) {
+	tmpDir, tearDown := testsupport.CreateTmpDir(t)
+	defer tearDown()
+	boltDB := db.Bolt{FilePath: filepath.Join(tmpDir, "test.db")}
+	assert.Error(t, boltDB.Ping())
+	assert.NoError(t, boltDB.Open())
+	assert.NoError(t, boltDB.Ping())
+	assert.NoError(t, boltDB.Close())
+	assert.Error(t, boltDB.Ping())
+}
+
+func TestCollectBoltStats(t *testing.T) {
+	tmpDir, tearDown := testsupport.CreateTmpDir(t)
+	defer tearDown()
+	boltDB := &db.Bolt{FilePath: filepath.Join(tmpDir, "test.db")}
+	assert.Equal(t, 0, testutil.CollectAndCount(boltDB))
+	assert.NoError(t, boltDB.Open())
+	defer boltDB.Close()
+
+	assert.NoError(t, boltDB.Ping())
+	assert.Equal(t, 9, testutil.CollectAndCount(boltDB))
+	expected := `
+# HELP acmeproxy_bolt_read_tx_total Number of read transactions started.
+# TYPE acmeproxy_bolt_read_tx_total counter
+acmeproxy_bolt_read_tx_total 1
+`
+	assert.NoError(t, testutil.CollectAndCompare(boltDB, strings.NewReader(expected), "acmeproxy_bolt_read_tx_total"))
+}
@@ -46,3 +46,27 @@ func (d *domainRepository) GetDomain(domainName string) (acme.Domain, error) {
 	})
 	return domain, errors.Wrap(err, op, "read domain from bucket")
 }
+
+// ListDomains reads all domains from the domain repository. The domains are
+// ordered by their name.
+func (d *domainRepository) ListDomains() ([]acme.Domain, error) {
+	const op errors.Op = "db/domainRepository.ListDomains"
+	var domains []acme.Domain
+
+	err := d.BoltDB.viewBucket(d.BucketName, func(b *bucket) error {
+		b.forEach(func(_, v []byte) error {
+			var domain acme.Domain
+			if err := dbrecords.UnmarshalBinary(v, &domain); err != nil {
+				return err
+			}
+			domains = append(domains, domain)
+			return nil
+		})
+		return nil
+	})
+	if errors.IsKind(err, errors.NotFound) {
+		// The bucket does not exist yet. Thus there are no domains.
+		return nil, nil
+	}
+	return domains, errors.Wrap(err, op, "read domains from bucket")
+}
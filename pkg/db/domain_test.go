@@ -111,3 +111,34 @@ func readFile(t *testing.T, filename string) []byte {
 	}
 	return bs
 }
+
+func TestListDomains(t *testing.T) {
+	fx := db.NewTestFixture(t)
+	defer fx.Close()
+	domainRepository := fx.DB.DomainRepository()
+
+	domains, err := domainRepository.ListDomains()
+	assert.NoError(t, err)
+	assert.Empty(t, domains)
+
+	userID := uuid.Must(uuid.NewRandom())
+	domainNames := []string{"www.example.com", "example.com", "mail.example.com"}
+	for _, domainName := range domainNames {
+		domainName := domainName
+		_, err := domainRepository.UpdateDomain(domainName, func(d *acme.Domain) error {
+			d.Name = domainName
+			d.UserID = userID
+			return nil
+		})
+		assert.NoError(t, err)
+	}
+
+	domains, err = domainRepository.ListDomains()
+	assert.NoError(t, err)
+	actualNames := make([]string, 0, len(domains))
+	for _, d := range domains {
+		assert.Equal(t, userID, d.UserID)
+		actualNames = append(actualNames, d.Name)
+	}
+	assert.Equal(t, []string{"example.com", "mail.example.com", "www.example.com"}, actualNames)
+}
@@ -0,0 +1,144 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: pkg/db/internal/dbrecords/audit_entry.proto
+
+package dbrecords
+
+import (
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	timestamp "github.com/golang/protobuf/ptypes/timestamp"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+type AuditEntry struct {
+	Time                 *timestamp.Timestamp `protobuf:"bytes,1,opt,name=time,proto3" json:"time,omitempty"`
+	Subject              string               `protobuf:"bytes,2,opt,name=subject,proto3" json:"subject,omitempty"`
+	Roles                []string             `protobuf:"bytes,3,rep,name=roles,proto3" json:"roles,omitempty"`
+	TokenID              string               `protobuf:"bytes,4,opt,name=tokenID,proto3" json:"tokenID,omitempty"`
+	PeerAddr             string               `protobuf:"bytes,5,opt,name=peerAddr,proto3" json:"peerAddr,omitempty"`
+	Operation            string               `protobuf:"bytes,6,opt,name=operation,proto3" json:"operation,omitempty"`
+	Resource             string               `protobuf:"bytes,7,opt,name=resource,proto3" json:"resource,omitempty"`
+	Outcome              string               `protobuf:"bytes,8,opt,name=outcome,proto3" json:"outcome,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *AuditEntry) Reset()         { *m = AuditEntry{} }
+func (m *AuditEntry) String() string { return proto.CompactTextString(m) }
+func (*AuditEntry) ProtoMessage()    {}
+func (*AuditEntry) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0564fd85ca13d632, []int{0}
+}
+
+func (m *AuditEntry) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AuditEntry.Unmarshal(m, b)
+}
+func (m *AuditEntry) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AuditEntry.Marshal(b, m, deterministic)
+}
+func (m *AuditEntry) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AuditEntry.Merge(m, src)
+}
+func (m *AuditEntry) XXX_Size() int {
+	return xxx_messageInfo_AuditEntry.Size(m)
+}
+func (m *AuditEntry) XXX_DiscardUnknown() {
+	xxx_messageInfo_AuditEntry.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AuditEntry proto.InternalMessageInfo
+
+func (m *AuditEntry) GetTime() *timestamp.Timestamp {
+	if m != nil {
+		return m.Time
+	}
+	return nil
+}
+
+func (m *AuditEntry) GetSubject() string {
+	if m != nil {
+		return m.Subject
+	}
+	return ""
+}
+
+func (m *AuditEntry) GetRoles() []string {
+	if m != nil {
+		return m.Roles
+	}
+	return nil
+}
+
+func (m *AuditEntry) GetTokenID() string {
+	if m != nil {
+		return m.TokenID
+	}
+	return ""
+}
+
+func (m *AuditEntry) GetPeerAddr() string {
+	if m != nil {
+		return m.PeerAddr
+	}
+	return ""
+}
+
+func (m *AuditEntry) GetOperation() string {
+	if m != nil {
+		return m.Operation
+	}
+	return ""
+}
+
+func (m *AuditEntry) GetResource() string {
+	if m != nil {
+		return m.Resource
+	}
+	return ""
+}
+
+func (m *AuditEntry) GetOutcome() string {
+	if m != nil {
+		return m.Outcome
+	}
+	return ""
+}
+
+func init() {
+	proto.RegisterType((*AuditEntry)(nil), "dbrecords.AuditEntry")
+}
+
+func init() {
+	proto.RegisterFile("pkg/db/internal/dbrecords/audit_entry.proto", fileDescriptor_0564fd85ca13d632)
+}
+
+var fileDescriptor_0564fd85ca13d632 = []byte{
+	// 238 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x44, 0x8f, 0x4f, 0x4b, 0xc4, 0x30,
+	0x10, 0xc5, 0xa9, 0xfb, 0xb7, 0xf1, 0x16, 0x3c, 0x84, 0x22, 0x58, 0x3c, 0x15, 0x84, 0x06, 0xf4,
+	0x13, 0x2c, 0xe8, 0xc1, 0x6b, 0xf1, 0x2e, 0x6d, 0x33, 0x96, 0xba, 0x6d, 0x27, 0x4c, 0x26, 0x07,
+	0xbf, 0xb8, 0x67, 0x49, 0xb2, 0x5d, 0x8f, 0xbf, 0xbc, 0xf7, 0x9b, 0x47, 0xc4, 0x93, 0x3d, 0x0f,
+	0xda, 0x74, 0x7a, 0x5c, 0x18, 0x68, 0x69, 0x27, 0x6d, 0x3a, 0x82, 0x1e, 0xc9, 0x38, 0xdd, 0x7a,
+	0x33, 0xf2, 0x27, 0x2c, 0x4c, 0x3f, 0xb5, 0x25, 0x64, 0x94, 0xf9, 0x35, 0x2c, 0x1e, 0x06, 0xc4,
+	0x61, 0x02, 0x1d, 0x83, 0xce, 0x7f, 0x69, 0x1e, 0x67, 0x70, 0xdc, 0xce, 0x36, 0x75, 0x1f, 0x7f,
+	0x33, 0x21, 0x4e, 0xe1, 0xc2, 0x5b, 0x38, 0x20, 0x6b, 0xb1, 0x0d, 0x0d, 0x95, 0x95, 0x59, 0x75,
+	0xfb, 0x5c, 0xd4, 0x49, 0xaf, 0x57, 0xbd, 0xfe, 0x58, 0xf5, 0x26, 0xf6, 0xa4, 0x12, 0x07, 0xe7,
+	0xbb, 0x6f, 0xe8, 0x59, 0xdd, 0x94, 0x59, 0x95, 0x37, 0x2b, 0xca, 0x3b, 0xb1, 0x23, 0x9c, 0xc0,
+	0xa9, 0x4d, 0xb9, 0xa9, 0xf2, 0x26, 0x41, 0xe8, 0x33, 0x9e, 0x61, 0x79, 0x7f, 0x55, 0xdb, 0xd4,
+	0xbf, 0xa0, 0x2c, 0xc4, 0xd1, 0x02, 0xd0, 0xc9, 0x18, 0x52, 0xbb, 0x18, 0x5d, 0x59, 0xde, 0x8b,
+	0x1c, 0x2d, 0x50, 0xcb, 0x23, 0x2e, 0x6a, 0x1f, 0xc3, 0xff, 0x87, 0x60, 0x12, 0x38, 0xf4, 0xd4,
+	0x83, 0x3a, 0x24, 0x73, 0xe5, 0xb0, 0x87, 0x9e, 0x7b, 0x9c, 0x41, 0x1d, 0xd3, 0xde, 0x05, 0xbb,
+	0x7d, 0xfc, 0xd3, 0xcb, 0xdf, 0x00, 0x64, 0xf4, 0x7d, 0x22, 0x5a, 0x01, 0x00, 0x00,
+}
@@ -6,6 +6,7 @@ package dbrecords
 import (
 	fmt "fmt"
 	proto "github.com/golang/protobuf/proto"
+	timestamp "github.com/golang/protobuf/ptypes/timestamp"
 	math "math"
 )
 
@@ -21,13 +22,15 @@ var _ = math.Inf
 const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
 
 type Domain struct {
-	UserID               []byte   `protobuf:"bytes,1,opt,name=userID,proto3" json:"userID,omitempty"`
-	Name                 string   `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
-	CertificatePEM       []byte   `protobuf:"bytes,3,opt,name=certificatePEM,proto3" json:"certificatePEM,omitempty"`
-	PrivateKeyPEM        []byte   `protobuf:"bytes,4,opt,name=privateKeyPEM,proto3" json:"privateKeyPEM,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+	UserID               []byte               `protobuf:"bytes,1,opt,name=userID,proto3" json:"userID,omitempty"`
+	Name                 string               `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	CertificatePEM       []byte               `protobuf:"bytes,3,opt,name=certificatePEM,proto3" json:"certificatePEM,omitempty"`
+	PrivateKeyPEM        []byte               `protobuf:"bytes,4,opt,name=privateKeyPEM,proto3" json:"privateKeyPEM,omitempty"`
+	OcspResponse         *Domain_OCSPResponse `protobuf:"bytes,5,opt,name=ocspResponse,proto3" json:"ocspResponse,omitempty"`
+	Revoked              bool                 `protobuf:"varint,6,opt,name=revoked,proto3" json:"revoked,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
 }
 
 func (m *Domain) Reset()         { *m = Domain{} }
@@ -83,8 +86,86 @@ func (m *Domain) GetPrivateKeyPEM() []byte {
 	return nil
 }
 
+func (m *Domain) GetOcspResponse() *Domain_OCSPResponse {
+	if m != nil {
+		return m.OcspResponse
+	}
+	return nil
+}
+
+func (m *Domain) GetRevoked() bool {
+	if m != nil {
+		return m.Revoked
+	}
+	return false
+}
+
+type Domain_OCSPResponse struct {
+	Raw                  []byte               `protobuf:"bytes,1,opt,name=raw,proto3" json:"raw,omitempty"`
+	Status               uint32               `protobuf:"varint,2,opt,name=status,proto3" json:"status,omitempty"`
+	ThisUpdate           *timestamp.Timestamp `protobuf:"bytes,3,opt,name=thisUpdate,proto3" json:"thisUpdate,omitempty"`
+	NextUpdate           *timestamp.Timestamp `protobuf:"bytes,4,opt,name=nextUpdate,proto3" json:"nextUpdate,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *Domain_OCSPResponse) Reset()         { *m = Domain_OCSPResponse{} }
+func (m *Domain_OCSPResponse) String() string { return proto.CompactTextString(m) }
+func (*Domain_OCSPResponse) ProtoMessage()    {}
+func (*Domain_OCSPResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8447184581058f96, []int{0, 0}
+}
+
+func (m *Domain_OCSPResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Domain_OCSPResponse.Unmarshal(m, b)
+}
+func (m *Domain_OCSPResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Domain_OCSPResponse.Marshal(b, m, deterministic)
+}
+func (m *Domain_OCSPResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Domain_OCSPResponse.Merge(m, src)
+}
+func (m *Domain_OCSPResponse) XXX_Size() int {
+	return xxx_messageInfo_Domain_OCSPResponse.Size(m)
+}
+func (m *Domain_OCSPResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_Domain_OCSPResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Domain_OCSPResponse proto.InternalMessageInfo
+
+func (m *Domain_OCSPResponse) GetRaw() []byte {
+	if m != nil {
+		return m.Raw
+	}
+	return nil
+}
+
+func (m *Domain_OCSPResponse) GetStatus() uint32 {
+	if m != nil {
+		return m.Status
+	}
+	return 0
+}
+
+func (m *Domain_OCSPResponse) GetThisUpdate() *timestamp.Timestamp {
+	if m != nil {
+		return m.ThisUpdate
+	}
+	return nil
+}
+
+func (m *Domain_OCSPResponse) GetNextUpdate() *timestamp.Timestamp {
+	if m != nil {
+		return m.NextUpdate
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*Domain)(nil), "dbrecords.Domain")
+	proto.RegisterType((*Domain_OCSPResponse)(nil), "dbrecords.Domain.OCSPResponse")
 }
 
 func init() {
@@ -92,16 +173,25 @@ func init() {
 }
 
 var fileDescriptor_8447184581058f96 = []byte{
-	// 162 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0x52, 0x2b, 0xc8, 0x4e, 0xd7,
-	0x4f, 0x49, 0xd2, 0xcf, 0xcc, 0x2b, 0x49, 0x2d, 0xca, 0x4b, 0xcc, 0xd1, 0x4f, 0x49, 0x2a, 0x4a,
-	0x4d, 0xce, 0x2f, 0x4a, 0x29, 0xd6, 0x4f, 0xc9, 0xcf, 0x4d, 0xcc, 0xcc, 0xd3, 0x2b, 0x28, 0xca,
-	0x2f, 0xc9, 0x17, 0xe2, 0x84, 0x8b, 0x2b, 0x35, 0x31, 0x72, 0xb1, 0xb9, 0x80, 0xe5, 0x84, 0xc4,
-	0xb8, 0xd8, 0x4a, 0x8b, 0x53, 0x8b, 0x3c, 0x5d, 0x24, 0x18, 0x15, 0x18, 0x35, 0x78, 0x82, 0xa0,
-	0x3c, 0x21, 0x21, 0x2e, 0x96, 0xbc, 0xc4, 0xdc, 0x54, 0x09, 0x26, 0x05, 0x46, 0x0d, 0xce, 0x20,
-	0x30, 0x5b, 0x48, 0x8d, 0x8b, 0x2f, 0x39, 0xb5, 0xa8, 0x24, 0x33, 0x2d, 0x33, 0x39, 0xb1, 0x24,
-	0x35, 0xc0, 0xd5, 0x57, 0x82, 0x19, 0xac, 0x07, 0x4d, 0x54, 0x48, 0x85, 0x8b, 0xb7, 0xa0, 0x28,
-	0xb3, 0x2c, 0xb1, 0x24, 0xd5, 0x3b, 0xb5, 0x12, 0xa4, 0x8c, 0x05, 0xac, 0x0c, 0x55, 0x30, 0x89,
-	0x0d, 0xec, 0x2c, 0x63, 0x40, 0x00, 0x00, 0x00, 0xff, 0xff, 0x91, 0x11, 0x6c, 0x02, 0xc0, 0x00,
-	0x00, 0x00,
+	// 307 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x84, 0x90, 0x41, 0x4e, 0xeb, 0x30,
+	0x10, 0x86, 0x95, 0xd7, 0xbe, 0x40, 0xdd, 0x16, 0x21, 0x2f, 0x50, 0xd4, 0x05, 0x44, 0x08, 0x55,
+	0x59, 0x39, 0x52, 0xd9, 0xb1, 0x84, 0xb2, 0x40, 0x08, 0x51, 0x19, 0x38, 0x80, 0x93, 0x4c, 0x8b,
+	0xd5, 0xc6, 0xb6, 0xec, 0x69, 0x81, 0x73, 0x70, 0x11, 0x8e, 0x88, 0xe2, 0x24, 0xa5, 0x65, 0xc3,
+	0xce, 0x33, 0xf3, 0x7d, 0x92, 0xff, 0x9f, 0x8c, 0xcd, 0x72, 0x91, 0x16, 0x59, 0x2a, 0x15, 0x82,
+	0x55, 0x62, 0x95, 0x16, 0x99, 0x85, 0x5c, 0xdb, 0xc2, 0xa5, 0x85, 0x2e, 0x85, 0x54, 0xcc, 0x58,
+	0x8d, 0x9a, 0xf6, 0xb6, 0xfb, 0xd1, 0xd9, 0x42, 0xeb, 0xc5, 0x0a, 0x52, 0x7f, 0xc8, 0xd6, 0xf3,
+	0x14, 0x65, 0x09, 0x0e, 0x45, 0x69, 0x6a, 0xf6, 0xfc, 0xb3, 0x43, 0xc2, 0xa9, 0x97, 0xe9, 0x09,
+	0x09, 0xd7, 0x0e, 0xec, 0xdd, 0x34, 0x0a, 0xe2, 0x20, 0x19, 0xf0, 0x66, 0xa2, 0x94, 0x74, 0x95,
+	0x28, 0x21, 0xfa, 0x17, 0x07, 0x49, 0x8f, 0xfb, 0x37, 0x1d, 0x93, 0xa3, 0x1c, 0x2c, 0xca, 0xb9,
+	0xcc, 0x05, 0xc2, 0xec, 0xf6, 0x21, 0xea, 0x78, 0xe7, 0xd7, 0x96, 0x5e, 0x90, 0xa1, 0xb1, 0x72,
+	0x23, 0x10, 0xee, 0xe1, 0xa3, 0xc2, 0xba, 0x1e, 0xdb, 0x5f, 0xd2, 0x6b, 0x32, 0xd0, 0xb9, 0x33,
+	0x1c, 0x9c, 0xd1, 0xca, 0x41, 0xf4, 0x3f, 0x0e, 0x92, 0xfe, 0xe4, 0x94, 0x6d, 0x73, 0xb0, 0xfa,
+	0x8b, 0xec, 0xf1, 0xe6, 0x69, 0xd6, 0x52, 0x7c, 0xcf, 0xa1, 0x11, 0x39, 0xb0, 0xb0, 0xd1, 0x4b,
+	0x28, 0xa2, 0x30, 0x0e, 0x92, 0x43, 0xde, 0x8e, 0xa3, 0xaf, 0x80, 0x0c, 0x76, 0x45, 0x7a, 0x4c,
+	0x3a, 0x56, 0xbc, 0x35, 0x29, 0xab, 0x67, 0x15, 0xdd, 0xa1, 0xc0, 0xb5, 0xf3, 0x21, 0x87, 0xbc,
+	0x99, 0xe8, 0x15, 0x21, 0xf8, 0x2a, 0xdd, 0x8b, 0x29, 0x04, 0x82, 0x8f, 0xd8, 0x9f, 0x8c, 0x58,
+	0xdd, 0x29, 0x6b, 0x3b, 0x65, 0xcf, 0x6d, 0xa7, 0x7c, 0x87, 0xae, 0x5c, 0x05, 0xef, 0xd8, 0xb8,
+	0xdd, 0xbf, 0xdd, 0x1f, 0x3a, 0x0b, 0xfd, 0xfd, 0xf2, 0x7b, 0x00, 0xed, 0xfc, 0xc5, 0x6b, 0xf2,
+	0x01, 0x00, 0x00,
 }
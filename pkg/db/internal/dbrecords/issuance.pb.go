@@ -0,0 +1,100 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: pkg/db/internal/dbrecords/issuance.proto
+
+package dbrecords
+
+import (
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	timestamp "github.com/golang/protobuf/ptypes/timestamp"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+type Issuance struct {
+	UserID               []byte               `protobuf:"bytes,1,opt,name=userID,proto3" json:"userID,omitempty"`
+	Domains              []string             `protobuf:"bytes,2,rep,name=domains,proto3" json:"domains,omitempty"`
+	IssuedAt             *timestamp.Timestamp `protobuf:"bytes,3,opt,name=issuedAt,proto3" json:"issuedAt,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *Issuance) Reset()         { *m = Issuance{} }
+func (m *Issuance) String() string { return proto.CompactTextString(m) }
+func (*Issuance) ProtoMessage()    {}
+func (*Issuance) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d931172c479636d0, []int{0}
+}
+
+func (m *Issuance) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Issuance.Unmarshal(m, b)
+}
+func (m *Issuance) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Issuance.Marshal(b, m, deterministic)
+}
+func (m *Issuance) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Issuance.Merge(m, src)
+}
+func (m *Issuance) XXX_Size() int {
+	return xxx_messageInfo_Issuance.Size(m)
+}
+func (m *Issuance) XXX_DiscardUnknown() {
+	xxx_messageInfo_Issuance.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Issuance proto.InternalMessageInfo
+
+func (m *Issuance) GetUserID() []byte {
+	if m != nil {
+		return m.UserID
+	}
+	return nil
+}
+
+func (m *Issuance) GetDomains() []string {
+	if m != nil {
+		return m.Domains
+	}
+	return nil
+}
+
+func (m *Issuance) GetIssuedAt() *timestamp.Timestamp {
+	if m != nil {
+		return m.IssuedAt
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*Issuance)(nil), "dbrecords.Issuance")
+}
+
+func init() {
+	proto.RegisterFile("pkg/db/internal/dbrecords/issuance.proto", fileDescriptor_d931172c479636d0)
+}
+
+var fileDescriptor_d931172c479636d0 = []byte{
+	// 172 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x3c, 0x8d, 0xbd, 0xca, 0x83, 0x30,
+	0x18, 0x46, 0xf1, 0x13, 0xfc, 0x34, 0xed, 0x94, 0xa1, 0x04, 0x97, 0x4a, 0xa7, 0x4c, 0x09, 0xb4,
+	0xd0, 0xbd, 0xd0, 0xc5, 0x55, 0x7a, 0x03, 0x89, 0x79, 0x2b, 0xa1, 0x9a, 0x48, 0xf2, 0x7a, 0xff,
+	0xa5, 0xfe, 0x8d, 0x0f, 0xe7, 0x3c, 0x1c, 0xc2, 0xc7, 0x4f, 0x27, 0x8d, 0x96, 0xd6, 0x21, 0x04,
+	0xa7, 0x7a, 0x69, 0x74, 0x80, 0xd6, 0x07, 0x13, 0xa5, 0x8d, 0x71, 0x52, 0xae, 0x05, 0x31, 0x06,
+	0x8f, 0x9e, 0x16, 0x3b, 0x29, 0xcf, 0x9d, 0xf7, 0x5d, 0x0f, 0x72, 0x06, 0x7a, 0x7a, 0x4b, 0xb4,
+	0x03, 0x44, 0x54, 0xc3, 0xb8, 0xb8, 0x17, 0x24, 0x79, 0xbd, 0xbe, 0xe9, 0x89, 0x64, 0x53, 0x84,
+	0x50, 0x3f, 0x59, 0x52, 0x25, 0xfc, 0xd8, 0xac, 0x8b, 0x32, 0xf2, 0x6f, 0xfc, 0xa0, 0xac, 0x8b,
+	0xec, 0xaf, 0x4a, 0x79, 0xd1, 0x6c, 0x93, 0xde, 0x49, 0xfe, 0x6b, 0x83, 0x79, 0x20, 0x4b, 0xab,
+	0x84, 0x1f, 0xae, 0xa5, 0x58, 0x8a, 0x62, 0x2b, 0x8a, 0xd7, 0x56, 0x6c, 0x76, 0x57, 0x67, 0x33,
+	0xbd, 0x7d, 0x07, 0x00, 0x6d, 0xd7, 0xf8, 0xb9, 0xd4, 0x00, 0x00, 0x00,
+}
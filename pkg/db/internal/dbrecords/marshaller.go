@@ -4,11 +4,15 @@ import (
 	"bytes"
 	"crypto"
 	fmt "fmt"
+	"time"
 
 	"github.com/fhofherr/acmeproxy/pkg/acme"
 	"github.com/fhofherr/acmeproxy/pkg/certutil"
 	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/fhofherr/acmeproxy/pkg/security"
 	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
 	"github.com/google/uuid"
 )
 
@@ -42,6 +46,18 @@ func (m *BinaryMarshaller) MarshalBinary() ([]byte, error) {
 		bs = m.marshalACMEDomain(obj)
 	case acme.Domain:
 		bs = m.marshalACMEDomain(&obj)
+	case *acme.Issuance:
+		bs = m.marshalACMEIssuance(obj)
+	case acme.Issuance:
+		bs = m.marshalACMEIssuance(&obj)
+	case *security.RevokedToken:
+		bs = m.marshalRevokedToken(obj)
+	case security.RevokedToken:
+		bs = m.marshalRevokedToken(&obj)
+	case *security.AuditEntry:
+		bs = m.marshalAuditEntry(obj)
+	case security.AuditEntry:
+		bs = m.marshalAuditEntry(&obj)
 	case uuid.UUID:
 		bs = m.marshalUUID(obj)
 	case string:
@@ -83,6 +99,9 @@ func (m *BinaryMarshaller) marshalACMEUser(user *acme.User) []byte {
 			KeyType:  uint32(kt),
 			KeyBytes: keyBytes,
 		},
+		Email:        user.Email,
+		Labels:       user.Labels,
+		PublicKeyPEM: user.PublicKey,
 	}
 	return m.marshalPB(&rec)
 }
@@ -121,10 +140,74 @@ func (m *BinaryMarshaller) marshalACMEDomain(d *acme.Domain) []byte {
 		Name:           d.Name,
 		CertificatePEM: d.Certificate,
 		PrivateKeyPEM:  d.PrivateKey,
+		OcspResponse:   m.marshalOCSPResponse(d.OCSPResponse),
+		Revoked:        d.Revoked,
 	}
 	return m.marshalPB(&rec)
 }
 
+func (m *BinaryMarshaller) marshalACMEIssuance(iss *acme.Issuance) []byte {
+	idBytes := m.marshalUUID(iss.UserID)
+	rec := Issuance{
+		UserID:   idBytes,
+		Domains:  iss.Domains,
+		IssuedAt: m.marshalTime(iss.IssuedAt),
+	}
+	return m.marshalPB(&rec)
+}
+
+func (m *BinaryMarshaller) marshalRevokedToken(rt *security.RevokedToken) []byte {
+	rec := RevokedToken{
+		Id:        rt.ID,
+		ExpiresAt: m.marshalTime(rt.ExpiresAt),
+	}
+	return m.marshalPB(&rec)
+}
+
+func (m *BinaryMarshaller) marshalAuditEntry(e *security.AuditEntry) []byte {
+	rec := AuditEntry{
+		Time:      m.marshalTime(e.Time),
+		Subject:   e.Subject,
+		Roles:     e.Roles,
+		TokenID:   e.TokenID,
+		PeerAddr:  e.PeerAddr,
+		Operation: e.Operation,
+		Resource:  e.Resource,
+		Outcome:   string(e.Outcome),
+	}
+	return m.marshalPB(&rec)
+}
+
+func (m *BinaryMarshaller) marshalOCSPResponse(res acme.OCSPResponse) *Domain_OCSPResponse {
+	if res.IsZero() {
+		return nil
+	}
+	return &Domain_OCSPResponse{
+		Raw:        res.Raw,
+		Status:     uint32(res.Status),
+		ThisUpdate: m.marshalTime(res.ThisUpdate),
+		NextUpdate: m.marshalTime(res.NextUpdate),
+	}
+}
+
+func (m *BinaryMarshaller) marshalTime(t time.Time) *timestamp.Timestamp {
+	const op errors.Op = "dbrecords/binaryMarshaller.marshalTime"
+
+	var ts *timestamp.Timestamp
+	if t.IsZero() {
+		return ts
+	}
+	m.do(func() error {
+		var err error
+		ts, err = ptypes.TimestampProto(t)
+		if err != nil {
+			return errors.New(op, err)
+		}
+		return nil
+	})
+	return ts
+}
+
 func (m *BinaryMarshaller) marshalPB(msg proto.Message) []byte {
 	const op errors.Op = "dbrecords/binaryMarshaller.marshalPB"
 	var bs []byte
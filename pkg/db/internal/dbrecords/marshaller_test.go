@@ -4,11 +4,13 @@ import (
 	"io/ioutil"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/fhofherr/acmeproxy/pkg/acme"
 	"github.com/fhofherr/acmeproxy/pkg/certutil"
 	"github.com/fhofherr/acmeproxy/pkg/db/internal/dbrecords"
 	"github.com/fhofherr/acmeproxy/pkg/internal/testsupport"
+	"github.com/fhofherr/acmeproxy/pkg/security"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 )
@@ -112,6 +114,9 @@ func TestMarshalAndUnmarshalUsers(t *testing.T) {
 				ID:         uuid.Must(uuid.NewRandom()),
 				Key:        certutil.KeyMust(certutil.ReadPrivateKeyFromFile(tt.keyType, keyFile, true)),
 				AccountURL: "https://example.com/some/account",
+				Email:      "jane.doe@example.com",
+				Labels:     map[string]string{"team": "web"},
+				PublicKey:  []byte("-----BEGIN PUBLIC KEY-----\n-----END PUBLIC KEY-----\n"),
 			}
 			source = user
 			if tt.toSource != nil {
@@ -173,6 +178,13 @@ func TestMarshalAndUnmarshalDomain(t *testing.T) {
 				Name:        domainName,
 				Certificate: certBytes,
 				PrivateKey:  keyBytes,
+				OCSPResponse: acme.OCSPResponse{
+					Raw:        []byte("ocsp response"),
+					Status:     acme.OCSPRevoked,
+					ThisUpdate: time.Unix(1571385600, 0).UTC(),
+					NextUpdate: time.Unix(1571990400, 0).UTC(),
+				},
+				Revoked: true,
 			}
 			var source interface{} = domain
 			if tt.toSource != nil {
@@ -188,6 +200,52 @@ func TestMarshalAndUnmarshalDomain(t *testing.T) {
 	}
 }
 
+func TestMarshalAndUnmarshalIssuance(t *testing.T) {
+	iss := acme.Issuance{
+		UserID:   uuid.Must(uuid.NewRandom()),
+		Domains:  []string{"example.com", "www.example.com"},
+		IssuedAt: time.Unix(1571385600, 0).UTC(),
+	}
+	bs, err := dbrecords.MarshalBinary(iss)
+	assert.NoError(t, err)
+	var actual acme.Issuance
+	err = dbrecords.UnmarshalBinary(bs, &actual)
+	assert.NoError(t, err)
+	assert.Equal(t, iss, actual)
+}
+
+func TestMarshalAndUnmarshalRevokedToken(t *testing.T) {
+	rt := security.RevokedToken{
+		ID:        "some-token-id",
+		ExpiresAt: time.Unix(1571385600, 0).UTC(),
+	}
+	bs, err := dbrecords.MarshalBinary(&rt)
+	assert.NoError(t, err)
+	var actual security.RevokedToken
+	err = dbrecords.UnmarshalBinary(bs, &actual)
+	assert.NoError(t, err)
+	assert.Equal(t, rt, actual)
+}
+
+func TestMarshalAndUnmarshalAuditEntry(t *testing.T) {
+	e := security.AuditEntry{
+		Time:      time.Unix(1571385600, 0).UTC(),
+		Subject:   uuid.Must(uuid.NewRandom()).String(),
+		Roles:     []string{"operator", "user"},
+		TokenID:   "some-token-id",
+		PeerAddr:  "192.0.2.1:40000",
+		Operation: "/pb.Domains/WatchCertificates",
+		Resource:  security.AuditDomain("www.example.com"),
+		Outcome:   security.AuditSuccess,
+	}
+	bs, err := dbrecords.MarshalBinary(e)
+	assert.NoError(t, err)
+	var actual security.AuditEntry
+	err = dbrecords.UnmarshalBinary(bs, &actual)
+	assert.NoError(t, err)
+	assert.Equal(t, e, actual)
+}
+
 func assertDomainObjectsEqual(t *testing.T, expected, actual interface{}) {
 	switch v := expected.(type) {
 	case acme.User:
@@ -0,0 +1,92 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: pkg/db/internal/dbrecords/revoked_token.proto
+
+package dbrecords
+
+import (
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	timestamp "github.com/golang/protobuf/ptypes/timestamp"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+type RevokedToken struct {
+	Id                   string               `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	ExpiresAt            *timestamp.Timestamp `protobuf:"bytes,2,opt,name=expiresAt,proto3" json:"expiresAt,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *RevokedToken) Reset()         { *m = RevokedToken{} }
+func (m *RevokedToken) String() string { return proto.CompactTextString(m) }
+func (*RevokedToken) ProtoMessage()    {}
+func (*RevokedToken) Descriptor() ([]byte, []int) {
+	return fileDescriptor_2707b61b4c008ab7, []int{0}
+}
+
+func (m *RevokedToken) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RevokedToken.Unmarshal(m, b)
+}
+func (m *RevokedToken) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RevokedToken.Marshal(b, m, deterministic)
+}
+func (m *RevokedToken) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RevokedToken.Merge(m, src)
+}
+func (m *RevokedToken) XXX_Size() int {
+	return xxx_messageInfo_RevokedToken.Size(m)
+}
+func (m *RevokedToken) XXX_DiscardUnknown() {
+	xxx_messageInfo_RevokedToken.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RevokedToken proto.InternalMessageInfo
+
+func (m *RevokedToken) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *RevokedToken) GetExpiresAt() *timestamp.Timestamp {
+	if m != nil {
+		return m.ExpiresAt
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*RevokedToken)(nil), "dbrecords.RevokedToken")
+}
+
+func init() {
+	proto.RegisterFile("pkg/db/internal/dbrecords/revoked_token.proto", fileDescriptor_2707b61b4c008ab7)
+}
+
+var fileDescriptor_2707b61b4c008ab7 = []byte{
+	// 162 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x44, 0xcc, 0xbf, 0x0a, 0xc2, 0x30,
+	0x10, 0x80, 0x71, 0xda, 0x41, 0x68, 0x14, 0x87, 0x4e, 0xa5, 0x8b, 0xc5, 0xa9, 0x8b, 0x09, 0xe8,
+	0xe2, 0xea, 0x2b, 0x94, 0x0e, 0x6e, 0xd2, 0x78, 0x67, 0x09, 0xfd, 0x73, 0xe1, 0x7a, 0x8a, 0x8f,
+	0x2f, 0x36, 0x54, 0xe7, 0xef, 0xe3, 0xa7, 0x0e, 0xbe, 0x6b, 0x0d, 0x58, 0xe3, 0x46, 0x41, 0x1e,
+	0x9b, 0xde, 0x80, 0x65, 0xbc, 0x13, 0xc3, 0x64, 0x18, 0x5f, 0xd4, 0x21, 0xdc, 0x84, 0x3a, 0x1c,
+	0xb5, 0x67, 0x12, 0x4a, 0x93, 0x5f, 0xce, 0x77, 0x2d, 0x51, 0xdb, 0xa3, 0x99, 0x83, 0x7d, 0x3e,
+	0x8c, 0xb8, 0x01, 0x27, 0x69, 0x06, 0x1f, 0xde, 0xfd, 0x55, 0x6d, 0xaa, 0x40, 0xd4, 0x5f, 0x21,
+	0xdd, 0xaa, 0xd8, 0x41, 0x16, 0x15, 0x51, 0x99, 0x54, 0xb1, 0x83, 0xf4, 0xac, 0x12, 0x7c, 0x7b,
+	0xc7, 0x38, 0x5d, 0x24, 0x8b, 0x8b, 0xa8, 0x5c, 0x1f, 0x73, 0x1d, 0x50, 0xbd, 0xa0, 0xba, 0x5e,
+	0xd0, 0xea, 0x3f, 0xdb, 0xd5, 0x9c, 0x4f, 0x9f, 0x01, 0x00, 0x66, 0x43, 0x01, 0x10, 0xbd, 0x00,
+	0x00, 0x00,
+}
@@ -4,10 +4,14 @@ import (
 	"crypto"
 	"crypto/x509"
 	"fmt"
+	"time"
 
 	"github.com/fhofherr/acmeproxy/pkg/acme"
 	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/fhofherr/acmeproxy/pkg/security"
 	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
 	"github.com/google/uuid"
 )
 
@@ -36,6 +40,12 @@ func (u *BinaryUnmarshaller) UnmarshalBinary(bs []byte) error {
 		u.unmarshalACMEUser(bs, obj)
 	case *acme.Domain:
 		u.unmarshalACMEDomain(bs, obj)
+	case *acme.Issuance:
+		u.unmarshalACMEIssuance(bs, obj)
+	case *security.RevokedToken:
+		u.unmarshalRevokedToken(bs, obj)
+	case *security.AuditEntry:
+		u.unmarshalAuditEntry(bs, obj)
 	case *uuid.UUID:
 		u.unmarshalUUID(bs, obj)
 	case *string:
@@ -78,6 +88,9 @@ func (u *BinaryUnmarshaller) unmarshalACMEUser(bs []byte, user *acme.User) {
 		user.AccountURL = rec.AccountURL
 		user.ID = id
 		user.Key = key
+		user.Email = rec.Email
+		user.Labels = rec.Labels
+		user.PublicKey = rec.PublicKeyPEM
 		return nil
 	})
 }
@@ -125,10 +138,98 @@ func (u *BinaryUnmarshaller) unmarshalACMEDomain(bs []byte, domain *acme.Domain)
 		domain.Name = rec.Name
 		domain.Certificate = rec.CertificatePEM
 		domain.PrivateKey = rec.PrivateKeyPEM
+		domain.OCSPResponse = u.unmarshalOCSPResponse(rec.OcspResponse)
+		domain.Revoked = rec.Revoked
 		return nil
 	})
 }
 
+func (u *BinaryUnmarshaller) unmarshalACMEIssuance(bs []byte, iss *acme.Issuance) {
+	const op errors.Op = "dbrecords/binaryUnmarshaller.unmarshalACMEIssuance"
+
+	u.do(func() error {
+		if iss == nil {
+			return errors.New(op, "issuance must not be nil")
+		}
+		var (
+			rec    Issuance
+			userID uuid.UUID
+		)
+		u.unmarshalPB(bs, &rec)
+		u.unmarshalUUID(rec.UserID, &userID)
+		iss.UserID = userID
+		iss.Domains = rec.Domains
+		iss.IssuedAt = u.unmarshalTime(rec.IssuedAt)
+		return nil
+	})
+}
+
+func (u *BinaryUnmarshaller) unmarshalRevokedToken(bs []byte, rt *security.RevokedToken) {
+	const op errors.Op = "dbrecords/binaryUnmarshaller.unmarshalRevokedToken"
+
+	u.do(func() error {
+		if rt == nil {
+			return errors.New(op, "revoked token must not be nil")
+		}
+		var rec RevokedToken
+		u.unmarshalPB(bs, &rec)
+		rt.ID = rec.Id
+		rt.ExpiresAt = u.unmarshalTime(rec.ExpiresAt)
+		return nil
+	})
+}
+
+func (u *BinaryUnmarshaller) unmarshalAuditEntry(bs []byte, e *security.AuditEntry) {
+	const op errors.Op = "dbrecords/binaryUnmarshaller.unmarshalAuditEntry"
+
+	u.do(func() error {
+		if e == nil {
+			return errors.New(op, "audit entry must not be nil")
+		}
+		var rec AuditEntry
+		u.unmarshalPB(bs, &rec)
+		e.Time = u.unmarshalTime(rec.Time)
+		e.Subject = rec.Subject
+		e.Roles = rec.Roles
+		e.TokenID = rec.TokenID
+		e.PeerAddr = rec.PeerAddr
+		e.Operation = rec.Operation
+		e.Resource = rec.Resource
+		e.Outcome = security.AuditOutcome(rec.Outcome)
+		return nil
+	})
+}
+
+func (u *BinaryUnmarshaller) unmarshalOCSPResponse(rec *Domain_OCSPResponse) acme.OCSPResponse {
+	if rec == nil {
+		return acme.OCSPResponse{}
+	}
+	return acme.OCSPResponse{
+		Raw:        rec.Raw,
+		Status:     acme.OCSPStatus(rec.Status),
+		ThisUpdate: u.unmarshalTime(rec.ThisUpdate),
+		NextUpdate: u.unmarshalTime(rec.NextUpdate),
+	}
+}
+
+func (u *BinaryUnmarshaller) unmarshalTime(ts *timestamp.Timestamp) time.Time {
+	const op errors.Op = "dbrecords/binaryUnmarshaller.unmarshalTime"
+
+	var t time.Time
+	if ts == nil {
+		return t
+	}
+	u.do(func() error {
+		var err error
+		t, err = ptypes.Timestamp(ts)
+		if err != nil {
+			return errors.New(op, err)
+		}
+		return nil
+	})
+	return t
+}
+
 func (u *BinaryUnmarshaller) unmarshalPB(bs []byte, msg proto.Message) {
 	const op errors.Op = "dbrecords/binaryUnmarshaller.unmarshalPB"
 
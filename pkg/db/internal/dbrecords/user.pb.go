@@ -21,12 +21,15 @@ var _ = math.Inf
 const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
 
 type User struct {
-	Id                   []byte           `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	AccountURL           string           `protobuf:"bytes,2,opt,name=accountURL,proto3" json:"accountURL,omitempty"`
-	AccountKey           *User_AccountKey `protobuf:"bytes,3,opt,name=accountKey,proto3" json:"accountKey,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}         `json:"-"`
-	XXX_unrecognized     []byte           `json:"-"`
-	XXX_sizecache        int32            `json:"-"`
+	Id                   []byte            `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	AccountURL           string            `protobuf:"bytes,2,opt,name=accountURL,proto3" json:"accountURL,omitempty"`
+	AccountKey           *User_AccountKey  `protobuf:"bytes,3,opt,name=accountKey,proto3" json:"accountKey,omitempty"`
+	Email                string            `protobuf:"bytes,4,opt,name=email,proto3" json:"email,omitempty"`
+	Labels               map[string]string `protobuf:"bytes,5,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	PublicKeyPEM         []byte            `protobuf:"bytes,6,opt,name=publicKeyPEM,proto3" json:"publicKeyPEM,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
 }
 
 func (m *User) Reset()         { *m = User{} }
@@ -75,6 +78,27 @@ func (m *User) GetAccountKey() *User_AccountKey {
 	return nil
 }
 
+func (m *User) GetEmail() string {
+	if m != nil {
+		return m.Email
+	}
+	return ""
+}
+
+func (m *User) GetLabels() map[string]string {
+	if m != nil {
+		return m.Labels
+	}
+	return nil
+}
+
+func (m *User) GetPublicKeyPEM() []byte {
+	if m != nil {
+		return m.PublicKeyPEM
+	}
+	return nil
+}
+
 type User_AccountKey struct {
 	KeyType              uint32   `protobuf:"varint,1,opt,name=keyType,proto3" json:"keyType,omitempty"`
 	KeyBytes             []byte   `protobuf:"bytes,2,opt,name=keyBytes,proto3" json:"keyBytes,omitempty"`
@@ -87,7 +111,7 @@ func (m *User_AccountKey) Reset()         { *m = User_AccountKey{} }
 func (m *User_AccountKey) String() string { return proto.CompactTextString(m) }
 func (*User_AccountKey) ProtoMessage()    {}
 func (*User_AccountKey) Descriptor() ([]byte, []int) {
-	return fileDescriptor_f0c8482e1488dd84, []int{0, 0}
+	return fileDescriptor_f0c8482e1488dd84, []int{0, 1}
 }
 
 func (m *User_AccountKey) XXX_Unmarshal(b []byte) error {
@@ -124,6 +148,7 @@ func (m *User_AccountKey) GetKeyBytes() []byte {
 
 func init() {
 	proto.RegisterType((*User)(nil), "dbrecords.User")
+	proto.RegisterMapType((map[string]string)(nil), "dbrecords.User.LabelsEntry")
 	proto.RegisterType((*User_AccountKey)(nil), "dbrecords.User.AccountKey")
 }
 
@@ -132,17 +157,23 @@ func init() {
 }
 
 var fileDescriptor_f0c8482e1488dd84 = []byte{
-	// 188 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0x52, 0x29, 0xc8, 0x4e, 0xd7,
-	0x4f, 0x49, 0xd2, 0xcf, 0xcc, 0x2b, 0x49, 0x2d, 0xca, 0x4b, 0xcc, 0xd1, 0x4f, 0x49, 0x2a, 0x4a,
-	0x4d, 0xce, 0x2f, 0x4a, 0x29, 0xd6, 0x2f, 0x2d, 0x4e, 0x2d, 0xd2, 0x2b, 0x28, 0xca, 0x2f, 0xc9,
-	0x17, 0xe2, 0x84, 0x8b, 0x2a, 0x6d, 0x63, 0xe4, 0x62, 0x09, 0x2d, 0x4e, 0x2d, 0x12, 0xe2, 0xe3,
-	0x62, 0xca, 0x4c, 0x91, 0x60, 0x54, 0x60, 0xd4, 0xe0, 0x09, 0x62, 0xca, 0x4c, 0x11, 0x92, 0xe3,
-	0xe2, 0x4a, 0x4c, 0x4e, 0xce, 0x2f, 0xcd, 0x2b, 0x09, 0x0d, 0xf2, 0x91, 0x60, 0x52, 0x60, 0xd4,
-	0xe0, 0x0c, 0x42, 0x12, 0x11, 0xb2, 0x82, 0xcb, 0x7b, 0xa7, 0x56, 0x4a, 0x30, 0x2b, 0x30, 0x6a,
-	0x70, 0x1b, 0x49, 0xe9, 0xc1, 0x0d, 0xd6, 0x03, 0x19, 0xaa, 0xe7, 0x08, 0x57, 0x11, 0x84, 0xa4,
-	0x5a, 0xca, 0x89, 0x8b, 0x0b, 0x21, 0x23, 0x24, 0xc1, 0xc5, 0x9e, 0x9d, 0x5a, 0x19, 0x52, 0x59,
-	0x90, 0x0a, 0xb6, 0x9e, 0x37, 0x08, 0xc6, 0x15, 0x92, 0xe2, 0xe2, 0xc8, 0x4e, 0xad, 0x74, 0xaa,
-	0x2c, 0x49, 0x2d, 0x06, 0xbb, 0x80, 0x27, 0x08, 0xce, 0x4f, 0x62, 0x03, 0x7b, 0xc5, 0x18, 0x10,
-	0x00, 0x00, 0xff, 0xff, 0x63, 0x9c, 0x1b, 0x64, 0xf2, 0x00, 0x00, 0x00,
+	// 276 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x5c, 0x90, 0xcf, 0x4a, 0xf3, 0x40,
+	0x14, 0xc5, 0x49, 0xd2, 0xe6, 0xfb, 0x72, 0x13, 0x45, 0x2e, 0x2e, 0x86, 0x08, 0x12, 0x8a, 0x8b,
+	0xac, 0x12, 0x68, 0x37, 0xda, 0x9d, 0x85, 0xae, 0x5a, 0x41, 0x06, 0xfb, 0x00, 0xf9, 0x73, 0x91,
+	0x90, 0x31, 0x09, 0x93, 0x44, 0x98, 0xb7, 0xf5, 0x51, 0xc4, 0x49, 0x1d, 0xa3, 0xcb, 0x73, 0xee,
+	0x39, 0x3f, 0xe6, 0x0c, 0xdc, 0x75, 0xf5, 0x6b, 0x5a, 0xe6, 0x69, 0xd5, 0x0c, 0x24, 0x9b, 0x4c,
+	0xa4, 0x65, 0x2e, 0xa9, 0x68, 0x65, 0xd9, 0xa7, 0x63, 0x4f, 0x32, 0xe9, 0x64, 0x3b, 0xb4, 0xe8,
+	0x19, 0x77, 0xf5, 0x61, 0xc3, 0xe2, 0xd4, 0x93, 0xc4, 0x4b, 0xb0, 0xab, 0x92, 0x59, 0x91, 0x15,
+	0x07, 0xdc, 0xae, 0x4a, 0xbc, 0x05, 0xc8, 0x8a, 0xa2, 0x1d, 0x9b, 0xe1, 0xc4, 0x8f, 0xcc, 0x8e,
+	0xac, 0xd8, 0xe3, 0x33, 0x07, 0xb7, 0xe6, 0x7e, 0x20, 0xc5, 0x9c, 0xc8, 0x8a, 0xfd, 0x75, 0x98,
+	0x18, 0x70, 0xf2, 0x05, 0x4d, 0x1e, 0x4d, 0x82, 0xcf, 0xd2, 0x78, 0x0d, 0x4b, 0x7a, 0xcb, 0x2a,
+	0xc1, 0x16, 0x1a, 0x3b, 0x09, 0xdc, 0x80, 0x2b, 0xb2, 0x9c, 0x44, 0xcf, 0x96, 0x91, 0x13, 0xfb,
+	0xeb, 0x9b, 0xbf, 0xb4, 0xa3, 0xbe, 0xee, 0x9b, 0x41, 0x2a, 0x7e, 0x8e, 0xe2, 0x0a, 0x82, 0x6e,
+	0xcc, 0x45, 0x55, 0x1c, 0x48, 0x3d, 0xef, 0x9f, 0x98, 0xab, 0x07, 0xfc, 0xf2, 0xc2, 0x07, 0xf0,
+	0x67, 0x55, 0xbc, 0x02, 0xa7, 0x26, 0xa5, 0xa7, 0x7a, 0xdc, 0xa9, 0xa7, 0xf7, 0xbc, 0x67, 0x62,
+	0xa4, 0xf3, 0xcc, 0x49, 0x6c, 0xed, 0x7b, 0x2b, 0xdc, 0x01, 0xfc, 0x6c, 0x40, 0x06, 0xff, 0x6a,
+	0x52, 0x2f, 0xaa, 0x23, 0xdd, 0xbe, 0xe0, 0xdf, 0x12, 0x43, 0xf8, 0x5f, 0x93, 0xda, 0xa9, 0x81,
+	0x7a, 0x0d, 0x09, 0xb8, 0xd1, 0xb9, 0xab, 0x3f, 0x7d, 0xf3, 0x39, 0x00, 0x67, 0x0b, 0x9f, 0x38,
+	0x9c, 0x01, 0x00, 0x00,
 }
@@ -0,0 +1,85 @@
+package db
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/fhofherr/acmeproxy/pkg/acme"
+	"github.com/fhofherr/acmeproxy/pkg/db/internal/dbrecords"
+	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/google/uuid"
+)
+
+type issuanceRepository struct {
+	BoltDB     *Bolt
+	BucketName string
+}
+
+// RecordIssuance stores iss in the bolt database.
+func (r *issuanceRepository) RecordIssuance(iss acme.Issuance) error {
+	const op errors.Op = "db/issuanceRepository.RecordIssuance"
+
+	nonce, err := uuid.NewRandom()
+	if err != nil {
+		return errors.New(op, "create key nonce", err)
+	}
+	key := timeKey{Time: iss.IssuedAt, Nonce: nonce}
+	err = r.BoltDB.updateBucket(r.BucketName, func(b *bucket) error {
+		b.writeRecord(key, &dbrecords.BinaryMarshaller{V: iss})
+		return nil
+	})
+	return errors.Wrap(err, op, "write issuance")
+}
+
+// ListIssuances reads all issuances recorded at or after since from the
+// bolt database. The issuances are ordered by the time they were issued.
+func (r *issuanceRepository) ListIssuances(since time.Time) ([]acme.Issuance, error) {
+	const op errors.Op = "db/issuanceRepository.ListIssuances"
+	var issuances []acme.Issuance
+
+	start, err := timeKey{Time: since}.MarshalBinary()
+	if err != nil {
+		return nil, errors.New(op, err)
+	}
+	err = r.BoltDB.viewBucket(r.BucketName, func(b *bucket) error {
+		b.forEachFrom(start, func(_, v []byte) error {
+			var iss acme.Issuance
+			if err := dbrecords.UnmarshalBinary(v, &iss); err != nil {
+				return err
+			}
+			issuances = append(issuances, iss)
+			return nil
+		})
+		return nil
+	})
+	if errors.IsKind(err, errors.NotFound) {
+		// The bucket does not exist yet. Thus there are no issuances.
+		return nil, nil
+	}
+	return issuances, errors.Wrap(err, op, "read issuances from bucket")
+}
+
+// PruneIssuances removes all issuances issued before the passed time from
+// the bolt database.
+func (r *issuanceRepository) PruneIssuances(before time.Time) error {
+	const op errors.Op = "db/issuanceRepository.PruneIssuances"
+
+	end, err := timeKey{Time: before}.MarshalBinary()
+	if err != nil {
+		return errors.New(op, err)
+	}
+	err = r.BoltDB.updateBucket(r.BucketName, func(b *bucket) error {
+		// Keys must not be deleted while iterating over them.
+		var keys [][]byte
+		b.forEachFrom(nil, func(k, _ []byte) error {
+			if bytes.Compare(k, end) >= 0 {
+				return errStopIteration
+			}
+			keys = append(keys, append([]byte(nil), k...))
+			return nil
+		})
+		b.deleteKeys(keys)
+		return nil
+	})
+	return errors.Wrap(err, op, "delete issuances")
+}
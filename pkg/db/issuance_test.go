@@ -0,0 +1,67 @@
+package db_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fhofherr/acmeproxy/pkg/acme"
+	"github.com/fhofherr/acmeproxy/pkg/db"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListIssuancesSince(t *testing.T) {
+	fx := db.NewTestFixture(t)
+	defer fx.Close()
+	issuanceRepository := fx.DB.IssuanceRepository()
+
+	issuances, err := issuanceRepository.ListIssuances(time.Unix(0, 0))
+	assert.NoError(t, err)
+	assert.Empty(t, issuances)
+
+	userID := uuid.Must(uuid.NewRandom())
+	now := time.Unix(1571385600, 0).UTC()
+	recorded := []acme.Issuance{
+		{UserID: userID, Domains: []string{"www.example.com"}, IssuedAt: now},
+		{UserID: userID, Domains: []string{"example.com"}, IssuedAt: now.Add(-48 * time.Hour)},
+		{UserID: userID, Domains: []string{"mail.example.com"}, IssuedAt: now.Add(-time.Hour)},
+		{UserID: userID, Domains: []string{"example.com", "www.example.com"}, IssuedAt: now.Add(-time.Hour)},
+	}
+	for _, iss := range recorded {
+		err := issuanceRepository.RecordIssuance(iss)
+		assert.NoError(t, err)
+	}
+
+	issuances, err = issuanceRepository.ListIssuances(now.Add(-24 * time.Hour))
+	assert.NoError(t, err)
+	if !assert.Len(t, issuances, 3) {
+		return
+	}
+	assert.ElementsMatch(t, recorded[2:], issuances[:2])
+	assert.Equal(t, recorded[0], issuances[2])
+}
+
+func TestPruneIssuances(t *testing.T) {
+	fx := db.NewTestFixture(t)
+	defer fx.Close()
+	issuanceRepository := fx.DB.IssuanceRepository()
+
+	userID := uuid.Must(uuid.NewRandom())
+	now := time.Unix(1571385600, 0).UTC()
+	recorded := []acme.Issuance{
+		{UserID: userID, Domains: []string{"www.example.com"}, IssuedAt: now.Add(-48 * time.Hour)},
+		{UserID: userID, Domains: []string{"example.com"}, IssuedAt: now.Add(-25 * time.Hour)},
+		{UserID: userID, Domains: []string{"mail.example.com"}, IssuedAt: now.Add(-time.Hour)},
+	}
+	for _, iss := range recorded {
+		err := issuanceRepository.RecordIssuance(iss)
+		assert.NoError(t, err)
+	}
+
+	err := issuanceRepository.PruneIssuances(now.Add(-24 * time.Hour))
+	assert.NoError(t, err)
+
+	issuances, err := issuanceRepository.ListIssuances(time.Unix(0, 0))
+	assert.NoError(t, err)
+	assert.Equal(t, recorded[2:], issuances)
+}
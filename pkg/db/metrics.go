@@ -0,0 +1,94 @@
+package db
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	boltFreePagesDesc = prometheus.NewDesc(
+		"acmeproxy_bolt_free_pages",
+		"Number of free pages on the freelist of the database.",
+		nil, nil,
+	)
+	boltPendingPagesDesc = prometheus.NewDesc(
+		"acmeproxy_bolt_pending_pages",
+		"Number of pending pages on the freelist of the database.",
+		nil, nil,
+	)
+	boltFreeAllocDesc = prometheus.NewDesc(
+		"acmeproxy_bolt_free_alloc_bytes",
+		"Bytes allocated in free pages of the database.",
+		nil, nil,
+	)
+	boltFreelistInuseDesc = prometheus.NewDesc(
+		"acmeproxy_bolt_freelist_inuse_bytes",
+		"Bytes used by the freelist of the database.",
+		nil, nil,
+	)
+	boltReadTxDesc = prometheus.NewDesc(
+		"acmeproxy_bolt_read_tx_total",
+		"Number of read transactions started.",
+		nil, nil,
+	)
+	boltOpenReadTxDesc = prometheus.NewDesc(
+		"acmeproxy_bolt_open_read_tx",
+		"Number of currently open read transactions.",
+		nil, nil,
+	)
+	boltWritesDesc = prometheus.NewDesc(
+		"acmeproxy_bolt_writes_total",
+		"Number of writes to disk performed by transactions.",
+		nil, nil,
+	)
+	boltWriteSecondsDesc = prometheus.NewDesc(
+		"acmeproxy_bolt_write_seconds_total",
+		"Time transactions spent writing to disk.",
+		nil, nil,
+	)
+	boltPageAllocDesc = prometheus.NewDesc(
+		"acmeproxy_bolt_page_alloc_bytes_total",
+		"Bytes allocated for pages by transactions.",
+		nil, nil,
+	)
+)
+
+// Describe implements prometheus.Collector.
+func (b *Bolt) Describe(ch chan<- *prometheus.Desc) {
+	ch <- boltFreePagesDesc
+	ch <- boltPendingPagesDesc
+	ch <- boltFreeAllocDesc
+	ch <- boltFreelistInuseDesc
+	ch <- boltReadTxDesc
+	ch <- boltOpenReadTxDesc
+	ch <- boltWritesDesc
+	ch <- boltWriteSecondsDesc
+	ch <- boltPageAllocDesc
+}
+
+// Collect implements prometheus.Collector. It reports the statistics of the
+// bolt database. Collect reports nothing if the database has not been
+// opened yet.
+func (b *Bolt) Collect(ch chan<- prometheus.Metric) {
+	b.mu.Lock()
+	db := b.db
+	b.mu.Unlock()
+	if db == nil {
+		return
+	}
+	stats := db.Stats()
+	gauge := func(desc *prometheus.Desc, v float64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, v)
+	}
+	counter := func(desc *prometheus.Desc, v float64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, v)
+	}
+	gauge(boltFreePagesDesc, float64(stats.FreePageN))
+	gauge(boltPendingPagesDesc, float64(stats.PendingPageN))
+	gauge(boltFreeAllocDesc, float64(stats.FreeAlloc))
+	gauge(boltFreelistInuseDesc, float64(stats.FreelistInuse))
+	counter(boltReadTxDesc, float64(stats.TxN))
+	gauge(boltOpenReadTxDesc, float64(stats.OpenTxN))
+	counter(boltWritesDesc, float64(stats.TxStats.Write))
+	counter(boltWriteSecondsDesc, stats.TxStats.WriteTime.Seconds())
+	counter(boltPageAllocDesc, float64(stats.TxStats.PageAlloc))
+}
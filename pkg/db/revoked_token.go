@@ -0,0 +1,59 @@
+package db
+
+import (
+	"github.com/fhofherr/acmeproxy/pkg/db/internal/dbrecords"
+	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/fhofherr/acmeproxy/pkg/security"
+)
+
+type revokedTokenRepository struct {
+	BoltDB     *Bolt
+	BucketName string
+}
+
+// SaveRevokedToken stores rt in the bolt database.
+func (r *revokedTokenRepository) SaveRevokedToken(rt security.RevokedToken) error {
+	const op errors.Op = "db/revokedTokenRepository.SaveRevokedToken"
+
+	err := r.BoltDB.updateBucket(r.BucketName, func(b *bucket) error {
+		b.writeRecord(&dbrecords.BinaryMarshaller{V: rt.ID}, &dbrecords.BinaryMarshaller{V: rt})
+		return nil
+	})
+	return errors.Wrap(err, op, "write revoked token")
+}
+
+// ListRevokedTokens reads all revoked tokens from the bolt database. The
+// tokens are ordered by their ID.
+func (r *revokedTokenRepository) ListRevokedTokens() ([]security.RevokedToken, error) {
+	const op errors.Op = "db/revokedTokenRepository.ListRevokedTokens"
+	var rts []security.RevokedToken
+
+	err := r.BoltDB.viewBucket(r.BucketName, func(b *bucket) error {
+		b.forEach(func(_, v []byte) error {
+			var rt security.RevokedToken
+			if err := dbrecords.UnmarshalBinary(v, &rt); err != nil {
+				return err
+			}
+			rts = append(rts, rt)
+			return nil
+		})
+		return nil
+	})
+	if errors.IsKind(err, errors.NotFound) {
+		// The bucket does not exist yet. Thus there are no revoked tokens.
+		return nil, nil
+	}
+	return rts, errors.Wrap(err, op, "read revoked tokens from bucket")
+}
+
+// DeleteRevokedToken removes the revoked token with the passed id from the
+// bolt database.
+func (r *revokedTokenRepository) DeleteRevokedToken(id string) error {
+	const op errors.Op = "db/revokedTokenRepository.DeleteRevokedToken"
+
+	err := r.BoltDB.updateBucket(r.BucketName, func(b *bucket) error {
+		b.deleteRecord(&dbrecords.BinaryMarshaller{V: id})
+		return nil
+	})
+	return errors.Wrap(err, op, "delete revoked token")
+}
@@ -0,0 +1,36 @@
+package db_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fhofherr/acmeproxy/pkg/db"
+	"github.com/fhofherr/acmeproxy/pkg/security"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSaveListAndDeleteRevokedTokens(t *testing.T) {
+	fx := db.NewTestFixture(t)
+	defer fx.Close()
+	repo := fx.DB.RevokedTokenRepository()
+
+	rts, err := repo.ListRevokedTokens()
+	assert.NoError(t, err)
+	assert.Empty(t, rts)
+
+	expected := []security.RevokedToken{
+		{ID: "token-1", ExpiresAt: time.Unix(1571385600, 0).UTC()},
+		{ID: "token-2"},
+	}
+	for _, rt := range expected {
+		assert.NoError(t, repo.SaveRevokedToken(rt))
+	}
+	rts, err = repo.ListRevokedTokens()
+	assert.NoError(t, err)
+	assert.Equal(t, expected, rts)
+
+	assert.NoError(t, repo.DeleteRevokedToken("token-1"))
+	rts, err = repo.ListRevokedTokens()
+	assert.NoError(t, err)
+	assert.Equal(t, expected[1:], rts)
+}
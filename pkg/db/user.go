@@ -1,6 +1,7 @@
 package db
 
 import (
+	"bytes"
 	"fmt"
 
 	"github.com/fhofherr/acmeproxy/pkg/acme"
@@ -51,3 +52,45 @@ func (r *userRepository) GetUser(id uuid.UUID) (acme.User, error) {
 	})
 	return user, errors.Wrap(err, op, fmt.Sprintf("get user: %v", id))
 }
+
+// ListUsers reads at most limit users whose id is greater than after from the
+// bolt database. If limit is zero ListUsers reads all those users. The users
+// are ordered by their id.
+func (r *userRepository) ListUsers(after uuid.UUID, limit int) ([]acme.User, error) {
+	const op errors.Op = "db/userRepository.ListUsers"
+	var users []acme.User
+
+	err := r.BoltDB.viewBucket(r.BucketName, func(b *bucket) error {
+		b.forEachFrom(after[:], func(k, v []byte) error {
+			if bytes.Equal(k, after[:]) {
+				return nil
+			}
+			if limit > 0 && len(users) >= limit {
+				return errStopIteration
+			}
+			var user acme.User
+			if err := dbrecords.UnmarshalBinary(v, &user); err != nil {
+				return err
+			}
+			users = append(users, user)
+			return nil
+		})
+		return nil
+	})
+	if errors.IsKind(err, errors.NotFound) {
+		// The bucket does not exist yet. Thus there are no users.
+		return nil, nil
+	}
+	return users, errors.Wrap(err, op, "read users from bucket")
+}
+
+// DeleteUser removes the user with the passed id from the bolt database.
+func (r *userRepository) DeleteUser(id uuid.UUID) error {
+	const op errors.Op = "db/userRepository.DeleteUser"
+
+	err := r.BoltDB.updateBucket(r.BucketName, func(b *bucket) error {
+		b.deleteRecord(&dbrecords.BinaryMarshaller{V: id})
+		return nil
+	})
+	return errors.Wrap(err, op, fmt.Sprintf("delete user: %v", id))
+}
@@ -160,3 +160,83 @@ func TestUpdateUser(t *testing.T) {
 		})
 	}
 }
+
+func TestListUsers(t *testing.T) {
+	keyFile := filepath.Join("testdata", t.Name(), "private_key.pem")
+	if *testsupport.FlagUpdate {
+		certutil.WritePrivateKeyForTesting(t, keyFile, certutil.EC256, true)
+	}
+	fx := db.NewTestFixture(t)
+	defer fx.Close()
+	userRepository := fx.DB.UserRepository()
+
+	users, err := userRepository.ListUsers(uuid.Nil, 0)
+	assert.NoError(t, err)
+	assert.Empty(t, users)
+
+	key := certutil.KeyMust(certutil.ReadPrivateKeyFromFile(certutil.EC256, keyFile, true))
+	ids := []uuid.UUID{
+		uuid.MustParse("3a0f5c1e-8a83-4c4e-9f5b-0f2b8c6f1d01"),
+		uuid.MustParse("0b3c1a2d-4e5f-4a6b-8c7d-9e0f1a2b3c4d"),
+		uuid.MustParse("7c8d9e0f-1a2b-4c3d-8e4f-5a6b7c8d9e0f"),
+	}
+	for _, id := range ids {
+		id := id
+		_, err := userRepository.UpdateUser(id, func(u *acme.User) error {
+			u.ID = id
+			u.Key = key
+			u.Labels = map[string]string{"team": "web"}
+			return nil
+		})
+		assert.NoError(t, err)
+	}
+
+	users, err = userRepository.ListUsers(uuid.Nil, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{ids[1], ids[0], ids[2]}, userIDs(users))
+
+	users, err = userRepository.ListUsers(uuid.Nil, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{ids[1], ids[0]}, userIDs(users))
+
+	users, err = userRepository.ListUsers(ids[0], 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{ids[2]}, userIDs(users))
+	assert.Equal(t, map[string]string{"team": "web"}, users[0].Labels)
+}
+
+func TestDeleteUser(t *testing.T) {
+	keyFile := filepath.Join("testdata", t.Name(), "private_key.pem")
+	if *testsupport.FlagUpdate {
+		certutil.WritePrivateKeyForTesting(t, keyFile, certutil.EC256, true)
+	}
+	fx := db.NewTestFixture(t)
+	defer fx.Close()
+	userRepository := fx.DB.UserRepository()
+
+	userID := uuid.Must(uuid.NewRandom())
+	_, err := userRepository.UpdateUser(userID, func(u *acme.User) error {
+		u.ID = userID
+		u.Key = certutil.KeyMust(certutil.ReadPrivateKeyFromFile(certutil.EC256, keyFile, true))
+		return nil
+	})
+	assert.NoError(t, err)
+
+	err = userRepository.DeleteUser(userID)
+	assert.NoError(t, err)
+	user, err := userRepository.GetUser(userID)
+	assert.NoError(t, err)
+	assert.True(t, user.IsZero())
+
+	// Deleting a missing user is not an error.
+	err = userRepository.DeleteUser(userID)
+	assert.NoError(t, err)
+}
+
+func userIDs(users []acme.User) []uuid.UUID {
+	ids := make([]uuid.UUID, len(users))
+	for i, u := range users {
+		ids[i] = u.ID
+	}
+	return ids
+}
@@ -3,6 +3,7 @@ package errors
 import (
 	"errors"
 	"strings"
+	"time"
 )
 
 // Is is a convenience wrapper for the Go standard library errors/Is function.
@@ -46,13 +47,24 @@ const (
 	NotFound
 
 	// Unauthorized signals that the caller of an operation was not allowed
-	// to execute this operation.
+	// to execute this operation, e.g. because it could not be
+	// authenticated.
 	Unauthorized
 
 	// InvalidArgument shows that an opperation was called with one or more
 	// invalid arguments. This usually indicates a client error. An HTTP API
 	// might return a status 400 Bad Request response.
 	InvalidArgument
+
+	// RateLimited signals that the caller exceeded a quota. The caller should
+	// not retry the operation before the quota resets. Errors of this Kind
+	// should carry the time the quota resets in their RetryAt field.
+	RateLimited
+
+	// PermissionDenied signals that the authenticated caller of an
+	// operation lacks the permission to execute it. An HTTP API might
+	// return a status 403 Forbidden response.
+	PermissionDenied
 )
 
 func (k Kind) String() string {
@@ -65,6 +77,10 @@ func (k Kind) String() string {
 		return "unauthorized"
 	case InvalidArgument:
 		return "invalid argument"
+	case RateLimited:
+		return "rate limited"
+	case PermissionDenied:
+		return "permission denied"
 	default:
 		return "unknown kind"
 	}
@@ -91,8 +107,20 @@ type Error struct {
 	// Err references an error which led to this error being returned. Err
 	// is used to build a trace of errors.
 	Err error
+
+	// RetryAt is the earliest time at which the failed operation should be
+	// retried. It is the zero time if it is unknown when, or if, the
+	// operation may be retried.
+	RetryAt time.Time
+
+	// RequestID identifies the request during which the error occurred. It
+	// allows to correlate the error with the log entries of the request.
+	RequestID string
 }
 
+// RequestID is the type of the request ID arguments accepted by New.
+type RequestID string
+
 // New creates a new Error.
 //
 // It accepts an arbitrary number of arguments of the following types:
@@ -113,6 +141,10 @@ type Error struct {
 //         follow the usual Go conventions for error messages, i.e. it should
 //         start with a lower case letter and be relatively short but
 //         meaningful.
+//     time.Time
+//         The earliest time at which the operation should be retried.
+//     RequestID
+//         The ID of the request during which the error occurred.
 //
 // If more than one argument of the above types is passed, the first passed wins.
 // Arguments of other types than the above are silently ignored.
@@ -135,6 +167,10 @@ func New(args ...interface{}) error {
 			err.Err = v
 		case string:
 			err.Msg = v
+		case time.Time:
+			err.RetryAt = v
+		case RequestID:
+			err.RequestID = string(v)
 		}
 	}
 	return err
@@ -167,6 +203,11 @@ func (e *Error) Error() string {
 		sep(sb)
 		sb.WriteString(e.Msg)
 	}
+	if !e.RetryAt.IsZero() {
+		sep(sb)
+		sb.WriteString("retry at ")
+		sb.WriteString(e.RetryAt.Format(time.RFC3339))
+	}
 	if e.Err != nil {
 		sep(sb)
 		sb.WriteString(e.Err.Error())
@@ -235,6 +276,12 @@ func (e *Error) Is(target error) bool {
 	if other.Msg != "" && other.Msg != e.Msg {
 		return false
 	}
+	if !other.RetryAt.IsZero() && !other.RetryAt.Equal(e.RetryAt) {
+		return false
+	}
+	if other.RequestID != "" && other.RequestID != e.RequestID {
+		return false
+	}
 	return true
 }
 
@@ -256,3 +303,50 @@ func GetKind(err error) Kind {
 func IsKind(err error, kind Kind) bool {
 	return GetKind(err) == kind
 }
+
+// GetRetryAt returns the earliest time at which the operation that failed with
+// err should be retried. It returns the first non-zero RetryAt found in the
+// chain of errors, or the zero time if there is none.
+func GetRetryAt(err error) time.Time {
+	var acpErr *Error
+
+	if err == nil || !As(err, &acpErr) {
+		return time.Time{}
+	}
+	if acpErr.RetryAt.IsZero() {
+		return GetRetryAt(acpErr.Err)
+	}
+	return acpErr.RetryAt
+}
+
+// GetRequestID returns the ID of the request during which err occurred. It
+// returns the first non-empty RequestID found in the chain of errors, or the
+// empty string if there is none.
+func GetRequestID(err error) string {
+	var acpErr *Error
+
+	if err == nil || !As(err, &acpErr) {
+		return ""
+	}
+	if acpErr.RequestID == "" {
+		return GetRequestID(acpErr.Err)
+	}
+	return acpErr.RequestID
+}
+
+// WithRequestID attaches the request ID id to err. It returns err unchanged if
+// err is nil, id is empty, or err already carries a request ID.
+//
+// If err is an *Error WithRequestID returns a copy of err with the RequestID
+// field set. Otherwise it wraps err into a new Error.
+func WithRequestID(err error, id string) error {
+	if err == nil || id == "" || GetRequestID(err) != "" {
+		return err
+	}
+	if acpErr, ok := err.(*Error); ok {
+		cpy := *acpErr
+		cpy.RequestID = id
+		return &cpy
+	}
+	return New(RequestID(id), err)
+}
@@ -3,6 +3,7 @@ package errors_test
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/fhofherr/acmeproxy/pkg/errors"
 	"github.com/stretchr/testify/assert"
@@ -17,6 +18,8 @@ func TestKindToString(t *testing.T) {
 		{errors.NotFound, "not found"},
 		{errors.Unauthorized, "unauthorized"},
 		{errors.InvalidArgument, "invalid argument"},
+		{errors.RateLimited, "rate limited"},
+		{errors.PermissionDenied, "permission denied"},
 		{errors.Kind(-1), "unknown kind"},
 	}
 	for _, tt := range tests {
@@ -66,6 +69,28 @@ func TestNewError(t *testing.T) {
 				Kind: errors.NotFound,
 			},
 		},
+		{
+			name: "rate limited with retry time",
+			args: []interface{}{
+				errors.RateLimited,
+				time.Date(2019, 10, 18, 12, 0, 0, 0, time.UTC),
+			},
+			expected: &errors.Error{
+				Kind:    errors.RateLimited,
+				RetryAt: time.Date(2019, 10, 18, 12, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "op and request id",
+			args: []interface{}{
+				errors.Op("some op"),
+				errors.RequestID("some-request"),
+			},
+			expected: &errors.Error{
+				Op:        "some op",
+				RequestID: "some-request",
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -144,6 +169,15 @@ func TestError_Error(t *testing.T) {
 			},
 			expected: "not found: something",
 		},
+		{
+			name: "error with retry time",
+			err: &errors.Error{
+				Kind:    errors.RateLimited,
+				Msg:     "quota exceeded",
+				RetryAt: time.Date(2019, 10, 18, 12, 0, 0, 0, time.UTC),
+			},
+			expected: "rate limited: quota exceeded: retry at 2019-10-18T12:00:00Z",
+		},
 	}
 	for _, tt := range tests {
 		tt := tt
@@ -244,6 +278,26 @@ func TestError_Is(t *testing.T) {
 			},
 			expected: true,
 		},
+		{
+			name: "retry time does not match",
+			err: &errors.Error{
+				RetryAt: time.Date(2019, 10, 18, 12, 0, 0, 0, time.UTC),
+			},
+			target: &errors.Error{
+				RetryAt: time.Date(2019, 10, 18, 13, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "retry time matches",
+			err: &errors.Error{
+				Kind:    errors.RateLimited,
+				RetryAt: time.Date(2019, 10, 18, 12, 0, 0, 0, time.UTC),
+			},
+			target: &errors.Error{
+				RetryAt: time.Date(2019, 10, 18, 12, 0, 0, 0, time.UTC),
+			},
+			expected: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -326,6 +380,84 @@ func TestGetKind(t *testing.T) {
 	}
 }
 
+func TestGetRetryAt(t *testing.T) {
+	retryAt := time.Date(2019, 10, 18, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		err      error
+		expected time.Time
+	}{
+		{
+			name: "errors other than errors.Error have no retry time",
+			err:  fmt.Errorf("not one of our errors"),
+		},
+		{
+			name: "no retry time",
+			err:  errors.New(errors.NotFound),
+		},
+		{
+			name:     "retry time of nested error",
+			err:      errors.New("outer", errors.New(errors.RateLimited, retryAt)),
+			expected: retryAt,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, errors.GetRetryAt(tt.err))
+		})
+	}
+}
+
+func TestWithRequestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		id       string
+		expected error
+	}{
+		{
+			name: "nil error",
+			id:   "some-request",
+		},
+		{
+			name:     "empty id",
+			err:      errors.New(errors.Op("some op")),
+			expected: errors.New(errors.Op("some op")),
+		},
+		{
+			name:     "sets request id of error",
+			err:      errors.New(errors.Op("some op"), errors.NotFound),
+			id:       "some-request",
+			expected: errors.New(errors.Op("some op"), errors.NotFound, errors.RequestID("some-request")),
+		},
+		{
+			name:     "wraps other errors",
+			err:      fmt.Errorf("not one of our errors"),
+			id:       "some-request",
+			expected: errors.New(errors.RequestID("some-request"), fmt.Errorf("not one of our errors")),
+		},
+		{
+			name:     "keeps request id of nested error",
+			err:      errors.New("outer", errors.New(errors.RequestID("first-request"))),
+			id:       "some-request",
+			expected: errors.New("outer", errors.New(errors.RequestID("first-request"))),
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			actual := errors.WithRequestID(tt.err, tt.id)
+			assert.Equal(t, tt.expected, actual)
+			if tt.err != nil && tt.id != "" {
+				assert.NotEmpty(t, errors.GetRequestID(actual))
+			}
+		})
+	}
+}
+
 func TestTrace(t *testing.T) {
 	tests := []struct {
 		name     string
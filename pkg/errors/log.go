@@ -8,6 +8,16 @@ import (
 
 // Log logs the passed error to logger. Does nothing if logger or error is
 // nil.
+//
+// The level of the log entry depends on the Kind of err. Errors of Kind
+// NotFound are logged at level "info". Errors caused by the caller, i.e. of
+// Kind InvalidArgument, Unauthorized, PermissionDenied, or RateLimited, are
+// logged at level "warn". All other errors are logged at level "error".
+//
+// If err is an Error Log adds its trace as a list of structured frames
+// under the key "trace". Each frame contains the Op, Kind, and Msg of an
+// Error in the chain, as far as they are set. The first error in the chain
+// which is not an Error is logged under the key "cause".
 func Log(logger log.Logger, err error) {
 	var acpErr *Error
 
@@ -19,12 +29,73 @@ func Log(logger log.Logger, err error) {
 		log.Log(logger, "level", "error", "message", err.Error(), "error", err)
 		return
 	}
-	// TODO determine "level" of error based on kind
-	log.Log(logger,
-		"level", "error",
+	kvs := []interface{}{
+		"level", LogLevel(err),
 		"message", acpErr.Msg,
-		"trace", acpErr.Trace(),
-		"error", err)
+		"trace", acpErr.frames(),
+	}
+	if kind := GetKind(err); kind != Unspecified {
+		kvs = append(kvs, "kind", kind.String())
+	}
+	if cause := acpErr.cause(); cause != nil {
+		kvs = append(kvs, "cause", cause.Error())
+	}
+	if id := GetRequestID(err); id != "" {
+		kvs = append(kvs, "request_id", id)
+	}
+	log.Log(logger, kvs...)
+}
+
+// LogLevel returns the level Log uses for err.
+func LogLevel(err error) string {
+	switch GetKind(err) {
+	case NotFound:
+		return "info"
+	case InvalidArgument, Unauthorized, PermissionDenied, RateLimited:
+		return "warn"
+	default:
+		return "error"
+	}
+}
+
+// frames returns the Op, Kind, and Msg of every Error in the chain of e in
+// the order of Trace.
+func (e *Error) frames() []map[string]string {
+	var (
+		frames []map[string]string
+		cur    = e
+	)
+
+	for cur != nil {
+		frame := map[string]string{"op": "unknown"}
+		if cur.Op != "" {
+			frame["op"] = string(cur.Op)
+		}
+		if cur.Kind != Unspecified {
+			frame["kind"] = cur.Kind.String()
+		}
+		if cur.Msg != "" {
+			frame["msg"] = cur.Msg
+		}
+		frames = append(frames, frame)
+		if !As(cur.Err, &cur) {
+			break
+		}
+	}
+	return frames
+}
+
+// cause returns the error referenced by the last Error in the chain of e,
+// or nil if there is none.
+func (e *Error) cause() error {
+	cur := e
+	for {
+		var next *Error
+		if !As(cur.Err, &next) {
+			return cur.Err
+		}
+		cur = next
+	}
 }
 
 // LogFunc calles the passed function f. Any error returned by fis logged using
@@ -11,13 +11,16 @@ import (
 
 func TestLog(t *testing.T) {
 	tests := []struct {
-		name     string
-		logger   *log.TestLogger
-		err      error
-		nEntries int
-		level    string
-		message  string
-		trace    []errors.Op
+		name      string
+		logger    *log.TestLogger
+		err       error
+		nEntries  int
+		level     string
+		message   string
+		trace     []map[string]string
+		kind      string
+		cause     string
+		requestID string
 	}{
 		{
 			name:   "nil error",
@@ -38,10 +41,52 @@ func TestLog(t *testing.T) {
 			nEntries: 1,
 			level:    "error",
 			message:  "some error",
-			trace: []errors.Op{
-				errors.Op("some op"),
+			trace: []map[string]string{
+				{"op": "some op", "msg": "some error"},
 			},
 		},
+		{
+			name:   "not found error",
+			logger: &log.TestLogger{},
+			err: errors.New(errors.Op("outer op"), "outer error",
+				errors.New(errors.Op("inner op"), errors.NotFound, fmt.Errorf("no such thing"))),
+			nEntries: 1,
+			level:    "info",
+			message:  "outer error",
+			trace: []map[string]string{
+				{"op": "outer op", "msg": "outer error"},
+				{"op": "inner op", "kind": "not found"},
+			},
+			kind:  "not found",
+			cause: "no such thing",
+		},
+		{
+			name:     "invalid argument error",
+			logger:   &log.TestLogger{},
+			err:      errors.New(errors.Op("some op"), errors.InvalidArgument, "some error"),
+			nEntries: 1,
+			level:    "warn",
+			message:  "some error",
+			kind:     "invalid argument",
+		},
+		{
+			name:     "permission denied error",
+			logger:   &log.TestLogger{},
+			err:      errors.New(errors.Op("some op"), errors.PermissionDenied, "some error"),
+			nEntries: 1,
+			level:    "warn",
+			message:  "some error",
+			kind:     "permission denied",
+		},
+		{
+			name:      "custom error with request id",
+			logger:    &log.TestLogger{},
+			err:       errors.New(errors.Op("some op"), errors.RequestID("some-request"), "some error"),
+			nEntries:  1,
+			level:     "error",
+			message:   "some error",
+			requestID: "some-request",
+		},
 	}
 
 	for _, tt := range tests {
@@ -53,7 +98,11 @@ func TestLog(t *testing.T) {
 				if tt.trace != nil {
 					traceMatches = assert.ObjectsAreEqual(tt.trace, e["trace"])
 				}
-				return e["level"] == tt.level && e["message"] == tt.message && traceMatches
+				kindMatches := tt.kind == "" || e["kind"] == tt.kind
+				causeMatches := tt.cause == "" || e["cause"] == tt.cause
+				requestIDMatches := tt.requestID == "" || e["request_id"] == tt.requestID
+				return e["level"] == tt.level && e["message"] == tt.message &&
+					traceMatches && kindMatches && causeMatches && requestIDMatches
 			})
 		})
 	}
@@ -92,15 +141,9 @@ func TestLogFunc(t *testing.T) {
 			logger:   &log.TestLogger{},
 			nEntries: 1,
 			pred: func(e log.TestLogEntry) bool {
-				maybeErr, found := e["error"]
-				if !found {
-					return false
-				}
-				err, ok := maybeErr.(*errors.Error)
-				if !ok {
-					return false
-				}
-				return e["level"] == "error" && errors.Is(err, errors.New(errors.Op("test-op"), "something failed"))
+				trace := []map[string]string{{"op": "test-op", "msg": "something failed"}}
+				return e["level"] == "error" && e["message"] == "something failed" &&
+					assert.ObjectsAreEqual(trace, e["trace"])
 			},
 		},
 	}
@@ -0,0 +1,7 @@
+// Package logging creates the loggers used by the components of acmeproxy.
+//
+// All loggers share the same output and format. Each component may log at
+// its own level, which allows to e.g. enable debug messages of lego without
+// enabling them for acmeproxy as a whole. The log entries of a component
+// carry its name under the key "component".
+package logging
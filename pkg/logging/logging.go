@@ -0,0 +1,199 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/fhofherr/golf-zap/golfzap"
+	"github.com/fhofherr/golf/log"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// FormatJSON writes every log entry as JSON object on a line of its own.
+	FormatJSON = "json"
+
+	// FormatConsole writes every log entry as human readable line.
+	FormatConsole = "console"
+
+	// ComponentLego is the component logging the messages of the lego ACME
+	// library.
+	ComponentLego = "lego"
+
+	// ComponentGRPC is the component logging the calls of the gRPC API.
+	ComponentGRPC = "grpc"
+
+	// ComponentAgent is the component logging the certificates obtained
+	// from the ACME CA and the challenges presented to it.
+	ComponentAgent = "agent"
+
+	componentKey = "component"
+)
+
+// Components contains the names of all components which may log at their
+// own level.
+var Components = []string{ComponentLego, ComponentGRPC, ComponentAgent}
+
+// Config configures the loggers.
+type Config struct {
+	Level           string            // One of debug, info, warn, or error; info if empty.
+	Format          string            // One of the Format constants; FormatJSON if empty.
+	ComponentLevels map[string]string // Levels of individual components; Level if missing.
+	Writer          io.Writer         // Destination of the log entries; os.Stderr if nil.
+}
+
+// Loggers creates the loggers of the components of acmeproxy.
+type Loggers struct {
+	zapLogger *zap.Logger
+	level     zapcore.Level
+	levels    map[string]zapcore.Level
+}
+
+// New creates Loggers as configured by cfg.
+func New(cfg Config) (*Loggers, error) {
+	const op errors.Op = "logging/New"
+
+	level, err := parseLevel(cfg.Level)
+	if err != nil {
+		return nil, errors.New(op, err)
+	}
+	l := &Loggers{
+		level:  level,
+		levels: make(map[string]zapcore.Level, len(cfg.ComponentLevels)),
+	}
+	minLevel := level
+	for component, s := range cfg.ComponentLevels {
+		if !isComponent(component) {
+			return nil, errors.New(op, errors.InvalidArgument, fmt.Sprintf("unknown component: %s", component))
+		}
+		lvl, err := parseLevel(s)
+		if err != nil {
+			return nil, errors.New(op, fmt.Sprintf("component %s", component), err)
+		}
+		l.levels[component] = lvl
+		if lvl < minLevel {
+			minLevel = lvl
+		}
+	}
+
+	encCfg := zap.NewProductionEncoderConfig()
+	var enc zapcore.Encoder
+	switch cfg.Format {
+	case "", FormatJSON:
+		enc = zapcore.NewJSONEncoder(encCfg)
+	case FormatConsole:
+		encCfg.EncodeTime = zapcore.ISO8601TimeEncoder
+		encCfg.EncodeLevel = zapcore.CapitalLevelEncoder
+		enc = zapcore.NewConsoleEncoder(encCfg)
+	default:
+		return nil, errors.New(op, errors.InvalidArgument, fmt.Sprintf("unknown format: %s", cfg.Format))
+	}
+	w := cfg.Writer
+	if w == nil {
+		w = os.Stderr
+	}
+	// The core has to accept the lowest of all levels. Each logger filters
+	// the entries according to its own level.
+	core := zapcore.NewCore(enc, zapcore.Lock(zapcore.AddSync(w)), minLevel)
+	l.zapLogger = zap.New(core, zap.AddCaller(), zap.AddStacktrace(zapcore.ErrorLevel))
+	return l, nil
+}
+
+// Logger returns the logger of everything that is not a component.
+func (l *Loggers) Logger() log.Logger {
+	return golfzap.New(l.zapLogger.WithOptions(withLevel(l.level)))
+}
+
+// Component returns the logger of the named component. It uses the level of
+// the component if one was configured.
+func (l *Loggers) Component(name string) log.Logger {
+	level, ok := l.levels[name]
+	if !ok {
+		level = l.level
+	}
+	zapLogger := l.zapLogger.WithOptions(withLevel(level)).With(zap.String(componentKey, name))
+	return golfzap.New(zapLogger)
+}
+
+// Sync flushes all buffered log entries.
+func (l *Loggers) Sync() error {
+	const op errors.Op = "logging/Loggers.Sync"
+
+	return errors.Wrap(l.zapLogger.Sync(), op)
+}
+
+// ParseComponentLevels parses a list of component levels of the form
+// 'component=level', e.g. 'lego=debug'.
+func ParseComponentLevels(specs []string) (map[string]string, error) {
+	const op errors.Op = "logging/ParseComponentLevels"
+
+	levels := make(map[string]string, len(specs))
+	for _, spec := range specs {
+		parts := strings.SplitN(spec, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, errors.New(op, errors.InvalidArgument, fmt.Sprintf("invalid component level: %s", spec))
+		}
+		levels[parts[0]] = parts[1]
+	}
+	return levels, nil
+}
+
+func parseLevel(s string) (zapcore.Level, error) {
+	const op errors.Op = "logging/parseLevel"
+
+	switch s {
+	case "":
+		return zapcore.InfoLevel, nil
+	case "debug":
+		return zapcore.DebugLevel, nil
+	case "info":
+		return zapcore.InfoLevel, nil
+	case "warn":
+		return zapcore.WarnLevel, nil
+	case "error":
+		return zapcore.ErrorLevel, nil
+	default:
+		return zapcore.InfoLevel, errors.New(op, errors.InvalidArgument, fmt.Sprintf("unknown level: %s", s))
+	}
+}
+
+func isComponent(name string) bool {
+	for _, c := range Components {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
+
+// withLevel restricts a logger to entries at or above level.
+func withLevel(level zapcore.Level) zap.Option {
+	return zap.WrapCore(func(c zapcore.Core) zapcore.Core {
+		return &levelCore{Core: c, level: level}
+	})
+}
+
+// levelCore drops all entries below level before passing them on to Core.
+type levelCore struct {
+	zapcore.Core
+	level zapcore.Level
+}
+
+func (c *levelCore) Enabled(lvl zapcore.Level) bool {
+	return c.level.Enabled(lvl) && c.Core.Enabled(lvl)
+}
+
+func (c *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelCore{Core: c.Core.With(fields), level: c.level}
+}
+
+func (c *levelCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.level.Enabled(ent.Level) {
+		return ce
+	}
+	return c.Core.Check(ent, ce)
+}
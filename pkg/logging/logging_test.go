@@ -0,0 +1,109 @@
+package logging_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/fhofherr/acmeproxy/pkg/logging"
+	"github.com/fhofherr/golf/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew_InvalidConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  logging.Config
+	}{
+		{
+			name: "unknown level",
+			cfg:  logging.Config{Level: "verbose"},
+		},
+		{
+			name: "unknown format",
+			cfg:  logging.Config{Format: "xml"},
+		},
+		{
+			name: "unknown component",
+			cfg:  logging.Config{ComponentLevels: map[string]string{"dns": "debug"}},
+		},
+		{
+			name: "unknown component level",
+			cfg:  logging.Config{ComponentLevels: map[string]string{logging.ComponentLego: "verbose"}},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := logging.New(tt.cfg)
+			errors.AssertMatches(t, errors.New(errors.InvalidArgument), err)
+		})
+	}
+}
+
+func TestLoggers_ComponentLevels(t *testing.T) {
+	var buf bytes.Buffer
+	loggers, err := logging.New(logging.Config{
+		Level: "warn",
+		ComponentLevels: map[string]string{
+			logging.ComponentLego: "debug",
+			logging.ComponentGRPC: "error",
+		},
+		Writer: &buf,
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	log.Log(loggers.Logger(), "level", "info", "message", "server info")
+	log.Log(loggers.Logger(), "level", "warn", "message", "server warn")
+	log.Log(loggers.Component(logging.ComponentLego), "level", "debug", "message", "lego debug")
+	log.Log(loggers.Component(logging.ComponentGRPC), "level", "warn", "message", "grpc warn")
+	log.Log(loggers.Component(logging.ComponentAgent), "level", "warn", "message", "agent warn")
+	assert.NoError(t, loggers.Sync())
+
+	var entries []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry map[string]interface{}
+		if !assert.NoError(t, json.Unmarshal([]byte(line), &entry)) {
+			return
+		}
+		entries = append(entries, entry)
+	}
+	if !assert.Len(t, entries, 3) {
+		return
+	}
+	assert.Equal(t, "server warn", entries[0]["msg"])
+	assert.NotContains(t, entries[0], "component")
+	assert.Equal(t, "lego debug", entries[1]["msg"])
+	assert.Equal(t, logging.ComponentLego, entries[1]["component"])
+	assert.Equal(t, "agent warn", entries[2]["msg"])
+	assert.Equal(t, logging.ComponentAgent, entries[2]["component"])
+}
+
+func TestLoggers_ConsoleFormat(t *testing.T) {
+	var buf bytes.Buffer
+	loggers, err := logging.New(logging.Config{Format: logging.FormatConsole, Writer: &buf})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	errors.Log(loggers.Logger(), errors.New(errors.Op("some op"), errors.NotFound, "some error"))
+	assert.NoError(t, loggers.Sync())
+
+	line := buf.String()
+	assert.Contains(t, line, "INFO")
+	assert.Contains(t, line, "some error")
+	assert.Contains(t, line, `"trace": [{"kind":"not found","msg":"some error","op":"some op"}]`)
+}
+
+func TestParseComponentLevels(t *testing.T) {
+	levels, err := logging.ParseComponentLevels([]string{"lego=debug", "grpc=warn"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"lego": "debug", "grpc": "warn"}, levels)
+
+	_, err = logging.ParseComponentLevels([]string{"lego"})
+	errors.AssertMatches(t, errors.New(errors.InvalidArgument), err)
+}
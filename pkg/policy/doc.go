@@ -0,0 +1,7 @@
+// Package policy decides which domain names acmeproxy's users may obtain
+// certificates for.
+//
+// A Policy consists of a list of denied names and of Rules. The Rules
+// applying to a request are selected based on the user's ID, or based on the
+// roles in the claims of the user's token.
+package policy
@@ -0,0 +1,358 @@
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+
+	"github.com/fhofherr/acmeproxy/pkg/api/auth"
+	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/google/uuid"
+	"golang.org/x/net/idna"
+)
+
+const (
+	// maxLabelLength is the maximum length of a single label of a domain
+	// name.
+	maxLabelLength = 63
+
+	// maxDomainNameLength is the maximum length of a domain name in its
+	// ASCII form as defined by RFC 1035.
+	maxDomainNameLength = 253
+)
+
+// Rules restrict the domain names a user may obtain certificates for.
+//
+// The zero value of Rules permits any well-formed domain name except
+// wildcard names.
+type Rules struct {
+	// AllowedSuffixes lists the domains below which users may register
+	// domain names. A suffix matches the domain itself as well as all its
+	// sub-domains. If AllowedSuffixes is empty any suffix is allowed.
+	AllowedSuffixes []string `json:"allowedSuffixes,omitempty"`
+
+	// AllowWildcards permits wildcard domain names like *.example.com.
+	AllowWildcards bool `json:"allowWildcards,omitempty"`
+
+	// MaxSANs limits the number of domain names in a single certificate. If
+	// MaxSANs is zero the number is not limited.
+	MaxSANs int `json:"maxSANs,omitempty"`
+}
+
+// Policy decides which domain names users may obtain certificates for.
+//
+// Policy selects the Rules for a request as follows: If Users contains Rules
+// for the user those are used. Otherwise the Rules of all roles contained in
+// the claims of the user's token are used; a request is permitted if the Rules
+// of at least one role permit it. If neither applies, Default is used.
+//
+// Requests without claims are checked against the Rules of the user, or
+// Default if there are none. Rules of roles never apply to them.
+//
+// DeniedNames are never permitted, regardless of the Rules. A denied name of
+// the form *.example.com denies all names below example.com.
+//
+// Policy normalizes all domain names before checking them. Normalization
+// converts internationalized domain names to their punycode form, converts
+// the name to lower case, and removes a trailing dot. Load normalizes the
+// configured DeniedNames and AllowedSuffixes in the same way. A Policy
+// created by other means must only contain normalized entries.
+type Policy struct {
+	DeniedNames []string            `json:"deniedNames,omitempty"`
+	Default     Rules               `json:"default"`
+	Users       map[uuid.UUID]Rules `json:"users,omitempty"`
+	Roles       map[auth.Role]Rules `json:"roles,omitempty"`
+}
+
+// Load reads a JSON encoded Policy from r.
+//
+// Load normalizes the DeniedNames of the Policy and the AllowedSuffixes of
+// all its Rules. It returns an error of kind InvalidArgument if any of them
+// is malformed.
+func Load(r io.Reader) (*Policy, error) {
+	const op errors.Op = "policy/Load"
+
+	var p Policy
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&p); err != nil {
+		return nil, errors.New(op, errors.InvalidArgument, "decode policy", err)
+	}
+	if err := p.normalizeEntries(); err != nil {
+		return nil, errors.New(op, err)
+	}
+	return &p, nil
+}
+
+// normalizeEntries replaces the DeniedNames of p and the AllowedSuffixes of
+// all its Rules by their normalized form.
+func (p *Policy) normalizeEntries() error {
+	const op errors.Op = "policy/policy.normalizeEntries"
+
+	deniedNames, err := normalizeAll(p.DeniedNames, true)
+	if err != nil {
+		return errors.New(op, "denied names", err)
+	}
+	p.DeniedNames = deniedNames
+	if err := p.Default.normalizeEntries(); err != nil {
+		return errors.New(op, "default rules", err)
+	}
+	for userID, rules := range p.Users {
+		if err := rules.normalizeEntries(); err != nil {
+			return errors.New(op, fmt.Sprintf("rules of user: %v", userID), err)
+		}
+		p.Users[userID] = rules
+	}
+	for role, rules := range p.Roles {
+		if err := rules.normalizeEntries(); err != nil {
+			return errors.New(op, fmt.Sprintf("rules of role: %s", role), err)
+		}
+		p.Roles[role] = rules
+	}
+	return nil
+}
+
+// CheckDomains checks if the user identified by userID may obtain a single
+// certificate for all of domainNames. The claims of the user are taken from
+// ctx.
+//
+// CheckDomains returns the normalized domain names if the user may obtain the
+// certificate. It returns an error of kind InvalidArgument if a domain name is
+// malformed or the certificate would contain too many names. It returns an
+// error of kind Unauthorized if the Rules do not permit a domain name.
+func (p *Policy) CheckDomains(ctx context.Context, userID uuid.UUID, domainNames []string) ([]string, error) {
+	const op errors.Op = "policy/policy.CheckDomains"
+
+	names, err := p.normalize(domainNames)
+	if err != nil {
+		return nil, errors.New(op, err)
+	}
+	for _, rules := range p.rulesFor(ctx, userID) {
+		if err = rules.check(names); err == nil {
+			return names, nil
+		}
+	}
+	return nil, errors.New(op, err)
+}
+
+// CheckTransfer checks if the already registered domainNames may be
+// transferred to the user identified by userID.
+//
+// The domain names were admitted when they were registered, possibly by the
+// Rules of a role. The roles of the receiving user are unknown, though.
+// CheckTransfer thus only applies Rules configured for the user in Users,
+// and permits the transfer otherwise. Each name is checked on its own, i.e.
+// MaxSANs does not apply. DeniedNames are never permitted.
+//
+// CheckTransfer returns the normalized domain names and the same errors as
+// CheckDomains.
+func (p *Policy) CheckTransfer(userID uuid.UUID, domainNames []string) ([]string, error) {
+	const op errors.Op = "policy/policy.CheckTransfer"
+
+	names, err := p.normalize(domainNames)
+	if err != nil {
+		return nil, errors.New(op, err)
+	}
+	rules, ok := p.Users[userID]
+	if !ok {
+		return names, nil
+	}
+	for _, name := range names {
+		if err := rules.check([]string{name}); err != nil {
+			return nil, errors.New(op, err)
+		}
+	}
+	return names, nil
+}
+
+// normalize normalizes all domainNames and makes sure none of them is
+// denied.
+func (p *Policy) normalize(domainNames []string) ([]string, error) {
+	const op errors.Op = "policy/policy.normalize"
+
+	if len(domainNames) == 0 {
+		return nil, errors.New(op, errors.InvalidArgument, "no domain names")
+	}
+	names := make([]string, 0, len(domainNames))
+	for _, dn := range domainNames {
+		name, err := Normalize(dn)
+		if err != nil {
+			return nil, errors.New(op, err)
+		}
+		if p.denied(name) {
+			return nil, errors.New(op, errors.Unauthorized, fmt.Sprintf("denied: %s", name))
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// rulesFor selects the Rules applying to a request of the user identified by
+// userID. It never returns an empty slice. Requests without claims are
+// subject to the rules of the user, or the Default rules.
+func (p *Policy) rulesFor(ctx context.Context, userID uuid.UUID) []Rules {
+	if rules, ok := p.Users[userID]; ok {
+		return []Rules{rules}
+	}
+	claims, ok := auth.ClaimsFromContext(ctx)
+	if !ok || claims == nil {
+		return []Rules{p.Default}
+	}
+	var rules []Rules
+	for _, role := range claims.Roles {
+		if r, ok := p.Roles[role]; ok {
+			rules = append(rules, r)
+		}
+	}
+	if len(rules) > 0 {
+		return rules
+	}
+	return []Rules{p.Default}
+}
+
+// denied checks if the normalized name matches any of the DeniedNames.
+func (p *Policy) denied(name string) bool {
+	for _, dn := range p.DeniedNames {
+		if strings.HasPrefix(dn, "*.") {
+			if strings.HasSuffix(name, dn[1:]) {
+				return true
+			}
+			continue
+		}
+		if name == dn {
+			return true
+		}
+	}
+	return false
+}
+
+func (r Rules) check(names []string) error {
+	const op errors.Op = "policy/rules.check"
+
+	if r.MaxSANs > 0 && len(names) > r.MaxSANs {
+		msg := fmt.Sprintf("%d domain names exceed maximum of %d", len(names), r.MaxSANs)
+		return errors.New(op, errors.InvalidArgument, msg)
+	}
+	for _, name := range names {
+		if !r.AllowWildcards && strings.HasPrefix(name, "*.") {
+			return errors.New(op, errors.Unauthorized, fmt.Sprintf("wildcard not allowed: %s", name))
+		}
+		if !r.suffixAllowed(name) {
+			return errors.New(op, errors.Unauthorized, fmt.Sprintf("suffix not allowed: %s", name))
+		}
+	}
+	return nil
+}
+
+func (r *Rules) normalizeEntries() error {
+	const op errors.Op = "policy/rules.normalizeEntries"
+
+	suffixes, err := normalizeAll(r.AllowedSuffixes, false)
+	if err != nil {
+		return errors.New(op, "allowed suffixes", err)
+	}
+	r.AllowedSuffixes = suffixes
+	return nil
+}
+
+func (r Rules) suffixAllowed(name string) bool {
+	if len(r.AllowedSuffixes) == 0 {
+		return true
+	}
+	name = strings.TrimPrefix(name, "*.")
+	for _, suffix := range r.AllowedSuffixes {
+		if hasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasSuffix returns true if name is equal to suffix, or is a sub-domain of
+// suffix. Both name and suffix must be normalized.
+func hasSuffix(name, suffix string) bool {
+	return name == suffix || strings.HasSuffix(name, "."+suffix)
+}
+
+// normalizeAll normalizes all names. It returns an error of kind
+// InvalidArgument if a name is malformed, or if it is a wildcard name and
+// allowWildcards is false.
+func normalizeAll(names []string, allowWildcards bool) ([]string, error) {
+	const op errors.Op = "policy/normalizeAll"
+
+	if len(names) == 0 {
+		return names, nil
+	}
+	normalized := make([]string, 0, len(names))
+	for _, n := range names {
+		name, err := Normalize(n)
+		if err != nil {
+			return nil, errors.New(op, err)
+		}
+		if !allowWildcards && strings.HasPrefix(name, "*.") {
+			return nil, errors.New(op, errors.InvalidArgument, fmt.Sprintf("wildcard not allowed: %s", n))
+		}
+		normalized = append(normalized, name)
+	}
+	return normalized, nil
+}
+
+// Normalize converts domainName into the form used by acmeproxy.
+//
+// Internationalized domain names are converted to punycode, all letters are
+// converted to lower case, and a trailing dot is removed. A leading *. label
+// marks a wildcard name and is retained. Normalize returns an error of kind
+// InvalidArgument if domainName is not a valid, fully qualified domain name.
+func Normalize(domainName string) (string, error) {
+	const op errors.Op = "policy/Normalize"
+
+	name := strings.TrimSuffix(strings.TrimSpace(domainName), ".")
+	prefix := ""
+	if strings.HasPrefix(name, "*.") {
+		prefix = "*."
+		name = name[2:]
+	}
+	if name == "" {
+		return "", errors.New(op, errors.InvalidArgument, "empty domain name")
+	}
+	if net.ParseIP(name) != nil {
+		return "", errors.New(op, errors.InvalidArgument, fmt.Sprintf("ip address: %s", domainName))
+	}
+	ascii, err := idna.Lookup.ToASCII(name)
+	if err != nil {
+		return "", errors.New(op, errors.InvalidArgument, fmt.Sprintf("malformed domain name: %s", domainName), err)
+	}
+	ascii = strings.ToLower(ascii)
+	for _, label := range strings.Split(ascii, ".") {
+		if !validLabel(label) {
+			return "", errors.New(op, errors.InvalidArgument, fmt.Sprintf("malformed domain name: %s", domainName))
+		}
+	}
+	if !strings.Contains(ascii, ".") {
+		return "", errors.New(op, errors.InvalidArgument, fmt.Sprintf("not fully qualified: %s", domainName))
+	}
+	if len(prefix)+len(ascii) > maxDomainNameLength {
+		return "", errors.New(op, errors.InvalidArgument, fmt.Sprintf("domain name too long: %s", domainName))
+	}
+	return prefix + ascii, nil
+}
+
+// validLabel checks if label conforms to the letters, digits, hyphen rule of
+// RFC 1123.
+func validLabel(label string) bool {
+	if label == "" || len(label) > maxLabelLength {
+		return false
+	}
+	if label[0] == '-' || label[len(label)-1] == '-' {
+		return false
+	}
+	for _, c := range label {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '-' {
+			return false
+		}
+	}
+	return true
+}
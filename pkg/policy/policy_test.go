@@ -0,0 +1,351 @@
+package policy_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/fhofherr/acmeproxy/pkg/api/auth"
+	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/fhofherr/acmeproxy/pkg/policy"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		err      error
+	}{
+		{name: "ascii domain", input: "www.example.com", expected: "www.example.com"},
+		{name: "upper case", input: "WWW.Example.COM", expected: "www.example.com"},
+		{name: "trailing dot", input: "www.example.com.", expected: "www.example.com"},
+		{name: "wildcard", input: "*.example.com", expected: "*.example.com"},
+		{name: "idn", input: "bücher.example.com", expected: "xn--bcher-kva.example.com"},
+		{name: "punycode", input: "xn--bcher-kva.example.com", expected: "xn--bcher-kva.example.com"},
+		{name: "empty", input: "", err: errors.New(errors.InvalidArgument)},
+		{name: "single label", input: "localhost", err: errors.New(errors.InvalidArgument)},
+		{name: "ip address", input: "127.0.0.1", err: errors.New(errors.InvalidArgument)},
+		{name: "invalid characters", input: "www.exa mple.com", err: errors.New(errors.InvalidArgument)},
+		{name: "nested wildcard", input: "*.*.example.com", err: errors.New(errors.InvalidArgument)},
+		{
+			name:  "too long",
+			input: strings.Repeat("a.", 127) + "com",
+			err:   errors.New(errors.InvalidArgument),
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := policy.Normalize(tt.input)
+			if tt.err != nil {
+				errors.AssertMatches(t, tt.err, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestCheckDomains(t *testing.T) {
+	userID := uuid.Must(uuid.NewRandom())
+	privilegedUserID := uuid.Must(uuid.NewRandom())
+	p := &policy.Policy{
+		DeniedNames: []string{"admin.example.com", "*.internal.example.com"},
+		Default: policy.Rules{
+			AllowedSuffixes: []string{"example.com"},
+			MaxSANs:         2,
+		},
+		Users: map[uuid.UUID]policy.Rules{
+			privilegedUserID: {AllowWildcards: true},
+		},
+		Roles: map[auth.Role]policy.Rules{
+			auth.Role("org"):   {AllowedSuffixes: []string{"example.org"}},
+			auth.Role("wild"):  {AllowedSuffixes: []string{"example.net"}, AllowWildcards: true},
+			auth.Role("other"): {AllowedSuffixes: []string{"example.io"}},
+		},
+	}
+	tests := []struct {
+		name     string
+		userID   uuid.UUID
+		roles    []auth.Role
+		noClaims bool
+		domains  []string
+		expected []string
+		err      error
+	}{
+		{
+			name:     "default rules permit domain",
+			userID:   userID,
+			domains:  []string{"WWW.example.com."},
+			expected: []string{"www.example.com"},
+		},
+		{
+			name:    "default rules deny suffix",
+			userID:  userID,
+			domains: []string{"www.example.org"},
+			err:     errors.New(errors.Unauthorized),
+		},
+		{
+			name:    "suffix must match whole labels",
+			userID:  userID,
+			domains: []string{"badexample.com"},
+			err:     errors.New(errors.Unauthorized),
+		},
+		{
+			name:    "default rules deny wildcards",
+			userID:  userID,
+			domains: []string{"*.example.com"},
+			err:     errors.New(errors.Unauthorized),
+		},
+		{
+			name:    "too many names",
+			userID:  userID,
+			domains: []string{"a.example.com", "b.example.com", "c.example.com"},
+			err:     errors.New(errors.InvalidArgument),
+		},
+		{
+			name:    "no names",
+			userID:  userID,
+			domains: []string{},
+			err:     errors.New(errors.InvalidArgument),
+		},
+		{
+			name:    "malformed name",
+			userID:  userID,
+			domains: []string{"www..example.com"},
+			err:     errors.New(errors.InvalidArgument),
+		},
+		{
+			name:    "denied name",
+			userID:  privilegedUserID,
+			domains: []string{"admin.example.com"},
+			err:     errors.New(errors.Unauthorized),
+		},
+		{
+			name:    "denied sub-domain",
+			userID:  privilegedUserID,
+			domains: []string{"db.internal.example.com"},
+			err:     errors.New(errors.Unauthorized),
+		},
+		{
+			name:     "user rules replace default rules",
+			userID:   privilegedUserID,
+			domains:  []string{"*.example.org", "a.example.com", "b.example.com"},
+			expected: []string{"*.example.org", "a.example.com", "b.example.com"},
+		},
+		{
+			name:     "role rules replace default rules",
+			userID:   userID,
+			roles:    []auth.Role{auth.Role("org")},
+			domains:  []string{"www.example.org"},
+			expected: []string{"www.example.org"},
+		},
+		{
+			name:    "role rules deny domain",
+			userID:  userID,
+			roles:   []auth.Role{auth.Role("org")},
+			domains: []string{"www.example.com"},
+			err:     errors.New(errors.Unauthorized),
+		},
+		{
+			name:     "any role may permit domain",
+			userID:   userID,
+			roles:    []auth.Role{auth.Role("org"), auth.Role("wild")},
+			domains:  []string{"*.example.net"},
+			expected: []string{"*.example.net"},
+		},
+		{
+			name:     "roles without rules use default rules",
+			userID:   userID,
+			roles:    []auth.Role{auth.Admin},
+			domains:  []string{"www.example.com"},
+			expected: []string{"www.example.com"},
+		},
+		{
+			name:     "request without claims uses default rules",
+			userID:   userID,
+			noClaims: true,
+			domains:  []string{"www.example.com"},
+			expected: []string{"www.example.com"},
+		},
+		{
+			name:     "request without claims ignores role rules",
+			userID:   userID,
+			noClaims: true,
+			domains:  []string{"www.example.org"},
+			err:      errors.New(errors.Unauthorized),
+		},
+		{
+			name:     "request without claims uses user rules",
+			userID:   privilegedUserID,
+			noClaims: true,
+			domains:  []string{"*.example.org"},
+			expected: []string{"*.example.org"},
+		},
+		{
+			name:     "request without claims checks denied names",
+			userID:   userID,
+			noClaims: true,
+			domains:  []string{"admin.example.com"},
+			err:      errors.New(errors.Unauthorized),
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if !tt.noClaims {
+				ctx = auth.AddClaimsToContext(ctx, &auth.Claims{Roles: tt.roles})
+			}
+			actual, err := p.CheckDomains(ctx, tt.userID, tt.domains)
+			if tt.err != nil {
+				errors.AssertMatches(t, tt.err, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestCheckTransfer(t *testing.T) {
+	userID := uuid.Must(uuid.NewRandom())
+	restrictedUserID := uuid.Must(uuid.NewRandom())
+	p := &policy.Policy{
+		DeniedNames: []string{"admin.example.com"},
+		Default:     policy.Rules{AllowedSuffixes: []string{"example.org"}, MaxSANs: 1},
+		Users: map[uuid.UUID]policy.Rules{
+			restrictedUserID: {AllowedSuffixes: []string{"example.com"}, MaxSANs: 1},
+		},
+		Roles: map[auth.Role]policy.Rules{
+			auth.Operator: {AllowedSuffixes: []string{"example.net"}},
+		},
+	}
+	tests := []struct {
+		name     string
+		userID   uuid.UUID
+		domains  []string
+		expected []string
+		err      error
+	}{
+		{
+			name:     "default rules do not apply",
+			userID:   userID,
+			domains:  []string{"WWW.example.com.", "www.example.net"},
+			expected: []string{"www.example.com", "www.example.net"},
+		},
+		{
+			name:     "user rules permit domains",
+			userID:   restrictedUserID,
+			domains:  []string{"a.example.com", "b.example.com"},
+			expected: []string{"a.example.com", "b.example.com"},
+		},
+		{
+			name:    "user rules deny domain",
+			userID:  restrictedUserID,
+			domains: []string{"www.example.net"},
+			err:     errors.New(errors.Unauthorized),
+		},
+		{
+			name:    "denied name",
+			userID:  userID,
+			domains: []string{"admin.example.com"},
+			err:     errors.New(errors.Unauthorized),
+		},
+		{
+			name:    "malformed name",
+			userID:  userID,
+			domains: []string{"www..example.com"},
+			err:     errors.New(errors.InvalidArgument),
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := p.CheckTransfer(tt.userID, tt.domains)
+			if tt.err != nil {
+				errors.AssertMatches(t, tt.err, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	userID := uuid.Must(uuid.NewRandom())
+	input := `{
+		"deniedNames": ["admin.example.com"],
+		"default": {"allowedSuffixes": ["example.com"], "maxSANs": 1},
+		"users": {"` + userID.String() + `": {"allowWildcards": true}},
+		"roles": {"admin": {"allowWildcards": true, "maxSANs": 10}}
+	}`
+	p, err := policy.Load(strings.NewReader(input))
+	assert.NoError(t, err)
+	expected := &policy.Policy{
+		DeniedNames: []string{"admin.example.com"},
+		Default:     policy.Rules{AllowedSuffixes: []string{"example.com"}, MaxSANs: 1},
+		Users:       map[uuid.UUID]policy.Rules{userID: {AllowWildcards: true}},
+		Roles:       map[auth.Role]policy.Rules{auth.Admin: {AllowWildcards: true, MaxSANs: 10}},
+	}
+	assert.Equal(t, expected, p)
+
+	_, err = policy.Load(strings.NewReader(`{"unknown": true}`))
+	errors.AssertMatches(t, errors.New(errors.InvalidArgument), err)
+}
+
+func TestLoadNormalizesEntries(t *testing.T) {
+	input := `{
+		"deniedNames": ["Admin.Example.COM.", "*.Bücher.example.com"],
+		"default": {"allowedSuffixes": ["Example.com."]},
+		"roles": {"operator": {"allowedSuffixes": ["bücher.example.org"]}}
+	}`
+	p, err := policy.Load(strings.NewReader(input))
+	assert.NoError(t, err)
+	expected := &policy.Policy{
+		DeniedNames: []string{"admin.example.com", "*.xn--bcher-kva.example.com"},
+		Default:     policy.Rules{AllowedSuffixes: []string{"example.com"}},
+		Roles: map[auth.Role]policy.Rules{
+			auth.Operator: {AllowedSuffixes: []string{"xn--bcher-kva.example.org"}},
+		},
+	}
+	assert.Equal(t, expected, p)
+
+	userID := uuid.Must(uuid.NewRandom())
+	ctx := auth.AddClaimsToContext(context.Background(), &auth.Claims{Roles: []auth.Role{auth.Operator}})
+	_, err = p.CheckDomains(ctx, userID, []string{"www.bücher.example.org"})
+	assert.NoError(t, err)
+	_, err = p.CheckDomains(ctx, userID, []string{"admin.example.com"})
+	errors.AssertMatches(t, errors.New(errors.Unauthorized), err)
+	_, err = p.CheckDomains(context.Background(), userID, []string{"shop.bücher.example.com"})
+	errors.AssertMatches(t, errors.New(errors.Unauthorized), err)
+}
+
+func TestLoadRejectsMalformedEntries(t *testing.T) {
+	userID := uuid.Must(uuid.NewRandom())
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "malformed denied name", input: `{"deniedNames": ["www..example.com"]}`},
+		{name: "malformed default suffix", input: `{"default": {"allowedSuffixes": ["exa mple.com"]}}`},
+		{
+			name:  "malformed user suffix",
+			input: `{"users": {"` + userID.String() + `": {"allowedSuffixes": ["-example.com"]}}}`,
+		},
+		{name: "malformed role suffix", input: `{"roles": {"admin": {"allowedSuffixes": [""]}}}`},
+		{name: "wildcard suffix", input: `{"default": {"allowedSuffixes": ["*.example.com"]}}`},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := policy.Load(strings.NewReader(tt.input))
+			errors.AssertMatches(t, errors.New(errors.InvalidArgument), err)
+		})
+	}
+}
@@ -0,0 +1,8 @@
+// Package requestid correlates the log entries and errors of a single request
+// to acmeproxy.
+//
+// The API servers accept a request ID from the caller or generate a new one
+// at the edge of acmeproxy. They store it in the context of the request. All
+// code handling the request reads the ID from the context and attaches it to
+// its log entries and errors.
+package requestid
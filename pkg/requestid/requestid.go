@@ -0,0 +1,128 @@
+package requestid
+
+import (
+	"context"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/fhofherr/golf/log"
+	"github.com/google/uuid"
+)
+
+const (
+	// MetadataKey is the key of the request ID in gRPC metadata.
+	MetadataKey = "x-request-id"
+
+	// Header is the HTTP header carrying the request ID.
+	Header = "X-Request-Id"
+
+	// TraceParentHeader is the HTTP header, or the key in gRPC metadata,
+	// carrying a W3C trace context. acmeproxy uses the trace ID as request
+	// ID if the caller did not pass one explicitly.
+	TraceParentHeader = "traceparent"
+
+	// LogKey is the key of the request ID in log entries.
+	LogKey = "request_id"
+
+	// maxLen is the maximum length of request IDs passed by callers.
+	maxLen = 128
+)
+
+type contextKey struct{}
+
+// New generates a new random request ID.
+func New() string {
+	return uuid.New().String()
+}
+
+// Valid checks if a request ID passed by a caller may be used. Valid request
+// IDs consist of at most 128 printable ASCII characters other than space.
+func Valid(id string) bool {
+	if id == "" || len(id) > maxLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// Select returns the request ID to use for a request. It returns id if it is
+// valid. Otherwise it returns the trace ID contained in traceParent if there
+// is one, or a new request ID.
+func Select(id, traceParent string) string {
+	if Valid(id) {
+		return id
+	}
+	if traceID, ok := FromTraceParent(traceParent); ok {
+		return traceID
+	}
+	return New()
+}
+
+// FromTraceParent extracts the trace ID from the value of a W3C traceparent
+// header. It returns false if the value is malformed.
+//
+// See https://www.w3.org/TR/trace-context/#traceparent-header.
+func FromTraceParent(traceParent string) (string, bool) {
+	parts := strings.Split(strings.TrimSpace(traceParent), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return "", false
+	}
+	traceID := parts[1]
+	if len(traceID) != 32 || strings.ToLower(traceID) != traceID || traceID == strings.Repeat("0", 32) {
+		return "", false
+	}
+	if _, err := hex.DecodeString(traceID); err != nil {
+		return "", false
+	}
+	return traceID, true
+}
+
+// NewContext returns a copy of ctx carrying the request ID id.
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the request ID carried by ctx. It returns the empty
+// string if ctx carries none.
+func FromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// Logger returns a logger adding the request ID carried by ctx to every log
+// entry. It returns logger unchanged if ctx carries no request ID.
+func Logger(ctx context.Context, logger log.Logger) log.Logger {
+	id := FromContext(ctx)
+	if id == "" {
+		return logger
+	}
+	return log.With(logger, LogKey, id)
+}
+
+// Middleware assigns each HTTP request a request ID and adds it to the
+// context of the request. It uses the ID the caller passed in the X-Request-Id
+// header, or the trace ID of the caller's traceparent header. If the caller
+// passed neither Middleware generates a new ID. The ID is sent back to the
+// caller in the X-Request-Id header.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		id := Select(req.Header.Get(Header), req.Header.Get(TraceParentHeader))
+		w.Header().Set(Header, id)
+		next.ServeHTTP(w, req.WithContext(NewContext(req.Context(), id)))
+	})
+}
+
+// Error attaches the request ID carried by ctx to err using
+// errors.WithRequestID.
+func Error(ctx context.Context, err error) error {
+	return errors.WithRequestID(err, FromContext(ctx))
+}
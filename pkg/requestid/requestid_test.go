@@ -0,0 +1,136 @@
+package requestid_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/fhofherr/acmeproxy/pkg/requestid"
+	"github.com/fhofherr/golf/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValid(t *testing.T) {
+	tests := []struct {
+		name  string
+		id    string
+		valid bool
+	}{
+		{name: "uuid", id: requestid.New(), valid: true},
+		{name: "printable ascii", id: "req-42/a_b.c", valid: true},
+		{name: "empty", id: ""},
+		{name: "space", id: "some request"},
+		{name: "control character", id: "some\nrequest"},
+		{name: "non ascii", id: "äöü"},
+		{name: "too long", id: strings.Repeat("a", 129)},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.valid, requestid.Valid(tt.id))
+		})
+	}
+}
+
+func TestFromTraceParent(t *testing.T) {
+	tests := []struct {
+		name        string
+		traceParent string
+		traceID     string
+		ok          bool
+	}{
+		{
+			name:        "valid traceparent",
+			traceParent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			traceID:     "4bf92f3577b34da6a3ce929d0e0e4736",
+			ok:          true,
+		},
+		{name: "empty", traceParent: ""},
+		{name: "invalid version", traceParent: "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		{name: "all zero trace id", traceParent: "00-00000000000000000000000000000000-00f067aa0ba902b7-01"},
+		{name: "upper case trace id", traceParent: "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01"},
+		{name: "short trace id", traceParent: "00-4bf92f35-00f067aa0ba902b7-01"},
+		{name: "no hex", traceParent: "00-4bf92f3577b34da6a3ce929d0e0e47zz-00f067aa0ba902b7-01"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			traceID, ok := requestid.FromTraceParent(tt.traceParent)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.traceID, traceID)
+		})
+	}
+}
+
+func TestSelect(t *testing.T) {
+	traceParent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	assert.Equal(t, "some-request", requestid.Select("some-request", traceParent))
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", requestid.Select("", traceParent))
+	assert.True(t, requestid.Valid(requestid.Select("some request", "")))
+	assert.NotEqual(t, requestid.Select("", ""), requestid.Select("", ""))
+}
+
+func TestContext(t *testing.T) {
+	assert.Empty(t, requestid.FromContext(context.Background()))
+
+	ctx := requestid.NewContext(context.Background(), "some-request")
+	assert.Equal(t, "some-request", requestid.FromContext(ctx))
+
+	logger := &log.TestLogger{}
+	log.Log(requestid.Logger(ctx, logger), "message", "some message")
+	logger.AssertHasMatchingLogEntries(t, 1, func(e log.TestLogEntry) bool {
+		return e["message"] == "some message" && e[requestid.LogKey] == "some-request"
+	})
+
+	err := requestid.Error(ctx, errors.New(errors.NotFound))
+	assert.Equal(t, "some-request", errors.GetRequestID(err))
+}
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   http.Header
+		expected string
+	}{
+		{
+			name:     "request id passed by caller",
+			header:   http.Header{requestid.Header: []string{"some-request"}},
+			expected: "some-request",
+		},
+		{
+			name: "trace id of traceparent",
+			header: http.Header{
+				"Traceparent": []string{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+			},
+			expected: "4bf92f3577b34da6a3ce929d0e0e4736",
+		},
+		{
+			name: "generated request id",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var actual string
+			handler := requestid.Middleware(http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+				actual = requestid.FromContext(req.Context())
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			for k, vs := range tt.header {
+				req.Header[k] = vs
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.NotEmpty(t, actual)
+			if tt.expected != "" {
+				assert.Equal(t, tt.expected, actual)
+			}
+			assert.Equal(t, actual, rec.Header().Get(requestid.Header))
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package security
+
+import (
+	"time"
+
+	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/google/uuid"
+)
+
+// AuditOutcome describes the result of an audited operation.
+type AuditOutcome string
+
+const (
+	// AuditSuccess signals that the operation succeeded.
+	AuditSuccess AuditOutcome = "success"
+	// AuditDenied signals that the caller was not authorized to perform the
+	// operation.
+	AuditDenied AuditOutcome = "denied"
+	// AuditFailure signals that the operation failed for any other reason.
+	AuditFailure AuditOutcome = "failure"
+)
+
+// AuditOutcomeOf returns the outcome of an operation which returned err.
+// Errors of kind Unauthorized or PermissionDenied signal that the operation
+// was denied.
+func AuditOutcomeOf(err error) AuditOutcome {
+	if err == nil {
+		return AuditSuccess
+	}
+	switch errors.GetKind(err) {
+	case errors.Unauthorized, errors.PermissionDenied:
+		return AuditDenied
+	default:
+		return AuditFailure
+	}
+}
+
+// AuditDomain returns the Resource of AuditEntries for the domain with the
+// passed name.
+func AuditDomain(name string) string {
+	return "domain/" + name
+}
+
+// AuditUser returns the Resource of AuditEntries for the user with the passed
+// ID.
+func AuditUser(id uuid.UUID) string {
+	return "user/" + id.String()
+}
+
+// AuditToken returns the Resource of AuditEntries for the token with the
+// passed ID.
+func AuditToken(id string) string {
+	return "token/" + id
+}
+
+// AuditEntry records a security-relevant operation.
+//
+// Subject and Roles are taken from the claims of the caller. TokenID is the
+// unique ID (jti) of the token the caller presented. It is empty if the
+// caller authenticated otherwise. PeerAddr is the network address of the
+// caller. Operation names the operation, e.g. the full name of a gRPC method.
+// Resource identifies the object the operation was performed on, e.g.
+// domain/www.example.com. It is empty if the operation did not target a
+// specific object.
+type AuditEntry struct {
+	Time      time.Time
+	Subject   string
+	Roles     []string
+	TokenID   string
+	PeerAddr  string
+	Operation string
+	Resource  string
+	Outcome   AuditOutcome
+}
+
+// AuditFilter selects AuditEntries.
+//
+// An entry matches the filter if it was recorded at or after Since and before
+// Until, and if its Subject and Resource are equal to those of the filter.
+// Zero values match any entry. Limit is the maximum number of entries
+// returned; zero means no limit.
+type AuditFilter struct {
+	Since    time.Time
+	Until    time.Time
+	Subject  string
+	Resource string
+	Limit    int
+}
+
+// Matches returns true if e matches f. It ignores Limit.
+func (f AuditFilter) Matches(e AuditEntry) bool {
+	if !f.Since.IsZero() && e.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !e.Time.Before(f.Until) {
+		return false
+	}
+	if f.Subject != "" && e.Subject != f.Subject {
+		return false
+	}
+	if f.Resource != "" && e.Resource != f.Resource {
+		return false
+	}
+	return true
+}
+
+// AuditRepository persists AuditEntries.
+//
+// ListAuditEntries returns the entries matching the filter ordered by the time
+// they were recorded. PruneAuditEntries removes all entries recorded before
+// the passed time, and then the oldest entries until at most maxEntries
+// remain. A zero time or a maxEntries of zero disables the respective limit.
+type AuditRepository interface {
+	RecordAuditEntry(AuditEntry) error
+	ListAuditEntries(AuditFilter) ([]AuditEntry, error)
+	PruneAuditEntries(before time.Time, maxEntries int) error
+}
@@ -0,0 +1,73 @@
+package security_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/fhofherr/acmeproxy/pkg/security"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditFilterMatches(t *testing.T) {
+	now := time.Now()
+	e := security.AuditEntry{
+		Time:     now,
+		Subject:  "jdoe",
+		Resource: "domain/www.example.com",
+	}
+	tests := []struct {
+		name    string
+		filter  security.AuditFilter
+		matches bool
+	}{
+		{
+			name:    "zero filter",
+			matches: true,
+		},
+		{
+			name:    "recorded at since",
+			filter:  security.AuditFilter{Since: now},
+			matches: true,
+		},
+		{
+			name:   "recorded before since",
+			filter: security.AuditFilter{Since: now.Add(time.Second)},
+		},
+		{
+			name:   "recorded at until",
+			filter: security.AuditFilter{Until: now},
+		},
+		{
+			name:   "different subject",
+			filter: security.AuditFilter{Subject: "root"},
+		},
+		{
+			name:    "same subject and resource",
+			filter:  security.AuditFilter{Subject: "jdoe", Resource: "domain/www.example.com"},
+			matches: true,
+		},
+		{
+			name:   "different resource",
+			filter: security.AuditFilter{Resource: "domain/www.example.org"},
+		},
+		{
+			name:    "limit is ignored",
+			filter:  security.AuditFilter{Limit: 1},
+			matches: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.matches, tt.filter.Matches(e))
+		})
+	}
+}
+
+func TestAuditOutcomeOf(t *testing.T) {
+	assert.Equal(t, security.AuditSuccess, security.AuditOutcomeOf(nil))
+	assert.Equal(t, security.AuditDenied, security.AuditOutcomeOf(errors.New(errors.Unauthorized)))
+	assert.Equal(t, security.AuditDenied, security.AuditOutcomeOf(errors.New(errors.PermissionDenied)))
+	assert.Equal(t, security.AuditFailure, security.AuditOutcomeOf(errors.New(errors.NotFound)))
+}
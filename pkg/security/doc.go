@@ -0,0 +1,8 @@
+// Package security defines the security-relevant records acmeproxy keeps:
+// revoked tokens and the audit trail of the operations performed through its
+// APIs.
+//
+// The records and the repositories persisting them do not depend on any API.
+// This allows the storage layer to implement the repositories without
+// depending on the packages using them.
+package security
@@ -0,0 +1,21 @@
+package security
+
+import "time"
+
+// RevokedToken represents a token which must not be accepted anymore, even
+// though it has not expired yet.
+//
+// ID is the unique ID (jti) of the revoked token. ExpiresAt is the time the
+// token expires. The token may be forgotten after this point in time. A zero
+// ExpiresAt means the token never expires.
+type RevokedToken struct {
+	ID        string
+	ExpiresAt time.Time
+}
+
+// RevokedTokenRepository persists revoked tokens.
+type RevokedTokenRepository interface {
+	SaveRevokedToken(RevokedToken) error
+	ListRevokedTokens() ([]RevokedToken, error)
+	DeleteRevokedToken(id string) error
+}
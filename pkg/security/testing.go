@@ -0,0 +1,103 @@
+package security
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// InMemoryRevokedTokenRepository is a simple in-memory implementation of the
+// RevokedTokenRepository interface.
+//
+// It is intended for testing purposes. The zero value of
+// InMemoryRevokedTokenRepository is ready to use.
+type InMemoryRevokedTokenRepository struct {
+	tokens map[string]RevokedToken
+	mu     sync.Mutex
+}
+
+// SaveRevokedToken saves rt in the InMemoryRevokedTokenRepository.
+func (r *InMemoryRevokedTokenRepository) SaveRevokedToken(rt RevokedToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.tokens == nil {
+		r.tokens = make(map[string]RevokedToken)
+	}
+	r.tokens[rt.ID] = rt
+	return nil
+}
+
+// ListRevokedTokens returns all revoked tokens ordered by their ID.
+func (r *InMemoryRevokedTokenRepository) ListRevokedTokens() ([]RevokedToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rts := make([]RevokedToken, 0, len(r.tokens))
+	for _, rt := range r.tokens {
+		rts = append(rts, rt)
+	}
+	sort.Slice(rts, func(i, j int) bool {
+		return rts[i].ID < rts[j].ID
+	})
+	return rts, nil
+}
+
+// DeleteRevokedToken removes the revoked token with the passed id.
+func (r *InMemoryRevokedTokenRepository) DeleteRevokedToken(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.tokens, id)
+	return nil
+}
+
+// InMemoryAuditRepository is a simple in-memory implementation of the
+// AuditRepository interface.
+//
+// It is intended for testing purposes. The zero value of
+// InMemoryAuditRepository is ready to use.
+type InMemoryAuditRepository struct {
+	entries []AuditEntry
+	mu      sync.Mutex
+}
+
+// RecordAuditEntry appends e to the InMemoryAuditRepository.
+func (r *InMemoryAuditRepository) RecordAuditEntry(e AuditEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, e)
+	sort.SliceStable(r.entries, func(i, j int) bool {
+		return r.entries[i].Time.Before(r.entries[j].Time)
+	})
+	return nil
+}
+
+// ListAuditEntries returns the entries matching f ordered by their time.
+func (r *InMemoryAuditRepository) ListAuditEntries(f AuditFilter) ([]AuditEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var entries []AuditEntry
+	for _, e := range r.entries {
+		if f.Limit > 0 && len(entries) == f.Limit {
+			break
+		}
+		if f.Matches(e) {
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+// PruneAuditEntries removes the entries recorded before the passed time and
+// the oldest entries exceeding maxEntries.
+func (r *InMemoryAuditRepository) PruneAuditEntries(before time.Time, maxEntries int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i := 0
+	for i < len(r.entries) && r.entries[i].Time.Before(before) {
+		i++
+	}
+	if maxEntries > 0 && len(r.entries)-i > maxEntries {
+		i = len(r.entries) - maxEntries
+	}
+	r.entries = append([]AuditEntry(nil), r.entries[i:]...)
+	return nil
+}
@@ -0,0 +1,10 @@
+// Package tracing records OpenTelemetry spans of the operations acmeproxy
+// performs.
+//
+// Code records spans using Start and End. The spans are exported by the
+// Provider installed with Provider.Install. Until a Provider is installed
+// all spans are discarded.
+//
+// Spans are named after the errors.Op of the operation they record. Every
+// span carries the request ID found in the context it was started with.
+package tracing
@@ -0,0 +1,36 @@
+package tracing
+
+import (
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Record installs a Provider recording all spans ended during the test t.
+// Once t has finished all spans are discarded again.
+//
+// Tests using Record must not run in parallel.
+func Record(t *testing.T) *tracetest.SpanRecorder {
+	rec := tracetest.NewSpanRecorder()
+	newProvider(sdktrace.WithSpanProcessor(rec)).Install()
+	t.Cleanup(func() {
+		otel.SetTracerProvider(trace.NewNoopTracerProvider())
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+	})
+	return rec
+}
+
+// SpanNames returns the names of the spans recorded by rec in the order they
+// ended.
+func SpanNames(rec *tracetest.SpanRecorder) []string {
+	spans := rec.Ended()
+	names := make([]string, 0, len(spans))
+	for _, s := range spans {
+		names = append(names, s.Name())
+	}
+	return names
+}
@@ -0,0 +1,174 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/fhofherr/acmeproxy/pkg/requestid"
+	"github.com/fhofherr/acmeproxy/pkg/version"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// ExporterNone discards all spans.
+	ExporterNone = "none"
+
+	// ExporterStdout writes the spans as JSON to standard output.
+	ExporterStdout = "stdout"
+
+	// ExporterOTLP sends the spans to an OpenTelemetry collector using
+	// OTLP over HTTP.
+	ExporterOTLP = "otlp"
+
+	// RequestIDKey is the attribute carrying the request ID of a span.
+	RequestIDKey = attribute.Key("acmeproxy.request_id")
+
+	// DomainKey is the attribute carrying the domain name an operation
+	// concerns.
+	DomainKey = attribute.Key("acmeproxy.domain")
+
+	// DomainsKey is the attribute carrying the domain names of a
+	// certificate.
+	DomainsKey = attribute.Key("acmeproxy.domains")
+
+	// UserIDKey is the attribute carrying the ID of the user an operation
+	// concerns.
+	UserIDKey = attribute.Key("acmeproxy.user_id")
+
+	serviceName = "acmeproxy"
+	tracerName  = "github.com/fhofherr/acmeproxy"
+)
+
+// Config configures the export of spans.
+type Config struct {
+	Exporter     string    // One of the Exporter constants; ExporterNone if empty.
+	OTLPEndpoint string    // host:port of the OTLP collector; localhost:4318 if empty.
+	OTLPInsecure bool      // Connect to the OTLP collector without TLS.
+	Writer       io.Writer // Destination of ExporterStdout; os.Stdout if nil.
+}
+
+// Provider creates spans and exports them.
+//
+// A nil *Provider is valid. It discards all spans.
+type Provider struct {
+	tp *sdktrace.TracerProvider
+}
+
+// NewProvider creates a Provider exporting spans as configured by cfg. It
+// returns nil if cfg selects ExporterNone.
+func NewProvider(cfg Config) (*Provider, error) {
+	const op errors.Op = "tracing/NewProvider"
+
+	var (
+		exp sdktrace.SpanExporter
+		err error
+	)
+	switch cfg.Exporter {
+	case "", ExporterNone:
+		return nil, nil
+	case ExporterStdout:
+		w := cfg.Writer
+		if w == nil {
+			w = os.Stdout
+		}
+		exp, err = stdouttrace.New(stdouttrace.WithWriter(w))
+	case ExporterOTLP:
+		var opts []otlptracehttp.Option
+		if cfg.OTLPEndpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpoint(cfg.OTLPEndpoint))
+		}
+		if cfg.OTLPInsecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		exp, err = otlptracehttp.New(context.Background(), opts...)
+	default:
+		return nil, errors.New(op, errors.InvalidArgument, fmt.Sprintf("unknown exporter: %s", cfg.Exporter))
+	}
+	if err != nil {
+		return nil, errors.New(op, fmt.Sprintf("create %s exporter", cfg.Exporter), err)
+	}
+	return newProvider(sdktrace.WithBatcher(exp)), nil
+}
+
+func newProvider(opts ...sdktrace.TracerProviderOption) *Provider {
+	attrs := []attribute.KeyValue{semconv.ServiceNameKey.String(serviceName)}
+	if version.GitTag != "" {
+		attrs = append(attrs, semconv.ServiceVersionKey.String(version.GitTag))
+	}
+	opts = append(opts, sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, attrs...)))
+	return &Provider{tp: sdktrace.NewTracerProvider(opts...)}
+}
+
+// Install makes p the Provider used by Start. Additionally it configures
+// acmeproxy to accept W3C trace contexts from its callers.
+//
+// Install does nothing if p is nil.
+func (p *Provider) Install() {
+	if p == nil {
+		return
+	}
+	otel.SetTracerProvider(p.tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+}
+
+// Shutdown exports all remaining spans and stops p. p must not be used
+// afterwards.
+func (p *Provider) Shutdown(ctx context.Context) error {
+	const op errors.Op = "tracing/provider.Shutdown"
+
+	if p == nil {
+		return nil
+	}
+	return errors.Wrap(p.tp.Shutdown(ctx), op)
+}
+
+// Start starts a new span called name. The span is a child of the span
+// carried by ctx, if there is any. Start returns a copy of ctx carrying the
+// new span.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return start(ctx, name, trace.SpanKindInternal, attrs)
+}
+
+// StartServer is like Start but marks the span as handling a request of a
+// remote caller.
+func StartServer(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return start(ctx, name, trace.SpanKindServer, attrs)
+}
+
+func start(
+	ctx context.Context, name string, kind trace.SpanKind, attrs []attribute.KeyValue,
+) (context.Context, trace.Span) {
+	if id := requestid.FromContext(ctx); id != "" {
+		attrs = append(attrs, RequestIDKey.String(id))
+	}
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithSpanKind(kind), trace.WithAttributes(attrs...))
+}
+
+// End ends span. If err is not nil End records it and marks the span as
+// failed.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Extract returns a copy of ctx carrying the trace context found in carrier.
+// The spans started with the returned context become children of the
+// caller's span.
+func Extract(ctx context.Context, carrier propagation.TextMapCarrier) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, carrier)
+}
@@ -0,0 +1,79 @@
+package tracing_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/fhofherr/acmeproxy/pkg/errors"
+	"github.com/fhofherr/acmeproxy/pkg/requestid"
+	"github.com/fhofherr/acmeproxy/pkg/tracing"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+func TestNewProvider(t *testing.T) {
+	// Discards the spans again once the test has finished.
+	tracing.Record(t)
+
+	p, err := tracing.NewProvider(tracing.Config{})
+	assert.NoError(t, err)
+	assert.Nil(t, p)
+	assert.NoError(t, p.Shutdown(context.Background()))
+
+	_, err = tracing.NewProvider(tracing.Config{Exporter: "zipkin"})
+	errors.AssertMatches(t, errors.New(errors.InvalidArgument), err)
+
+	var buf bytes.Buffer
+	p, err = tracing.NewProvider(tracing.Config{Exporter: tracing.ExporterStdout, Writer: &buf})
+	if !assert.NoError(t, err) {
+		return
+	}
+	p.Install()
+	_, span := tracing.Start(context.Background(), "test/span")
+	tracing.End(span, nil)
+	assert.NoError(t, p.Shutdown(context.Background()))
+	assert.Contains(t, buf.String(), `"Name":"test/span"`)
+}
+
+func TestStartEnd(t *testing.T) {
+	rec := tracing.Record(t)
+	ctx := requestid.NewContext(context.Background(), "some-request")
+
+	ctx, parent := tracing.Start(ctx, "test/parent", attribute.String("domain", "www.example.com"))
+	_, child := tracing.StartServer(ctx, "test/child")
+	tracing.End(child, errors.New("failed"))
+	tracing.End(parent, nil)
+
+	spans := rec.Ended()
+	if !assert.Len(t, spans, 2) {
+		return
+	}
+	assert.Equal(t, []string{"test/child", "test/parent"}, tracing.SpanNames(rec))
+	assert.Equal(t, spans[1].SpanContext().SpanID(), spans[0].Parent().SpanID())
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Len(t, spans[0].Events(), 1)
+	assert.Equal(t, codes.Unset, spans[1].Status().Code)
+	assert.Contains(t, spans[1].Attributes(), attribute.String("domain", "www.example.com"))
+	assert.Contains(t, spans[1].Attributes(), tracing.RequestIDKey.String("some-request"))
+}
+
+func TestExtract(t *testing.T) {
+	rec := tracing.Record(t)
+	carrier := propagation.MapCarrier{
+		"traceparent": "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+	}
+
+	ctx := tracing.Extract(context.Background(), carrier)
+	_, span := tracing.StartServer(ctx, "test/span")
+	tracing.End(span, nil)
+
+	spans := rec.Ended()
+	if !assert.Len(t, spans, 1) {
+		return
+	}
+	assert.Equal(t, "0af7651916cd43dd8448eb211c80319c", spans[0].SpanContext().TraceID().String())
+	assert.Equal(t, "b7ad6b7169203331", spans[0].Parent().SpanID().String())
+}
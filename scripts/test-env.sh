@@ -3,6 +3,7 @@
 set -e
 
 : "${ACMEPROXY_ACME_DIRECTORY_URL:=https://localhost:14000/dir}"
+: "${ACMEPROXY_ACME_RESOLVER_ADDR:=localhost:8053}"
 : "${ACMEPROXY_HTTP_API_ADDR:=localhost:5002}"
 : "${ACMEPROXY_IMAGE_TAG:=acmeproxy:latest}"
 : "${ACMEPROXY_PEBBLE_DIR:=$PWD/.pebble}"
@@ -65,6 +66,8 @@ function start_test_env {
         --volume "$ACMEPROXY_PEBBLE_DIR/test/certs:/tmp/certs" \
         --env LEGO_CA_CERTIFICATES="/tmp/certs/pebble.minica.pem" \
         --env ACMEPROXY_ACME_DIRECTORY_URL="$ACMEPROXY_ACME_DIRECTORY_URL" \
+        --env ACMEPROXY_ACME_RESOLVER_ADDR="$ACMEPROXY_ACME_RESOLVER_ADDR" \
+        --env ACMEPROXY_ACME_HTTP01_PORT="$ACMEPROY_HTTP_API_PORT" \
         --env ACMEPROXY_HTTP_API_ADDR="$ACMEPROXY_HTTP_API_ADDR" \
         "$ACMEPROXY_IMAGE_TAG"
 }